@@ -0,0 +1,84 @@
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// kind: AuthSecretGrant
+// apiVersion: apps.bigkevmcd.com/v1alpha1
+// metadata:
+//   namespace: platform
+// spec:
+//   from:
+//     - namespace: team-a
+//   to:
+//     - name: org-github-token
+
+// AuthSecretGrantSpec defines the WebhookSecrets in other namespaces that can
+// reference auth secrets in the AuthSecretGrant's namespace.
+type AuthSecretGrantSpec struct {
+	// From is the namespaces that WebhookSecrets can reference the secrets
+	// from.
+	From []AuthSecretGrantFrom `json:"from"`
+	// To is the secrets that can be referenced, if it's empty, any secret in
+	// the namespace can be referenced.
+	To []AuthSecretGrantTo `json:"to,omitempty"`
+}
+
+// AuthSecretGrantFrom is a namespace that is granted access to secrets.
+type AuthSecretGrantFrom struct {
+	Namespace string `json:"namespace"`
+}
+
+// AuthSecretGrantTo is a secret that access is granted to.
+type AuthSecretGrantTo struct {
+	Name string `json:"name"`
+}
+
+// Allows returns true if the grant allows WebhookSecrets in the namespace to
+// reference the named secret.
+func (s AuthSecretGrantSpec) Allows(namespace, secretName string) bool {
+	from := false
+	for _, f := range s.From {
+		if f.Namespace == namespace {
+			from = true
+			break
+		}
+	}
+	if !from {
+		return false
+	}
+	if len(s.To) == 0 {
+		return true
+	}
+	for _, t := range s.To {
+		if t.Name == secretName {
+			return true
+		}
+	}
+	return false
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// AuthSecretGrant is the Schema for the authsecretgrants API
+// +kubebuilder:resource:path=authsecretgrants,scope=Namespaced
+type AuthSecretGrant struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec AuthSecretGrantSpec `json:"spec,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// AuthSecretGrantList contains a list of AuthSecretGrant
+type AuthSecretGrantList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []AuthSecretGrant `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&AuthSecretGrant{}, &AuthSecretGrantList{})
+}
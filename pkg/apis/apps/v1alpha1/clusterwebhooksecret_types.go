@@ -0,0 +1,87 @@
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// kind: ClusterWebhookSecret
+// apiVersion: apps.bigkevmcd.com/v1alpha1
+// spec:
+//   repo:
+//     url: "https://github.com/testing/testing.git"
+//   authSecretRef:
+//     name: "gitops-github-auth-token"
+//     namespace: "platform"
+//   webhookURL:
+//     hookURL: "https://example.com/"
+//   namespaces:
+//     - team-a
+//   namespaceSelector:
+//     matchLabels:
+//       webhooks: "true"
+
+// ClusterWebhookSecretSpec defines the desired state of ClusterWebhookSecret
+//
+// The fields of a WebhookSecretSpec configure the webhook, which is created
+// by a WebhookSecret in the namespace of the AuthSecretRef, which must have a
+// Namespace.
+//
+// The generated Secret is copied into the Namespaces, and the namespaces that
+// match the NamespaceSelector.
+type ClusterWebhookSecretSpec struct {
+	WebhookSecretSpec `json:",inline"`
+	// Namespaces are the namespaces that the generated Secret is copied to.
+	Namespaces []string `json:"namespaces,omitempty"`
+	// NamespaceSelector selects more namespaces that the generated Secret is
+	// copied to, by their labels.
+	NamespaceSelector *metav1.LabelSelector `json:"namespaceSelector,omitempty"`
+}
+
+// ClusterWebhookSecretLabel is set on the copies of the generated Secret, to
+// the name of the ClusterWebhookSecret that they were copied for.
+const ClusterWebhookSecretLabel = "apps.bigkevmcd.com/cluster-webhook-secret"
+
+// ClusterWebhookSecretStatus defines the observed state of ClusterWebhookSecret
+type ClusterWebhookSecretStatus struct {
+	// ObservedGeneration is the generation of the ClusterWebhookSecret that
+	// was last reconciled.
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+	// WebhookID is the ID of the webhook from the WebhookSecret.
+	WebhookID string `json:"webhookID,omitempty"`
+	// HookURL is the URL that the webhook was created with.
+	HookURL string `json:"hookURL,omitempty"`
+	// Namespaces are the namespaces that the generated Secret was copied to.
+	Namespaces []string    `json:"namespaces,omitempty"`
+	Conditions []Condition `json:"conditions,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// ClusterWebhookSecret is the Schema for the clusterwebhooksecrets API
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:path=clusterwebhooksecrets,scope=Cluster
+// +kubebuilder:printcolumn:name="Ready",type="string",JSONPath=".status.conditions[?(@.type==\"Ready\")].status"
+// +kubebuilder:printcolumn:name="Reason",type="string",JSONPath=".status.conditions[?(@.type==\"Ready\")].reason"
+// +kubebuilder:printcolumn:name="Webhook",type="string",JSONPath=".status.webhookID"
+// +kubebuilder:printcolumn:name="Hook URL",type="string",JSONPath=".status.hookURL",priority=1
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
+type ClusterWebhookSecret struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   ClusterWebhookSecretSpec   `json:"spec,omitempty"`
+	Status ClusterWebhookSecretStatus `json:"status,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// ClusterWebhookSecretList contains a list of ClusterWebhookSecret
+type ClusterWebhookSecretList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ClusterWebhookSecret `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&ClusterWebhookSecret{}, &ClusterWebhookSecretList{})
+}
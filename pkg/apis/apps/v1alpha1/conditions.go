@@ -0,0 +1,51 @@
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ConditionType is the type of a condition on a WebhookSecret, a
+// WebhookSecretSet or a ClusterWebhookSecret.
+type ConditionType string
+
+const (
+	// SecretReadyCondition indicates that the generated Secret exists.
+	SecretReadyCondition ConditionType = "SecretReady"
+	// WebhookReadyCondition indicates that the webhook exists in the git
+	// host, and matches the WebhookSecret.
+	WebhookReadyCondition ConditionType = "WebhookReady"
+	// AuthResolvedCondition indicates that the auth token was loaded, and a
+	// client created for the git host.
+	AuthResolvedCondition ConditionType = "AuthResolved"
+	// RouteResolvedCondition indicates that the URL for the webhook was
+	// calculated.
+	RouteResolvedCondition ConditionType = "RouteResolved"
+	// ReposListedCondition indicates that the repositories for a
+	// WebhookSecretSet were listed from the git host.
+	ReposListedCondition ConditionType = "ReposListed"
+	// SecretsSyncedCondition indicates that the generated Secret for a
+	// ClusterWebhookSecret was copied to all of its namespaces.
+	SecretsSyncedCondition ConditionType = "SecretsSynced"
+	// ReadyCondition indicates that all the other conditions are true.
+	ReadyCondition ConditionType = "Ready"
+)
+
+// Condition is an observation of the state of a WebhookSecret.
+//
+// This follows the conventions for conditions in Kubernetes APIs.
+type Condition struct {
+	// Type of the condition.
+	Type ConditionType `json:"type"`
+	// Status of the condition, one of True, False, Unknown.
+	Status metav1.ConditionStatus `json:"status"`
+	// ObservedGeneration is the generation of the WebhookSecret that the
+	// condition was set for.
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+	// LastTransitionTime is the last time the condition changed status.
+	LastTransitionTime metav1.Time `json:"lastTransitionTime"`
+	// Reason is a CamelCase reason for the last transition.
+	Reason string `json:"reason"`
+	// Message is a human readable message with details about the
+	// transition.
+	Message string `json:"message,omitempty"`
+}
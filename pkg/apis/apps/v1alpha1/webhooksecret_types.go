@@ -11,48 +11,172 @@ import (
 //   repoURL: "https://github.com/testing/testing.git"
 //   authSecretRef:
 //     name: "gitops-github-auth-token"
+//     namespace: "platform"
 //   webhookURL:
 //     route:
 //       name: "el-gitop-eventlistener-route"
 //   events:
 //     - push
 //     - pull_request
+//   rotation:
+//     interval: 2160h
+//     gracePeriod: 1h
 
 // WebhookSecretSpec defines the desired state of WebhookSecret
 //
 // This is used to authenticate requests to the API for Repo.
+//
+// Repos can be used instead of Repo to create webhooks in more than one
+// repository, all with the same generated secret.
 type WebhookSecretSpec struct {
-	Repo          Repo             `json:"repo"`
-	AuthSecretRef WebhookSecretRef `json:"authSecretRef"`
-	Key           string           `json:"key,omitempty"`
-	WebhookURL    HookRoute        `json:"webhookURL"`
+	Repo          Repo          `json:"repo,omitempty"`
+	Repos         []Repo        `json:"repos,omitempty"`
+	AuthSecretRef AuthSecretRef `json:"authSecretRef"`
+	Key           string        `json:"key,omitempty"`
+	WebhookURL    HookRoute     `json:"webhookURL"`
+	Events        []HookEvent   `json:"events,omitempty"`
+	Rotation      *RotationSpec `json:"rotation,omitempty"`
+}
+
+// RotateAnnotation can be set on a WebhookSecret to rotate the secret
+// immediately, the secret is rotated each time the value of the annotation
+// changes.
+const RotateAnnotation = "apps.bigkevmcd.com/rotate"
+
+// RotationSpec configures the scheduled rotation of the generated secret.
+type RotationSpec struct {
+	// Interval is how often the secret is rotated, e.g. "2160h" for 90 days.
+	Interval *metav1.Duration `json:"interval,omitempty"`
+	// GracePeriod is how long the previous secret is kept in the Secret after
+	// the secret is rotated, under the key with a ".previous" suffix, it's
+	// never shorter than 5 minutes.
+	GracePeriod *metav1.Duration `json:"gracePeriod,omitempty"`
 }
 
 // WebhookSecretStatus defines the observed state of WebhookSecret
 type WebhookSecretStatus struct {
 	WebhookID string           `json:"webhookID,omitempty"`
 	SecretRef WebhookSecretRef `json:"secretRef,omitempty"`
+	// HookURL is the URL that the webhook was created with.
+	HookURL string `json:"hookURL,omitempty"`
+	// EventListenerService is the name of the Service for the EventListener
+	// that the webhook URL is calculated from.
+	EventListenerService string `json:"eventListenerService,omitempty"`
+	// ObservedGeneration is the generation of the WebhookSecret that was last
+	// reconciled.
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+	// LastSyncTime is the last time that the webhook was synchronised with the
+	// git host.
+	LastSyncTime *metav1.Time `json:"lastSyncTime,omitempty"`
+	// LastRotationTime is the last time that the secret was generated.
+	LastRotationTime *metav1.Time `json:"lastRotationTime,omitempty"`
+	// LastRotationRequest is the value of the RotateAnnotation when the
+	// secret was last generated.
+	LastRotationRequest string `json:"lastRotationRequest,omitempty"`
+	// Webhooks are the webhooks in each of the Repos, the WebhookID and
+	// HookURL are only used for a single Repo.
+	Webhooks   []RepoWebhook `json:"webhooks,omitempty"`
+	Conditions []Condition   `json:"conditions,omitempty"`
 }
 
+// RepoWebhook is the webhook in one of the Repos of a WebhookSecret.
+type RepoWebhook struct {
+	Repo      Repo   `json:"repo"`
+	WebhookID string `json:"webhookID,omitempty"`
+	// HookURL is the URL that the webhook was created with.
+	HookURL string `json:"hookURL,omitempty"`
+	// LastSyncTime is the last time that the webhook was synchronised with
+	// the git host.
+	LastSyncTime *metav1.Time `json:"lastSyncTime,omitempty"`
+	// Error is the error from the last attempt to synchronise the webhook, it's
+	// empty if the webhook is up to date.
+	Error string `json:"error,omitempty"`
+}
+
+// HookEvent is an event that a webhook can be subscribed to.
+//
+// Not all drivers support all events.
+// +kubebuilder:validation:Enum=push;pull_request;pull_request_comment;branch;tag;issues;issue_comment;release;deployment
+type HookEvent string
+
+const (
+	PushEvent               HookEvent = "push"
+	PullRequestEvent        HookEvent = "pull_request"
+	PullRequestCommentEvent HookEvent = "pull_request_comment"
+	BranchEvent             HookEvent = "branch"
+	TagEvent                HookEvent = "tag"
+	IssuesEvent             HookEvent = "issues"
+	IssueCommentEvent       HookEvent = "issue_comment"
+	ReleaseEvent            HookEvent = "release"
+	DeploymentEvent         HookEvent = "deployment"
+)
+
 type Repo struct {
+	// URL is the URL of the repository, or of the organization or group for
+	// organization and group hooks, e.g. https://github.com/my-org.
 	URL      string `json:"url"`
 	Driver   string `json:"driver,omitempty"`
 	Endpoint string `json:"endpoint,omitempty"`
+	// Scope is the level that the webhook is created at, the default is the
+	// repository.
+	Scope HookScope `json:"scope,omitempty"`
 }
 
+// HookScope is the level that a webhook is created at.
+//
+// Organization hooks are only supported by GitHub, and group hooks by GitLab,
+// they deliver the events for all the repositories in the organization or
+// group.
+// +kubebuilder:validation:Enum=repository;organization;group
+type HookScope string
+
+const (
+	RepositoryScope   HookScope = "repository"
+	OrganizationScope HookScope = "organization"
+	GroupScope        HookScope = "group"
+)
+
 // WebhookSecretRef is the secret to be created.
 type WebhookSecretRef struct {
 	Name string `json:"name"`
 	Key  string `json:"key,omitempty"`
 }
 
+// AuthSecretRef is the secret with the credentials for the git host's API.
+//
+// If the Namespace is not the namespace of the WebhookSecret, an
+// AuthSecretGrant in the secret's namespace must allow the reference.
+type AuthSecretRef struct {
+	Name      string `json:"name"`
+	Namespace string `json:"namespace,omitempty"`
+	Key       string `json:"key,omitempty"`
+}
+
 // HookRoute is the way to get the URL for the Webhook.
 //
 // HookURL is a static URL.
 // RouteRef uses an OpenShift route to calculate the URL.
+// IngressRef uses a Kubernetes Ingress to calculate the URL.
+// HTTPRouteRef uses a Gateway API HTTPRoute to calculate the URL.
+// ServiceRef uses the load balancer of a LoadBalancer Service.
+// KnativeServiceRef uses the URL of a Knative Service.
+// EventListenerRef uses the Route or Ingress that exposes a Tekton
+// EventListener.
+//
+// URLTemplate is a text/template that is executed with the URL calculated
+// from the other fields, the repository's Owner and Name, and the Name,
+// Namespace, Labels and Annotations of the WebhookSecret. If the result is a
+// relative URL it's resolved against the calculated URL, and the final URL
+// must be an absolute http or https URL.
 type HookRoute struct {
-	RouteRef *RouteReference `json:"routeRef,omitempty"`
-	HookURL  string          `json:"hookURL,omitempty"`
+	RouteRef          *RouteReference         `json:"routeRef,omitempty"`
+	IngressRef        *IngressReference       `json:"ingressRef,omitempty"`
+	HTTPRouteRef      *HTTPRouteReference     `json:"httpRouteRef,omitempty"`
+	ServiceRef        *ServiceReference       `json:"serviceRef,omitempty"`
+	KnativeServiceRef *RouteReference         `json:"knativeServiceRef,omitempty"`
+	EventListenerRef  *EventListenerReference `json:"eventListenerRef,omitempty"`
+	HookURL           string                  `json:"hookURL,omitempty"`
+	URLTemplate       string                  `json:"urlTemplate,omitempty"`
 }
 
 // RouteReference is a generic reference with a name/namespace, and the addition
@@ -71,11 +195,97 @@ func (r RouteReference) NamespacedName() types.NamespacedName {
 	}
 }
 
+// IngressReference is a reference to a networking.k8s.io/v1 Ingress, with
+// the Host of the rule to use if the Ingress has rules for more than one host,
+// and a Path to add a custom endpoint.
+//
+// If the Path is empty, and the rule has a single path, that path is used.
+type IngressReference struct {
+	Name      string `json:"name"`
+	Namespace string `json:"namespace"`
+	Host      string `json:"host,omitempty"`
+	Path      string `json:"path,omitempty"`
+}
+
+// NamespacedName returns a NamespacedName for this reference.
+func (r IngressReference) NamespacedName() types.NamespacedName {
+	return types.NamespacedName{
+		Name:      r.Name,
+		Namespace: r.Namespace,
+	}
+}
+
+// HTTPRouteReference is a reference to a Gateway API HTTPRoute, with the
+// Hostname to use if the HTTPRoute has more than one hostname, and a Path to
+// add a custom endpoint.
+//
+// The scheme and port of the URL come from the listener of the HTTPRoute's
+// parent Gateway.
+type HTTPRouteReference struct {
+	Name      string `json:"name"`
+	Namespace string `json:"namespace"`
+	Hostname  string `json:"hostname,omitempty"`
+	Path      string `json:"path,omitempty"`
+}
+
+// NamespacedName returns a NamespacedName for this reference.
+func (r HTTPRouteReference) NamespacedName() types.NamespacedName {
+	return types.NamespacedName{
+		Name:      r.Name,
+		Namespace: r.Namespace,
+	}
+}
+
+// ServiceReference is a reference to a LoadBalancer Service, with the Port to
+// use if the Service has more than one port, and a Path to add a custom
+// endpoint.
+type ServiceReference struct {
+	Name      string `json:"name"`
+	Namespace string `json:"namespace"`
+	Port      int32  `json:"port,omitempty"`
+	Path      string `json:"path,omitempty"`
+}
+
+// NamespacedName returns a NamespacedName for this reference.
+func (r ServiceReference) NamespacedName() types.NamespacedName {
+	return types.NamespacedName{
+		Name:      r.Name,
+		Namespace: r.Namespace,
+	}
+}
+
+// EventListenerReference is a reference to a Tekton EventListener, with a
+// Path to add a custom endpoint.
+//
+// If the Interceptor is set, the secretRef of the named interceptor, github
+// or gitlab, in the EventListener's triggers is set to the generated Secret.
+type EventListenerReference struct {
+	Name      string `json:"name"`
+	Namespace string `json:"namespace"`
+	Path      string `json:"path,omitempty"`
+	// +kubebuilder:validation:Enum=github;gitlab
+	Interceptor string `json:"interceptor,omitempty"`
+}
+
+// NamespacedName returns a NamespacedName for this reference.
+func (r EventListenerReference) NamespacedName() types.NamespacedName {
+	return types.NamespacedName{
+		Name:      r.Name,
+		Namespace: r.Namespace,
+	}
+}
+
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
 
 // WebhookSecret is the Schema for the webhooksecrets API
 // +kubebuilder:subresource:status
 // +kubebuilder:resource:path=webhooksecrets,scope=Namespaced
+// +kubebuilder:printcolumn:name="Ready",type="string",JSONPath=".status.conditions[?(@.type==\"Ready\")].status"
+// +kubebuilder:printcolumn:name="Reason",type="string",JSONPath=".status.conditions[?(@.type==\"Ready\")].reason"
+// +kubebuilder:printcolumn:name="Webhook",type="string",JSONPath=".status.webhookID"
+// +kubebuilder:printcolumn:name="Hook URL",type="string",JSONPath=".status.hookURL",priority=1
+// +kubebuilder:printcolumn:name="Last Sync",type="date",JSONPath=".status.lastSyncTime"
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
 type WebhookSecret struct {
 	metav1.TypeMeta   `json:",inline"`
 	metav1.ObjectMeta `json:"metadata,omitempty"`
@@ -0,0 +1,118 @@
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// kind: WebhookSecretSet
+// apiVersion: apps.bigkevmcd.com/v1alpha1
+// spec:
+//   owner:
+//     url: "https://github.com/my-org"
+//   selector:
+//     name: "^service-"
+//     topics:
+//       - tekton
+//   template:
+//     spec:
+//       authSecretRef:
+//         name: "gitops-github-auth-token"
+//       webhookURL:
+//         hookURL: "https://example.com/"
+
+// WebhookSecretSetSpec defines the desired state of WebhookSecretSet
+//
+// A WebhookSecret is created from the Template for each of the repositories
+// of the Owner that match the Selector, with the Repo set to the repository.
+//
+// The AuthSecretRef in the Template is used to list the repositories.
+type WebhookSecretSetSpec struct {
+	// Owner is the GitHub organization or GitLab group that the repositories
+	// are listed from, GitLab subgroups are included.
+	Owner    Repo                  `json:"owner"`
+	Selector RepoSelector          `json:"selector,omitempty"`
+	Template WebhookSecretTemplate `json:"template"`
+	// ResyncInterval is how often the repositories are listed, the default
+	// is 15 minutes.
+	ResyncInterval *metav1.Duration `json:"resyncInterval,omitempty"`
+}
+
+// RepoSelector selects repositories, a repository must match all of the
+// fields that are set.
+type RepoSelector struct {
+	// Name is a regular expression that the name of the repository must
+	// match, without the owner.
+	Name string `json:"name,omitempty"`
+	// Topics are the topics that the repository must all have.
+	Topics []string `json:"topics,omitempty"`
+	// Visibility is the visibility that the repository must have.
+	// +kubebuilder:validation:Enum=public;private;internal
+	Visibility string `json:"visibility,omitempty"`
+	// Archived selects archived repositories, which are ignored by default.
+	Archived bool `json:"archived,omitempty"`
+}
+
+// WebhookSecretTemplate is the template for the WebhookSecrets created by a
+// WebhookSecretSet.
+type WebhookSecretTemplate struct {
+	Metadata WebhookSecretTemplateMeta `json:"metadata,omitempty"`
+	Spec     WebhookSecretSpec         `json:"spec"`
+}
+
+// WebhookSecretTemplateMeta is the labels and annotations for the
+// WebhookSecrets created by a WebhookSecretSet.
+type WebhookSecretTemplateMeta struct {
+	Labels      map[string]string `json:"labels,omitempty"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+// WebhookSecretSetStatus defines the observed state of WebhookSecretSet
+type WebhookSecretSetStatus struct {
+	// ObservedGeneration is the generation of the WebhookSecretSet that was
+	// last reconciled.
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+	// LastSyncTime is the last time that the repositories were listed.
+	LastSyncTime *metav1.Time `json:"lastSyncTime,omitempty"`
+	// MatchedRepos is the number of repositories that match the Selector.
+	MatchedRepos int `json:"matchedRepos,omitempty"`
+	// WebhookSecrets are the WebhookSecrets created for the repositories.
+	WebhookSecrets []SetWebhookSecret `json:"webhookSecrets,omitempty"`
+	Conditions     []Condition        `json:"conditions,omitempty"`
+}
+
+// SetWebhookSecret is a WebhookSecret that was created for a repository.
+type SetWebhookSecret struct {
+	Name    string `json:"name"`
+	RepoURL string `json:"repoURL"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// WebhookSecretSet is the Schema for the webhooksecretsets API
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:path=webhooksecretsets,scope=Namespaced
+// +kubebuilder:printcolumn:name="Ready",type="string",JSONPath=".status.conditions[?(@.type==\"Ready\")].status"
+// +kubebuilder:printcolumn:name="Reason",type="string",JSONPath=".status.conditions[?(@.type==\"Ready\")].reason"
+// +kubebuilder:printcolumn:name="Repos",type="integer",JSONPath=".status.matchedRepos"
+// +kubebuilder:printcolumn:name="Last Sync",type="date",JSONPath=".status.lastSyncTime"
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
+type WebhookSecretSet struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   WebhookSecretSetSpec   `json:"spec,omitempty"`
+	Status WebhookSecretSetStatus `json:"status,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// WebhookSecretSetList contains a list of WebhookSecretSet
+type WebhookSecretSetList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []WebhookSecretSet `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&WebhookSecretSet{}, &WebhookSecretSetList{})
+}
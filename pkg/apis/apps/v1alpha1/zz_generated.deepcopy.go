@@ -5,9 +5,309 @@
 package v1alpha1
 
 import (
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	runtime "k8s.io/apimachinery/pkg/runtime"
 )
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AuthSecretGrant) DeepCopyInto(out *AuthSecretGrant) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AuthSecretGrant.
+func (in *AuthSecretGrant) DeepCopy() *AuthSecretGrant {
+	if in == nil {
+		return nil
+	}
+	out := new(AuthSecretGrant)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *AuthSecretGrant) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AuthSecretGrantFrom) DeepCopyInto(out *AuthSecretGrantFrom) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AuthSecretGrantFrom.
+func (in *AuthSecretGrantFrom) DeepCopy() *AuthSecretGrantFrom {
+	if in == nil {
+		return nil
+	}
+	out := new(AuthSecretGrantFrom)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AuthSecretGrantList) DeepCopyInto(out *AuthSecretGrantList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]AuthSecretGrant, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AuthSecretGrantList.
+func (in *AuthSecretGrantList) DeepCopy() *AuthSecretGrantList {
+	if in == nil {
+		return nil
+	}
+	out := new(AuthSecretGrantList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *AuthSecretGrantList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AuthSecretGrantSpec) DeepCopyInto(out *AuthSecretGrantSpec) {
+	*out = *in
+	if in.From != nil {
+		in, out := &in.From, &out.From
+		*out = make([]AuthSecretGrantFrom, len(*in))
+		copy(*out, *in)
+	}
+	if in.To != nil {
+		in, out := &in.To, &out.To
+		*out = make([]AuthSecretGrantTo, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AuthSecretGrantSpec.
+func (in *AuthSecretGrantSpec) DeepCopy() *AuthSecretGrantSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(AuthSecretGrantSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AuthSecretGrantTo) DeepCopyInto(out *AuthSecretGrantTo) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AuthSecretGrantTo.
+func (in *AuthSecretGrantTo) DeepCopy() *AuthSecretGrantTo {
+	if in == nil {
+		return nil
+	}
+	out := new(AuthSecretGrantTo)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AuthSecretRef) DeepCopyInto(out *AuthSecretRef) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AuthSecretRef.
+func (in *AuthSecretRef) DeepCopy() *AuthSecretRef {
+	if in == nil {
+		return nil
+	}
+	out := new(AuthSecretRef)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClusterWebhookSecret) DeepCopyInto(out *ClusterWebhookSecret) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterWebhookSecret.
+func (in *ClusterWebhookSecret) DeepCopy() *ClusterWebhookSecret {
+	if in == nil {
+		return nil
+	}
+	out := new(ClusterWebhookSecret)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ClusterWebhookSecret) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClusterWebhookSecretList) DeepCopyInto(out *ClusterWebhookSecretList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]ClusterWebhookSecret, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterWebhookSecretList.
+func (in *ClusterWebhookSecretList) DeepCopy() *ClusterWebhookSecretList {
+	if in == nil {
+		return nil
+	}
+	out := new(ClusterWebhookSecretList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *ClusterWebhookSecretList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClusterWebhookSecretSpec) DeepCopyInto(out *ClusterWebhookSecretSpec) {
+	*out = *in
+	in.WebhookSecretSpec.DeepCopyInto(&out.WebhookSecretSpec)
+	if in.Namespaces != nil {
+		in, out := &in.Namespaces, &out.Namespaces
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.NamespaceSelector != nil {
+		in, out := &in.NamespaceSelector, &out.NamespaceSelector
+		*out = new(v1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterWebhookSecretSpec.
+func (in *ClusterWebhookSecretSpec) DeepCopy() *ClusterWebhookSecretSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ClusterWebhookSecretSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClusterWebhookSecretStatus) DeepCopyInto(out *ClusterWebhookSecretStatus) {
+	*out = *in
+	if in.Namespaces != nil {
+		in, out := &in.Namespaces, &out.Namespaces
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterWebhookSecretStatus.
+func (in *ClusterWebhookSecretStatus) DeepCopy() *ClusterWebhookSecretStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ClusterWebhookSecretStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Condition) DeepCopyInto(out *Condition) {
+	*out = *in
+	in.LastTransitionTime.DeepCopyInto(&out.LastTransitionTime)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Condition.
+func (in *Condition) DeepCopy() *Condition {
+	if in == nil {
+		return nil
+	}
+	out := new(Condition)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *EventListenerReference) DeepCopyInto(out *EventListenerReference) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EventListenerReference.
+func (in *EventListenerReference) DeepCopy() *EventListenerReference {
+	if in == nil {
+		return nil
+	}
+	out := new(EventListenerReference)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HTTPRouteReference) DeepCopyInto(out *HTTPRouteReference) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HTTPRouteReference.
+func (in *HTTPRouteReference) DeepCopy() *HTTPRouteReference {
+	if in == nil {
+		return nil
+	}
+	out := new(HTTPRouteReference)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *HookRoute) DeepCopyInto(out *HookRoute) {
 	*out = *in
@@ -16,6 +316,31 @@ func (in *HookRoute) DeepCopyInto(out *HookRoute) {
 		*out = new(RouteReference)
 		**out = **in
 	}
+	if in.IngressRef != nil {
+		in, out := &in.IngressRef, &out.IngressRef
+		*out = new(IngressReference)
+		**out = **in
+	}
+	if in.HTTPRouteRef != nil {
+		in, out := &in.HTTPRouteRef, &out.HTTPRouteRef
+		*out = new(HTTPRouteReference)
+		**out = **in
+	}
+	if in.ServiceRef != nil {
+		in, out := &in.ServiceRef, &out.ServiceRef
+		*out = new(ServiceReference)
+		**out = **in
+	}
+	if in.KnativeServiceRef != nil {
+		in, out := &in.KnativeServiceRef, &out.KnativeServiceRef
+		*out = new(RouteReference)
+		**out = **in
+	}
+	if in.EventListenerRef != nil {
+		in, out := &in.EventListenerRef, &out.EventListenerRef
+		*out = new(EventListenerReference)
+		**out = **in
+	}
 	return
 }
 
@@ -29,6 +354,22 @@ func (in *HookRoute) DeepCopy() *HookRoute {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *IngressReference) DeepCopyInto(out *IngressReference) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new IngressReference.
+func (in *IngressReference) DeepCopy() *IngressReference {
+	if in == nil {
+		return nil
+	}
+	out := new(IngressReference)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Repo) DeepCopyInto(out *Repo) {
 	*out = *in
@@ -45,6 +386,74 @@ func (in *Repo) DeepCopy() *Repo {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RepoSelector) DeepCopyInto(out *RepoSelector) {
+	*out = *in
+	if in.Topics != nil {
+		in, out := &in.Topics, &out.Topics
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RepoSelector.
+func (in *RepoSelector) DeepCopy() *RepoSelector {
+	if in == nil {
+		return nil
+	}
+	out := new(RepoSelector)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RepoWebhook) DeepCopyInto(out *RepoWebhook) {
+	*out = *in
+	out.Repo = in.Repo
+	if in.LastSyncTime != nil {
+		in, out := &in.LastSyncTime, &out.LastSyncTime
+		*out = (*in).DeepCopy()
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RepoWebhook.
+func (in *RepoWebhook) DeepCopy() *RepoWebhook {
+	if in == nil {
+		return nil
+	}
+	out := new(RepoWebhook)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RotationSpec) DeepCopyInto(out *RotationSpec) {
+	*out = *in
+	if in.Interval != nil {
+		in, out := &in.Interval, &out.Interval
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.GracePeriod != nil {
+		in, out := &in.GracePeriod, &out.GracePeriod
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RotationSpec.
+func (in *RotationSpec) DeepCopy() *RotationSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(RotationSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *RouteReference) DeepCopyInto(out *RouteReference) {
 	*out = *in
@@ -61,13 +470,45 @@ func (in *RouteReference) DeepCopy() *RouteReference {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ServiceReference) DeepCopyInto(out *ServiceReference) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ServiceReference.
+func (in *ServiceReference) DeepCopy() *ServiceReference {
+	if in == nil {
+		return nil
+	}
+	out := new(ServiceReference)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SetWebhookSecret) DeepCopyInto(out *SetWebhookSecret) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SetWebhookSecret.
+func (in *SetWebhookSecret) DeepCopy() *SetWebhookSecret {
+	if in == nil {
+		return nil
+	}
+	out := new(SetWebhookSecret)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *WebhookSecret) DeepCopyInto(out *WebhookSecret) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
 	in.Spec.DeepCopyInto(&out.Spec)
-	out.Status = in.Status
+	in.Status.DeepCopyInto(&out.Status)
 	return
 }
 
@@ -138,12 +579,144 @@ func (in *WebhookSecretRef) DeepCopy() *WebhookSecretRef {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WebhookSecretSet) DeepCopyInto(out *WebhookSecretSet) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WebhookSecretSet.
+func (in *WebhookSecretSet) DeepCopy() *WebhookSecretSet {
+	if in == nil {
+		return nil
+	}
+	out := new(WebhookSecretSet)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *WebhookSecretSet) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WebhookSecretSetList) DeepCopyInto(out *WebhookSecretSetList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]WebhookSecretSet, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WebhookSecretSetList.
+func (in *WebhookSecretSetList) DeepCopy() *WebhookSecretSetList {
+	if in == nil {
+		return nil
+	}
+	out := new(WebhookSecretSetList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *WebhookSecretSetList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WebhookSecretSetSpec) DeepCopyInto(out *WebhookSecretSetSpec) {
+	*out = *in
+	out.Owner = in.Owner
+	in.Selector.DeepCopyInto(&out.Selector)
+	in.Template.DeepCopyInto(&out.Template)
+	if in.ResyncInterval != nil {
+		in, out := &in.ResyncInterval, &out.ResyncInterval
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WebhookSecretSetSpec.
+func (in *WebhookSecretSetSpec) DeepCopy() *WebhookSecretSetSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(WebhookSecretSetSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WebhookSecretSetStatus) DeepCopyInto(out *WebhookSecretSetStatus) {
+	*out = *in
+	if in.LastSyncTime != nil {
+		in, out := &in.LastSyncTime, &out.LastSyncTime
+		*out = (*in).DeepCopy()
+	}
+	if in.WebhookSecrets != nil {
+		in, out := &in.WebhookSecrets, &out.WebhookSecrets
+		*out = make([]SetWebhookSecret, len(*in))
+		copy(*out, *in)
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WebhookSecretSetStatus.
+func (in *WebhookSecretSetStatus) DeepCopy() *WebhookSecretSetStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(WebhookSecretSetStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *WebhookSecretSpec) DeepCopyInto(out *WebhookSecretSpec) {
 	*out = *in
 	out.Repo = in.Repo
+	if in.Repos != nil {
+		in, out := &in.Repos, &out.Repos
+		*out = make([]Repo, len(*in))
+		copy(*out, *in)
+	}
 	out.AuthSecretRef = in.AuthSecretRef
 	in.WebhookURL.DeepCopyInto(&out.WebhookURL)
+	if in.Events != nil {
+		in, out := &in.Events, &out.Events
+		*out = make([]HookEvent, len(*in))
+		copy(*out, *in)
+	}
+	if in.Rotation != nil {
+		in, out := &in.Rotation, &out.Rotation
+		*out = new(RotationSpec)
+		(*in).DeepCopyInto(*out)
+	}
 	return
 }
 
@@ -161,6 +734,28 @@ func (in *WebhookSecretSpec) DeepCopy() *WebhookSecretSpec {
 func (in *WebhookSecretStatus) DeepCopyInto(out *WebhookSecretStatus) {
 	*out = *in
 	out.SecretRef = in.SecretRef
+	if in.LastSyncTime != nil {
+		in, out := &in.LastSyncTime, &out.LastSyncTime
+		*out = (*in).DeepCopy()
+	}
+	if in.LastRotationTime != nil {
+		in, out := &in.LastRotationTime, &out.LastRotationTime
+		*out = (*in).DeepCopy()
+	}
+	if in.Webhooks != nil {
+		in, out := &in.Webhooks, &out.Webhooks
+		*out = make([]RepoWebhook, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 	return
 }
 
@@ -173,3 +768,51 @@ func (in *WebhookSecretStatus) DeepCopy() *WebhookSecretStatus {
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WebhookSecretTemplate) DeepCopyInto(out *WebhookSecretTemplate) {
+	*out = *in
+	in.Metadata.DeepCopyInto(&out.Metadata)
+	in.Spec.DeepCopyInto(&out.Spec)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WebhookSecretTemplate.
+func (in *WebhookSecretTemplate) DeepCopy() *WebhookSecretTemplate {
+	if in == nil {
+		return nil
+	}
+	out := new(WebhookSecretTemplate)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WebhookSecretTemplateMeta) DeepCopyInto(out *WebhookSecretTemplateMeta) {
+	*out = *in
+	if in.Labels != nil {
+		in, out := &in.Labels, &out.Labels
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Annotations != nil {
+		in, out := &in.Annotations, &out.Annotations
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WebhookSecretTemplateMeta.
+func (in *WebhookSecretTemplateMeta) DeepCopy() *WebhookSecretTemplateMeta {
+	if in == nil {
+		return nil
+	}
+	out := new(WebhookSecretTemplateMeta)
+	in.DeepCopyInto(out)
+	return out
+}
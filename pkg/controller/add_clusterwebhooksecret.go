@@ -0,0 +1,10 @@
+package controller
+
+import (
+	"github.com/bigkevmcd/webhook-secret-operator/pkg/controller/clusterwebhooksecret"
+)
+
+func init() {
+	// AddToManagerFuncs is a list of functions to create controllers and add them to a manager.
+	AddToManagerFuncs = append(AddToManagerFuncs, clusterwebhooksecret.Add)
+}
@@ -0,0 +1,10 @@
+package controller
+
+import (
+	"github.com/bigkevmcd/webhook-secret-operator/pkg/controller/webhooksecretset"
+)
+
+func init() {
+	// AddToManagerFuncs is a list of functions to create controllers and add them to a manager.
+	AddToManagerFuncs = append(AddToManagerFuncs, webhooksecretset.Add)
+}
@@ -0,0 +1,228 @@
+package clusterwebhooksecret
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	v1alpha1 "github.com/bigkevmcd/webhook-secret-operator/pkg/apis/apps/v1alpha1"
+	"github.com/bigkevmcd/webhook-secret-operator/pkg/controller/common"
+)
+
+var log = logf.Log.WithName("controller_clusterwebhooksecret")
+
+// Add creates a new ClusterWebhookSecret Controller and adds it to the Manager. The Manager will set fields on the Controller
+// and Start it when the Manager is Started.
+func Add(mgr manager.Manager) error {
+	return add(mgr, newReconciler(mgr))
+}
+
+// newReconciler returns a new reconcile.Reconciler
+func newReconciler(mgr manager.Manager) *ReconcileClusterWebhookSecret {
+	return &ReconcileClusterWebhookSecret{
+		kubeClient: mgr.GetClient(),
+		scheme:     mgr.GetScheme(),
+		recorder:   mgr.GetEventRecorderFor("clusterwebhooksecret-controller"),
+	}
+}
+
+// add adds a new Controller to mgr with r as the reconcile.Reconciler
+//
+// The WebhookSecret that creates the webhook is watched so that its status
+// can be copied, and the generated Secret is watched so that the copies are
+// updated when it's rotated.
+func add(mgr manager.Manager, r reconcile.Reconciler) error {
+	c, err := controller.New("clusterwebhooksecret-controller", mgr, controller.Options{Reconciler: r})
+	if err != nil {
+		return err
+	}
+
+	err = c.Watch(&source.Kind{Type: &v1alpha1.ClusterWebhookSecret{}}, &handler.EnqueueRequestForObject{},
+		predicate.GenerationChangedPredicate{})
+	if err != nil {
+		return err
+	}
+
+	err = c.Watch(&source.Kind{Type: &v1alpha1.WebhookSecret{}}, &handler.EnqueueRequestForOwner{
+		IsController: true,
+		OwnerType:    &v1alpha1.ClusterWebhookSecret{},
+	})
+	if err != nil {
+		return err
+	}
+
+	err = c.Watch(&source.Kind{Type: &corev1.Secret{}}, &handler.EnqueueRequestsFromMapFunc{
+		ToRequests: &secretMapper{kubeClient: mgr.GetClient()},
+	})
+	if err != nil {
+		return err
+	}
+
+	return c.Watch(&source.Kind{Type: &corev1.Namespace{}}, &handler.EnqueueRequestsFromMapFunc{
+		ToRequests: &namespaceMapper{kubeClient: mgr.GetClient()},
+	}, namespaceLabelsChanged)
+}
+
+// ReconcileClusterWebhookSecret reconciles a ClusterWebhookSecret object
+type ReconcileClusterWebhookSecret struct {
+	kubeClient client.Client
+	scheme     *runtime.Scheme
+	recorder   record.EventRecorder
+}
+
+// Reconcile ensures that there's a WebhookSecret for a ClusterWebhookSecret
+// in the namespace of its auth secret, and that the Secret it generates is
+// copied to each of the ClusterWebhookSecret's namespaces.
+//
+// The WebhookSecret and the copies are controlled by the
+// ClusterWebhookSecret, so they're garbage collected when it's deleted.
+func (r *ReconcileClusterWebhookSecret) Reconcile(request reconcile.Request) (reconcile.Result, error) {
+	reqLogger := log.WithValues("Request.Name", request.Name)
+	reqLogger.Info("Reconciling ClusterWebhookSecret")
+
+	ctx := context.Background()
+
+	instance := &v1alpha1.ClusterWebhookSecret{}
+	err := r.kubeClient.Get(ctx, request.NamespacedName, instance)
+	if err != nil {
+		if !errors.IsNotFound(err) {
+			return reconcile.Result{}, err
+		}
+		return reconcile.Result{}, nil
+	}
+
+	if !instance.ObjectMeta.DeletionTimestamp.IsZero() {
+		return reconcile.Result{}, nil
+	}
+
+	err = r.reconcileClusterWebhookSecret(ctx, reqLogger, instance)
+	if statusErr := r.updateStatus(ctx, instance); statusErr != nil {
+		reqLogger.Error(statusErr, "failed to update the ClusterWebhookSecret status")
+		if err == nil {
+			return reconcile.Result{}, fmt.Errorf("failed to update the ClusterWebhookSecret status: %s", statusErr)
+		}
+	}
+	return reconcile.Result{}, err
+}
+
+func (r *ReconcileClusterWebhookSecret) reconcileClusterWebhookSecret(ctx context.Context, logger logr.Logger, cws *v1alpha1.ClusterWebhookSecret) error {
+	// The spec is only reconciled again when it's changed, so there's no
+	// point in returning an error.
+	if cws.Spec.AuthSecretRef.Namespace == "" {
+		msg := "authSecretRef.namespace must be set for a ClusterWebhookSecret"
+		setCondition(cws, v1alpha1.WebhookReadyCondition, metav1.ConditionFalse, reasonNamespaceMissing, msg)
+		r.recorder.Event(cws, corev1.EventTypeWarning, reasonNamespaceMissing, msg)
+		return nil
+	}
+	ws, err := r.reconcileWebhookSecret(ctx, logger, cws)
+	if err != nil {
+		return err
+	}
+	namespaces, err := r.targetNamespaces(ctx, cws)
+	if err != nil {
+		if isInvalidSelector(err) {
+			setCondition(cws, v1alpha1.SecretsSyncedCondition, metav1.ConditionFalse, reasonSelectorInvalid, err.Error())
+			r.recorder.Event(cws, corev1.EventTypeWarning, reasonSelectorInvalid, err.Error())
+			return nil
+		}
+		return err
+	}
+	return r.reconcileCopies(ctx, logger, cws, ws, namespaces)
+}
+
+// reconcileWebhookSecret ensures that the WebhookSecret for the
+// ClusterWebhookSecret exists and has its spec, and copies the state of the
+// webhook into the status of the ClusterWebhookSecret.
+func (r *ReconcileClusterWebhookSecret) reconcileWebhookSecret(ctx context.Context, logger logr.Logger, cws *v1alpha1.ClusterWebhookSecret) (*v1alpha1.WebhookSecret, error) {
+	want := newWebhookSecret(cws)
+	if err := controllerutil.SetControllerReference(cws, want, r.scheme); err != nil {
+		return nil, err
+	}
+	id := types.NamespacedName{Name: want.Name, Namespace: want.Namespace}
+	ws := &v1alpha1.WebhookSecret{}
+	err := r.kubeClient.Get(ctx, id, ws)
+	switch {
+	case errors.IsNotFound(err):
+		logger.Info("Creating a WebhookSecret", "namespace", id.Namespace, "name", id.Name)
+		if err := r.kubeClient.Create(ctx, want); err != nil {
+			return nil, r.webhookSecretFailed(cws, fmt.Errorf("failed to create WebhookSecret %s: %w", id, err))
+		}
+		r.recorder.Eventf(cws, corev1.EventTypeNormal, reasonWebhookSecretCreated, "Created WebhookSecret %s", id)
+		ws = want
+	case err != nil:
+		return nil, r.webhookSecretFailed(cws, fmt.Errorf("failed to get WebhookSecret %s: %w", id, err))
+	case !metav1.IsControlledBy(ws, cws):
+		err := fmt.Errorf("WebhookSecret %s already exists and is not controlled by the ClusterWebhookSecret", id)
+		setCondition(cws, v1alpha1.WebhookReadyCondition, metav1.ConditionFalse, reasonWebhookSecretExists, err.Error())
+		r.recorder.Event(cws, corev1.EventTypeWarning, reasonWebhookSecretExists, err.Error())
+		return nil, err
+	case !equality.Semantic.DeepEqual(ws.Spec, want.Spec):
+		logger.Info("Updating a WebhookSecret", "namespace", id.Namespace, "name", id.Name)
+		ws.Spec = want.Spec
+		if err := r.kubeClient.Update(ctx, ws); err != nil {
+			return nil, r.webhookSecretFailed(cws, fmt.Errorf("failed to update WebhookSecret %s: %w", id, err))
+		}
+		r.recorder.Eventf(cws, corev1.EventTypeNormal, reasonWebhookSecretUpdated, "Updated WebhookSecret %s", id)
+	}
+
+	cws.Status.WebhookID = ws.Status.WebhookID
+	cws.Status.HookURL = ws.Status.HookURL
+	ready := common.FindCondition(ws.Status.Conditions, v1alpha1.ReadyCondition)
+	if ready == nil || ws.Status.ObservedGeneration != ws.Generation {
+		setCondition(cws, v1alpha1.WebhookReadyCondition, metav1.ConditionUnknown, reasonPending,
+			fmt.Sprintf("WebhookSecret %s has not been reconciled", id))
+		return ws, nil
+	}
+	setCondition(cws, v1alpha1.WebhookReadyCondition, ready.Status, ready.Reason, ready.Message)
+	return ws, nil
+}
+
+// webhookSecretFailed records the error in the WebhookReady condition and as
+// an event, and returns it.
+func (r *ReconcileClusterWebhookSecret) webhookSecretFailed(cws *v1alpha1.ClusterWebhookSecret, err error) error {
+	setCondition(cws, v1alpha1.WebhookReadyCondition, metav1.ConditionFalse, reasonWebhookSecretFailed, err.Error())
+	r.recorder.Event(cws, corev1.EventTypeWarning, reasonWebhookSecretFailed, err.Error())
+	return err
+}
+
+// updateStatus records the conditions and the generation that was reconciled
+// in the status of the ClusterWebhookSecret.
+func (r *ReconcileClusterWebhookSecret) updateStatus(ctx context.Context, cws *v1alpha1.ClusterWebhookSecret) error {
+	cws.Status.ObservedGeneration = cws.Generation
+	setReadyCondition(cws)
+	return r.kubeClient.Status().Update(ctx, cws)
+}
+
+// newWebhookSecret returns the WebhookSecret that creates the webhook for the
+// ClusterWebhookSecret, it has the same name, and is in the namespace of the
+// auth secret, so no AuthSecretGrant is needed.
+func newWebhookSecret(cws *v1alpha1.ClusterWebhookSecret) *v1alpha1.WebhookSecret {
+	return &v1alpha1.WebhookSecret{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: v1alpha1.SchemeGroupVersion.String(),
+			Kind:       "WebhookSecret",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      cws.Name,
+			Namespace: cws.Spec.AuthSecretRef.Namespace,
+		},
+		Spec: *cws.Spec.WebhookSecretSpec.DeepCopy(),
+	}
+}
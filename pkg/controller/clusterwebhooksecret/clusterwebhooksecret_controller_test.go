@@ -0,0 +1,489 @@
+package clusterwebhooksecret
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	logf "sigs.k8s.io/controller-runtime/pkg/runtime/log"
+
+	v1alpha1 "github.com/bigkevmcd/webhook-secret-operator/pkg/apis/apps/v1alpha1"
+	"github.com/bigkevmcd/webhook-secret-operator/pkg/controller/common"
+	"github.com/bigkevmcd/webhook-secret-operator/test"
+)
+
+const (
+	testName           = "test-cluster-secret"
+	testHomeNamespace  = "platform"
+	testRepoURL        = "https://github.com/example/example.git"
+	testAuthSecretName = "test-auth-secret"
+	testHookEndpoint   = "https://example.com/"
+	testWebhookID      = "1234"
+	testToken          = "test-token"
+)
+
+// Reconciling a new ClusterWebhookSecret creates a WebhookSecret in the
+// namespace of the auth secret, and waits for it to generate the Secret.
+func TestClusterWebhookSecretControllerCreatesWebhookSecret(t *testing.T) {
+	logf.SetLogger(logf.ZapLogger(true))
+	cws := makeClusterWebhookSecret()
+	cl, r := makeReconciler(t, cws)
+
+	_, err := r.Reconcile(makeReconcileRequest())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ws := &v1alpha1.WebhookSecret{}
+	if err := cl.Get(context.TODO(), types.NamespacedName{Name: testName, Namespace: testHomeNamespace}, ws); err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(cws.Spec.WebhookSecretSpec, ws.Spec); diff != "" {
+		t.Fatalf("incorrect WebhookSecret spec:\n%s", diff)
+	}
+	if !metav1.IsControlledBy(ws, cws) {
+		t.Fatalf("WebhookSecret is not controlled by the ClusterWebhookSecret, got %#v", ws.OwnerReferences)
+	}
+	cws = loadClusterWebhookSecret(t, cl)
+	assertCondition(t, cws, v1alpha1.WebhookReadyCondition, metav1.ConditionUnknown, reasonPending)
+	assertCondition(t, cws, v1alpha1.SecretsSyncedCondition, metav1.ConditionUnknown, reasonSecretPending)
+	assertCondition(t, cws, v1alpha1.ReadyCondition, metav1.ConditionUnknown, reasonPending)
+	assertEvents(t, r.recorder, "Normal WebhookSecretCreated Created WebhookSecret platform/test-cluster-secret")
+}
+
+// The generated Secret is copied to the listed namespaces, and the namespaces
+// that match the selector, but not to the namespace of the auth secret.
+func TestClusterWebhookSecretControllerCopiesSecret(t *testing.T) {
+	logf.SetLogger(logf.ZapLogger(true))
+	cws := makeClusterWebhookSecret()
+	cws.Spec.Namespaces = []string{"team-a"}
+	cws.Spec.NamespaceSelector = &metav1.LabelSelector{MatchLabels: map[string]string{"webhooks": "true"}}
+	cl, r := makeReconciler(t, cws, makeReadyWebhookSecret(t, cws), makeGeneratedSecret(),
+		makeNamespace("team-b", map[string]string{"webhooks": "true"}),
+		makeNamespace("team-c", nil),
+		makeNamespace(testHomeNamespace, map[string]string{"webhooks": "true"}))
+
+	_, err := r.Reconcile(makeReconcileRequest())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assertCopies(t, cl, cws, "team-a", "team-b")
+	cws = loadClusterWebhookSecret(t, cl)
+	if diff := cmp.Diff([]string{"team-a", "team-b"}, cws.Status.Namespaces); diff != "" {
+		t.Fatalf("incorrect namespaces:\n%s", diff)
+	}
+	if cws.Status.WebhookID != testWebhookID || cws.Status.HookURL != testHookEndpoint {
+		t.Fatalf("got webhook %#v with URL %#v, want %#v with %#v", cws.Status.WebhookID, cws.Status.HookURL, testWebhookID, testHookEndpoint)
+	}
+	assertCondition(t, cws, v1alpha1.WebhookReadyCondition, metav1.ConditionTrue, reasonReady)
+	assertCondition(t, cws, v1alpha1.SecretsSyncedCondition, metav1.ConditionTrue, reasonSecretsSynced)
+	assertCondition(t, cws, v1alpha1.ReadyCondition, metav1.ConditionTrue, reasonReady)
+	assertEvents(t, r.recorder,
+		"Normal SecretCopied Copied Secret to team-a/test-cluster-secret",
+		"Normal SecretCopied Copied Secret to team-b/test-cluster-secret")
+}
+
+// When the generated Secret is rotated, the copies are updated.
+func TestClusterWebhookSecretControllerUpdatesCopies(t *testing.T) {
+	logf.SetLogger(logf.ZapLogger(true))
+	cws := makeClusterWebhookSecret()
+	cws.Spec.Namespaces = []string{"team-a"}
+	stale := makeCopy(t, cws, "team-a")
+	stale.Data["token"] = []byte("old-token")
+	cl, r := makeReconciler(t, cws, makeReadyWebhookSecret(t, cws), makeGeneratedSecret(), stale)
+
+	_, err := r.Reconcile(makeReconcileRequest())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assertCopies(t, cl, cws, "team-a")
+	assertEvents(t, r.recorder, "Normal SecretCopied Updated the copy of the Secret in team-a/test-cluster-secret")
+}
+
+// Copies in namespaces that are no longer selected are deleted.
+func TestClusterWebhookSecretControllerDeletesCopies(t *testing.T) {
+	logf.SetLogger(logf.ZapLogger(true))
+	cws := makeClusterWebhookSecret()
+	cws.Spec.Namespaces = []string{"team-a"}
+	cws.Status.Namespaces = []string{"team-a", "team-b"}
+	cl, r := makeReconciler(t, cws, makeReadyWebhookSecret(t, cws), makeGeneratedSecret(),
+		makeCopy(t, cws, "team-a"), makeCopy(t, cws, "team-b"))
+
+	_, err := r.Reconcile(makeReconcileRequest())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = cl.Get(context.TODO(), types.NamespacedName{Name: testName, Namespace: "team-b"}, &corev1.Secret{})
+	if !errors.IsNotFound(err) {
+		t.Fatalf("copy of the Secret was not deleted, got %v", err)
+	}
+	cws = loadClusterWebhookSecret(t, cl)
+	if diff := cmp.Diff([]string{"team-a"}, cws.Status.Namespaces); diff != "" {
+		t.Fatalf("incorrect namespaces:\n%s", diff)
+	}
+	assertEvents(t, r.recorder, "Normal SecretDeleted Deleted Secret team-b/test-cluster-secret, the namespace is no longer selected")
+}
+
+// Copies that weren't recorded in the status, because the status couldn't be
+// updated, are found by their label and deleted.
+func TestClusterWebhookSecretControllerDeletesUnrecordedCopies(t *testing.T) {
+	logf.SetLogger(logf.ZapLogger(true))
+	cws := makeClusterWebhookSecret()
+	cws.Spec.Namespaces = []string{"team-a"}
+	unrelated := makeCopy(t, makeClusterWebhookSecret(), "team-c")
+	unrelated.OwnerReferences = nil
+	cl, r := makeReconciler(t, cws, makeReadyWebhookSecret(t, cws), makeGeneratedSecret(),
+		makeCopy(t, cws, "team-a"), makeCopy(t, cws, "team-b"), unrelated)
+
+	_, err := r.Reconcile(makeReconcileRequest())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = cl.Get(context.TODO(), types.NamespacedName{Name: testName, Namespace: "team-b"}, &corev1.Secret{})
+	if !errors.IsNotFound(err) {
+		t.Fatalf("copy of the Secret was not deleted, got %v", err)
+	}
+	if err := cl.Get(context.TODO(), types.NamespacedName{Name: testName, Namespace: "team-c"}, &corev1.Secret{}); err != nil {
+		t.Fatalf("Secret that isn't controlled by the ClusterWebhookSecret was deleted: %s", err)
+	}
+	cws = loadClusterWebhookSecret(t, cl)
+	if diff := cmp.Diff([]string{"team-a"}, cws.Status.Namespaces); diff != "" {
+		t.Fatalf("incorrect namespaces:\n%s", diff)
+	}
+	assertEvents(t, r.recorder, "Normal SecretDeleted Deleted Secret team-b/test-cluster-secret, the namespace is no longer selected")
+}
+
+// If the status can't be updated, the error is returned so that the
+// ClusterWebhookSecret is reconciled again.
+func TestClusterWebhookSecretControllerWithFailedStatusUpdate(t *testing.T) {
+	logf.SetLogger(logf.ZapLogger(true))
+	cws := makeClusterWebhookSecret()
+	cws.Spec.Namespaces = []string{"team-a"}
+	cl, r := makeReconciler(t, cws, makeReadyWebhookSecret(t, cws), makeGeneratedSecret())
+	r.kubeClient = statusFailingClient{Client: cl, err: fmt.Errorf("status update failed")}
+
+	_, err := r.Reconcile(makeReconcileRequest())
+	if !test.MatchError(t, "failed to update the ClusterWebhookSecret status: status update failed", err) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	assertCopies(t, cl, cws, "team-a")
+}
+
+// Secrets that aren't controlled by the ClusterWebhookSecret are not
+// overwritten.
+func TestClusterWebhookSecretControllerWithExistingSecret(t *testing.T) {
+	logf.SetLogger(logf.ZapLogger(true))
+	cws := makeClusterWebhookSecret()
+	cws.Spec.Namespaces = []string{"team-a", "team-b"}
+	existing := makeGeneratedSecret()
+	existing.Namespace = "team-a"
+	existing.Data["token"] = []byte("unrelated")
+	cl, r := makeReconciler(t, cws, makeReadyWebhookSecret(t, cws), makeGeneratedSecret(), existing)
+
+	_, err := r.Reconcile(makeReconcileRequest())
+	if !test.MatchError(t, "failed to sync the Secret", err) {
+		t.Fatalf("expected a sync error, got %#v", err)
+	}
+
+	assertCopies(t, cl, cws, "team-b")
+	unchanged := &corev1.Secret{}
+	if err := cl.Get(context.TODO(), types.NamespacedName{Name: testName, Namespace: "team-a"}, unchanged); err != nil {
+		t.Fatal(err)
+	}
+	if string(unchanged.Data["token"]) != "unrelated" {
+		t.Fatalf("existing Secret was overwritten, got %#v", unchanged.Data)
+	}
+	cws = loadClusterWebhookSecret(t, cl)
+	assertCondition(t, cws, v1alpha1.SecretsSyncedCondition, metav1.ConditionFalse, reasonSecretFailed)
+	assertCondition(t, cws, v1alpha1.ReadyCondition, metav1.ConditionFalse, reasonSecretFailed)
+	assertEvents(t, r.recorder,
+		"Warning SecretFailed Secret team-a/test-cluster-secret already exists and is not controlled by the ClusterWebhookSecret",
+		"Normal SecretCopied Copied Secret to team-b/test-cluster-secret")
+}
+
+// The state of the webhook is copied from the WebhookSecret.
+func TestClusterWebhookSecretControllerWithFailingWebhook(t *testing.T) {
+	logf.SetLogger(logf.ZapLogger(true))
+	cws := makeClusterWebhookSecret()
+	ws := makeWebhookSecret(t, cws)
+	ws.Status.Conditions = []v1alpha1.Condition{
+		{Type: v1alpha1.ReadyCondition, Status: metav1.ConditionFalse, Reason: "WebhookFailed", Message: "failed to create webhook"},
+	}
+	cl, r := makeReconciler(t, cws, ws, makeGeneratedSecret())
+
+	_, err := r.Reconcile(makeReconcileRequest())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cws = loadClusterWebhookSecret(t, cl)
+	assertCondition(t, cws, v1alpha1.WebhookReadyCondition, metav1.ConditionFalse, "WebhookFailed")
+	assertCondition(t, cws, v1alpha1.ReadyCondition, metav1.ConditionFalse, "WebhookFailed")
+}
+
+// Changes to the spec are applied to the WebhookSecret.
+func TestClusterWebhookSecretControllerUpdatesWebhookSecret(t *testing.T) {
+	logf.SetLogger(logf.ZapLogger(true))
+	cws := makeClusterWebhookSecret()
+	ws := makeReadyWebhookSecret(t, cws)
+	cws.Spec.Events = []v1alpha1.HookEvent{v1alpha1.PushEvent}
+	cl, r := makeReconciler(t, cws, ws, makeGeneratedSecret())
+
+	_, err := r.Reconcile(makeReconcileRequest())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	updated := &v1alpha1.WebhookSecret{}
+	if err := cl.Get(context.TODO(), types.NamespacedName{Name: testName, Namespace: testHomeNamespace}, updated); err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(cws.Spec.WebhookSecretSpec, updated.Spec); diff != "" {
+		t.Fatalf("WebhookSecret was not updated:\n%s", diff)
+	}
+	assertEvents(t, r.recorder, "Normal WebhookSecretUpdated Updated WebhookSecret platform/test-cluster-secret")
+}
+
+// An existing WebhookSecret that isn't controlled by the ClusterWebhookSecret
+// is not changed.
+func TestClusterWebhookSecretControllerWithExistingWebhookSecret(t *testing.T) {
+	logf.SetLogger(logf.ZapLogger(true))
+	cws := makeClusterWebhookSecret()
+	existing := newWebhookSecret(cws)
+	cl, r := makeReconciler(t, cws, existing)
+
+	_, err := r.Reconcile(makeReconcileRequest())
+	if !test.MatchError(t, "already exists and is not controlled", err) {
+		t.Fatalf("expected an existing WebhookSecret error, got %#v", err)
+	}
+
+	cws = loadClusterWebhookSecret(t, cl)
+	assertCondition(t, cws, v1alpha1.WebhookReadyCondition, metav1.ConditionFalse, reasonWebhookSecretExists)
+	assertCondition(t, cws, v1alpha1.ReadyCondition, metav1.ConditionFalse, reasonWebhookSecretExists)
+}
+
+// The WebhookSecret is created in the namespace of the auth secret, so it
+// must have one.
+func TestClusterWebhookSecretControllerWithMissingAuthSecretNamespace(t *testing.T) {
+	logf.SetLogger(logf.ZapLogger(true))
+	cws := makeClusterWebhookSecret()
+	cws.Spec.AuthSecretRef.Namespace = ""
+	cl, r := makeReconciler(t, cws)
+
+	_, err := r.Reconcile(makeReconcileRequest())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cws = loadClusterWebhookSecret(t, cl)
+	assertCondition(t, cws, v1alpha1.ReadyCondition, metav1.ConditionFalse, reasonNamespaceMissing)
+	assertEvents(t, r.recorder, "Warning AuthSecretNamespaceMissing authSecretRef.namespace must be set for a ClusterWebhookSecret")
+}
+
+func TestClusterWebhookSecretControllerWithInvalidSelector(t *testing.T) {
+	logf.SetLogger(logf.ZapLogger(true))
+	cws := makeClusterWebhookSecret()
+	cws.Spec.NamespaceSelector = &metav1.LabelSelector{
+		MatchExpressions: []metav1.LabelSelectorRequirement{{Key: "webhooks", Operator: "Unknown"}},
+	}
+	cl, r := makeReconciler(t, cws, makeReadyWebhookSecret(t, cws), makeGeneratedSecret())
+
+	_, err := r.Reconcile(makeReconcileRequest())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cws = loadClusterWebhookSecret(t, cl)
+	assertCondition(t, cws, v1alpha1.SecretsSyncedCondition, metav1.ConditionFalse, reasonSelectorInvalid)
+}
+
+func makeReconciler(t *testing.T, cws *v1alpha1.ClusterWebhookSecret, objs ...runtime.Object) (client.Client, *ReconcileClusterWebhookSecret) {
+	t.Helper()
+	s := scheme.Scheme
+	s.AddKnownTypes(v1alpha1.SchemeGroupVersion, cws, &v1alpha1.ClusterWebhookSecretList{},
+		&v1alpha1.WebhookSecret{}, &v1alpha1.WebhookSecretList{})
+
+	cl := fake.NewFakeClient(append([]runtime.Object{cws}, objs...)...)
+	return cl, &ReconcileClusterWebhookSecret{
+		kubeClient: cl,
+		scheme:     s,
+		recorder:   record.NewFakeRecorder(20),
+	}
+}
+
+func makeClusterWebhookSecret() *v1alpha1.ClusterWebhookSecret {
+	return &v1alpha1.ClusterWebhookSecret{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: v1alpha1.SchemeGroupVersion.String(),
+			Kind:       "ClusterWebhookSecret",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name: testName,
+			UID:  types.UID("test-cluster-secret-uid"),
+		},
+		Spec: v1alpha1.ClusterWebhookSecretSpec{
+			WebhookSecretSpec: v1alpha1.WebhookSecretSpec{
+				Repo:          v1alpha1.Repo{URL: testRepoURL},
+				AuthSecretRef: v1alpha1.AuthSecretRef{Name: testAuthSecretName, Namespace: testHomeNamespace},
+				WebhookURL:    v1alpha1.HookRoute{HookURL: testHookEndpoint},
+			},
+		},
+	}
+}
+
+// makeWebhookSecret returns the WebhookSecret that was created for the
+// ClusterWebhookSecret.
+func makeWebhookSecret(t *testing.T, cws *v1alpha1.ClusterWebhookSecret) *v1alpha1.WebhookSecret {
+	t.Helper()
+	ws := newWebhookSecret(cws)
+	if err := controllerutil.SetControllerReference(cws, ws, scheme.Scheme); err != nil {
+		t.Fatal(err)
+	}
+	return ws
+}
+
+// makeReadyWebhookSecret returns a WebhookSecret that has created the webhook.
+func makeReadyWebhookSecret(t *testing.T, cws *v1alpha1.ClusterWebhookSecret) *v1alpha1.WebhookSecret {
+	t.Helper()
+	ws := makeWebhookSecret(t, cws)
+	ws.Status = v1alpha1.WebhookSecretStatus{
+		WebhookID: testWebhookID,
+		HookURL:   testHookEndpoint,
+		SecretRef: v1alpha1.WebhookSecretRef{Name: testName, Key: "token"},
+		Conditions: []v1alpha1.Condition{
+			{Type: v1alpha1.ReadyCondition, Status: metav1.ConditionTrue, Reason: reasonReady},
+		},
+	}
+	return ws
+}
+
+// makeGeneratedSecret returns the Secret generated by the WebhookSecret.
+func makeGeneratedSecret() *corev1.Secret {
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      testName,
+			Namespace: testHomeNamespace,
+		},
+		Data: map[string][]byte{
+			"token": []byte(testToken),
+		},
+	}
+}
+
+// makeCopy returns a copy of the generated Secret in the namespace.
+func makeCopy(t *testing.T, cws *v1alpha1.ClusterWebhookSecret, ns string) *corev1.Secret {
+	t.Helper()
+	secret := newCopy(cws, makeGeneratedSecret(), ns)
+	if err := controllerutil.SetControllerReference(cws, secret, scheme.Scheme); err != nil {
+		t.Fatal(err)
+	}
+	return secret
+}
+
+func makeNamespace(name string, l map[string]string) *corev1.Namespace {
+	return &corev1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   name,
+			Labels: l,
+		},
+	}
+}
+
+func makeReconcileRequest() reconcile.Request {
+	return reconcile.Request{
+		NamespacedName: types.NamespacedName{Name: testName},
+	}
+}
+
+func loadClusterWebhookSecret(t *testing.T, cl client.Client) *v1alpha1.ClusterWebhookSecret {
+	t.Helper()
+	cws := &v1alpha1.ClusterWebhookSecret{}
+	if err := cl.Get(context.TODO(), makeReconcileRequest().NamespacedName, cws); err != nil {
+		t.Fatal(err)
+	}
+	return cws
+}
+
+// assertCopies checks that there's a copy of the generated Secret controlled
+// by the ClusterWebhookSecret in each of the namespaces.
+func assertCopies(t *testing.T, cl client.Client, cws *v1alpha1.ClusterWebhookSecret, namespaces ...string) {
+	t.Helper()
+	for _, ns := range namespaces {
+		secret := &corev1.Secret{}
+		if err := cl.Get(context.TODO(), types.NamespacedName{Name: testName, Namespace: ns}, secret); err != nil {
+			t.Fatalf("failed to get the copy in %s: %s", ns, err)
+		}
+		if string(secret.Data["token"]) != testToken {
+			t.Fatalf("copy in %s got token %#v, want %#v", ns, string(secret.Data["token"]), testToken)
+		}
+		if !metav1.IsControlledBy(secret, cws) {
+			t.Fatalf("copy in %s is not controlled by the ClusterWebhookSecret, got %#v", ns, secret.OwnerReferences)
+		}
+		if l := secret.Labels[v1alpha1.ClusterWebhookSecretLabel]; l != cws.Name {
+			t.Fatalf("copy in %s got label %#v, want %#v", ns, l, cws.Name)
+		}
+	}
+}
+
+func assertCondition(t *testing.T, cws *v1alpha1.ClusterWebhookSecret, ct v1alpha1.ConditionType, status metav1.ConditionStatus, reason string) {
+	t.Helper()
+	c := common.FindCondition(cws.Status.Conditions, ct)
+	if c == nil {
+		t.Fatalf("condition %s not found in %#v", ct, cws.Status.Conditions)
+	}
+	if c.Status != status || c.Reason != reason {
+		t.Fatalf("condition %s got %s/%s, want %s/%s", ct, c.Status, c.Reason, status, reason)
+	}
+}
+
+func assertEvents(t *testing.T, r record.EventRecorder, want ...string) {
+	t.Helper()
+	events := r.(*record.FakeRecorder).Events
+	got := []string{}
+	for len(events) > 0 {
+		got = append(got, <-events)
+	}
+	if diff := cmp.Diff(want, got, cmpopts.EquateEmpty()); diff != "" {
+		t.Fatalf("events failed:\n%s", diff)
+	}
+}
+
+// statusFailingClient is a client that fails to update the status of
+// resources.
+type statusFailingClient struct {
+	client.Client
+	err error
+}
+
+func (c statusFailingClient) Status() client.StatusWriter {
+	return failingStatusWriter{err: c.err}
+}
+
+type failingStatusWriter struct {
+	err error
+}
+
+func (w failingStatusWriter) Update(ctx context.Context, obj runtime.Object, opts ...client.UpdateOption) error {
+	return w.err
+}
+
+func (w failingStatusWriter) Patch(ctx context.Context, obj runtime.Object, patch client.Patch, opts ...client.PatchOption) error {
+	return w.err
+}
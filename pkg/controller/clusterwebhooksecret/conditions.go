@@ -0,0 +1,43 @@
+package clusterwebhooksecret
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	v1alpha1 "github.com/bigkevmcd/webhook-secret-operator/pkg/apis/apps/v1alpha1"
+	"github.com/bigkevmcd/webhook-secret-operator/pkg/controller/common"
+)
+
+// Reasons for the conditions and events on a ClusterWebhookSecret.
+const (
+	reasonNamespaceMissing     = "AuthSecretNamespaceMissing"
+	reasonSelectorInvalid      = "SelectorInvalid"
+	reasonWebhookSecretCreated = "WebhookSecretCreated"
+	reasonWebhookSecretUpdated = "WebhookSecretUpdated"
+	reasonWebhookSecretFailed  = "WebhookSecretFailed"
+	reasonWebhookSecretExists  = "WebhookSecretExists"
+	reasonSecretPending        = "SecretPending"
+	reasonSecretCopied         = "SecretCopied"
+	reasonSecretDeleted        = "SecretDeleted"
+	reasonSecretFailed         = "SecretFailed"
+	reasonSecretsSynced        = "SecretsSynced"
+	reasonReady                = common.ReasonReady
+	reasonPending              = common.ReasonPending
+)
+
+// readyConditions are the conditions that must all be true for the
+// ClusterWebhookSecret to be Ready.
+var readyConditions = []v1alpha1.ConditionType{
+	v1alpha1.WebhookReadyCondition,
+	v1alpha1.SecretsSyncedCondition,
+}
+
+// setCondition adds or updates a condition on the ClusterWebhookSecret.
+func setCondition(cws *v1alpha1.ClusterWebhookSecret, t v1alpha1.ConditionType, status metav1.ConditionStatus, reason, message string) {
+	common.SetCondition(&cws.Status.Conditions, cws.Generation, t, status, reason, message)
+}
+
+// setReadyCondition sets the Ready condition from the other conditions, in
+// the same way as for a WebhookSecret.
+func setReadyCondition(cws *v1alpha1.ClusterWebhookSecret) {
+	common.SetReadyCondition(&cws.Status.Conditions, cws.Generation, readyConditions)
+}
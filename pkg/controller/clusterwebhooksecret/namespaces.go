@@ -0,0 +1,127 @@
+package clusterwebhooksecret
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	v1alpha1 "github.com/bigkevmcd/webhook-secret-operator/pkg/apis/apps/v1alpha1"
+)
+
+// targetNamespaces returns the sorted namespaces that the generated Secret
+// should be copied to, the Namespaces and the namespaces that match the
+// NamespaceSelector.
+//
+// The namespace of the auth secret is never included, the generated Secret
+// is already there.
+func (r *ReconcileClusterWebhookSecret) targetNamespaces(ctx context.Context, cws *v1alpha1.ClusterWebhookSecret) ([]string, error) {
+	found := map[string]bool{}
+	for _, ns := range cws.Spec.Namespaces {
+		found[ns] = true
+	}
+	if cws.Spec.NamespaceSelector != nil {
+		selector, err := namespaceSelector(cws)
+		if err != nil {
+			return nil, err
+		}
+		list := &corev1.NamespaceList{}
+		if err := r.kubeClient.List(ctx, list, client.MatchingLabelsSelector{Selector: selector}); err != nil {
+			return nil, fmt.Errorf("failed to list namespaces: %w", err)
+		}
+		for _, ns := range list.Items {
+			found[ns.Name] = true
+		}
+	}
+	delete(found, cws.Spec.AuthSecretRef.Namespace)
+
+	namespaces := []string{}
+	for ns := range found {
+		namespaces = append(namespaces, ns)
+	}
+	sort.Strings(namespaces)
+	return namespaces, nil
+}
+
+// namespaceSelector parses the NamespaceSelector of the ClusterWebhookSecret,
+// an empty selector matches all namespaces.
+func namespaceSelector(cws *v1alpha1.ClusterWebhookSecret) (labels.Selector, error) {
+	selector, err := metav1.LabelSelectorAsSelector(cws.Spec.NamespaceSelector)
+	if err != nil {
+		return nil, invalidSelectorError{err: err}
+	}
+	return selector, nil
+}
+
+type invalidSelectorError struct {
+	err error
+}
+
+func (e invalidSelectorError) Error() string {
+	return fmt.Sprintf("invalid namespace selector: %s", e.err)
+}
+
+func isInvalidSelector(err error) bool {
+	_, ok := err.(invalidSelectorError)
+	return ok
+}
+
+// namespaceMapper maps Namespaces to the ClusterWebhookSecrets that list the
+// namespace, or select it by its labels.
+type namespaceMapper struct {
+	kubeClient client.Client
+}
+
+// Map implements the handler.Mapper interface.
+func (m *namespaceMapper) Map(obj handler.MapObject) []reconcile.Request {
+	list := &v1alpha1.ClusterWebhookSecretList{}
+	if err := m.kubeClient.List(context.TODO(), list); err != nil {
+		log.Error(err, "failed to list ClusterWebhookSecrets for Namespace", "namespace", obj.Meta.GetName())
+		return nil
+	}
+	requests := []reconcile.Request{}
+	for i := range list.Items {
+		cws := &list.Items[i]
+		if !selectsNamespace(cws, obj.Meta) {
+			continue
+		}
+		requests = append(requests, reconcile.Request{
+			NamespacedName: types.NamespacedName{Name: cws.Name},
+		})
+	}
+	return requests
+}
+
+// selectsNamespace returns true if the generated Secret for the
+// ClusterWebhookSecret should be copied to the namespace.
+func selectsNamespace(cws *v1alpha1.ClusterWebhookSecret, ns metav1.Object) bool {
+	if containsString(cws.Spec.Namespaces, ns.GetName()) {
+		return true
+	}
+	if cws.Spec.NamespaceSelector == nil {
+		return false
+	}
+	selector, err := namespaceSelector(cws)
+	if err != nil {
+		return false
+	}
+	return selector.Matches(labels.Set(ns.GetLabels()))
+}
+
+// namespaceLabelsChanged is a predicate that ignores updates to Namespaces
+// that don't change their labels, the copies of the generated Secret only
+// depend on the name and labels.
+var namespaceLabelsChanged = predicate.Funcs{
+	UpdateFunc: func(e event.UpdateEvent) bool {
+		return !labels.Equals(labels.Set(e.MetaOld.GetLabels()), labels.Set(e.MetaNew.GetLabels()))
+	},
+}
@@ -0,0 +1,75 @@
+package clusterwebhooksecret
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+func TestNamespaceMapper(t *testing.T) {
+	listed := makeClusterWebhookSecret()
+	listed.Spec.Namespaces = []string{"team-a"}
+	selecting := makeClusterWebhookSecret()
+	selecting.Name = "selecting"
+	selecting.UID = types.UID("selecting-uid")
+	selecting.Spec.NamespaceSelector = &metav1.LabelSelector{MatchLabels: map[string]string{"webhooks": "true"}}
+	cl, _ := makeReconciler(t, listed, selecting)
+	m := &namespaceMapper{kubeClient: cl}
+
+	mapperTests := []struct {
+		ns   *corev1.Namespace
+		want []reconcile.Request
+	}{
+		{makeNamespace("team-a", nil), []reconcile.Request{{NamespacedName: types.NamespacedName{Name: testName}}}},
+		{makeNamespace("team-b", map[string]string{"webhooks": "true"}), []reconcile.Request{{NamespacedName: types.NamespacedName{Name: "selecting"}}}},
+		{makeNamespace("team-c", map[string]string{"webhooks": "false"}), []reconcile.Request{}},
+	}
+
+	for _, tt := range mapperTests {
+		t.Run(tt.ns.Name, func(rt *testing.T) {
+			got := m.Map(handler.MapObject{Meta: tt.ns, Object: tt.ns})
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				rt.Errorf("Map() failed:\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestSecretMapper(t *testing.T) {
+	cws := makeClusterWebhookSecret()
+	ws := makeWebhookSecret(t, cws)
+	cl, _ := makeReconciler(t, cws, ws)
+	m := &secretMapper{kubeClient: cl}
+	want := []reconcile.Request{{NamespacedName: types.NamespacedName{Name: testName}}}
+
+	generated := makeGeneratedSecret()
+	generated.OwnerReferences = []metav1.OwnerReference{
+		*metav1.NewControllerRef(ws, ws.GroupVersionKind()),
+	}
+	unrelated := makeGeneratedSecret()
+	unrelated.Namespace = "team-a"
+
+	mapperTests := []struct {
+		name   string
+		secret *corev1.Secret
+		want   []reconcile.Request
+	}{
+		{"copy", makeCopy(t, cws, "team-a"), want},
+		{"generated secret", generated, want},
+		{"unrelated secret", unrelated, nil},
+	}
+
+	for _, tt := range mapperTests {
+		t.Run(tt.name, func(rt *testing.T) {
+			got := m.Map(handler.MapObject{Meta: tt.secret, Object: tt.secret})
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				rt.Errorf("Map() failed:\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,222 @@
+package clusterwebhooksecret
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	v1alpha1 "github.com/bigkevmcd/webhook-secret-operator/pkg/apis/apps/v1alpha1"
+)
+
+// reconcileCopies copies the Secret generated by the WebhookSecret to each of
+// the namespaces, and deletes the copies from namespaces that are no longer
+// selected.
+//
+// Only Secrets that are controlled by the ClusterWebhookSecret are updated or
+// deleted, a namespace with an existing Secret with the same name is
+// recorded as a failure.
+func (r *ReconcileClusterWebhookSecret) reconcileCopies(ctx context.Context, logger logr.Logger, cws *v1alpha1.ClusterWebhookSecret, ws *v1alpha1.WebhookSecret, namespaces []string) error {
+	sourceID := types.NamespacedName{Name: ws.Name, Namespace: ws.Namespace}
+	source := &corev1.Secret{}
+	if err := r.kubeClient.Get(ctx, sourceID, source); err != nil {
+		if !errors.IsNotFound(err) {
+			return fmt.Errorf("failed to get Secret %s: %w", sourceID, err)
+		}
+		setCondition(cws, v1alpha1.SecretsSyncedCondition, metav1.ConditionUnknown, reasonSecretPending,
+			fmt.Sprintf("waiting for Secret %s to be created", sourceID))
+		return nil
+	}
+
+	copied := []string{}
+	failures := []string{}
+	for _, ns := range namespaces {
+		owned, err := r.copySecret(ctx, logger, cws, source, ns)
+		if owned {
+			copied = append(copied, ns)
+		}
+		if err != nil {
+			failures = append(failures, err.Error())
+			r.recorder.Event(cws, corev1.EventTypeWarning, reasonSecretFailed, err.Error())
+		}
+	}
+
+	existing, err := r.copyNamespaces(ctx, cws, source.Name)
+	if err != nil {
+		setCondition(cws, v1alpha1.SecretsSyncedCondition, metav1.ConditionFalse, reasonSecretFailed, err.Error())
+		return err
+	}
+	for _, ns := range existing {
+		if containsString(namespaces, ns) {
+			continue
+		}
+		if err := r.deleteCopy(ctx, logger, cws, source.Name, ns); err != nil {
+			copied = append(copied, ns)
+			failures = append(failures, err.Error())
+			r.recorder.Event(cws, corev1.EventTypeWarning, reasonSecretFailed, err.Error())
+		}
+	}
+	cws.Status.Namespaces = copied
+
+	if len(failures) > 0 {
+		msg := strings.Join(failures, "; ")
+		setCondition(cws, v1alpha1.SecretsSyncedCondition, metav1.ConditionFalse, reasonSecretFailed, msg)
+		return fmt.Errorf("failed to sync the Secret %s: %s", sourceID, msg)
+	}
+	setCondition(cws, v1alpha1.SecretsSyncedCondition, metav1.ConditionTrue, reasonSecretsSynced,
+		fmt.Sprintf("Secret %s was copied to %d namespaces", sourceID, len(copied)))
+	return nil
+}
+
+// copyNamespaces returns the namespaces with a copy of the Secret that's
+// controlled by the ClusterWebhookSecret, found from the label on the copies,
+// and the namespaces recorded in the status, which includes copies that were
+// made before the copies were labelled.
+func (r *ReconcileClusterWebhookSecret) copyNamespaces(ctx context.Context, cws *v1alpha1.ClusterWebhookSecret, name string) ([]string, error) {
+	copies := &corev1.SecretList{}
+	if err := r.kubeClient.List(ctx, copies, client.MatchingLabels{v1alpha1.ClusterWebhookSecretLabel: cws.Name}); err != nil {
+		return nil, fmt.Errorf("failed to list the copies of Secret %s: %w", name, err)
+	}
+	namespaces := append([]string{}, cws.Status.Namespaces...)
+	for i := range copies.Items {
+		c := &copies.Items[i]
+		if c.Name != name || !metav1.IsControlledBy(c, cws) || containsString(namespaces, c.Namespace) {
+			continue
+		}
+		namespaces = append(namespaces, c.Namespace)
+	}
+	return namespaces, nil
+}
+
+// copySecret creates or updates the copy of the Secret in the namespace,
+// returning true if there's a copy controlled by the ClusterWebhookSecret.
+func (r *ReconcileClusterWebhookSecret) copySecret(ctx context.Context, logger logr.Logger, cws *v1alpha1.ClusterWebhookSecret, source *corev1.Secret, ns string) (bool, error) {
+	want := newCopy(cws, source, ns)
+	if err := controllerutil.SetControllerReference(cws, want, r.scheme); err != nil {
+		return false, err
+	}
+	id := types.NamespacedName{Name: want.Name, Namespace: ns}
+	existing := &corev1.Secret{}
+	err := r.kubeClient.Get(ctx, id, existing)
+	switch {
+	case errors.IsNotFound(err):
+		logger.Info("Copying the Secret", "namespace", ns, "name", id.Name)
+		if err := r.kubeClient.Create(ctx, want); err != nil {
+			return false, fmt.Errorf("failed to create Secret %s: %w", id, err)
+		}
+		r.recorder.Eventf(cws, corev1.EventTypeNormal, reasonSecretCopied, "Copied Secret to %s", id)
+		return true, nil
+	case err != nil:
+		return false, fmt.Errorf("failed to get Secret %s: %w", id, err)
+	case !metav1.IsControlledBy(existing, cws):
+		return false, fmt.Errorf("Secret %s already exists and is not controlled by the ClusterWebhookSecret", id)
+	case !equality.Semantic.DeepEqual(existing.Data, want.Data) || existing.Type != want.Type || existing.Labels[v1alpha1.ClusterWebhookSecretLabel] != cws.Name:
+		logger.Info("Updating the copy of the Secret", "namespace", ns, "name", id.Name)
+		existing.Data = want.Data
+		existing.Type = want.Type
+		if existing.Labels == nil {
+			existing.Labels = map[string]string{}
+		}
+		existing.Labels[v1alpha1.ClusterWebhookSecretLabel] = cws.Name
+		if err := r.kubeClient.Update(ctx, existing); err != nil {
+			return true, fmt.Errorf("failed to update Secret %s: %w", id, err)
+		}
+		r.recorder.Eventf(cws, corev1.EventTypeNormal, reasonSecretCopied, "Updated the copy of the Secret in %s", id)
+	}
+	return true, nil
+}
+
+// deleteCopy deletes the copy of the Secret from a namespace that's no longer
+// selected, if it's controlled by the ClusterWebhookSecret.
+func (r *ReconcileClusterWebhookSecret) deleteCopy(ctx context.Context, logger logr.Logger, cws *v1alpha1.ClusterWebhookSecret, name, ns string) error {
+	id := types.NamespacedName{Name: name, Namespace: ns}
+	existing := &corev1.Secret{}
+	if err := r.kubeClient.Get(ctx, id, existing); err != nil {
+		if errors.IsNotFound(err) {
+			return nil
+		}
+		return fmt.Errorf("failed to get Secret %s: %w", id, err)
+	}
+	if !metav1.IsControlledBy(existing, cws) {
+		return nil
+	}
+	logger.Info("Deleting the copy of the Secret", "namespace", ns, "name", name)
+	if err := r.kubeClient.Delete(ctx, existing); err != nil && !errors.IsNotFound(err) {
+		return fmt.Errorf("failed to delete Secret %s: %w", id, err)
+	}
+	r.recorder.Eventf(cws, corev1.EventTypeNormal, reasonSecretDeleted, "Deleted Secret %s, the namespace is no longer selected", id)
+	return nil
+}
+
+// newCopy returns a copy of the data in the Secret, in another namespace,
+// labelled with the name of the ClusterWebhookSecret.
+func newCopy(cws *v1alpha1.ClusterWebhookSecret, source *corev1.Secret, ns string) *corev1.Secret {
+	return &corev1.Secret{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "v1",
+			Kind:       "Secret",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      source.Name,
+			Namespace: ns,
+			Labels: map[string]string{
+				v1alpha1.ClusterWebhookSecretLabel: cws.Name,
+			},
+		},
+		Type: source.Type,
+		Data: source.DeepCopy().Data,
+	}
+}
+
+// secretMapper maps Secrets to the ClusterWebhookSecret that they were
+// generated for, either a copy controlled by the ClusterWebhookSecret, or
+// the Secret generated by its WebhookSecret.
+type secretMapper struct {
+	kubeClient client.Client
+}
+
+// Map implements the handler.Mapper interface.
+func (m *secretMapper) Map(obj handler.MapObject) []reconcile.Request {
+	owner := metav1.GetControllerOf(obj.Meta)
+	if owner == nil || owner.APIVersion != v1alpha1.SchemeGroupVersion.String() {
+		return nil
+	}
+	switch owner.Kind {
+	case "ClusterWebhookSecret":
+		return []reconcile.Request{{NamespacedName: types.NamespacedName{Name: owner.Name}}}
+	case "WebhookSecret":
+		ws := &v1alpha1.WebhookSecret{}
+		id := types.NamespacedName{Name: owner.Name, Namespace: obj.Meta.GetNamespace()}
+		if err := m.kubeClient.Get(context.TODO(), id, ws); err != nil {
+			if !errors.IsNotFound(err) {
+				log.Error(err, "failed to get WebhookSecret for Secret", "namespace", id.Namespace, "name", id.Name)
+			}
+			return nil
+		}
+		wsOwner := metav1.GetControllerOf(ws)
+		if wsOwner == nil || wsOwner.APIVersion != v1alpha1.SchemeGroupVersion.String() || wsOwner.Kind != "ClusterWebhookSecret" {
+			return nil
+		}
+		return []reconcile.Request{{NamespacedName: types.NamespacedName{Name: wsOwner.Name}}}
+	}
+	return nil
+}
+
+func containsString(s []string, v string) bool {
+	for _, item := range s {
+		if item == v {
+			return true
+		}
+	}
+	return false
+}
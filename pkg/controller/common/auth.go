@@ -0,0 +1,86 @@
+package common
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	v1alpha1 "github.com/bigkevmcd/webhook-secret-operator/pkg/apis/apps/v1alpha1"
+	"github.com/bigkevmcd/webhook-secret-operator/pkg/git"
+	"github.com/bigkevmcd/webhook-secret-operator/pkg/secrets"
+)
+
+// Reasons for the AuthResolved condition.
+const (
+	ReasonAuthResolved         = "AuthResolved"
+	ReasonAuthSecretNotFound   = "AuthSecretNotFound"
+	ReasonAuthSecretInvalid    = "AuthSecretInvalid"
+	ReasonAuthSecretMalformed  = "AuthSecretMalformed"
+	ReasonAuthSecretNotGranted = "AuthSecretNotGranted"
+)
+
+// Object is a resource that events can be recorded for.
+type Object interface {
+	runtime.Object
+	metav1.Object
+}
+
+// AuthResolver resolves the credentials in the auth secrets referenced by
+// objects, recording the result in the AuthResolved condition of the object,
+// and failures as Warning events.
+type AuthResolver struct {
+	Reader   client.Reader
+	Secrets  secrets.SecretGetter
+	Recorder record.EventRecorder
+}
+
+// Credentials returns the credentials from the auth secret referenced from the
+// object of the kind, if the auth secret is in another namespace, an
+// AuthSecretGrant must allow the reference.
+//
+// The AuthResolved condition is only set if the credentials can't be
+// resolved, the caller sets it with Resolved once it has created a client
+// with the credentials.
+func (a AuthResolver) Credentials(ctx context.Context, obj Object, conditions *[]v1alpha1.Condition, kind string, ref v1alpha1.AuthSecretRef) (git.Credentials, error) {
+	id := AuthSecretID(ref, obj.GetNamespace())
+	if err := CheckAuthSecretGranted(ctx, a.Reader, kind, obj.GetNamespace(), id); err != nil {
+		reason := ReasonAuthSecretInvalid
+		if IsNotGranted(err) {
+			reason = ReasonAuthSecretNotGranted
+		}
+		return git.Credentials{}, a.Failed(obj, conditions, reason, err)
+	}
+	creds, err := a.Secrets.Credentials(ctx, id, ref.Key)
+	if errors.IsNotFound(err) {
+		SetCondition(conditions, obj.GetGeneration(), v1alpha1.AuthResolvedCondition, metav1.ConditionFalse, ReasonAuthSecretNotFound, err.Error())
+		a.Recorder.Eventf(obj, corev1.EventTypeWarning, ReasonAuthSecretNotFound, "Auth secret %s was not found", id)
+		return git.Credentials{}, err
+	}
+	if secrets.IsInvalidSecret(err) {
+		return git.Credentials{}, a.Failed(obj, conditions, ReasonAuthSecretMalformed, err)
+	}
+	if err != nil {
+		return git.Credentials{}, a.Failed(obj, conditions, ReasonAuthSecretInvalid, fmt.Errorf("could not get authentication token from %s: %s", id, err))
+	}
+	return creds, nil
+}
+
+// Failed records the error in the AuthResolved condition of the object, and
+// as a Warning event, and returns the error.
+func (a AuthResolver) Failed(obj Object, conditions *[]v1alpha1.Condition, reason string, err error) error {
+	SetCondition(conditions, obj.GetGeneration(), v1alpha1.AuthResolvedCondition, metav1.ConditionFalse, reason, err.Error())
+	a.Recorder.Event(obj, corev1.EventTypeWarning, reason, err.Error())
+	return err
+}
+
+// Resolved records that the credentials were resolved in the AuthResolved
+// condition of the object.
+func (a AuthResolver) Resolved(obj Object, conditions *[]v1alpha1.Condition) {
+	SetCondition(conditions, obj.GetGeneration(), v1alpha1.AuthResolvedCondition, metav1.ConditionTrue, ReasonAuthResolved, "")
+}
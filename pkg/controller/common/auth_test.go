@@ -0,0 +1,133 @@
+package common
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	v1alpha1 "github.com/bigkevmcd/webhook-secret-operator/pkg/apis/apps/v1alpha1"
+	"github.com/bigkevmcd/webhook-secret-operator/pkg/secrets"
+	"github.com/bigkevmcd/webhook-secret-operator/test"
+)
+
+func TestAuthResolverCredentials(t *testing.T) {
+	authTests := []struct {
+		name       string
+		ref        v1alpha1.AuthSecretRef
+		objs       []runtime.Object
+		wantToken  string
+		wantReason string
+		wantEvent  string
+		wantErr    string
+	}{
+		{"resolved", v1alpha1.AuthSecretRef{Name: testAuthSecretName},
+			[]runtime.Object{makeAuthSecret(testNamespace, map[string][]byte{"token": []byte("test-token")})},
+			"test-token", "", "", ""},
+		{"not found", v1alpha1.AuthSecretRef{Name: testAuthSecretName}, nil,
+			"", ReasonAuthSecretNotFound, "Warning AuthSecretNotFound Auth secret test-ns/auth-secret was not found", "not found"},
+		{"malformed", v1alpha1.AuthSecretRef{Name: testAuthSecretName, Key: "github-token"},
+			[]runtime.Object{makeAuthSecret(testNamespace, map[string][]byte{"token": []byte("test-token")})},
+			"", ReasonAuthSecretMalformed, "Warning AuthSecretMalformed secret invalid, no 'github-token' key in test-ns/auth-secret", "no 'github-token' key"},
+		{"not granted", v1alpha1.AuthSecretRef{Name: testAuthSecretName, Namespace: testPlatformNamespace},
+			[]runtime.Object{makeAuthSecret(testPlatformNamespace, map[string][]byte{"token": []byte("test-token")})},
+			"", ReasonAuthSecretNotGranted, "Warning AuthSecretNotGranted no AuthSecretGrant in platform allows WebhookSecrets in test-ns to reference the secret auth-secret", "no AuthSecretGrant"},
+	}
+
+	s := scheme.Scheme
+	s.AddKnownTypes(v1alpha1.SchemeGroupVersion, &v1alpha1.WebhookSecret{}, &v1alpha1.AuthSecretGrant{}, &v1alpha1.AuthSecretGrantList{})
+	for _, tt := range authTests {
+		t.Run(tt.name, func(rt *testing.T) {
+			cl := fake.NewFakeClientWithScheme(s, tt.objs...)
+			recorder := record.NewFakeRecorder(10)
+			auth := AuthResolver{Reader: cl, Secrets: secrets.New(cl), Recorder: recorder}
+			ws := makeWebhookSecret(tt.ref)
+
+			creds, err := auth.Credentials(context.TODO(), ws, &ws.Status.Conditions, "WebhookSecret", tt.ref)
+			if !test.MatchError(rt, tt.wantErr, err) {
+				rt.Fatalf("error failed to match, got %#v, want %s", err, tt.wantErr)
+			}
+			if creds.Token != tt.wantToken {
+				rt.Fatalf("token got %q, want %q", creds.Token, tt.wantToken)
+			}
+			if tt.wantReason == "" {
+				if c := FindCondition(ws.Status.Conditions, v1alpha1.AuthResolvedCondition); c != nil {
+					rt.Fatalf("AuthResolved condition set to %#v", c)
+				}
+				assertNoEvents(rt, recorder)
+				return
+			}
+			assertAuthResolved(rt, ws, metav1.ConditionFalse, tt.wantReason)
+			if e := <-recorder.Events; e != tt.wantEvent {
+				rt.Fatalf("event got %q, want %q", e, tt.wantEvent)
+			}
+		})
+	}
+}
+
+func TestAuthResolverFailed(t *testing.T) {
+	recorder := record.NewFakeRecorder(10)
+	auth := AuthResolver{Recorder: recorder}
+	ws := makeWebhookSecret(v1alpha1.AuthSecretRef{Name: testAuthSecretName})
+	want := errors.New("unknown driver")
+
+	if err := auth.Failed(ws, &ws.Status.Conditions, "UnknownDriver", want); err != want {
+		t.Fatalf("got %v, want %v", err, want)
+	}
+	assertAuthResolved(t, ws, metav1.ConditionFalse, "UnknownDriver")
+	if e := <-recorder.Events; e != "Warning UnknownDriver unknown driver" {
+		t.Fatalf("event got %q", e)
+	}
+
+	auth.Resolved(ws, &ws.Status.Conditions)
+	assertAuthResolved(t, ws, metav1.ConditionTrue, ReasonAuthResolved)
+}
+
+func assertAuthResolved(t *testing.T, ws *v1alpha1.WebhookSecret, status metav1.ConditionStatus, reason string) {
+	t.Helper()
+	c := FindCondition(ws.Status.Conditions, v1alpha1.AuthResolvedCondition)
+	if c == nil {
+		t.Fatal("AuthResolved condition not set")
+	}
+	if c.Status != status || c.Reason != reason {
+		t.Fatalf("AuthResolved condition got %s/%s, want %s/%s", c.Status, c.Reason, status, reason)
+	}
+}
+
+func assertNoEvents(t *testing.T, recorder *record.FakeRecorder) {
+	t.Helper()
+	select {
+	case e := <-recorder.Events:
+		t.Fatalf("unexpected event %q", e)
+	default:
+	}
+}
+
+func makeWebhookSecret(ref v1alpha1.AuthSecretRef) *v1alpha1.WebhookSecret {
+	return &v1alpha1.WebhookSecret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:       "test-webhook-secret",
+			Namespace:  testNamespace,
+			Generation: 1,
+		},
+		Spec: v1alpha1.WebhookSecretSpec{
+			AuthSecretRef: ref,
+		},
+	}
+}
+
+func makeAuthSecret(ns string, data map[string][]byte) *corev1.Secret {
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      testAuthSecretName,
+			Namespace: ns,
+		},
+		Data: data,
+	}
+}
@@ -0,0 +1,72 @@
+package common
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	v1alpha1 "github.com/bigkevmcd/webhook-secret-operator/pkg/apis/apps/v1alpha1"
+)
+
+// Reasons for the Ready condition when it's not set from a failing
+// condition.
+const (
+	ReasonReady   = "Ready"
+	ReasonPending = "Pending"
+)
+
+// SetCondition adds or updates a condition in the conditions of an object
+// with the generation.
+//
+// The LastTransitionTime is only changed if the status of the condition
+// changes.
+func SetCondition(conditions *[]v1alpha1.Condition, generation int64, t v1alpha1.ConditionType, status metav1.ConditionStatus, reason, message string) {
+	c := v1alpha1.Condition{
+		Type:               t,
+		Status:             status,
+		ObservedGeneration: generation,
+		LastTransitionTime: metav1.Now(),
+		Reason:             reason,
+		Message:            message,
+	}
+	for i, existing := range *conditions {
+		if existing.Type != t {
+			continue
+		}
+		if existing.Status == status {
+			c.LastTransitionTime = existing.LastTransitionTime
+		}
+		(*conditions)[i] = c
+		return
+	}
+	*conditions = append(*conditions, c)
+}
+
+// FindCondition returns the condition with the type, or nil if it's not set.
+func FindCondition(conditions []v1alpha1.Condition, t v1alpha1.ConditionType) *v1alpha1.Condition {
+	for i := range conditions {
+		if conditions[i].Type == t {
+			return &conditions[i]
+		}
+	}
+	return nil
+}
+
+// SetReadyCondition sets the Ready condition from the readyConditions.
+//
+// If any of the conditions are False, the Ready condition takes its reason and
+// message from the first of them, if any have not been set yet, the Ready
+// condition is Unknown.
+func SetReadyCondition(conditions *[]v1alpha1.Condition, generation int64, readyConditions []v1alpha1.ConditionType) {
+	for _, t := range readyConditions {
+		if c := FindCondition(*conditions, t); c != nil && c.Status == metav1.ConditionFalse {
+			SetCondition(conditions, generation, v1alpha1.ReadyCondition, metav1.ConditionFalse, c.Reason, c.Message)
+			return
+		}
+	}
+	for _, t := range readyConditions {
+		if c := FindCondition(*conditions, t); c == nil || c.Status != metav1.ConditionTrue {
+			SetCondition(conditions, generation, v1alpha1.ReadyCondition, metav1.ConditionUnknown, ReasonPending, string(t)+" has not been determined")
+			return
+		}
+	}
+	SetCondition(conditions, generation, v1alpha1.ReadyCondition, metav1.ConditionTrue, ReasonReady, "")
+}
@@ -0,0 +1,61 @@
+package common
+
+import (
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	v1alpha1 "github.com/bigkevmcd/webhook-secret-operator/pkg/apis/apps/v1alpha1"
+)
+
+func TestSetConditionAddsCondition(t *testing.T) {
+	conditions := []v1alpha1.Condition{}
+
+	SetCondition(&conditions, 3, v1alpha1.SecretReadyCondition, metav1.ConditionTrue, "SecretCreated", "")
+
+	c := FindCondition(conditions, v1alpha1.SecretReadyCondition)
+	if c == nil {
+		t.Fatal("condition was not added")
+	}
+	if c.Status != metav1.ConditionTrue || c.Reason != "SecretCreated" || c.ObservedGeneration != 3 {
+		t.Fatalf("incorrect condition: %#v", c)
+	}
+}
+
+func TestSetConditionKeepsTransitionTimeWhenStatusUnchanged(t *testing.T) {
+	past := metav1.NewTime(time.Now().Add(-time.Hour))
+	conditions := []v1alpha1.Condition{
+		{Type: v1alpha1.WebhookReadyCondition, Status: metav1.ConditionTrue, Reason: "WebhookCreated", LastTransitionTime: past},
+	}
+
+	SetCondition(&conditions, 1, v1alpha1.WebhookReadyCondition, metav1.ConditionTrue, "WebhookVerified", "")
+
+	c := FindCondition(conditions, v1alpha1.WebhookReadyCondition)
+	if !c.LastTransitionTime.Equal(&past) {
+		t.Errorf("transition time changed, got %v, want %v", c.LastTransitionTime, past)
+	}
+	if c.Reason != "WebhookVerified" {
+		t.Errorf("reason not updated, got %s, want %s", c.Reason, "WebhookVerified")
+	}
+
+	SetCondition(&conditions, 1, v1alpha1.WebhookReadyCondition, metav1.ConditionFalse, "WebhookFailed", "failed")
+
+	c = FindCondition(conditions, v1alpha1.WebhookReadyCondition)
+	if c.LastTransitionTime.Equal(&past) {
+		t.Error("transition time was not changed")
+	}
+	if l := len(conditions); l != 1 {
+		t.Errorf("got %d conditions, want 1", l)
+	}
+}
+
+func TestFindConditionWithMissingCondition(t *testing.T) {
+	conditions := []v1alpha1.Condition{
+		{Type: v1alpha1.SecretReadyCondition, Status: metav1.ConditionTrue},
+	}
+
+	if c := FindCondition(conditions, v1alpha1.WebhookReadyCondition); c != nil {
+		t.Fatalf("got %#v, want nil", c)
+	}
+}
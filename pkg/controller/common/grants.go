@@ -0,0 +1,58 @@
+package common
+
+import (
+	"context"
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	v1alpha1 "github.com/bigkevmcd/webhook-secret-operator/pkg/apis/apps/v1alpha1"
+)
+
+// AuthSecretID returns the namespaced name of the auth secret referenced from
+// an object in the namespace, the secret is in the object's namespace unless
+// the reference has a namespace.
+func AuthSecretID(ref v1alpha1.AuthSecretRef, namespace string) types.NamespacedName {
+	ns := ref.Namespace
+	if ns == "" {
+		ns = namespace
+	}
+	return types.NamespacedName{Name: ref.Name, Namespace: ns}
+}
+
+// CheckAuthSecretGranted returns an error if the auth secret is in another
+// namespace to the object of the kind, and no AuthSecretGrant in that
+// namespace allows it to be referenced from the object's namespace.
+func CheckAuthSecretGranted(ctx context.Context, c client.Reader, kind, namespace string, id types.NamespacedName) error {
+	if id.Namespace == namespace {
+		return nil
+	}
+	grants := &v1alpha1.AuthSecretGrantList{}
+	if err := c.List(ctx, grants, client.InNamespace(id.Namespace)); err != nil {
+		return fmt.Errorf("failed to list AuthSecretGrants in %s: %w", id.Namespace, err)
+	}
+	for _, g := range grants.Items {
+		if g.Spec.Allows(namespace, id.Name) {
+			return nil
+		}
+	}
+	return notGrantedError{id: id, kind: kind, namespace: namespace}
+}
+
+type notGrantedError struct {
+	id        types.NamespacedName
+	kind      string
+	namespace string
+}
+
+func (e notGrantedError) Error() string {
+	return fmt.Sprintf("no AuthSecretGrant in %s allows %ss in %s to reference the secret %s", e.id.Namespace, e.kind, e.namespace, e.id.Name)
+}
+
+// IsNotGranted returns true if the error is because no AuthSecretGrant allows
+// the auth secret to be referenced.
+func IsNotGranted(err error) bool {
+	_, ok := err.(notGrantedError)
+	return ok
+}
@@ -0,0 +1,104 @@
+package common
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	v1alpha1 "github.com/bigkevmcd/webhook-secret-operator/pkg/apis/apps/v1alpha1"
+	"github.com/bigkevmcd/webhook-secret-operator/test"
+)
+
+const (
+	testNamespace         = "test-ns"
+	testPlatformNamespace = "platform"
+	testAuthSecretName    = "auth-secret"
+)
+
+func TestAuthSecretID(t *testing.T) {
+	idTests := []struct {
+		ref  v1alpha1.AuthSecretRef
+		want types.NamespacedName
+	}{
+		{v1alpha1.AuthSecretRef{Name: testAuthSecretName}, types.NamespacedName{Name: testAuthSecretName, Namespace: testNamespace}},
+		{v1alpha1.AuthSecretRef{Name: testAuthSecretName, Namespace: testPlatformNamespace}, types.NamespacedName{Name: testAuthSecretName, Namespace: testPlatformNamespace}},
+	}
+
+	for _, tt := range idTests {
+		if got := AuthSecretID(tt.ref, testNamespace); got != tt.want {
+			t.Errorf("AuthSecretID(%#v) got %s, want %s", tt.ref, got, tt.want)
+		}
+	}
+}
+
+func TestCheckAuthSecretGranted(t *testing.T) {
+	grantTests := []struct {
+		name      string
+		namespace string
+		grants    []v1alpha1.AuthSecretGrantSpec
+		wantErr   string
+	}{
+		{"same namespace", testNamespace, nil, ""},
+		{"no grants", testPlatformNamespace, nil,
+			"no AuthSecretGrant in platform allows WebhookSecrets in test-ns to reference the secret auth-secret"},
+		{"granted to all secrets", testPlatformNamespace, []v1alpha1.AuthSecretGrantSpec{
+			{From: []v1alpha1.AuthSecretGrantFrom{{Namespace: testNamespace}}},
+		}, ""},
+		{"granted to the secret", testPlatformNamespace, []v1alpha1.AuthSecretGrantSpec{
+			{
+				From: []v1alpha1.AuthSecretGrantFrom{{Namespace: "other-ns"}, {Namespace: testNamespace}},
+				To:   []v1alpha1.AuthSecretGrantTo{{Name: testAuthSecretName}},
+			},
+		}, ""},
+		{"granted to another secret", testPlatformNamespace, []v1alpha1.AuthSecretGrantSpec{
+			{
+				From: []v1alpha1.AuthSecretGrantFrom{{Namespace: testNamespace}},
+				To:   []v1alpha1.AuthSecretGrantTo{{Name: "other-secret"}},
+			},
+		}, "no AuthSecretGrant in platform allows"},
+		{"granted to another namespace", testPlatformNamespace, []v1alpha1.AuthSecretGrantSpec{
+			{From: []v1alpha1.AuthSecretGrantFrom{{Namespace: "other-ns"}}},
+		}, "no AuthSecretGrant in platform allows"},
+	}
+
+	s := scheme.Scheme
+	s.AddKnownTypes(v1alpha1.SchemeGroupVersion, &v1alpha1.AuthSecretGrant{}, &v1alpha1.AuthSecretGrantList{})
+	for _, tt := range grantTests {
+		t.Run(tt.name, func(rt *testing.T) {
+			objs := []runtime.Object{}
+			for i, g := range tt.grants {
+				objs = append(objs, makeAuthSecretGrant(fmt.Sprintf("grant-%d", i), testPlatformNamespace, g))
+			}
+			cl := fake.NewFakeClientWithScheme(s, objs...)
+			id := types.NamespacedName{Name: testAuthSecretName, Namespace: tt.namespace}
+
+			err := CheckAuthSecretGranted(context.TODO(), cl, "WebhookSecret", testNamespace, id)
+			if !test.MatchError(rt, tt.wantErr, err) {
+				rt.Fatalf("error failed to match, got %#v, want %s", err, tt.wantErr)
+			}
+			if tt.wantErr != "" && !IsNotGranted(err) {
+				rt.Fatalf("IsNotGranted(%#v) got false, want true", err)
+			}
+		})
+	}
+}
+
+func makeAuthSecretGrant(name, ns string, spec v1alpha1.AuthSecretGrantSpec) *v1alpha1.AuthSecretGrant {
+	return &v1alpha1.AuthSecretGrant{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: v1alpha1.SchemeGroupVersion.String(),
+			Kind:       "AuthSecretGrant",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: ns,
+		},
+		Spec: spec,
+	}
+}
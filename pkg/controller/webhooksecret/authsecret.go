@@ -0,0 +1,43 @@
+package webhooksecret
+
+import (
+	"reflect"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+
+	v1alpha1 "github.com/bigkevmcd/webhook-secret-operator/pkg/apis/apps/v1alpha1"
+)
+
+// authSecretRefIndexKey is the field index for WebhookSecrets by the auth
+// secret that they reference.
+const authSecretRefIndexKey = "spec.authSecretRef"
+
+// indexAuthSecretRef is a client.IndexerFunc that returns the namespaced name
+// of the auth secret referenced by a WebhookSecret.
+func indexAuthSecretRef(o runtime.Object) []string {
+	ws, ok := o.(*v1alpha1.WebhookSecret)
+	if !ok || ws.Spec.AuthSecretRef.Name == "" {
+		return nil
+	}
+	return []string{authSecretID(ws).String()}
+}
+
+// authSecretChanged is a predicate that ignores updates to Secrets that don't
+// change the credentials in the Secret.
+var authSecretChanged = predicate.Funcs{
+	UpdateFunc: func(e event.UpdateEvent) bool {
+		oldSecret, ok := e.ObjectOld.(*corev1.Secret)
+		if !ok {
+			return true
+		}
+		newSecret, ok := e.ObjectNew.(*corev1.Secret)
+		if !ok {
+			return true
+		}
+		return oldSecret.Type != newSecret.Type ||
+			!reflect.DeepEqual(oldSecret.Data, newSecret.Data)
+	},
+}
@@ -0,0 +1,132 @@
+package webhooksecret
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	logf "sigs.k8s.io/controller-runtime/pkg/runtime/log"
+
+	v1alpha1 "github.com/bigkevmcd/webhook-secret-operator/pkg/apis/apps/v1alpha1"
+)
+
+func TestIndexAuthSecretRef(t *testing.T) {
+	otherNamespace := makeWebhookSecret(v1alpha1.HookRoute{HookURL: testHookEndpoint})
+	otherNamespace.Spec.AuthSecretRef.Namespace = testPlatformNamespace
+	indexTests := []struct {
+		name string
+		ws   *v1alpha1.WebhookSecret
+		want []string
+	}{
+		{"same namespace", makeWebhookSecret(v1alpha1.HookRoute{HookURL: testHookEndpoint}), []string{"test-webhook-ns/auth-secret"}},
+		{"other namespace", otherNamespace, []string{"platform/auth-secret"}},
+		{"no auth secret", &v1alpha1.WebhookSecret{}, nil},
+	}
+
+	for _, tt := range indexTests {
+		t.Run(tt.name, func(rt *testing.T) {
+			if got := indexAuthSecretRef(tt.ws); !reflect.DeepEqual(got, tt.want) {
+				rt.Errorf("got %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAuthSecretMapper(t *testing.T) {
+	referencing := makeWebhookSecret(v1alpha1.HookRoute{HookURL: testHookEndpoint})
+	other := makeWebhookSecret(v1alpha1.HookRoute{HookURL: testHookEndpoint})
+	other.Name = "other-webhook-secret"
+	other.Spec.AuthSecretRef.Name = "other-auth-secret"
+	otherNamespace := makeWebhookSecret(v1alpha1.HookRoute{HookURL: testHookEndpoint})
+	otherNamespace.Name = "platform-webhook-secret"
+	otherNamespace.Spec.AuthSecretRef.Namespace = testPlatformNamespace
+	cl, _ := makeReconciler(t, referencing, referencing, other, otherNamespace)
+	secret := makeTestSecret(testAuthSecretName)
+
+	m := &refMapper{kubeClient: cl, indexKey: authSecretRefIndexKey, index: indexAuthSecretRef}
+	requests := m.Map(handler.MapObject{Meta: secret, Object: secret})
+
+	want := []reconcile.Request{makeReconcileRequest()}
+	if !reflect.DeepEqual(requests, want) {
+		t.Fatalf("got %#v, want %#v", requests, want)
+	}
+}
+
+func TestAuthSecretChanged(t *testing.T) {
+	changeTests := []struct {
+		name      string
+		newSecret *corev1.Secret
+		want      bool
+	}{
+		{"no change", makeTestSecret(testAuthSecretName), false},
+		{"token changed", func() *corev1.Secret {
+			s := makeTestSecret(testAuthSecretName)
+			s.Data["token"] = []byte("new-token")
+			return s
+		}(), true},
+		{"type changed", func() *corev1.Secret {
+			s := makeTestSecret(testAuthSecretName)
+			s.Type = corev1.SecretTypeBasicAuth
+			return s
+		}(), true},
+		{"labels changed", func() *corev1.Secret {
+			s := makeTestSecret(testAuthSecretName)
+			s.ObjectMeta.Labels = map[string]string{"test": "value"}
+			return s
+		}(), false},
+	}
+
+	for _, tt := range changeTests {
+		t.Run(tt.name, func(rt *testing.T) {
+			oldSecret := makeTestSecret(testAuthSecretName)
+			got := authSecretChanged.Update(event.UpdateEvent{
+				MetaOld: oldSecret, ObjectOld: oldSecret,
+				MetaNew: tt.newSecret, ObjectNew: tt.newSecret,
+			})
+			if got != tt.want {
+				rt.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// If the auth secret is created after the WebhookSecret, reconciling again
+// should clear the AuthResolved failure and create the webhook.
+func TestWebhookSecretControllerWithAuthSecretCreatedLater(t *testing.T) {
+	logf.SetLogger(logf.ZapLogger(true))
+	ws := makeWebhookSecret(v1alpha1.HookRoute{HookURL: testHookEndpoint})
+	cl, r := makeReconciler(t, ws, ws)
+	req := makeReconcileRequest()
+
+	_, err := r.Reconcile(req)
+	if !apierrors.IsNotFound(err) {
+		t.Fatalf("expected a not found error, got %v", err)
+	}
+	secret := makeTestSecret(testAuthSecretName)
+	if err := cl.Create(context.Background(), secret); err != nil {
+		t.Fatal(err)
+	}
+	requests := (&refMapper{kubeClient: cl, indexKey: authSecretRefIndexKey, index: indexAuthSecretRef}).Map(handler.MapObject{Meta: secret, Object: secret})
+	if !reflect.DeepEqual(requests, []reconcile.Request{req}) {
+		t.Fatalf("got %#v, want %#v", requests, []reconcile.Request{req})
+	}
+
+	_, err = r.Reconcile(requests[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ws = &v1alpha1.WebhookSecret{}
+	if err := cl.Get(context.Background(), req.NamespacedName, ws); err != nil {
+		t.Fatal(err)
+	}
+	assertCondition(t, ws, v1alpha1.AuthResolvedCondition, metav1.ConditionTrue, reasonAuthResolved)
+	assertCondition(t, ws, v1alpha1.ReadyCondition, metav1.ConditionTrue, reasonReady)
+	r.gitClientFactory.(*stubClientFactory).client.assertHookCreated(testHookEndpoint, stubSecret)
+}
@@ -0,0 +1,85 @@
+package webhooksecret
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	v1alpha1 "github.com/bigkevmcd/webhook-secret-operator/pkg/apis/apps/v1alpha1"
+	"github.com/bigkevmcd/webhook-secret-operator/pkg/controller/common"
+)
+
+// Reasons for the conditions and events on a WebhookSecret.
+const (
+	reasonSecretCreated          = "SecretCreated"
+	reasonSecretExists           = "SecretExists"
+	reasonSecretFailed           = "SecretFailed"
+	reasonSecretRotated          = "SecretRotated"
+	reasonSecretPruned           = "SecretPruned"
+	reasonWebhookCreated         = "WebhookCreated"
+	reasonWebhookUpdated         = "WebhookUpdated"
+	reasonWebhookVerified        = "WebhookVerified"
+	reasonWebhookDeleted         = "WebhookDeleted"
+	reasonWebhookExists          = "WebhookExists"
+	reasonWebhookAdopted         = "WebhookAdopted"
+	reasonWebhookFailed          = "WebhookFailed"
+	reasonWebhookDenied          = "WebhookPermissionDenied"
+	reasonAuthResolved           = common.ReasonAuthResolved
+	reasonAuthSecretNotFound     = common.ReasonAuthSecretNotFound
+	reasonAuthSecretInvalid      = common.ReasonAuthSecretInvalid
+	reasonAuthSecretMalformed    = common.ReasonAuthSecretMalformed
+	reasonAuthSecretNotGranted   = common.ReasonAuthSecretNotGranted
+	reasonUnknownDriver          = "UnknownDriver"
+	reasonUnsupportedScope       = "UnsupportedScope"
+	reasonHookURL                = "HookURL"
+	reasonRouteResolved          = "RouteResolved"
+	reasonRouteNotFound          = "RouteNotFound"
+	reasonIngressResolved        = "IngressResolved"
+	reasonIngressNotFound        = "IngressNotFound"
+	reasonIngressInvalid         = "IngressInvalid"
+	reasonHTTPRouteResolved      = "HTTPRouteResolved"
+	reasonHTTPRouteNotFound      = "HTTPRouteNotFound"
+	reasonHTTPRouteInvalid       = "HTTPRouteInvalid"
+	reasonServiceResolved        = "ServiceResolved"
+	reasonServiceNotFound        = "ServiceNotFound"
+	reasonServiceInvalid         = "ServiceInvalid"
+	reasonServicePending         = "ServicePending"
+	reasonKnativeServiceResolved = "KnativeServiceResolved"
+	reasonKnativeServiceNotFound = "KnativeServiceNotFound"
+	reasonKnativeServiceInvalid  = "KnativeServiceInvalid"
+	reasonKnativeServicePending  = "KnativeServicePending"
+	reasonEventListenerResolved  = "EventListenerResolved"
+	reasonEventListenerNotFound  = "EventListenerNotFound"
+	reasonEventListenerInvalid   = "EventListenerInvalid"
+	reasonEventListenerPending   = "EventListenerPending"
+	reasonInterceptorConfigured  = "InterceptorConfigured"
+	reasonInterceptorFailed      = "InterceptorFailed"
+	reasonURLTemplate            = "URLTemplate"
+	reasonURLTemplateInvalid     = "URLTemplateInvalid"
+	reasonReposInvalid           = "ReposInvalid"
+	reasonWebhookURLInvalid      = "WebhookURLInvalid"
+	reasonReady                  = common.ReasonReady
+	reasonPending                = common.ReasonPending
+)
+
+// readyConditions are the conditions that must all be true for the
+// WebhookSecret to be Ready.
+var readyConditions = []v1alpha1.ConditionType{
+	v1alpha1.SecretReadyCondition,
+	v1alpha1.AuthResolvedCondition,
+	v1alpha1.RouteResolvedCondition,
+	v1alpha1.WebhookReadyCondition,
+}
+
+// setCondition adds or updates a condition on the WebhookSecret.
+func setCondition(ws *v1alpha1.WebhookSecret, t v1alpha1.ConditionType, status metav1.ConditionStatus, reason, message string) {
+	common.SetCondition(&ws.Status.Conditions, ws.Generation, t, status, reason, message)
+}
+
+// findCondition returns the condition with the type, or nil if it's not set.
+func findCondition(ws *v1alpha1.WebhookSecret, t v1alpha1.ConditionType) *v1alpha1.Condition {
+	return common.FindCondition(ws.Status.Conditions, t)
+}
+
+// setReadyCondition sets the Ready condition from the other conditions.
+func setReadyCondition(ws *v1alpha1.WebhookSecret) {
+	common.SetReadyCondition(&ws.Status.Conditions, ws.Generation, readyConditions)
+}
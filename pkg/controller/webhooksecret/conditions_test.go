@@ -0,0 +1,73 @@
+package webhooksecret
+
+import (
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	v1alpha1 "github.com/bigkevmcd/webhook-secret-operator/pkg/apis/apps/v1alpha1"
+)
+
+func TestSetReadyCondition(t *testing.T) {
+	readyTests := []struct {
+		name       string
+		conditions map[v1alpha1.ConditionType]metav1.ConditionStatus
+		wantStatus metav1.ConditionStatus
+		wantReason string
+	}{
+		{
+			name: "all conditions true",
+			conditions: map[v1alpha1.ConditionType]metav1.ConditionStatus{
+				v1alpha1.SecretReadyCondition:   metav1.ConditionTrue,
+				v1alpha1.AuthResolvedCondition:  metav1.ConditionTrue,
+				v1alpha1.RouteResolvedCondition: metav1.ConditionTrue,
+				v1alpha1.WebhookReadyCondition:  metav1.ConditionTrue,
+			},
+			wantStatus: metav1.ConditionTrue,
+			wantReason: reasonReady,
+		},
+		{
+			name: "failing condition",
+			conditions: map[v1alpha1.ConditionType]metav1.ConditionStatus{
+				v1alpha1.SecretReadyCondition:   metav1.ConditionTrue,
+				v1alpha1.AuthResolvedCondition:  metav1.ConditionTrue,
+				v1alpha1.RouteResolvedCondition: metav1.ConditionFalse,
+			},
+			wantStatus: metav1.ConditionFalse,
+			wantReason: "test-reason",
+		},
+		{
+			name: "failing condition with a missing condition",
+			conditions: map[v1alpha1.ConditionType]metav1.ConditionStatus{
+				v1alpha1.SecretReadyCondition:  metav1.ConditionTrue,
+				v1alpha1.WebhookReadyCondition: metav1.ConditionFalse,
+			},
+			wantStatus: metav1.ConditionFalse,
+			wantReason: "test-reason",
+		},
+		{
+			name: "missing condition",
+			conditions: map[v1alpha1.ConditionType]metav1.ConditionStatus{
+				v1alpha1.SecretReadyCondition: metav1.ConditionTrue,
+			},
+			wantStatus: metav1.ConditionUnknown,
+			wantReason: reasonPending,
+		},
+	}
+
+	for _, tt := range readyTests {
+		t.Run(tt.name, func(rt *testing.T) {
+			ws := makeWebhookSecret(v1alpha1.HookRoute{HookURL: testHookEndpoint})
+			for k, v := range tt.conditions {
+				setCondition(ws, k, v, "test-reason", "")
+			}
+
+			setReadyCondition(ws)
+
+			c := findCondition(ws, v1alpha1.ReadyCondition)
+			if c.Status != tt.wantStatus || c.Reason != tt.wantReason {
+				rt.Errorf("got %s/%s, want %s/%s", c.Status, c.Reason, tt.wantStatus, tt.wantReason)
+			}
+		})
+	}
+}
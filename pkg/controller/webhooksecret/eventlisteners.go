@@ -0,0 +1,260 @@
+package webhooksecret
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	routev1 "github.com/openshift/api/route/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	v1alpha1 "github.com/bigkevmcd/webhook-secret-operator/pkg/apis/apps/v1alpha1"
+	"github.com/bigkevmcd/webhook-secret-operator/pkg/routes"
+)
+
+// eventListenerRefIndexKey is the field index for WebhookSecrets by the
+// EventListener that they reference.
+const eventListenerRefIndexKey = "spec.webhookURL.eventListenerRef"
+
+// indexEventListenerRef is a client.IndexerFunc that returns the namespaced
+// name of the EventListener referenced by a WebhookSecret.
+func indexEventListenerRef(o runtime.Object) []string {
+	ws, ok := o.(*v1alpha1.WebhookSecret)
+	if !ok || ws.Spec.WebhookURL.EventListenerRef == nil {
+		return nil
+	}
+	return []string{ws.Spec.WebhookURL.EventListenerRef.NamespacedName().String()}
+}
+
+// eventListenerServiceIndexKey is the field index for WebhookSecrets by the
+// Service of the EventListener that they reference, which is recorded in the
+// status when the hook URL is calculated.
+const eventListenerServiceIndexKey = "status.eventListenerService"
+
+// indexEventListenerService is a client.IndexerFunc that returns the
+// namespaced name of the Service for the EventListener referenced by a
+// WebhookSecret.
+func indexEventListenerService(o runtime.Object) []string {
+	ws, ok := o.(*v1alpha1.WebhookSecret)
+	if !ok || ws.Spec.WebhookURL.EventListenerRef == nil || ws.Status.EventListenerService == "" {
+		return nil
+	}
+	ns := ws.Spec.WebhookURL.EventListenerRef.NamespacedName().Namespace
+	return []string{types.NamespacedName{Name: ws.Status.EventListenerService, Namespace: ns}.String()}
+}
+
+// eventListenerChanged is a predicate that ignores updates to EventListeners
+// that don't change the address, or the spec, which has the interceptors that
+// are configured with the secret.
+//
+// Routes and Ingresses that expose the EventListener's Service are watched
+// separately, see watchEventListenerServices.
+var eventListenerChanged = predicate.Funcs{
+	UpdateFunc: func(e event.UpdateEvent) bool {
+		return unstructuredFieldChanged("spec").Update(e) ||
+			unstructuredFieldChanged("status", "address").Update(e)
+	},
+}
+
+// routeServiceChanged is a predicate that ignores updates to Routes that don't
+// change the URL calculated for the Route, or the Service that it routes to.
+var routeServiceChanged = predicate.Funcs{
+	UpdateFunc: func(e event.UpdateEvent) bool {
+		if routeURLChanged.Update(e) {
+			return true
+		}
+		oldRoute, ok := e.ObjectOld.(*routev1.Route)
+		if !ok {
+			return true
+		}
+		newRoute, ok := e.ObjectNew.(*routev1.Route)
+		if !ok {
+			return true
+		}
+		return !reflect.DeepEqual(oldRoute.Spec.To, newRoute.Spec.To)
+	},
+}
+
+// watchEventListenerServices indexes WebhookSecrets by the Service of the
+// EventListener that they reference, and watches Routes and Ingresses,
+// reconciling the WebhookSecrets for the Services that they expose.
+func watchEventListenerServices(mgr manager.Manager, c controller.Controller) error {
+	served, err := isServed(mgr, routes.EventListenerGVK)
+	if !served || err != nil {
+		return err
+	}
+	err = mgr.GetFieldIndexer().IndexField(context.TODO(), &v1alpha1.WebhookSecret{}, eventListenerServiceIndexKey, indexEventListenerService)
+	if err != nil {
+		return err
+	}
+	err = c.Watch(&source.Kind{Type: &routev1.Route{}}, &handler.EnqueueRequestsFromMapFunc{
+		ToRequests: &refMapper{kubeClient: mgr.GetClient(), indexKey: eventListenerServiceIndexKey, index: indexEventListenerService, keys: routeServiceKeys},
+	}, routeServiceChanged)
+	if err != nil {
+		return err
+	}
+	served, err = isServed(mgr, routes.IngressGVK)
+	if !served || err != nil {
+		return err
+	}
+	u := &unstructured.Unstructured{}
+	u.SetGroupVersionKind(routes.IngressGVK)
+	return c.Watch(&source.Kind{Type: u}, &handler.EnqueueRequestsFromMapFunc{
+		ToRequests: &refMapper{kubeClient: mgr.GetClient(), indexKey: eventListenerServiceIndexKey, index: indexEventListenerService, keys: ingressServiceKeys},
+	}, ingressURLChanged)
+}
+
+// routeServiceKeys returns the namespaced name of the Service that a Route
+// routes to.
+func routeServiceKeys(obj handler.MapObject) []string {
+	route, ok := obj.Object.(*routev1.Route)
+	if !ok || route.Spec.To.Kind != "Service" {
+		return nil
+	}
+	return []string{types.NamespacedName{Name: route.Spec.To.Name, Namespace: route.Namespace}.String()}
+}
+
+// ingressServiceKeys returns the namespaced names of the Services that are
+// backends for an Ingress.
+func ingressServiceKeys(obj handler.MapObject) []string {
+	u, ok := obj.Object.(*unstructured.Unstructured)
+	if !ok {
+		return nil
+	}
+	names, err := routes.IngressServiceNames(u)
+	if err != nil {
+		log.Error(err, "failed to parse Ingress", "name", u.GetName(), "namespace", u.GetNamespace())
+		return nil
+	}
+	keys := []string{}
+	for _, name := range names {
+		keys = append(keys, types.NamespacedName{Name: name, Namespace: u.GetNamespace()}.String())
+	}
+	return keys
+}
+
+// recordEventListenerService records the name of the Service for the
+// EventListener referenced by the WebhookSecret in the status, so that it's
+// reconciled when Routes and Ingresses for the Service change.
+func (r *ReconcileWebhookSecret) recordEventListenerService(ctx context.Context, ws *v1alpha1.WebhookSecret) {
+	name, err := r.eventListenerGetter.EventListenerServiceName(ctx, ws.Spec.WebhookURL.EventListenerRef.NamespacedName())
+	if err != nil {
+		log.Info("Failed to get the Service for the EventListener", "reason", err.Error())
+	}
+	ws.Status.EventListenerService = name
+}
+
+// configureInterceptor sets the secretRef of the interceptor in the
+// EventListener referenced by the WebhookSecret to the generated Secret, if
+// the reference has an interceptor.
+func (r *ReconcileWebhookSecret) configureInterceptor(ctx context.Context, ws *v1alpha1.WebhookSecret) error {
+	ref := ws.Spec.WebhookURL.EventListenerRef
+	if ref == nil || ref.Interceptor == "" || ws.Status.SecretRef.Name == "" {
+		return nil
+	}
+	if ref.Namespace != ws.Namespace {
+		err := fmt.Errorf("eventlistener %s must be in namespace %s to configure the %s interceptor with the secret", ref.NamespacedName(), ws.Namespace, ref.Interceptor)
+		r.recorder.Event(ws, corev1.EventTypeWarning, reasonInterceptorFailed, err.Error())
+		return err
+	}
+	el := &unstructured.Unstructured{}
+	el.SetGroupVersionKind(routes.EventListenerGVK)
+	if err := r.kubeClient.Get(ctx, ref.NamespacedName(), el); err != nil {
+		return fmt.Errorf("error getting eventlistener %s: %w", ref.NamespacedName(), err)
+	}
+	changed, err := setInterceptorSecretRef(el, ref.Interceptor, ws.Status.SecretRef.Name, secretKey(ws))
+	if err != nil {
+		err = fmt.Errorf("eventlistener %s %s", ref.NamespacedName(), err)
+		r.recorder.Event(ws, corev1.EventTypeWarning, reasonInterceptorFailed, err.Error())
+		return err
+	}
+	if !changed {
+		return nil
+	}
+	if err := r.kubeClient.Update(ctx, el); err != nil {
+		r.recorder.Eventf(ws, corev1.EventTypeWarning, reasonInterceptorFailed, "Failed to update EventListener %s: %s", ref.NamespacedName(), err)
+		return fmt.Errorf("failed to update eventlistener %s: %w", ref.NamespacedName(), err)
+	}
+	r.recorder.Eventf(ws, corev1.EventTypeNormal, reasonInterceptorConfigured, "Configured the %s interceptor of EventListener %s with Secret %s", ref.Interceptor, ref.NamespacedName(), ws.Status.SecretRef.Name)
+	return nil
+}
+
+// setInterceptorSecretRef sets the secretRef of every instance of the named
+// interceptor in the triggers of the EventListener, and returns true if any
+// of them changed.
+//
+// Interceptors can be referenced by name, with a secretRef param, or use the
+// deprecated embedded configuration, with a secretRef field.
+func setInterceptorSecretRef(el *unstructured.Unstructured, interceptor, secretName, key string) (bool, error) {
+	triggers, _, err := unstructured.NestedSlice(el.Object, "spec", "triggers")
+	if err != nil {
+		return false, err
+	}
+	secretRef := map[string]interface{}{"secretName": secretName, "secretKey": key}
+	changed := false
+	found := false
+	for _, t := range triggers {
+		trigger, ok := t.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		interceptors, _ := trigger["interceptors"].([]interface{})
+		for _, i := range interceptors {
+			in, ok := i.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			if embedded, ok := in[interceptor].(map[string]interface{}); ok {
+				found = true
+				if !reflect.DeepEqual(embedded["secretRef"], secretRef) {
+					embedded["secretRef"] = secretRef
+					changed = true
+				}
+				continue
+			}
+			if name, _, _ := unstructured.NestedString(in, "ref", "name"); name != interceptor {
+				continue
+			}
+			found = true
+			params, updated := setParam(in["params"], "secretRef", secretRef)
+			if updated {
+				in["params"] = params
+				changed = true
+			}
+		}
+	}
+	if !found {
+		return false, fmt.Errorf("has no triggers with the %s interceptor", interceptor)
+	}
+	if !changed {
+		return false, nil
+	}
+	return true, unstructured.SetNestedSlice(el.Object, triggers, "spec", "triggers")
+}
+
+// setParam sets the value of the named param, adding it if necessary, and
+// returns true if the params changed.
+func setParam(p interface{}, name string, value interface{}) ([]interface{}, bool) {
+	params, _ := p.([]interface{})
+	for _, v := range params {
+		param, ok := v.(map[string]interface{})
+		if !ok || param["name"] != name {
+			continue
+		}
+		if reflect.DeepEqual(param["value"], value) {
+			return params, false
+		}
+		param["value"] = value
+		return params, true
+	}
+	return append(params, map[string]interface{}{"name": name, "value": value}), true
+}
@@ -0,0 +1,382 @@
+package webhooksecret
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	routev1 "github.com/openshift/api/route/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	logf "sigs.k8s.io/controller-runtime/pkg/runtime/log"
+
+	v1alpha1 "github.com/bigkevmcd/webhook-secret-operator/pkg/apis/apps/v1alpha1"
+	"github.com/bigkevmcd/webhook-secret-operator/pkg/routes"
+	"github.com/bigkevmcd/webhook-secret-operator/test"
+)
+
+var testEventListenerID = types.NamespacedName{Name: "my-listener", Namespace: "test-webhook-ns"}
+
+func TestIndexEventListenerRef(t *testing.T) {
+	indexTests := []struct {
+		name string
+		ws   *v1alpha1.WebhookSecret
+		want []string
+	}{
+		{"hook URL", makeWebhookSecret(v1alpha1.HookRoute{HookURL: testHookEndpoint}), nil},
+		{"eventlistener ref", makeWebhookSecret(v1alpha1.HookRoute{EventListenerRef: &v1alpha1.EventListenerReference{Name: "my-listener", Namespace: "test-webhook-ns"}}), []string{"test-webhook-ns/my-listener"}},
+	}
+
+	for _, tt := range indexTests {
+		t.Run(tt.name, func(rt *testing.T) {
+			if got := indexEventListenerRef(tt.ws); !reflect.DeepEqual(got, tt.want) {
+				rt.Errorf("got %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIndexEventListenerService(t *testing.T) {
+	resolved := makeWebhookSecret(v1alpha1.HookRoute{EventListenerRef: &v1alpha1.EventListenerReference{Name: "my-listener", Namespace: "test-webhook-ns"}})
+	resolved.Status.EventListenerService = "el-my-listener"
+	stale := makeWebhookSecret(v1alpha1.HookRoute{HookURL: testHookEndpoint})
+	stale.Status.EventListenerService = "el-my-listener"
+	indexTests := []struct {
+		name string
+		ws   *v1alpha1.WebhookSecret
+		want []string
+	}{
+		{"hook URL", stale, nil},
+		{"eventlistener ref without a service", makeWebhookSecret(v1alpha1.HookRoute{EventListenerRef: &v1alpha1.EventListenerReference{Name: "my-listener", Namespace: "test-webhook-ns"}}), nil},
+		{"eventlistener ref", resolved, []string{"test-webhook-ns/el-my-listener"}},
+	}
+
+	for _, tt := range indexTests {
+		t.Run(tt.name, func(rt *testing.T) {
+			if got := indexEventListenerService(tt.ws); !reflect.DeepEqual(got, tt.want) {
+				rt.Errorf("got %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEventListenerServiceMapper(t *testing.T) {
+	referencing := makeWebhookSecret(v1alpha1.HookRoute{
+		EventListenerRef: &v1alpha1.EventListenerReference{Name: "my-listener", Namespace: "test-webhook-ns"},
+	})
+	referencing.Status.EventListenerService = "el-my-listener"
+	other := makeWebhookSecret(v1alpha1.HookRoute{
+		EventListenerRef: &v1alpha1.EventListenerReference{Name: "other-listener", Namespace: "test-webhook-ns"},
+	})
+	other.Name = "other-webhook-secret"
+	other.Status.EventListenerService = "el-other-listener"
+	cl, _ := makeReconciler(t, referencing, referencing, other)
+	route := test.MakeRoute(types.NamespacedName{Name: "el-route", Namespace: "test-webhook-ns"}, routeToService("el-my-listener"))
+	ingress := test.MakeIngress(types.NamespacedName{Name: "el-ingress", Namespace: "test-webhook-ns"},
+		test.IngressServiceRule("hooks.example.com", "/", "el-my-listener"),
+		test.IngressServiceRule("hooks.example.org", "/", "el-my-listener"))
+	otherNamespace := test.MakeRoute(types.NamespacedName{Name: "el-route", Namespace: "other-ns"}, routeToService("el-my-listener"))
+
+	mapperTests := []struct {
+		name   string
+		mapper *refMapper
+		obj    handler.MapObject
+		want   []reconcile.Request
+	}{
+		{"route", &refMapper{kubeClient: cl, indexKey: eventListenerServiceIndexKey, index: indexEventListenerService, keys: routeServiceKeys},
+			handler.MapObject{Meta: route, Object: route}, []reconcile.Request{makeReconcileRequest()}},
+		{"ingress", &refMapper{kubeClient: cl, indexKey: eventListenerServiceIndexKey, index: indexEventListenerService, keys: ingressServiceKeys},
+			handler.MapObject{Meta: ingress, Object: ingress}, []reconcile.Request{makeReconcileRequest()}},
+		{"route in another namespace", &refMapper{kubeClient: cl, indexKey: eventListenerServiceIndexKey, index: indexEventListenerService, keys: routeServiceKeys},
+			handler.MapObject{Meta: otherNamespace, Object: otherNamespace}, []reconcile.Request{}},
+	}
+
+	for _, tt := range mapperTests {
+		t.Run(tt.name, func(rt *testing.T) {
+			if got := tt.mapper.Map(tt.obj); !reflect.DeepEqual(got, tt.want) {
+				rt.Fatalf("got %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRouteServiceChanged(t *testing.T) {
+	routeID := types.NamespacedName{Name: "el-route", Namespace: "test-webhook-ns"}
+	changeTests := []struct {
+		name     string
+		newRoute *routev1.Route
+		want     bool
+	}{
+		{"no change", test.MakeRoute(routeID, routeToService("el-my-listener")), false},
+		{"host changed", test.MakeRoute(routeID, routeToService("el-my-listener"), test.Host("hooks.example.org")), true},
+		{"service changed", test.MakeRoute(routeID, routeToService("el-other-listener")), true},
+	}
+
+	for _, tt := range changeTests {
+		t.Run(tt.name, func(rt *testing.T) {
+			oldRoute := test.MakeRoute(routeID, routeToService("el-my-listener"))
+			got := routeServiceChanged.Update(event.UpdateEvent{
+				MetaOld: oldRoute, ObjectOld: oldRoute,
+				MetaNew: tt.newRoute, ObjectNew: tt.newRoute,
+			})
+			if got != tt.want {
+				rt.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEventListenerChanged(t *testing.T) {
+	changeTests := []struct {
+		name  string
+		newEL *unstructured.Unstructured
+		want  bool
+	}{
+		{"no change", test.MakeEventListener(testEventListenerID), false},
+		{"address added", test.MakeEventListener(testEventListenerID, test.EventListenerAddress("el-my-listener")), true},
+		{"trigger added", test.MakeEventListener(testEventListenerID, test.InterceptorTrigger("push", "github")), true},
+		{"labels changed", test.MakeEventListener(testEventListenerID, func(u *unstructured.Unstructured) { u.SetLabels(map[string]string{"test": "value"}) }), false},
+	}
+
+	for _, tt := range changeTests {
+		t.Run(tt.name, func(rt *testing.T) {
+			oldEL := test.MakeEventListener(testEventListenerID)
+			got := eventListenerChanged.Update(event.UpdateEvent{
+				MetaOld: oldEL, ObjectOld: oldEL,
+				MetaNew: tt.newEL, ObjectNew: tt.newEL,
+			})
+			if got != tt.want {
+				rt.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSetInterceptorSecretRef(t *testing.T) {
+	secretRef := map[string]interface{}{"secretName": "test-secret", "secretKey": "token"}
+	eventTypes := test.InterceptorParam("eventTypes", []interface{}{"push"})
+	interceptorTests := []struct {
+		name        string
+		el          *unstructured.Unstructured
+		interceptor string
+		want        *unstructured.Unstructured
+		wantChanged bool
+		wantErr     string
+	}{
+		{"adds the param",
+			test.MakeEventListener(testEventListenerID, test.InterceptorTrigger("push", "github", eventTypes)),
+			"github",
+			test.MakeEventListener(testEventListenerID, test.InterceptorTrigger("push", "github", eventTypes, test.InterceptorParam("secretRef", secretRef))),
+			true, ""},
+		{"updates the param",
+			test.MakeEventListener(testEventListenerID, test.InterceptorTrigger("push", "github", test.InterceptorParam("secretRef", map[string]interface{}{"secretName": "old-secret", "secretKey": "token"}))),
+			"github",
+			test.MakeEventListener(testEventListenerID, test.InterceptorTrigger("push", "github", test.InterceptorParam("secretRef", secretRef))),
+			true, ""},
+		{"already configured",
+			test.MakeEventListener(testEventListenerID, test.InterceptorTrigger("push", "github", test.InterceptorParam("secretRef", secretRef))),
+			"github",
+			test.MakeEventListener(testEventListenerID, test.InterceptorTrigger("push", "github", test.InterceptorParam("secretRef", secretRef))),
+			false, ""},
+		{"only the named interceptor",
+			test.MakeEventListener(testEventListenerID, test.InterceptorTrigger("push", "github"), test.InterceptorTrigger("mr", "gitlab")),
+			"gitlab",
+			test.MakeEventListener(testEventListenerID, test.InterceptorTrigger("push", "github"), test.InterceptorTrigger("mr", "gitlab", test.InterceptorParam("secretRef", secretRef))),
+			true, ""},
+		{"embedded interceptor",
+			test.MakeEventListener(testEventListenerID, test.LegacyInterceptorTrigger("push", "github")),
+			"github",
+			test.MakeEventListener(testEventListenerID, test.LegacyInterceptorTrigger("push", "github"), func(u *unstructured.Unstructured) {
+				triggers, _, _ := unstructured.NestedSlice(u.Object, "spec", "triggers")
+				triggers[0].(map[string]interface{})["interceptors"].([]interface{})[0].(map[string]interface{})["github"].(map[string]interface{})["secretRef"] = secretRef
+				_ = unstructured.SetNestedSlice(u.Object, triggers, "spec", "triggers")
+			}),
+			true, ""},
+		{"no interceptor",
+			test.MakeEventListener(testEventListenerID, test.InterceptorTrigger("push", "github")),
+			"gitlab",
+			test.MakeEventListener(testEventListenerID, test.InterceptorTrigger("push", "github")),
+			false, "has no triggers with the gitlab interceptor"},
+	}
+
+	for _, tt := range interceptorTests {
+		t.Run(tt.name, func(rt *testing.T) {
+			changed, err := setInterceptorSecretRef(tt.el, tt.interceptor, "test-secret", "token")
+			if !test.MatchError(rt, tt.wantErr, err) {
+				rt.Fatalf("error failed to match, got %#v, want %s", err, tt.wantErr)
+			}
+			if changed != tt.wantChanged {
+				rt.Errorf("got changed %v, want %v", changed, tt.wantChanged)
+			}
+			if !reflect.DeepEqual(tt.el, tt.want) {
+				rt.Errorf("got %#v, want %#v", tt.el, tt.want)
+			}
+		})
+	}
+}
+
+// If using an EventListener, then the URL for the webhook should be
+// calculated from the Route that exposes the EventListener's Service.
+func TestWebhookSecretControllerWithEventListenerRef(t *testing.T) {
+	logf.SetLogger(logf.ZapLogger(true))
+	ws := makeWebhookSecret(v1alpha1.HookRoute{
+		EventListenerRef: &v1alpha1.EventListenerReference{
+			Name:      "my-listener",
+			Namespace: "test-webhook-ns",
+			Path:      "/el-listener",
+		},
+	})
+	_, r := makeReconciler(
+		t, ws, ws, makeTestSecret(testAuthSecretName),
+		test.MakeEventListener(testEventListenerID, test.EventListenerAddress("el-my-listener")),
+		test.MakeRoute(types.NamespacedName{Name: "el-route", Namespace: "test-webhook-ns"}, test.Host("hooks.example.com"), routeToService("el-my-listener")))
+	req := makeReconcileRequest()
+
+	_, err := r.Reconcile(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ws = &v1alpha1.WebhookSecret{}
+	if err := r.kubeClient.Get(context.Background(), req.NamespacedName, ws); err != nil {
+		t.Fatal(err)
+	}
+	assertCondition(t, ws, v1alpha1.RouteResolvedCondition, metav1.ConditionTrue, reasonEventListenerResolved)
+	if ws.Status.EventListenerService != "el-my-listener" {
+		t.Errorf("got EventListenerService %q, want %q", ws.Status.EventListenerService, "el-my-listener")
+	}
+	r.gitClientFactory.(*stubClientFactory).client.assertHookCreated("https://hooks.example.com/el-listener", stubSecret)
+}
+
+// If the EventListener's Service isn't exposed, then the Service is recorded in
+// the status, so that the WebhookSecret is reconciled when a Route or Ingress
+// is created for it.
+func TestWebhookSecretControllerWithEventListenerRefNotExposed(t *testing.T) {
+	logf.SetLogger(logf.ZapLogger(true))
+	ws := makeWebhookSecret(v1alpha1.HookRoute{
+		EventListenerRef: &v1alpha1.EventListenerReference{Name: "my-listener", Namespace: "test-webhook-ns"},
+	})
+	// The fake client can't list unstructured objects of unregistered kinds.
+	scheme.Scheme.AddKnownTypeWithName(routes.IngressGVK.GroupVersion().WithKind("IngressList"), &unstructured.UnstructuredList{})
+	_, r := makeReconciler(t, ws, ws, makeTestSecret(testAuthSecretName),
+		test.MakeEventListener(testEventListenerID, test.EventListenerAddress("el-my-listener")))
+	req := makeReconcileRequest()
+
+	_, err := r.Reconcile(req)
+	if !test.MatchError(t, "service el-my-listener is not exposed by a Route or Ingress", err) {
+		t.Fatalf("error failed to match, got %#v", err)
+	}
+
+	ws = &v1alpha1.WebhookSecret{}
+	if err := r.kubeClient.Get(context.Background(), req.NamespacedName, ws); err != nil {
+		t.Fatal(err)
+	}
+	assertCondition(t, ws, v1alpha1.RouteResolvedCondition, metav1.ConditionFalse, reasonEventListenerInvalid)
+	if ws.Status.EventListenerService != "el-my-listener" {
+		t.Errorf("got EventListenerService %q, want %q", ws.Status.EventListenerService, "el-my-listener")
+	}
+	r.gitClientFactory.(*stubClientFactory).client.assertNoHookCreated()
+}
+
+// If the EventListenerRef has an interceptor, then the EventListener should
+// be configured with the generated secret.
+func TestWebhookSecretControllerWithEventListenerRefAndInterceptor(t *testing.T) {
+	logf.SetLogger(logf.ZapLogger(true))
+	ws := makeWebhookSecret(v1alpha1.HookRoute{
+		EventListenerRef: &v1alpha1.EventListenerReference{
+			Name:        "my-listener",
+			Namespace:   "test-webhook-ns",
+			Interceptor: "github",
+		},
+	})
+	_, r := makeReconciler(
+		t, ws, ws, makeTestSecret(testAuthSecretName),
+		test.MakeEventListener(testEventListenerID, test.EventListenerAddress("el-my-listener"), test.InterceptorTrigger("push", "github")),
+		test.MakeRoute(types.NamespacedName{Name: "el-route", Namespace: "test-webhook-ns"}, test.Host("hooks.example.com"), routeToService("el-my-listener")))
+	req := makeReconcileRequest()
+
+	_, err := r.Reconcile(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	el := &unstructured.Unstructured{}
+	el.SetGroupVersionKind(routes.EventListenerGVK)
+	if err := r.kubeClient.Get(context.Background(), testEventListenerID, el); err != nil {
+		t.Fatal(err)
+	}
+	want := test.MakeEventListener(testEventListenerID, test.InterceptorTrigger("push", "github",
+		test.InterceptorParam("secretRef", map[string]interface{}{"secretName": "test-webhook-secret", "secretKey": "token"})))
+	triggers, _, _ := unstructured.NestedSlice(el.Object, "spec", "triggers")
+	wantTriggers, _, _ := unstructured.NestedSlice(want.Object, "spec", "triggers")
+	if !reflect.DeepEqual(triggers, wantTriggers) {
+		t.Fatalf("got %#v, want %#v", triggers, wantTriggers)
+	}
+	assertEvents(t, r.recorder,
+		"Normal SecretCreated Created Secret test-webhook-secret",
+		"Normal WebhookCreated Created webhook 1234567 for https://hooks.example.com/",
+		"Normal InterceptorConfigured Configured the github interceptor of EventListener test-webhook-ns/my-listener with Secret test-webhook-secret")
+}
+
+// If the EventListener has no address, then the WebhookSecret should be
+// requeued.
+func TestWebhookSecretControllerWithEventListenerRefWaitingForAddress(t *testing.T) {
+	logf.SetLogger(logf.ZapLogger(true))
+	ws := makeWebhookSecret(v1alpha1.HookRoute{
+		EventListenerRef: &v1alpha1.EventListenerReference{Name: "my-listener", Namespace: "test-webhook-ns"},
+	})
+	_, r := makeReconciler(t, ws, ws, makeTestSecret(testAuthSecretName), test.MakeEventListener(testEventListenerID))
+	req := makeReconcileRequest()
+
+	res, err := r.Reconcile(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.RequeueAfter != pendingRequeueDelay {
+		t.Fatalf("got RequeueAfter %v, want %v", res.RequeueAfter, pendingRequeueDelay)
+	}
+
+	ws = &v1alpha1.WebhookSecret{}
+	if err := r.kubeClient.Get(context.Background(), req.NamespacedName, ws); err != nil {
+		t.Fatal(err)
+	}
+	assertCondition(t, ws, v1alpha1.RouteResolvedCondition, metav1.ConditionFalse, reasonEventListenerPending)
+	r.gitClientFactory.(*stubClientFactory).client.assertNoHookCreated()
+}
+
+// If the EventListener doesn't exist, then the WebhookSecret should reflect
+// the error.
+func TestWebhookSecretControllerWithEventListenerRefAndEventListenerMissing(t *testing.T) {
+	logf.SetLogger(logf.ZapLogger(true))
+	ws := makeWebhookSecret(v1alpha1.HookRoute{
+		EventListenerRef: &v1alpha1.EventListenerReference{Name: "my-listener", Namespace: "test-webhook-ns"},
+	})
+	_, r := makeReconciler(t, ws, ws, makeTestSecret(testAuthSecretName))
+	req := makeReconcileRequest()
+
+	_, err := r.Reconcile(req)
+	if !routes.IsNotFound(err) {
+		t.Fatalf("expected a not found error, got %v", err)
+	}
+
+	ws = &v1alpha1.WebhookSecret{}
+	if err := r.kubeClient.Get(context.Background(), req.NamespacedName, ws); err != nil {
+		t.Fatal(err)
+	}
+	assertCondition(t, ws, v1alpha1.RouteResolvedCondition, metav1.ConditionFalse, reasonEventListenerNotFound)
+	assertEvents(t, r.recorder,
+		"Normal SecretCreated Created Secret test-webhook-secret",
+		"Warning EventListenerNotFound EventListener test-webhook-ns/my-listener was not found")
+	r.gitClientFactory.(*stubClientFactory).client.assertNoHookCreated()
+}
+
+func routeToService(serviceName string) func(*routev1.Route) {
+	return func(r *routev1.Route) {
+		r.Spec.To = routev1.RouteTargetReference{Kind: "Service", Name: serviceName}
+	}
+}
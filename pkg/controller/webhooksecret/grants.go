@@ -0,0 +1,45 @@
+package webhooksecret
+
+import (
+	"context"
+
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	v1alpha1 "github.com/bigkevmcd/webhook-secret-operator/pkg/apis/apps/v1alpha1"
+	"github.com/bigkevmcd/webhook-secret-operator/pkg/controller/common"
+)
+
+// authSecretID returns the namespaced name of the auth secret for a
+// WebhookSecret, which is in the WebhookSecret's namespace unless the
+// reference has a namespace.
+func authSecretID(ws *v1alpha1.WebhookSecret) types.NamespacedName {
+	return common.AuthSecretID(ws.Spec.AuthSecretRef, ws.Namespace)
+}
+
+// grantMapper maps AuthSecretGrants to the WebhookSecrets that reference
+// auth secrets in the grant's namespace.
+type grantMapper struct {
+	kubeClient client.Client
+}
+
+// Map implements the handler.Mapper interface.
+func (m *grantMapper) Map(obj handler.MapObject) []reconcile.Request {
+	list := &v1alpha1.WebhookSecretList{}
+	if err := m.kubeClient.List(context.TODO(), list); err != nil {
+		log.Error(err, "failed to list WebhookSecrets for AuthSecretGrant", "namespace", obj.Meta.GetNamespace())
+		return nil
+	}
+	requests := []reconcile.Request{}
+	for _, ws := range list.Items {
+		if ws.Spec.AuthSecretRef.Namespace != obj.Meta.GetNamespace() || ws.Namespace == obj.Meta.GetNamespace() {
+			continue
+		}
+		requests = append(requests, reconcile.Request{
+			NamespacedName: types.NamespacedName{Name: ws.Name, Namespace: ws.Namespace},
+		})
+	}
+	return requests
+}
@@ -0,0 +1,105 @@
+package webhooksecret
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	logf "sigs.k8s.io/controller-runtime/pkg/runtime/log"
+
+	v1alpha1 "github.com/bigkevmcd/webhook-secret-operator/pkg/apis/apps/v1alpha1"
+	"github.com/bigkevmcd/webhook-secret-operator/pkg/controller/common"
+)
+
+var _ handler.Mapper = (*grantMapper)(nil)
+
+const testPlatformNamespace = "platform"
+
+// A WebhookSecret can use an auth secret in another namespace, if an
+// AuthSecretGrant in that namespace allows it.
+func TestWebhookSecretControllerWithGrantedAuthSecret(t *testing.T) {
+	logf.SetLogger(logf.ZapLogger(true))
+	ws := makeWebhookSecret(v1alpha1.HookRoute{HookURL: testHookEndpoint})
+	ws.Spec.AuthSecretRef.Namespace = testPlatformNamespace
+	authSecret := makeTestSecret(testAuthSecretName)
+	authSecret.Namespace = testPlatformNamespace
+	grant := makeAuthSecretGrant("team-grant", testPlatformNamespace, v1alpha1.AuthSecretGrantSpec{
+		From: []v1alpha1.AuthSecretGrantFrom{{Namespace: testWebhookSecretNamespace}},
+	})
+	_, r := makeReconciler(t, ws, ws, authSecret, grant)
+	req := makeReconcileRequest()
+
+	_, err := r.Reconcile(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ws = &v1alpha1.WebhookSecret{}
+	if err := r.kubeClient.Get(context.Background(), req.NamespacedName, ws); err != nil {
+		t.Fatal(err)
+	}
+	assertCondition(t, ws, v1alpha1.AuthResolvedCondition, metav1.ConditionTrue, reasonAuthResolved)
+	r.gitClientFactory.(*stubClientFactory).client.assertHookCreated(testHookEndpoint, stubSecret)
+}
+
+// If no AuthSecretGrant allows the reference, the auth secret should not be
+// used.
+func TestWebhookSecretControllerWithUngrantedAuthSecret(t *testing.T) {
+	logf.SetLogger(logf.ZapLogger(true))
+	ws := makeWebhookSecret(v1alpha1.HookRoute{HookURL: testHookEndpoint})
+	ws.Spec.AuthSecretRef.Namespace = testPlatformNamespace
+	authSecret := makeTestSecret(testAuthSecretName)
+	authSecret.Namespace = testPlatformNamespace
+	_, r := makeReconciler(t, ws, ws, authSecret)
+	req := makeReconcileRequest()
+
+	_, err := r.Reconcile(req)
+	if !common.IsNotGranted(err) {
+		t.Fatalf("expected a not granted error, got %v", err)
+	}
+
+	ws = &v1alpha1.WebhookSecret{}
+	if err := r.kubeClient.Get(context.Background(), req.NamespacedName, ws); err != nil {
+		t.Fatal(err)
+	}
+	assertCondition(t, ws, v1alpha1.AuthResolvedCondition, metav1.ConditionFalse, reasonAuthSecretNotGranted)
+	assertCondition(t, ws, v1alpha1.ReadyCondition, metav1.ConditionFalse, reasonAuthSecretNotGranted)
+	assertEvents(t, r.recorder,
+		"Normal SecretCreated Created Secret test-webhook-secret",
+		"Warning AuthSecretNotGranted no AuthSecretGrant in platform allows WebhookSecrets in test-webhook-ns to reference the secret auth-secret")
+	r.gitClientFactory.(*stubClientFactory).client.assertNoHookCreated()
+}
+
+func TestGrantMapper(t *testing.T) {
+	referencing := makeWebhookSecret(v1alpha1.HookRoute{HookURL: testHookEndpoint})
+	referencing.Spec.AuthSecretRef.Namespace = testPlatformNamespace
+	local := makeWebhookSecret(v1alpha1.HookRoute{HookURL: testHookEndpoint})
+	local.Name = "local-webhook-secret"
+	cl, _ := makeReconciler(t, referencing, referencing, local)
+	grant := makeAuthSecretGrant("team-grant", testPlatformNamespace, v1alpha1.AuthSecretGrantSpec{})
+
+	m := &grantMapper{kubeClient: cl}
+	requests := m.Map(handler.MapObject{Meta: grant, Object: grant})
+
+	want := []reconcile.Request{makeReconcileRequest()}
+	if !reflect.DeepEqual(requests, want) {
+		t.Fatalf("got %#v, want %#v", requests, want)
+	}
+}
+
+func makeAuthSecretGrant(name, ns string, spec v1alpha1.AuthSecretGrantSpec) *v1alpha1.AuthSecretGrant {
+	return &v1alpha1.AuthSecretGrant{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: v1alpha1.SchemeGroupVersion.String(),
+			Kind:       "AuthSecretGrant",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: ns,
+		},
+		Spec: spec,
+	}
+}
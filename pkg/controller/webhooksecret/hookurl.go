@@ -0,0 +1,176 @@
+package webhooksecret
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	v1alpha1 "github.com/bigkevmcd/webhook-secret-operator/pkg/apis/apps/v1alpha1"
+	"github.com/bigkevmcd/webhook-secret-operator/pkg/routes"
+)
+
+// urlSource is a kind of object that the hook URL can be calculated from, with
+// the reasons for the RouteResolved condition.
+//
+// The pending reason is used for objects that don't have a URL yet, e.g. a
+// Service that is waiting for a load balancer.
+type urlSource struct {
+	kind     string
+	resolved string
+	notFound string
+	invalid  string
+	pending  string
+}
+
+var (
+	ingressSource        = urlSource{kind: "Ingress", resolved: reasonIngressResolved, notFound: reasonIngressNotFound, invalid: reasonIngressInvalid}
+	httpRouteSource      = urlSource{kind: "HTTPRoute", resolved: reasonHTTPRouteResolved, notFound: reasonHTTPRouteNotFound, invalid: reasonHTTPRouteInvalid}
+	serviceSource        = urlSource{kind: "Service", resolved: reasonServiceResolved, notFound: reasonServiceNotFound, invalid: reasonServiceInvalid, pending: reasonServicePending}
+	knativeServiceSource = urlSource{kind: "Knative Service", resolved: reasonKnativeServiceResolved, notFound: reasonKnativeServiceNotFound, invalid: reasonKnativeServiceInvalid, pending: reasonKnativeServicePending}
+	eventListenerSource  = urlSource{kind: "EventListener", resolved: reasonEventListenerResolved, notFound: reasonEventListenerNotFound, invalid: reasonEventListenerInvalid, pending: reasonEventListenerPending}
+)
+
+var errNoWebhookURL = errors.New("one of hookURL, routeRef, ingressRef, httpRouteRef, serviceRef, knativeServiceRef, eventListenerRef or urlTemplate must be provided")
+
+// validateWebhookURL checks that the HookRoute has exactly one source for the
+// hook URL, a urlTemplate can be used with any of them, or on its own.
+func validateWebhookURL(ws *v1alpha1.WebhookSecret) error {
+	u := ws.Spec.WebhookURL
+	sources := []string{}
+	if u.HookURL != "" {
+		sources = append(sources, "hookURL")
+	}
+	if u.RouteRef != nil {
+		sources = append(sources, "routeRef")
+	}
+	if u.IngressRef != nil {
+		sources = append(sources, "ingressRef")
+	}
+	if u.HTTPRouteRef != nil {
+		sources = append(sources, "httpRouteRef")
+	}
+	if u.ServiceRef != nil {
+		sources = append(sources, "serviceRef")
+	}
+	if u.KnativeServiceRef != nil {
+		sources = append(sources, "knativeServiceRef")
+	}
+	if u.EventListenerRef != nil {
+		sources = append(sources, "eventListenerRef")
+	}
+	if len(sources) > 1 {
+		return fmt.Errorf("only one of hookURL, routeRef, ingressRef, httpRouteRef, serviceRef, knativeServiceRef or eventListenerRef can be provided, got %s", strings.Join(sources, ", "))
+	}
+	if len(sources) == 0 && u.URLTemplate == "" {
+		return errNoWebhookURL
+	}
+	return nil
+}
+
+// hookURL calculates the URL for the webhook, and expands the urlTemplate, if
+// there is one, with the calculated URL.
+func (r *ReconcileWebhookSecret) hookURL(ctx context.Context, ws *v1alpha1.WebhookSecret) (string, error) {
+	hookURL, err := r.baseHookURL(ctx, ws)
+	if err != nil {
+		return "", err
+	}
+	return r.repoHookURL(ws, ws.Spec.Repo, hookURL)
+}
+
+// repoHookURL expands the urlTemplate, if there is one, for the repo with the
+// URL calculated from the other fields of the HookRoute.
+func (r *ReconcileWebhookSecret) repoHookURL(ws *v1alpha1.WebhookSecret, repo v1alpha1.Repo, hookURL string) (string, error) {
+	if ws.Spec.WebhookURL.URLTemplate == "" {
+		return hookURL, nil
+	}
+	expanded, err := r.expandURLTemplate(ws, repo, hookURL)
+	if err != nil {
+		log.Error(err, "Failed to expand the urlTemplate")
+		setCondition(ws, v1alpha1.RouteResolvedCondition, metav1.ConditionFalse, reasonURLTemplateInvalid, err.Error())
+		r.recorder.Event(ws, corev1.EventTypeWarning, reasonURLTemplateInvalid, err.Error())
+		return "", err
+	}
+	if hookURL == "" {
+		setCondition(ws, v1alpha1.RouteResolvedCondition, metav1.ConditionTrue, reasonURLTemplate, "")
+	}
+	return expanded, nil
+}
+
+// baseHookURL calculates the URL for the webhook from the fields of the
+// HookRoute, if only a urlTemplate is provided, the URL is empty.
+func (r *ReconcileWebhookSecret) baseHookURL(ctx context.Context, ws *v1alpha1.WebhookSecret) (string, error) {
+	u := ws.Spec.WebhookURL
+	if u.HookURL != "" {
+		setCondition(ws, v1alpha1.RouteResolvedCondition, metav1.ConditionTrue, reasonHookURL, "")
+		return u.HookURL, nil
+	}
+	if ref := u.IngressRef; ref != nil {
+		hookURL, err := r.ingressGetter.IngressURL(ctx, ref.NamespacedName(), ref.Host, ref.Path)
+		return r.resolvedURL(ws, ingressSource, ref.NamespacedName(), hookURL, err)
+	}
+	if ref := u.HTTPRouteRef; ref != nil {
+		hookURL, err := r.httpRouteGetter.HTTPRouteURL(ctx, ref.NamespacedName(), ref.Hostname, ref.Path)
+		return r.resolvedURL(ws, httpRouteSource, ref.NamespacedName(), hookURL, err)
+	}
+	if ref := u.ServiceRef; ref != nil {
+		hookURL, err := r.serviceGetter.ServiceURL(ctx, ref.NamespacedName(), ref.Port, ref.Path)
+		return r.resolvedURL(ws, serviceSource, ref.NamespacedName(), hookURL, err)
+	}
+	if ref := u.KnativeServiceRef; ref != nil {
+		hookURL, err := r.knativeServiceGetter.KnativeServiceURL(ctx, ref.NamespacedName(), ref.Path)
+		return r.resolvedURL(ws, knativeServiceSource, ref.NamespacedName(), hookURL, err)
+	}
+	if ref := u.EventListenerRef; ref != nil {
+		r.recordEventListenerService(ctx, ws)
+		hookURL, err := r.eventListenerGetter.EventListenerURL(ctx, ref.NamespacedName(), ref.Path)
+		return r.resolvedURL(ws, eventListenerSource, ref.NamespacedName(), hookURL, err)
+	}
+	if u.RouteRef == nil {
+		if u.URLTemplate != "" {
+			return "", nil
+		}
+		setCondition(ws, v1alpha1.RouteResolvedCondition, metav1.ConditionFalse, reasonWebhookURLInvalid, errNoWebhookURL.Error())
+		r.recorder.Event(ws, corev1.EventTypeWarning, reasonWebhookURLInvalid, errNoWebhookURL.Error())
+		return "", errNoWebhookURL
+	}
+	hookURL, err := r.routeGetter.RouteURL(ctx, u.RouteRef.NamespacedName(), u.RouteRef.Path)
+	if err != nil {
+		log.Error(err, "Failed to get the URL for route")
+		setCondition(ws, v1alpha1.RouteResolvedCondition, metav1.ConditionFalse, reasonRouteNotFound, err.Error())
+		r.recorder.Eventf(ws, corev1.EventTypeWarning, reasonRouteNotFound, "Route %s was not found", u.RouteRef.NamespacedName())
+		return "", err
+	}
+	setCondition(ws, v1alpha1.RouteResolvedCondition, metav1.ConditionTrue, reasonRouteResolved, "")
+	return hookURL, nil
+}
+
+// resolvedURL records the result of calculating the hook URL from the object
+// in the RouteResolved condition, and failures as events.
+//
+// Objects that are waiting for a URL are not failures, and are only recorded
+// in the condition.
+func (r *ReconcileWebhookSecret) resolvedURL(ws *v1alpha1.WebhookSecret, src urlSource, id types.NamespacedName, hookURL string, err error) (string, error) {
+	if routes.IsPending(err) {
+		log.Info("Waiting for the URL", "kind", src.kind, "id", id, "reason", err.Error())
+		setCondition(ws, v1alpha1.RouteResolvedCondition, metav1.ConditionFalse, src.pending, err.Error())
+		return "", err
+	}
+	if err != nil {
+		log.Error(err, "Failed to get the URL", "kind", src.kind, "id", id)
+		if routes.IsNotFound(err) {
+			setCondition(ws, v1alpha1.RouteResolvedCondition, metav1.ConditionFalse, src.notFound, err.Error())
+			r.recorder.Eventf(ws, corev1.EventTypeWarning, src.notFound, "%s %s was not found", src.kind, id)
+			return "", err
+		}
+		setCondition(ws, v1alpha1.RouteResolvedCondition, metav1.ConditionFalse, src.invalid, err.Error())
+		r.recorder.Event(ws, corev1.EventTypeWarning, src.invalid, err.Error())
+		return "", err
+	}
+	setCondition(ws, v1alpha1.RouteResolvedCondition, metav1.ConditionTrue, src.resolved, "")
+	return hookURL, nil
+}
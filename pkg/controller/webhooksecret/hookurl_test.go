@@ -0,0 +1,76 @@
+package webhooksecret
+
+import (
+	"context"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	logf "sigs.k8s.io/controller-runtime/pkg/runtime/log"
+
+	v1alpha1 "github.com/bigkevmcd/webhook-secret-operator/pkg/apis/apps/v1alpha1"
+	"github.com/bigkevmcd/webhook-secret-operator/test"
+)
+
+func TestValidateWebhookURL(t *testing.T) {
+	validTests := []struct {
+		name    string
+		route   v1alpha1.HookRoute
+		wantErr string
+	}{
+		{"hook url", v1alpha1.HookRoute{HookURL: testHookEndpoint}, ""},
+		{"route", v1alpha1.HookRoute{RouteRef: &v1alpha1.RouteReference{Name: "test-route", Namespace: "test-ns"}}, ""},
+		{"route and template", v1alpha1.HookRoute{RouteRef: &v1alpha1.RouteReference{Name: "test-route", Namespace: "test-ns"}, URLTemplate: "/hooks"}, ""},
+		{"only a template", v1alpha1.HookRoute{URLTemplate: "https://hooks.example.com/"}, ""},
+		{"no source", v1alpha1.HookRoute{}, "one of hookURL, routeRef, .* or urlTemplate must be provided"},
+		{"hook url and route", v1alpha1.HookRoute{HookURL: testHookEndpoint, RouteRef: &v1alpha1.RouteReference{Name: "test-route", Namespace: "test-ns"}}, "can be provided, got hookURL, routeRef"},
+		{"service and event listener", v1alpha1.HookRoute{ServiceRef: &v1alpha1.ServiceReference{Name: "test-svc", Namespace: "test-ns"}, EventListenerRef: &v1alpha1.EventListenerReference{Name: "test-el", Namespace: "test-ns"}}, "can be provided, got serviceRef, eventListenerRef"},
+	}
+
+	for _, tt := range validTests {
+		t.Run(tt.name, func(rt *testing.T) {
+			ws := makeWebhookSecret(tt.route)
+
+			err := validateWebhookURL(ws)
+			if !test.MatchError(rt, tt.wantErr, err) {
+				rt.Fatalf("error failed to match, got %#v, want %s", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// If the webhookURL has no source for the URL, then the WebhookSecret should
+// reflect the error.
+func TestWebhookSecretControllerWithNoWebhookURL(t *testing.T) {
+	logf.SetLogger(logf.ZapLogger(true))
+	ws := makeWebhookSecret(v1alpha1.HookRoute{})
+	_, r := makeReconciler(t, ws, ws, makeTestSecret(testAuthSecretName))
+	req := makeReconcileRequest()
+
+	_, err := r.Reconcile(req)
+	if !test.MatchError(t, "one of hookURL, .* must be provided", err) {
+		t.Fatalf("got incorrect error %v", err)
+	}
+
+	ws = &v1alpha1.WebhookSecret{}
+	if err := r.kubeClient.Get(context.Background(), req.NamespacedName, ws); err != nil {
+		t.Fatal(err)
+	}
+	assertCondition(t, ws, v1alpha1.RouteResolvedCondition, metav1.ConditionFalse, reasonWebhookURLInvalid)
+	assertEvents(t, r.recorder,
+		"Warning WebhookURLInvalid "+errNoWebhookURL.Error())
+	r.gitClientFactory.(*stubClientFactory).client.assertNoHookCreated()
+}
+
+// baseHookURL doesn't rely on the WebhookSecret having been validated.
+func TestBaseHookURLWithNoWebhookURL(t *testing.T) {
+	ws := makeWebhookSecret(v1alpha1.HookRoute{})
+	_, r := makeReconciler(t, ws, ws)
+
+	_, err := r.baseHookURL(context.Background(), ws)
+	if err != errNoWebhookURL {
+		t.Fatalf("got %v, want %v", err, errNoWebhookURL)
+	}
+	assertCondition(t, ws, v1alpha1.RouteResolvedCondition, metav1.ConditionFalse, reasonWebhookURLInvalid)
+	assertEvents(t, r.recorder,
+		"Warning WebhookURLInvalid "+errNoWebhookURL.Error())
+}
@@ -0,0 +1,28 @@
+package webhooksecret
+
+import (
+	"k8s.io/apimachinery/pkg/runtime"
+
+	v1alpha1 "github.com/bigkevmcd/webhook-secret-operator/pkg/apis/apps/v1alpha1"
+)
+
+// httpRouteRefIndexKey is the field index for WebhookSecrets by the HTTPRoute
+// that they reference.
+const httpRouteRefIndexKey = "spec.webhookURL.httpRouteRef"
+
+// indexHTTPRouteRef is a client.IndexerFunc that returns the namespaced name
+// of the HTTPRoute referenced by a WebhookSecret.
+func indexHTTPRouteRef(o runtime.Object) []string {
+	ws, ok := o.(*v1alpha1.WebhookSecret)
+	if !ok || ws.Spec.WebhookURL.HTTPRouteRef == nil {
+		return nil
+	}
+	return []string{ws.Spec.WebhookURL.HTTPRouteRef.NamespacedName().String()}
+}
+
+// httpRouteURLChanged is a predicate that ignores updates to HTTPRoutes that
+// don't change the spec, which the URL is calculated from.
+//
+// Changes to the parent Gateway are picked up when the WebhookSecret is next
+// resynchronised.
+var httpRouteURLChanged = unstructuredFieldChanged("spec")
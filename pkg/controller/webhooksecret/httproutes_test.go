@@ -0,0 +1,143 @@
+package webhooksecret
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	logf "sigs.k8s.io/controller-runtime/pkg/runtime/log"
+
+	v1alpha1 "github.com/bigkevmcd/webhook-secret-operator/pkg/apis/apps/v1alpha1"
+	"github.com/bigkevmcd/webhook-secret-operator/pkg/routes"
+	"github.com/bigkevmcd/webhook-secret-operator/test"
+)
+
+var (
+	testHTTPRouteID = types.NamespacedName{Name: "my-test-httproute", Namespace: "gateway-test"}
+	testGatewayID   = types.NamespacedName{Name: "test-gateway", Namespace: "gateway-test"}
+)
+
+func TestIndexHTTPRouteRef(t *testing.T) {
+	indexTests := []struct {
+		name string
+		ws   *v1alpha1.WebhookSecret
+		want []string
+	}{
+		{"hook URL", makeWebhookSecret(v1alpha1.HookRoute{HookURL: testHookEndpoint}), nil},
+		{"httproute ref", makeWebhookSecret(v1alpha1.HookRoute{HTTPRouteRef: &v1alpha1.HTTPRouteReference{Name: "my-test-httproute", Namespace: "gateway-test"}}), []string{"gateway-test/my-test-httproute"}},
+	}
+
+	for _, tt := range indexTests {
+		t.Run(tt.name, func(rt *testing.T) {
+			if got := indexHTTPRouteRef(tt.ws); !reflect.DeepEqual(got, tt.want) {
+				rt.Errorf("got %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHTTPRouteMapper(t *testing.T) {
+	referencing := makeWebhookSecret(v1alpha1.HookRoute{
+		HTTPRouteRef: &v1alpha1.HTTPRouteReference{Name: "my-test-httproute", Namespace: "gateway-test"},
+	})
+	other := makeWebhookSecret(v1alpha1.HookRoute{
+		HTTPRouteRef: &v1alpha1.HTTPRouteReference{Name: "other-httproute", Namespace: "gateway-test"},
+	})
+	other.Name = "other-webhook-secret"
+	cl, _ := makeReconciler(t, referencing, referencing, other)
+	route := test.MakeHTTPRoute(testHTTPRouteID)
+
+	m := &refMapper{kubeClient: cl, indexKey: httpRouteRefIndexKey, index: indexHTTPRouteRef}
+	requests := m.Map(handler.MapObject{Meta: route, Object: route})
+
+	want := []reconcile.Request{makeReconcileRequest()}
+	if !reflect.DeepEqual(requests, want) {
+		t.Fatalf("got %#v, want %#v", requests, want)
+	}
+}
+
+// If using an HTTPRoute, then the URL for the webhook should be calculated
+// from the HTTPRoute hostname and the Gateway listener.
+func TestWebhookSecretControllerWithHTTPRouteRef(t *testing.T) {
+	logf.SetLogger(logf.ZapLogger(true))
+	ws := makeWebhookSecret(v1alpha1.HookRoute{
+		HTTPRouteRef: &v1alpha1.HTTPRouteReference{
+			Name:      "my-test-httproute",
+			Namespace: "gateway-test",
+			Path:      "/el-listener",
+		},
+	})
+	_, r := makeReconciler(
+		t, ws, ws, makeTestSecret(testAuthSecretName),
+		test.MakeHTTPRoute(testHTTPRouteID, test.Hostnames("hooks.example.com"), test.ParentRef("test-gateway", nil)),
+		test.MakeGateway(testGatewayID, test.Listener("https", "HTTPS", 8443, "*.example.com")))
+	req := makeReconcileRequest()
+
+	_, err := r.Reconcile(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ws = &v1alpha1.WebhookSecret{}
+	if err := r.kubeClient.Get(context.Background(), req.NamespacedName, ws); err != nil {
+		t.Fatal(err)
+	}
+	assertCondition(t, ws, v1alpha1.RouteResolvedCondition, metav1.ConditionTrue, reasonHTTPRouteResolved)
+	r.gitClientFactory.(*stubClientFactory).client.assertHookCreated("https://hooks.example.com:8443/el-listener", stubSecret)
+}
+
+// If the HTTPRoute doesn't exist, then the WebhookSecret should reflect the
+// error.
+func TestWebhookSecretControllerWithHTTPRouteRefAndHTTPRouteMissing(t *testing.T) {
+	logf.SetLogger(logf.ZapLogger(true))
+	ws := makeWebhookSecret(v1alpha1.HookRoute{
+		HTTPRouteRef: &v1alpha1.HTTPRouteReference{Name: "my-test-httproute", Namespace: "gateway-test"},
+	})
+	_, r := makeReconciler(t, ws, ws, makeTestSecret(testAuthSecretName))
+	req := makeReconcileRequest()
+
+	_, err := r.Reconcile(req)
+	if !routes.IsNotFound(err) {
+		t.Fatalf("expected a not found error, got %v", err)
+	}
+
+	ws = &v1alpha1.WebhookSecret{}
+	if err := r.kubeClient.Get(context.Background(), req.NamespacedName, ws); err != nil {
+		t.Fatal(err)
+	}
+	assertCondition(t, ws, v1alpha1.RouteResolvedCondition, metav1.ConditionFalse, reasonHTTPRouteNotFound)
+	assertEvents(t, r.recorder,
+		"Normal SecretCreated Created Secret test-webhook-secret",
+		"Warning HTTPRouteNotFound HTTPRoute gateway-test/my-test-httproute was not found")
+	r.gitClientFactory.(*stubClientFactory).client.assertNoHookCreated()
+}
+
+// If the HTTPRoute's Gateway has no HTTP listeners, then the WebhookSecret
+// should reflect the error.
+func TestWebhookSecretControllerWithHTTPRouteRefAndNoListener(t *testing.T) {
+	logf.SetLogger(logf.ZapLogger(true))
+	ws := makeWebhookSecret(v1alpha1.HookRoute{
+		HTTPRouteRef: &v1alpha1.HTTPRouteReference{Name: "my-test-httproute", Namespace: "gateway-test"},
+	})
+	_, r := makeReconciler(
+		t, ws, ws, makeTestSecret(testAuthSecretName),
+		test.MakeHTTPRoute(testHTTPRouteID),
+		test.MakeGateway(testGatewayID, test.Listener("tcp", "TCP", 5432, "")))
+	req := makeReconcileRequest()
+
+	_, err := r.Reconcile(req)
+	if !test.MatchError(t, "has no parent Gateway with an HTTP or HTTPS listener", err) {
+		t.Fatalf("got incorrect error %v", err)
+	}
+
+	ws = &v1alpha1.WebhookSecret{}
+	if err := r.kubeClient.Get(context.Background(), req.NamespacedName, ws); err != nil {
+		t.Fatal(err)
+	}
+	assertCondition(t, ws, v1alpha1.RouteResolvedCondition, metav1.ConditionFalse, reasonHTTPRouteInvalid)
+	r.gitClientFactory.(*stubClientFactory).client.assertNoHookCreated()
+}
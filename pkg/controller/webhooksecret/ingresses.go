@@ -0,0 +1,25 @@
+package webhooksecret
+
+import (
+	"k8s.io/apimachinery/pkg/runtime"
+
+	v1alpha1 "github.com/bigkevmcd/webhook-secret-operator/pkg/apis/apps/v1alpha1"
+)
+
+// ingressRefIndexKey is the field index for WebhookSecrets by the Ingress
+// that they reference.
+const ingressRefIndexKey = "spec.webhookURL.ingressRef"
+
+// indexIngressRef is a client.IndexerFunc that returns the namespaced name of
+// the Ingress referenced by a WebhookSecret.
+func indexIngressRef(o runtime.Object) []string {
+	ws, ok := o.(*v1alpha1.WebhookSecret)
+	if !ok || ws.Spec.WebhookURL.IngressRef == nil {
+		return nil
+	}
+	return []string{ws.Spec.WebhookURL.IngressRef.NamespacedName().String()}
+}
+
+// ingressURLChanged is a predicate that ignores updates to Ingresses that
+// don't change the spec, which the URL is calculated from.
+var ingressURLChanged = unstructuredFieldChanged("spec")
@@ -0,0 +1,177 @@
+package webhooksecret
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	logf "sigs.k8s.io/controller-runtime/pkg/runtime/log"
+
+	v1alpha1 "github.com/bigkevmcd/webhook-secret-operator/pkg/apis/apps/v1alpha1"
+	"github.com/bigkevmcd/webhook-secret-operator/pkg/routes"
+	"github.com/bigkevmcd/webhook-secret-operator/test"
+)
+
+var testIngressID = types.NamespacedName{Name: "my-test-ingress", Namespace: "ingress-test"}
+
+func TestIndexIngressRef(t *testing.T) {
+	indexTests := []struct {
+		name string
+		ws   *v1alpha1.WebhookSecret
+		want []string
+	}{
+		{"hook URL", makeWebhookSecret(v1alpha1.HookRoute{HookURL: testHookEndpoint}), nil},
+		{"ingress ref", makeWebhookSecret(v1alpha1.HookRoute{IngressRef: &v1alpha1.IngressReference{Name: "my-test-ingress", Namespace: "ingress-test"}}), []string{"ingress-test/my-test-ingress"}},
+	}
+
+	for _, tt := range indexTests {
+		t.Run(tt.name, func(rt *testing.T) {
+			if got := indexIngressRef(tt.ws); !reflect.DeepEqual(got, tt.want) {
+				rt.Errorf("got %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIngressMapper(t *testing.T) {
+	referencing := makeWebhookSecret(v1alpha1.HookRoute{
+		IngressRef: &v1alpha1.IngressReference{Name: "my-test-ingress", Namespace: "ingress-test"},
+	})
+	other := makeWebhookSecret(v1alpha1.HookRoute{
+		IngressRef: &v1alpha1.IngressReference{Name: "other-ingress", Namespace: "ingress-test"},
+	})
+	other.Name = "other-webhook-secret"
+	cl, _ := makeReconciler(t, referencing, referencing, other)
+	ingress := test.MakeIngress(testIngressID)
+
+	m := &refMapper{kubeClient: cl, indexKey: ingressRefIndexKey, index: indexIngressRef}
+	requests := m.Map(handler.MapObject{Meta: ingress, Object: ingress})
+
+	want := []reconcile.Request{makeReconcileRequest()}
+	if !reflect.DeepEqual(requests, want) {
+		t.Fatalf("got %#v, want %#v", requests, want)
+	}
+}
+
+func TestIngressURLChanged(t *testing.T) {
+	changeTests := []struct {
+		name       string
+		newIngress *unstructured.Unstructured
+		want       bool
+	}{
+		{"no change", test.MakeIngress(testIngressID), false},
+		{"host changed", test.MakeIngress(testIngressID, test.IngressRule("new.example.com"), test.IngressTLS("new.example.com")), true},
+		{"tls removed", test.MakeIngress(testIngressID, test.IngressRule("example.com")), true},
+		{"labels changed", func() *unstructured.Unstructured {
+			i := test.MakeIngress(testIngressID)
+			i.SetLabels(map[string]string{"test": "value"})
+			return i
+		}(), false},
+	}
+
+	for _, tt := range changeTests {
+		t.Run(tt.name, func(rt *testing.T) {
+			oldIngress := test.MakeIngress(testIngressID)
+			got := ingressURLChanged.Update(event.UpdateEvent{
+				MetaOld: oldIngress, ObjectOld: oldIngress,
+				MetaNew: tt.newIngress, ObjectNew: tt.newIngress,
+			})
+			if got != tt.want {
+				rt.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// If using an Ingress, then the URL for the webhook should be calculated from
+// the Ingress rule.
+func TestWebhookSecretControllerWithIngressRef(t *testing.T) {
+	logf.SetLogger(logf.ZapLogger(true))
+	ws := makeWebhookSecret(v1alpha1.HookRoute{
+		IngressRef: &v1alpha1.IngressReference{
+			Name:      "my-test-ingress",
+			Namespace: "ingress-test",
+			Host:      "hooks.example.com",
+		},
+	})
+	_, r := makeReconciler(
+		t, ws, ws, makeTestSecret(testAuthSecretName),
+		test.MakeIngress(testIngressID,
+			test.IngressRule("example.com"),
+			test.IngressRule("hooks.example.com", "/el-listener"),
+			test.IngressTLS("hooks.example.com")))
+	req := makeReconcileRequest()
+
+	_, err := r.Reconcile(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ws = &v1alpha1.WebhookSecret{}
+	if err := r.kubeClient.Get(context.Background(), req.NamespacedName, ws); err != nil {
+		t.Fatal(err)
+	}
+	assertCondition(t, ws, v1alpha1.RouteResolvedCondition, metav1.ConditionTrue, reasonIngressResolved)
+	if ws.Status.HookURL != "https://hooks.example.com/el-listener" {
+		t.Fatalf("got hook URL %s", ws.Status.HookURL)
+	}
+	r.gitClientFactory.(*stubClientFactory).client.assertHookCreated("https://hooks.example.com/el-listener", stubSecret)
+}
+
+// If the Ingress doesn't exist, then the WebhookSecret should reflect the
+// error.
+func TestWebhookSecretControllerWithIngressRefAndIngressMissing(t *testing.T) {
+	logf.SetLogger(logf.ZapLogger(true))
+	ws := makeWebhookSecret(v1alpha1.HookRoute{
+		IngressRef: &v1alpha1.IngressReference{Name: "my-test-ingress", Namespace: "ingress-test"},
+	})
+	_, r := makeReconciler(t, ws, ws, makeTestSecret(testAuthSecretName))
+	req := makeReconcileRequest()
+
+	_, err := r.Reconcile(req)
+	if !routes.IsNotFound(err) {
+		t.Fatalf("expected a not found error, got %v", err)
+	}
+
+	ws = &v1alpha1.WebhookSecret{}
+	if err := r.kubeClient.Get(context.Background(), req.NamespacedName, ws); err != nil {
+		t.Fatal(err)
+	}
+	assertCondition(t, ws, v1alpha1.RouteResolvedCondition, metav1.ConditionFalse, reasonIngressNotFound)
+	assertCondition(t, ws, v1alpha1.ReadyCondition, metav1.ConditionFalse, reasonIngressNotFound)
+	assertEvents(t, r.recorder,
+		"Normal SecretCreated Created Secret test-webhook-secret",
+		"Warning IngressNotFound Ingress ingress-test/my-test-ingress was not found")
+	r.gitClientFactory.(*stubClientFactory).client.assertNoHookCreated()
+}
+
+// If the Ingress has rules for more than one host, and no host is selected,
+// then the WebhookSecret should reflect the error.
+func TestWebhookSecretControllerWithIngressRefAndAmbiguousHost(t *testing.T) {
+	logf.SetLogger(logf.ZapLogger(true))
+	ws := makeWebhookSecret(v1alpha1.HookRoute{
+		IngressRef: &v1alpha1.IngressReference{Name: "my-test-ingress", Namespace: "ingress-test"},
+	})
+	_, r := makeReconciler(
+		t, ws, ws, makeTestSecret(testAuthSecretName),
+		test.MakeIngress(testIngressID, test.IngressRule("example.com"), test.IngressRule("hooks.example.com")))
+	req := makeReconcileRequest()
+
+	_, err := r.Reconcile(req)
+	if !test.MatchError(t, "has rules for more than one host", err) {
+		t.Fatalf("got incorrect error %v", err)
+	}
+
+	ws = &v1alpha1.WebhookSecret{}
+	if err := r.kubeClient.Get(context.Background(), req.NamespacedName, ws); err != nil {
+		t.Fatal(err)
+	}
+	assertCondition(t, ws, v1alpha1.RouteResolvedCondition, metav1.ConditionFalse, reasonIngressInvalid)
+	r.gitClientFactory.(*stubClientFactory).client.assertNoHookCreated()
+}
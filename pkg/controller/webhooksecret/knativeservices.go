@@ -0,0 +1,25 @@
+package webhooksecret
+
+import (
+	"k8s.io/apimachinery/pkg/runtime"
+
+	v1alpha1 "github.com/bigkevmcd/webhook-secret-operator/pkg/apis/apps/v1alpha1"
+)
+
+// knativeServiceRefIndexKey is the field index for WebhookSecrets by the
+// Knative Service that they reference.
+const knativeServiceRefIndexKey = "spec.webhookURL.knativeServiceRef"
+
+// indexKnativeServiceRef is a client.IndexerFunc that returns the namespaced
+// name of the Knative Service referenced by a WebhookSecret.
+func indexKnativeServiceRef(o runtime.Object) []string {
+	ws, ok := o.(*v1alpha1.WebhookSecret)
+	if !ok || ws.Spec.WebhookURL.KnativeServiceRef == nil {
+		return nil
+	}
+	return []string{ws.Spec.WebhookURL.KnativeServiceRef.NamespacedName().String()}
+}
+
+// knativeServiceURLChanged is a predicate that ignores updates to Knative
+// Services that don't change the URL in the status.
+var knativeServiceURLChanged = unstructuredFieldChanged("status", "url")
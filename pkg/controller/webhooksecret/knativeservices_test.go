@@ -0,0 +1,105 @@
+package webhooksecret
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	logf "sigs.k8s.io/controller-runtime/pkg/runtime/log"
+
+	v1alpha1 "github.com/bigkevmcd/webhook-secret-operator/pkg/apis/apps/v1alpha1"
+	"github.com/bigkevmcd/webhook-secret-operator/test"
+)
+
+var testKnativeServiceID = types.NamespacedName{Name: "my-test-ksvc", Namespace: "knative-test"}
+
+func TestIndexKnativeServiceRef(t *testing.T) {
+	indexTests := []struct {
+		name string
+		ws   *v1alpha1.WebhookSecret
+		want []string
+	}{
+		{"hook URL", makeWebhookSecret(v1alpha1.HookRoute{HookURL: testHookEndpoint}), nil},
+		{"knative service ref", makeWebhookSecret(v1alpha1.HookRoute{KnativeServiceRef: &v1alpha1.RouteReference{Name: "my-test-ksvc", Namespace: "knative-test"}}), []string{"knative-test/my-test-ksvc"}},
+	}
+
+	for _, tt := range indexTests {
+		t.Run(tt.name, func(rt *testing.T) {
+			if got := indexKnativeServiceRef(tt.ws); !reflect.DeepEqual(got, tt.want) {
+				rt.Errorf("got %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestKnativeServiceURLChanged(t *testing.T) {
+	oldService := test.MakeKnativeService(testKnativeServiceID, "")
+	newService := test.MakeKnativeService(testKnativeServiceID, "https://my-test-ksvc.knative-test.example.com")
+
+	if !knativeServiceURLChanged.Update(event.UpdateEvent{
+		MetaOld: oldService, ObjectOld: oldService,
+		MetaNew: newService, ObjectNew: newService,
+	}) {
+		t.Fatal("expected a change to the URL to be detected")
+	}
+}
+
+// If using a Knative Service, then the URL for the webhook should be the URL
+// of the Knative Service.
+func TestWebhookSecretControllerWithKnativeServiceRef(t *testing.T) {
+	logf.SetLogger(logf.ZapLogger(true))
+	ws := makeWebhookSecret(v1alpha1.HookRoute{
+		KnativeServiceRef: &v1alpha1.RouteReference{
+			Name:      "my-test-ksvc",
+			Namespace: "knative-test",
+			Path:      "/el-listener",
+		},
+	})
+	_, r := makeReconciler(
+		t, ws, ws, makeTestSecret(testAuthSecretName),
+		test.MakeKnativeService(testKnativeServiceID, "https://my-test-ksvc.knative-test.example.com"))
+	req := makeReconcileRequest()
+
+	_, err := r.Reconcile(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ws = &v1alpha1.WebhookSecret{}
+	if err := r.kubeClient.Get(context.Background(), req.NamespacedName, ws); err != nil {
+		t.Fatal(err)
+	}
+	assertCondition(t, ws, v1alpha1.RouteResolvedCondition, metav1.ConditionTrue, reasonKnativeServiceResolved)
+	r.gitClientFactory.(*stubClientFactory).client.assertHookCreated("https://my-test-ksvc.knative-test.example.com/el-listener", stubSecret)
+}
+
+// If the Knative Service has no URL yet, then the WebhookSecret should be
+// requeued.
+func TestWebhookSecretControllerWithKnativeServiceRefWaitingForURL(t *testing.T) {
+	logf.SetLogger(logf.ZapLogger(true))
+	ws := makeWebhookSecret(v1alpha1.HookRoute{
+		KnativeServiceRef: &v1alpha1.RouteReference{Name: "my-test-ksvc", Namespace: "knative-test"},
+	})
+	_, r := makeReconciler(
+		t, ws, ws, makeTestSecret(testAuthSecretName),
+		test.MakeKnativeService(testKnativeServiceID, ""))
+	req := makeReconcileRequest()
+
+	res, err := r.Reconcile(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.RequeueAfter != pendingRequeueDelay {
+		t.Fatalf("got RequeueAfter %v, want %v", res.RequeueAfter, pendingRequeueDelay)
+	}
+
+	ws = &v1alpha1.WebhookSecret{}
+	if err := r.kubeClient.Get(context.Background(), req.NamespacedName, ws); err != nil {
+		t.Fatal(err)
+	}
+	assertCondition(t, ws, v1alpha1.RouteResolvedCondition, metav1.ConditionFalse, reasonKnativeServicePending)
+	r.gitClientFactory.(*stubClientFactory).client.assertNoHookCreated()
+}
@@ -0,0 +1,38 @@
+package webhooksecret
+
+import (
+	"context"
+
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	v1alpha1 "github.com/bigkevmcd/webhook-secret-operator/pkg/apis/apps/v1alpha1"
+)
+
+// providersMapper maps changes to the providers ConfigMap to all
+// WebhookSecrets, as any of them could be using the providers.
+type providersMapper struct {
+	kubeClient client.Client
+	configMap  types.NamespacedName
+}
+
+// Map implements the handler.Mapper interface.
+func (m *providersMapper) Map(obj handler.MapObject) []reconcile.Request {
+	if obj.Meta.GetName() != m.configMap.Name || obj.Meta.GetNamespace() != m.configMap.Namespace {
+		return nil
+	}
+	list := &v1alpha1.WebhookSecretList{}
+	if err := m.kubeClient.List(context.TODO(), list); err != nil {
+		log.Error(err, "failed to list WebhookSecrets for the providers ConfigMap")
+		return nil
+	}
+	requests := []reconcile.Request{}
+	for _, ws := range list.Items {
+		requests = append(requests, reconcile.Request{
+			NamespacedName: types.NamespacedName{Name: ws.Name, Namespace: ws.Namespace},
+		})
+	}
+	return requests
+}
@@ -0,0 +1,50 @@
+package webhooksecret
+
+import (
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	v1alpha1 "github.com/bigkevmcd/webhook-secret-operator/pkg/apis/apps/v1alpha1"
+	"github.com/bigkevmcd/webhook-secret-operator/pkg/providers"
+)
+
+var _ handler.Mapper = (*providersMapper)(nil)
+
+func TestProvidersMapper(t *testing.T) {
+	ws := makeWebhookSecret(v1alpha1.HookRoute{HookURL: testHookEndpoint})
+	cl, _ := makeReconciler(t, ws, ws)
+	m := &providersMapper{kubeClient: cl, configMap: testProvidersConfigMap}
+
+	cm := makeProvidersConfigMap("")
+	requests := m.Map(handler.MapObject{Meta: cm, Object: cm})
+	want := []reconcile.Request{makeReconcileRequest()}
+	if !reflect.DeepEqual(requests, want) {
+		t.Fatalf("got %#v, want %#v", requests, want)
+	}
+
+	cm.Name = "other-config-map"
+	if requests := m.Map(handler.MapObject{Meta: cm, Object: cm}); len(requests) != 0 {
+		t.Fatalf("got %#v for an unrelated ConfigMap", requests)
+	}
+}
+
+func makeProvidersConfigMap(data string) *corev1.ConfigMap {
+	return &corev1.ConfigMap{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "v1",
+			Kind:       "ConfigMap",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      testProvidersConfigMap.Name,
+			Namespace: testProvidersConfigMap.Namespace,
+		},
+		Data: map[string]string{
+			providers.Key: data,
+		},
+	}
+}
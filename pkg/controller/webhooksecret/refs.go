@@ -0,0 +1,118 @@
+package webhooksecret
+
+import (
+	"context"
+	"reflect"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	v1alpha1 "github.com/bigkevmcd/webhook-secret-operator/pkg/apis/apps/v1alpha1"
+)
+
+// refMapper maps objects to the WebhookSecrets that reference them, using a
+// field index of the namespaced names of the referenced objects.
+type refMapper struct {
+	kubeClient client.Client
+	indexKey   string
+	index      client.IndexerFunc
+	// keys returns the keys in the index for the object, if it's nil, the
+	// namespaced name of the object is used.
+	keys func(handler.MapObject) []string
+}
+
+// Map implements the handler.Mapper interface.
+func (m *refMapper) Map(obj handler.MapObject) []reconcile.Request {
+	keys := []string{types.NamespacedName{Name: obj.Meta.GetName(), Namespace: obj.Meta.GetNamespace()}.String()}
+	if m.keys != nil {
+		keys = m.keys(obj)
+	}
+	requests := []reconcile.Request{}
+	seen := map[types.NamespacedName]bool{}
+	for _, id := range keys {
+		list := &v1alpha1.WebhookSecretList{}
+		if err := m.kubeClient.List(context.TODO(), list, client.MatchingFields{m.indexKey: id}); err != nil {
+			log.Error(err, "failed to list WebhookSecrets for referenced object", "index", m.indexKey, "id", id)
+			continue
+		}
+		for i := range list.Items {
+			// Not all clients filter by the index.
+			if !containsString(m.index(&list.Items[i]), id) {
+				continue
+			}
+			name := types.NamespacedName{Name: list.Items[i].Name, Namespace: list.Items[i].Namespace}
+			if seen[name] {
+				continue
+			}
+			seen[name] = true
+			requests = append(requests, reconcile.Request{NamespacedName: name})
+		}
+	}
+	return requests
+}
+
+// watchRefs indexes WebhookSecrets with the index, and watches objects of the
+// type, reconciling the WebhookSecrets that reference them.
+func watchRefs(mgr manager.Manager, c controller.Controller, obj runtime.Object, indexKey string, index client.IndexerFunc, prct ...predicate.Predicate) error {
+	err := mgr.GetFieldIndexer().IndexField(context.TODO(), &v1alpha1.WebhookSecret{}, indexKey, index)
+	if err != nil {
+		return err
+	}
+	return c.Watch(&source.Kind{Type: obj}, &handler.EnqueueRequestsFromMapFunc{
+		ToRequests: &refMapper{kubeClient: mgr.GetClient(), indexKey: indexKey, index: index},
+	}, prct...)
+}
+
+// watchOptionalRefs is watchRefs for kinds that might not be installed in the
+// cluster, e.g. from CRDs, the objects are watched as unstructured objects,
+// and only if the API server serves the kind when the operator starts.
+func watchOptionalRefs(mgr manager.Manager, c controller.Controller, gvk schema.GroupVersionKind, indexKey string, index client.IndexerFunc, prct ...predicate.Predicate) error {
+	served, err := isServed(mgr, gvk)
+	if !served || err != nil {
+		return err
+	}
+	u := &unstructured.Unstructured{}
+	u.SetGroupVersionKind(gvk)
+	return watchRefs(mgr, c, u, indexKey, index, prct...)
+}
+
+// isServed returns true if the API server serves the kind.
+func isServed(mgr manager.Manager, gvk schema.GroupVersionKind) (bool, error) {
+	_, err := mgr.GetRESTMapper().RESTMapping(gvk.GroupKind(), gvk.Version)
+	if meta.IsNoMatchError(err) {
+		log.Info("Kind is not served by the cluster, references to it will not be watched", "kind", gvk.String())
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// unstructuredFieldChanged returns a predicate that ignores updates to
+// unstructured objects that don't change the field at the path.
+func unstructuredFieldChanged(fields ...string) predicate.Funcs {
+	return predicate.Funcs{
+		UpdateFunc: func(e event.UpdateEvent) bool {
+			oldObj, ok := e.ObjectOld.(*unstructured.Unstructured)
+			if !ok {
+				return true
+			}
+			newObj, ok := e.ObjectNew.(*unstructured.Unstructured)
+			if !ok {
+				return true
+			}
+			oldField, _, _ := unstructured.NestedFieldNoCopy(oldObj.Object, fields...)
+			newField, _, _ := unstructured.NestedFieldNoCopy(newObj.Object, fields...)
+			return !reflect.DeepEqual(oldField, newField)
+		},
+	}
+}
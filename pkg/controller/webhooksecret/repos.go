@@ -0,0 +1,354 @@
+package webhooksecret
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	v1alpha1 "github.com/bigkevmcd/webhook-secret-operator/pkg/apis/apps/v1alpha1"
+	"github.com/bigkevmcd/webhook-secret-operator/pkg/git"
+)
+
+// validateRepos checks that the WebhookSecret has either a repo or a list of
+// repos, and that no repository is listed more than once.
+func validateRepos(ws *v1alpha1.WebhookSecret) error {
+	if len(ws.Spec.Repos) == 0 {
+		if ws.Spec.Repo.URL == "" {
+			return fmt.Errorf("one of repo or repos must be provided")
+		}
+		return nil
+	}
+	if ws.Spec.Repo.URL != "" {
+		return fmt.Errorf("only one of repo or repos can be provided")
+	}
+	seen := map[string]bool{}
+	for _, repo := range ws.Spec.Repos {
+		if repo.URL == "" {
+			return fmt.Errorf("every repository in repos must have a url")
+		}
+		if seen[repo.URL] {
+			return fmt.Errorf("repository %s is listed more than once in repos", repo.URL)
+		}
+		seen[repo.URL] = true
+	}
+	return nil
+}
+
+// repoWebhooks returns the webhooks recorded in the status for each of the
+// repos, in the order of the repos, and the recorded webhooks for repositories
+// that are no longer listed.
+func repoWebhooks(ws *v1alpha1.WebhookSecret) ([]v1alpha1.RepoWebhook, []v1alpha1.RepoWebhook) {
+	recorded := map[string]v1alpha1.RepoWebhook{}
+	for _, wh := range ws.Status.Webhooks {
+		recorded[wh.Repo.URL] = wh
+	}
+	listed := []v1alpha1.RepoWebhook{}
+	for _, repo := range ws.Spec.Repos {
+		wh := recorded[repo.URL]
+		wh.Repo = repo
+		listed = append(listed, wh)
+		delete(recorded, repo.URL)
+	}
+	unlisted := []v1alpha1.RepoWebhook{}
+	for _, wh := range ws.Status.Webhooks {
+		if _, ok := recorded[wh.Repo.URL]; ok {
+			unlisted = append(unlisted, wh)
+		}
+	}
+	return listed, unlisted
+}
+
+// reconcileRepos ensures that there's a webhook with the secret in each of the
+// repos, and removes the webhooks from repositories that are no longer listed.
+//
+// A failure in one repository doesn't stop the other repositories from being
+// reconciled, the error is recorded for the repository in the status.
+//
+// If the Secret was created, the recorded webhooks were created with a secret
+// that no longer exists, and are updated with the new secret.
+func (r *ReconcileWebhookSecret) reconcileRepos(ctx context.Context, logger logr.Logger, ws *v1alpha1.WebhookSecret, s *corev1.Secret, created bool) (reconcile.Result, error) {
+	baseURL, err := r.baseHookURL(ctx, ws)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+	listed, unlisted := repoWebhooks(ws)
+	unlisted = r.deleteUnlistedWebhooks(ctx, logger, ws, unlisted)
+
+	if !created && rotationDue(ws, s, time.Now()) {
+		rotated, err := r.rotateRepoSecret(ctx, logger, ws, s, baseURL, listed)
+		if err != nil {
+			ws.Status.Webhooks = append(listed, unlisted...)
+			setReposReady(ws)
+			return reconcile.Result{}, err
+		}
+		if err := r.recordRepoWebhooks(ctx, ws, listed, unlisted); err != nil {
+			return reconcile.Result{}, err
+		}
+		s = rotated
+	} else if !created && previousExpired(ws, s, time.Now()) {
+		if s, err = r.prunePreviousSecret(ctx, logger, ws, s); err != nil {
+			return reconcile.Result{}, err
+		}
+	}
+
+	secret := string(s.Data[secretKey(ws)])
+	for i := range listed {
+		wh := &listed[i]
+		wh.Error = ""
+		hookID, hookURL := wh.WebhookID, wh.HookURL
+		if err := r.reconcileRepoWebhook(ctx, logger, ws, wh, baseURL, secret, created); err != nil {
+			logger.Error(err, "failed to reconcile the webhook", "repo", wh.Repo.URL)
+			wh.Error = err.Error()
+		}
+		// Record the webhook as soon as it has been created or updated, so
+		// that it isn't lost if a later repository fails.
+		if wh.WebhookID != hookID || wh.HookURL != hookURL {
+			if err := r.recordRepoWebhooks(ctx, ws, listed, unlisted); err != nil {
+				return reconcile.Result{}, err
+			}
+		}
+	}
+	ws.Status.Webhooks = append(listed, unlisted...)
+	if err := setReposReady(ws); err != nil {
+		return reconcile.Result{}, err
+	}
+	return reconcile.Result{RequeueAfter: requeueAfter(ws, s, time.Now())}, nil
+}
+
+// reconcileRepoWebhook ensures that the webhook in the repo matches the
+// WebhookSecret, updating the webhook if the hook URL or secret has changed,
+// and recreating it if it's missing or has drifted.
+func (r *ReconcileWebhookSecret) reconcileRepoWebhook(ctx context.Context, logger logr.Logger, ws *v1alpha1.WebhookSecret, wh *v1alpha1.RepoWebhook, baseURL, secret string, secretChanged bool) error {
+	hookURL, err := r.repoHookURL(ws, wh.Repo, baseURL)
+	if err != nil {
+		return err
+	}
+	client, err := r.authenticatedRepoClient(ctx, ws, wh.Repo)
+	if err != nil {
+		return err
+	}
+	logger = logger.WithValues("repo", wh.Repo.URL)
+
+	if wh.WebhookID != "" && (secretChanged || (wh.HookURL != "" && wh.HookURL != hookURL)) {
+		hookID, err := client.Update(ctx, wh.WebhookID, hookURL, secret, ws.Spec.Events)
+		if err == nil {
+			logger.Info("Hook updated", "id", hookID, "hookURL", hookURL)
+			r.recorder.Eventf(ws, corev1.EventTypeNormal, reasonWebhookUpdated, "Updated webhook %s in %s to %s", hookID, wh.Repo.URL, hookURL)
+			syncedRepoWebhook(wh, hookID, hookURL)
+			return nil
+		}
+		if !git.IsNotFound(err) {
+			return r.repoWebhookFailed(ws, wh.Repo, err)
+		}
+		logger.Info("Hook not found", "id", wh.WebhookID)
+		wh.WebhookID = ""
+	}
+
+	hookID, create, err := r.removeStaleWebhooks(ctx, logger, client, ws, wh.WebhookID, hookURL, secret)
+	if err != nil {
+		return r.repoWebhookFailed(ws, wh.Repo, err)
+	}
+	if !create {
+		syncedRepoWebhook(wh, hookID, hookURL)
+		return nil
+	}
+	hookID, err = client.Create(ctx, hookURL, secret, ws.Spec.Events)
+	if err != nil {
+		return r.repoWebhookFailed(ws, wh.Repo, err)
+	}
+	logger.Info("Hook created", "id", hookID, "hookURL", hookURL)
+	r.recorder.Eventf(ws, corev1.EventTypeNormal, reasonWebhookCreated, "Created webhook %s in %s for %s", hookID, wh.Repo.URL, hookURL)
+	syncedRepoWebhook(wh, hookID, hookURL)
+	return nil
+}
+
+// recordRepoWebhooks records the webhooks in the status of the WebhookSecret.
+func (r *ReconcileWebhookSecret) recordRepoWebhooks(ctx context.Context, ws *v1alpha1.WebhookSecret, listed, unlisted []v1alpha1.RepoWebhook) error {
+	ws.Status.Webhooks = append(append([]v1alpha1.RepoWebhook{}, listed...), unlisted...)
+	if err := r.kubeClient.Status().Update(ctx, ws); err != nil {
+		log.Error(err, "Failed to update WebhookSecret status")
+		return fmt.Errorf("failed to update status after reconciling the webhooks: %s", err)
+	}
+	return nil
+}
+
+// rotateRepoSecret replaces the secret for the webhooks in the repos with a
+// newly generated one, returning the updated Secret.
+//
+// As with a single repository, the Secret is updated before the webhooks. If
+// any of the webhooks can't be updated, the updated webhooks and the Secret
+// are reverted to the existing secret, webhooks that can't be reverted are
+// forgotten so that they are replaced on the next reconciliation.
+func (r *ReconcileWebhookSecret) rotateRepoSecret(ctx context.Context, logger logr.Logger, ws *v1alpha1.WebhookSecret, s *corev1.Secret, baseURL string, listed []v1alpha1.RepoWebhook) (*corev1.Secret, error) {
+	rotated, err := r.secretFactory.RotateSecret(ws, s)
+	if err != nil {
+		setCondition(ws, v1alpha1.SecretReadyCondition, metav1.ConditionFalse, reasonSecretFailed, err.Error())
+		return nil, err
+	}
+	key := secretKey(ws)
+
+	type updatedHook struct {
+		wh      *v1alpha1.RepoWebhook
+		client  git.HooksClient
+		hookURL string
+	}
+	updated := []updatedHook{}
+	revert := func() {
+		for _, u := range updated {
+			revertedID, err := u.client.Update(ctx, u.wh.WebhookID, u.hookURL, string(s.Data[key]), ws.Spec.Events)
+			if err != nil {
+				logger.Error(err, "failed to revert the webhook", "repo", u.wh.Repo.URL, "id", u.wh.WebhookID)
+				u.wh.WebhookID = ""
+				u.wh.Error = r.repoWebhookFailed(ws, u.wh.Repo, err).Error()
+				continue
+			}
+			u.wh.WebhookID = revertedID
+		}
+	}
+
+	logger.Info("Rotating the secret", "repos", len(listed))
+	if err := r.updateRotatedSecret(ctx, ws, rotated); err != nil {
+		return nil, err
+	}
+	for i := range listed {
+		wh := &listed[i]
+		if wh.WebhookID == "" {
+			continue
+		}
+		client, hookURL, err := r.updateRepoWebhookSecret(ctx, ws, wh, baseURL, string(rotated.Data[key]))
+		if err != nil {
+			logger.Error(err, "failed to rotate the secret for the webhook, reverting the webhooks", "repo", wh.Repo.URL)
+			wh.Error = err.Error()
+			revert()
+			r.revertRotatedSecret(ctx, logger, ws, s, rotated)
+			return nil, err
+		}
+		updated = append(updated, updatedHook{wh: wh, client: client, hookURL: hookURL})
+	}
+
+	now := metav1.Now()
+	ws.Status.LastRotationTime = &now
+	ws.Status.LastRotationRequest = ws.ObjectMeta.Annotations[v1alpha1.RotateAnnotation]
+	logger.Info("Secret rotated", "webhooks", len(updated))
+	r.recorder.Eventf(ws, corev1.EventTypeNormal, reasonSecretRotated, "Rotated the secret for %d webhooks", len(updated))
+	setCondition(ws, v1alpha1.SecretReadyCondition, metav1.ConditionTrue, reasonSecretRotated, "")
+	return rotated, nil
+}
+
+// updateRepoWebhookSecret updates the webhook in the repo with the secret,
+// returning the client and hook URL that were used to update it.
+func (r *ReconcileWebhookSecret) updateRepoWebhookSecret(ctx context.Context, ws *v1alpha1.WebhookSecret, wh *v1alpha1.RepoWebhook, baseURL, secret string) (git.HooksClient, string, error) {
+	hookURL, err := r.repoHookURL(ws, wh.Repo, baseURL)
+	if err != nil {
+		return nil, "", err
+	}
+	client, err := r.authenticatedRepoClient(ctx, ws, wh.Repo)
+	if err != nil {
+		return nil, "", err
+	}
+	hookID, err := client.Update(ctx, wh.WebhookID, hookURL, secret, ws.Spec.Events)
+	if err != nil {
+		return nil, "", r.repoWebhookFailed(ws, wh.Repo, err)
+	}
+	wh.WebhookID = hookID
+	return client, hookURL, nil
+}
+
+// deleteUnlistedWebhooks deletes the webhooks from repositories that are no
+// longer listed, returning the webhooks that couldn't be deleted, with the
+// error, so that they can be retried.
+func (r *ReconcileWebhookSecret) deleteUnlistedWebhooks(ctx context.Context, logger logr.Logger, ws *v1alpha1.WebhookSecret, unlisted []v1alpha1.RepoWebhook) []v1alpha1.RepoWebhook {
+	remaining := []v1alpha1.RepoWebhook{}
+	for _, wh := range unlisted {
+		if wh.WebhookID == "" {
+			continue
+		}
+		if err := r.deleteRepoWebhook(ctx, ws, wh); err != nil {
+			logger.Error(err, "failed to delete the webhook", "repo", wh.Repo.URL, "id", wh.WebhookID)
+			wh.Error = err.Error()
+			remaining = append(remaining, wh)
+		}
+	}
+	return remaining
+}
+
+// deleteRepoWebhooks deletes the webhooks in all the repos that are recorded
+// in the status.
+func (r *ReconcileWebhookSecret) deleteRepoWebhooks(ctx context.Context, logger logr.Logger, ws *v1alpha1.WebhookSecret) error {
+	failed := []string{}
+	for _, wh := range ws.Status.Webhooks {
+		if wh.WebhookID == "" {
+			continue
+		}
+		if err := r.deleteRepoWebhook(ctx, ws, wh); err != nil {
+			logger.Error(err, "failed to delete the webhook", "repo", wh.Repo.URL, "id", wh.WebhookID)
+			failed = append(failed, fmt.Sprintf("%s: %s", wh.Repo.URL, err))
+		}
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("failed to delete the webhooks for %d repositories: %s", len(failed), strings.Join(failed, "; "))
+	}
+	return nil
+}
+
+// deleteRepoWebhook deletes the webhook from the git host, a webhook that
+// doesn't exist is not an error.
+func (r *ReconcileWebhookSecret) deleteRepoWebhook(ctx context.Context, ws *v1alpha1.WebhookSecret, wh v1alpha1.RepoWebhook) error {
+	client, err := r.authenticatedRepoClient(ctx, ws, wh.Repo)
+	if err != nil {
+		return err
+	}
+	err = client.Delete(ctx, wh.WebhookID)
+	if git.IsNotFound(err) {
+		log.Info("Webhook was already deleted", "id", wh.WebhookID, "repo", wh.Repo.URL)
+		return nil
+	}
+	if err != nil {
+		return r.repoWebhookFailed(ws, wh.Repo, err)
+	}
+	r.recorder.Eventf(ws, corev1.EventTypeNormal, reasonWebhookDeleted, "Deleted webhook %s in %s", wh.WebhookID, wh.Repo.URL)
+	return nil
+}
+
+// repoWebhookFailed records the error from the git host for the repo as an
+// event, and returns it, including the HTTP status code if there is one.
+func (r *ReconcileWebhookSecret) repoWebhookFailed(ws *v1alpha1.WebhookSecret, repo v1alpha1.Repo, err error) error {
+	if status := git.ErrorStatus(err); status != 0 {
+		err = fmt.Errorf("%w (HTTP status %d)", err, status)
+	}
+	r.recorder.Eventf(ws, corev1.EventTypeWarning, scmErrorReason(err), "%s: %s", repo.URL, err)
+	return err
+}
+
+// setReposReady sets the WebhookReady condition from the errors recorded for
+// the webhooks, returning an error if any of the webhooks failed.
+func setReposReady(ws *v1alpha1.WebhookSecret) error {
+	failed := []string{}
+	for _, wh := range ws.Status.Webhooks {
+		if wh.Error != "" {
+			failed = append(failed, fmt.Sprintf("%s: %s", wh.Repo.URL, wh.Error))
+		}
+	}
+	if len(failed) == 0 {
+		setCondition(ws, v1alpha1.WebhookReadyCondition, metav1.ConditionTrue, reasonWebhookVerified, "")
+		return nil
+	}
+	err := fmt.Errorf("failed to reconcile the webhooks for %d of %d repositories: %s", len(failed), len(ws.Status.Webhooks), strings.Join(failed, "; "))
+	setCondition(ws, v1alpha1.WebhookReadyCondition, metav1.ConditionFalse, reasonWebhookFailed, err.Error())
+	return err
+}
+
+// syncedRepoWebhook records that the webhook is up to date.
+func syncedRepoWebhook(wh *v1alpha1.RepoWebhook, hookID, hookURL string) {
+	now := metav1.Now()
+	wh.WebhookID = hookID
+	wh.HookURL = hookURL
+	wh.LastSyncTime = &now
+	wh.Error = ""
+}
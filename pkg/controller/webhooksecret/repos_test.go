@@ -0,0 +1,323 @@
+package webhooksecret
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/jenkins-x/go-scm/scm"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	logf "sigs.k8s.io/controller-runtime/pkg/runtime/log"
+
+	v1alpha1 "github.com/bigkevmcd/webhook-secret-operator/pkg/apis/apps/v1alpha1"
+	"github.com/bigkevmcd/webhook-secret-operator/pkg/git"
+	"github.com/bigkevmcd/webhook-secret-operator/test"
+)
+
+const (
+	testOtherRepoURL   = "https://github.com/example/other.git"
+	testOtherWebhookID = "7654321"
+)
+
+func TestValidateRepos(t *testing.T) {
+	validTests := []struct {
+		name    string
+		repo    v1alpha1.Repo
+		repos   []v1alpha1.Repo
+		wantErr string
+	}{
+		{"single repo", v1alpha1.Repo{URL: testRepoURL}, nil, ""},
+		{"repos", v1alpha1.Repo{}, []v1alpha1.Repo{{URL: testRepoURL}, {URL: testOtherRepoURL}}, ""},
+		{"no repo", v1alpha1.Repo{}, nil, "one of repo or repos must be provided"},
+		{"repo and repos", v1alpha1.Repo{URL: testRepoURL}, []v1alpha1.Repo{{URL: testOtherRepoURL}}, "only one of repo or repos can be provided"},
+		{"repo without a url", v1alpha1.Repo{}, []v1alpha1.Repo{{URL: testRepoURL}, {}}, "every repository in repos must have a url"},
+		{"duplicate repos", v1alpha1.Repo{}, []v1alpha1.Repo{{URL: testRepoURL}, {URL: testRepoURL}}, "repository https://github.com/example/example.git is listed more than once in repos"},
+	}
+
+	for _, tt := range validTests {
+		t.Run(tt.name, func(rt *testing.T) {
+			ws := makeWebhookSecret(v1alpha1.HookRoute{HookURL: testHookEndpoint})
+			ws.Spec.Repo = tt.repo
+			ws.Spec.Repos = tt.repos
+
+			err := validateRepos(ws)
+			if !test.MatchError(rt, tt.wantErr, err) {
+				rt.Fatalf("error failed to match, got %#v, want %s", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// Reconciling a WebhookSecret with repos should create a webhook in each of
+// the repositories with the same secret.
+func TestWebhookSecretControllerWithRepos(t *testing.T) {
+	logf.SetLogger(logf.ZapLogger(true))
+	ws := makeReposWebhookSecret(testRepoURL, testOtherRepoURL)
+	_, r := makeReconciler(t, ws, ws, makeTestSecret(testAuthSecretName))
+	hc, other := addRepoClients(t, r)
+	req := makeReconcileRequest()
+
+	res, err := r.Reconcile(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.RequeueAfter != hookResyncPeriod {
+		t.Fatalf("RequeueAfter got %v, want %v", res.RequeueAfter, hookResyncPeriod)
+	}
+	hc.assertHookCreated(testHookEndpoint, stubSecret)
+	other.assertHookCreated(testHookEndpoint, stubSecret)
+	loaded := &v1alpha1.WebhookSecret{}
+	if err := r.kubeClient.Get(context.TODO(), req.NamespacedName, loaded); err != nil {
+		t.Fatal(err)
+	}
+	assertRepoWebhooks(t, loaded, map[string]string{testRepoURL: testWebhookID, testOtherRepoURL: testOtherWebhookID})
+	assertCondition(t, loaded, v1alpha1.WebhookReadyCondition, metav1.ConditionTrue, reasonWebhookVerified)
+	assertEvents(t, r.recorder,
+		"Normal SecretCreated Created Secret test-webhook-secret",
+		"Normal WebhookCreated Created webhook 1234567 in https://github.com/example/example.git for https://example.com/",
+		"Normal WebhookCreated Created webhook 7654321 in https://github.com/example/other.git for https://example.com/")
+}
+
+// If the webhook can't be created in one of the repos, the other webhooks
+// should still be created, and the failure reported for the repository.
+func TestWebhookSecretControllerWithReposPartialFailure(t *testing.T) {
+	logf.SetLogger(logf.ZapLogger(true))
+	ws := makeReposWebhookSecret(testRepoURL, testOtherRepoURL)
+	_, r := makeReconciler(t, ws, ws, makeTestSecret(testAuthSecretName))
+	hc, other := addRepoClients(t, r)
+	other.createErr = git.SCMError{Status: http.StatusForbidden}
+	req := makeReconcileRequest()
+
+	_, err := r.Reconcile(req)
+	if !test.MatchError(t, `failed to reconcile the webhooks for 1 of 2 repositories: https://github.com/example/other.git: .*\(HTTP status 403\)`, err) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	hc.assertHookCreated(testHookEndpoint, stubSecret)
+	loaded := &v1alpha1.WebhookSecret{}
+	if err := r.kubeClient.Get(context.TODO(), req.NamespacedName, loaded); err != nil {
+		t.Fatal(err)
+	}
+	assertRepoWebhooks(t, loaded, map[string]string{testRepoURL: testWebhookID, testOtherRepoURL: ""})
+	if loaded.Status.Webhooks[1].Error == "" {
+		t.Fatal("the failure was not recorded for the repository")
+	}
+	assertCondition(t, loaded, v1alpha1.WebhookReadyCondition, metav1.ConditionFalse, reasonWebhookFailed)
+}
+
+// If the status can't be updated after creating a webhook, the next
+// reconciliation adopts the webhook rather than creating another one.
+func TestWebhookSecretControllerWithReposFailedStatusUpdate(t *testing.T) {
+	logf.SetLogger(logf.ZapLogger(true))
+	ws := makeReposWebhookSecret(testRepoURL, testOtherRepoURL)
+	cl, r := makeReconciler(t, ws, ws, makeTestSecret(testAuthSecretName), makeGeneratedSecret(stubSecret))
+	hc, other := addRepoClients(t, r)
+	r.kubeClient = statusFailingClient{Client: cl, err: errors.New("status update failed")}
+	req := makeReconcileRequest()
+
+	_, err := r.Reconcile(req)
+	if !test.MatchError(t, "failed to update status after reconciling the webhooks: status update failed", err) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	hc.assertHookCreated(testHookEndpoint, stubSecret)
+	other.assertNoHookCreated()
+
+	r.kubeClient = cl
+	hc.created = make(map[string]string)
+	if _, err := r.Reconcile(req); err != nil {
+		t.Fatal(err)
+	}
+	hc.assertNoHookCreated()
+	other.assertHookCreated(testHookEndpoint, stubSecret)
+	if l := len(hc.hooks) + len(other.hooks); l != 2 {
+		t.Fatalf("got %d hooks, want 2", l)
+	}
+	loaded := &v1alpha1.WebhookSecret{}
+	if err := cl.Get(context.TODO(), req.NamespacedName, loaded); err != nil {
+		t.Fatal(err)
+	}
+	assertRepoWebhooks(t, loaded, map[string]string{testRepoURL: testWebhookID, testOtherRepoURL: testOtherWebhookID})
+}
+
+// When a repository is removed from the repos, the webhook should be deleted
+// from the repository.
+func TestWebhookSecretControllerWithRemovedRepo(t *testing.T) {
+	logf.SetLogger(logf.ZapLogger(true))
+	ws := makeReposWebhookSecret(testRepoURL)
+	ws.Status.SecretRef = v1alpha1.WebhookSecretRef{Name: testWebhookSecretName}
+	ws.Status.Webhooks = []v1alpha1.RepoWebhook{
+		{Repo: v1alpha1.Repo{URL: testRepoURL}, WebhookID: testWebhookID, HookURL: testHookEndpoint},
+		{Repo: v1alpha1.Repo{URL: testOtherRepoURL}, WebhookID: testOtherWebhookID, HookURL: testHookEndpoint},
+	}
+	_, r := makeReconciler(t, ws, ws, makeTestSecret(testAuthSecretName), makeGeneratedSecret(stubSecret))
+	hc, other := addRepoClients(t, r)
+	hc.addHook(&git.Hook{ID: testWebhookID, URL: testHookEndpoint, Events: []string{"push"}, Active: true, Driver: scm.DriverGithub})
+	req := makeReconcileRequest()
+
+	_, err := r.Reconcile(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	other.assertHookDeleted(testOtherWebhookID)
+	hc.assertNoHookDeleted()
+	hc.assertNoHookCreated()
+	loaded := &v1alpha1.WebhookSecret{}
+	if err := r.kubeClient.Get(context.TODO(), req.NamespacedName, loaded); err != nil {
+		t.Fatal(err)
+	}
+	assertRepoWebhooks(t, loaded, map[string]string{testRepoURL: testWebhookID})
+	assertEvents(t, r.recorder, "Normal WebhookDeleted Deleted webhook 7654321 in https://github.com/example/other.git")
+}
+
+// Rotating the secret should update the webhooks in all the repos.
+func TestWebhookSecretControllerWithReposRotation(t *testing.T) {
+	logf.SetLogger(logf.ZapLogger(true))
+	ws := makeReposWebhookSecret(testRepoURL, testOtherRepoURL)
+	ws.ObjectMeta.Annotations = map[string]string{v1alpha1.RotateAnnotation: "2020-07-01"}
+	ws.Status.Webhooks = []v1alpha1.RepoWebhook{
+		{Repo: v1alpha1.Repo{URL: testRepoURL}, WebhookID: testWebhookID, HookURL: testHookEndpoint},
+		{Repo: v1alpha1.Repo{URL: testOtherRepoURL}, WebhookID: testOtherWebhookID, HookURL: testHookEndpoint},
+	}
+	_, r := makeReconciler(t, ws, ws, makeTestSecret(testAuthSecretName), makeGeneratedSecret("existing-secret"))
+	hc, other := addRepoClients(t, r)
+	hc.addHook(&git.Hook{ID: testWebhookID, URL: testHookEndpoint, Events: []string{"push"}, Active: true, Driver: scm.DriverGithub})
+	other.addHook(&git.Hook{ID: testOtherWebhookID, URL: testHookEndpoint, Events: []string{"push"}, Active: true, Driver: scm.DriverGithub})
+	req := makeReconcileRequest()
+
+	_, err := r.Reconcile(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertSecretToken(t, r.kubeClient, stubSecret)
+	hc.assertHookUpdated(testWebhookID, testHookEndpoint, stubSecret)
+	other.assertHookUpdated(testOtherWebhookID, testHookEndpoint, stubSecret)
+	loaded := &v1alpha1.WebhookSecret{}
+	if err := r.kubeClient.Get(context.TODO(), req.NamespacedName, loaded); err != nil {
+		t.Fatal(err)
+	}
+	if loaded.Status.LastRotationRequest != "2020-07-01" {
+		t.Errorf("LastRotationRequest got %#v, want %#v", loaded.Status.LastRotationRequest, "2020-07-01")
+	}
+}
+
+// With a grace period, both secrets should be in the Secret before any of the
+// webhooks are updated.
+func TestWebhookSecretControllerWithReposRotationGracePeriod(t *testing.T) {
+	logf.SetLogger(logf.ZapLogger(true))
+	ws := makeReposWebhookSecret(testRepoURL, testOtherRepoURL)
+	ws.ObjectMeta.Annotations = map[string]string{v1alpha1.RotateAnnotation: "2020-07-01"}
+	ws.Spec.Rotation = &v1alpha1.RotationSpec{GracePeriod: &metav1.Duration{Duration: time.Hour}}
+	ws.Status.Webhooks = []v1alpha1.RepoWebhook{
+		{Repo: v1alpha1.Repo{URL: testRepoURL}, WebhookID: testWebhookID, HookURL: testHookEndpoint},
+		{Repo: v1alpha1.Repo{URL: testOtherRepoURL}, WebhookID: testOtherWebhookID, HookURL: testHookEndpoint},
+	}
+	_, r := makeReconciler(t, ws, ws, makeTestSecret(testAuthSecretName), makeGeneratedSecret("existing-secret"))
+	hc, other := addRepoClients(t, r)
+	hc.addHook(&git.Hook{ID: testWebhookID, URL: testHookEndpoint, Events: []string{"push"}, Active: true, Driver: scm.DriverGithub})
+	other.addHook(&git.Hook{ID: testOtherWebhookID, URL: testHookEndpoint, Events: []string{"push"}, Active: true, Driver: scm.DriverGithub})
+	rotatedData := map[string][]byte{
+		"token":          []byte(stubSecret),
+		"token.previous": []byte("existing-secret"),
+	}
+	hc.onUpdate = func() { assertSecretData(t, r.kubeClient, rotatedData) }
+	other.onUpdate = func() { assertSecretData(t, r.kubeClient, rotatedData) }
+
+	_, err := r.Reconcile(makeReconcileRequest())
+	if err != nil {
+		t.Fatal(err)
+	}
+	hc.assertHookUpdated(testWebhookID, testHookEndpoint, stubSecret)
+	other.assertHookUpdated(testOtherWebhookID, testHookEndpoint, stubSecret)
+	assertSecretData(t, r.kubeClient, rotatedData)
+}
+
+// When a WebhookSecret with repos is deleted, the webhooks in all the
+// repositories should be deleted.
+func TestWebhookSecretControllerDeletedWebhookSecretWithRepos(t *testing.T) {
+	logf.SetLogger(logf.ZapLogger(true))
+	ws := makeReposWebhookSecret(testRepoURL, testOtherRepoURL)
+	ws.Status.Webhooks = []v1alpha1.RepoWebhook{
+		{Repo: v1alpha1.Repo{URL: testRepoURL}, WebhookID: testWebhookID},
+		{Repo: v1alpha1.Repo{URL: testOtherRepoURL}, WebhookID: testOtherWebhookID},
+	}
+	ws.ObjectMeta.Finalizers = []string{webhookFinalizer}
+	now := metav1.NewTime(time.Now())
+	ws.ObjectMeta.DeletionTimestamp = &now
+	_, r := makeReconciler(t, ws, ws, makeTestSecret(testAuthSecretName))
+	hc, other := addRepoClients(t, r)
+	req := makeReconcileRequest()
+
+	_, err := r.Reconcile(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hc.assertHookDeleted(testWebhookID)
+	other.assertHookDeleted(testOtherWebhookID)
+	assertEvents(t, r.recorder,
+		"Normal WebhookDeleted Deleted webhook 1234567 in https://github.com/example/example.git",
+		"Normal WebhookDeleted Deleted webhook 7654321 in https://github.com/example/other.git")
+}
+
+// If a webhook was already deleted from the repository, no event is recorded
+// for it.
+func TestWebhookSecretControllerDeletedWebhookSecretWithReposAndMissingWebhook(t *testing.T) {
+	logf.SetLogger(logf.ZapLogger(true))
+	ws := makeReposWebhookSecret(testRepoURL, testOtherRepoURL)
+	ws.Status.Webhooks = []v1alpha1.RepoWebhook{
+		{Repo: v1alpha1.Repo{URL: testRepoURL}, WebhookID: testWebhookID},
+		{Repo: v1alpha1.Repo{URL: testOtherRepoURL}, WebhookID: testOtherWebhookID},
+	}
+	ws.ObjectMeta.Finalizers = []string{webhookFinalizer}
+	now := metav1.NewTime(time.Now())
+	ws.ObjectMeta.DeletionTimestamp = &now
+	_, r := makeReconciler(t, ws, ws, makeTestSecret(testAuthSecretName))
+	hc, other := addRepoClients(t, r)
+	other.deleteErr = git.SCMError{Status: http.StatusNotFound}
+	req := makeReconcileRequest()
+
+	_, err := r.Reconcile(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hc.assertHookDeleted(testWebhookID)
+	assertEvents(t, r.recorder,
+		"Normal WebhookDeleted Deleted webhook 1234567 in https://github.com/example/example.git")
+}
+
+func makeReposWebhookSecret(urls ...string) *v1alpha1.WebhookSecret {
+	ws := makeWebhookSecret(v1alpha1.HookRoute{HookURL: testHookEndpoint})
+	ws.Spec.Repo = v1alpha1.Repo{}
+	for _, u := range urls {
+		ws.Spec.Repos = append(ws.Spec.Repos, v1alpha1.Repo{URL: u})
+	}
+	return ws
+}
+
+// addRepoClients configures the reconciler with a separate stub client for
+// each of the test repositories.
+func addRepoClients(t *testing.T, r *ReconcileWebhookSecret) (*stubHookClient, *stubHookClient) {
+	f := r.gitClientFactory.(*stubClientFactory)
+	other := newStubHookClient(t, "example/other", testOtherWebhookID)
+	f.repoClients = map[string]*stubHookClient{
+		testRepoURL:      f.client,
+		testOtherRepoURL: other,
+	}
+	return f.client, other
+}
+
+func assertRepoWebhooks(t *testing.T, ws *v1alpha1.WebhookSecret, want map[string]string) {
+	t.Helper()
+	got := map[string]string{}
+	for _, wh := range ws.Status.Webhooks {
+		got[wh.Repo.URL] = wh.WebhookID
+	}
+	if len(got) != len(want) {
+		t.Fatalf("webhooks got %#v, want %#v", got, want)
+	}
+	for k, v := range want {
+		if got[k] != v {
+			t.Fatalf("webhooks got %#v, want %#v", got, want)
+		}
+	}
+}
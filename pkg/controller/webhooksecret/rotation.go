@@ -0,0 +1,173 @@
+package webhooksecret
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	v1alpha1 "github.com/bigkevmcd/webhook-secret-operator/pkg/apis/apps/v1alpha1"
+	"github.com/bigkevmcd/webhook-secret-operator/pkg/git"
+)
+
+// rotationRequested returns true if the RotateAnnotation has changed since the
+// secret was last generated.
+func rotationRequested(ws *v1alpha1.WebhookSecret) bool {
+	v := ws.ObjectMeta.Annotations[v1alpha1.RotateAnnotation]
+	return v != "" && v != ws.Status.LastRotationRequest
+}
+
+// nextRotation returns the time that the secret is next due to be rotated, and
+// false if the secret isn't rotated on a schedule.
+//
+// If the secret has never been rotated, the creation time of the Secret is
+// used.
+func nextRotation(ws *v1alpha1.WebhookSecret, s *corev1.Secret) (time.Time, bool) {
+	if ws.Spec.Rotation == nil || ws.Spec.Rotation.Interval == nil || ws.Spec.Rotation.Interval.Duration <= 0 {
+		return time.Time{}, false
+	}
+	last := s.ObjectMeta.CreationTimestamp.Time
+	if ws.Status.LastRotationTime != nil {
+		last = ws.Status.LastRotationTime.Time
+	}
+	return last.Add(ws.Spec.Rotation.Interval.Duration), true
+}
+
+// minimumGracePeriod is the shortest time that the previous secret is kept
+// after rotation, so that receivers have time to pick up the new secret.
+const minimumGracePeriod = 5 * time.Minute
+
+// gracePeriod returns how long the previous secret is kept after rotation,
+// this is never shorter than the minimumGracePeriod.
+func gracePeriod(ws *v1alpha1.WebhookSecret) time.Duration {
+	if ws.Spec.Rotation == nil || ws.Spec.Rotation.GracePeriod == nil || ws.Spec.Rotation.GracePeriod.Duration < minimumGracePeriod {
+		return minimumGracePeriod
+	}
+	return ws.Spec.Rotation.GracePeriod.Duration
+}
+
+// previousExpiry returns the time that the previous secret should be removed
+// from the Secret, and false if the Secret has no previous secret.
+func previousExpiry(ws *v1alpha1.WebhookSecret, s *corev1.Secret) (time.Time, bool) {
+	if _, ok := s.Data[previousKey(ws)]; !ok {
+		return time.Time{}, false
+	}
+	last := s.ObjectMeta.CreationTimestamp.Time
+	if ws.Status.LastRotationTime != nil {
+		last = ws.Status.LastRotationTime.Time
+	}
+	return last.Add(gracePeriod(ws)), true
+}
+
+// previousExpired returns true if the previous secret should be removed now.
+func previousExpired(ws *v1alpha1.WebhookSecret, s *corev1.Secret, now time.Time) bool {
+	expiry, ok := previousExpiry(ws, s)
+	return ok && !now.Before(expiry)
+}
+
+// rotationDue returns true if the secret should be rotated now.
+func rotationDue(ws *v1alpha1.WebhookSecret, s *corev1.Secret, now time.Time) bool {
+	if rotationRequested(ws) {
+		return true
+	}
+	next, ok := nextRotation(ws, s)
+	return ok && !now.Before(next)
+}
+
+// requeueAfter returns the delay before the WebhookSecret should be reconciled
+// again, this is the hookResyncPeriod, unless the secret is due to be rotated,
+// or the previous secret is due to be removed before then.
+func requeueAfter(ws *v1alpha1.WebhookSecret, s *corev1.Secret, now time.Time) time.Duration {
+	after := hookResyncPeriod
+	for _, f := range []func(*v1alpha1.WebhookSecret, *corev1.Secret) (time.Time, bool){nextRotation, previousExpiry} {
+		next, ok := f(ws, s)
+		if !ok {
+			continue
+		}
+		if d := next.Sub(now); d < after {
+			after = d
+		}
+	}
+	if after < time.Second {
+		return time.Second
+	}
+	return after
+}
+
+// rotateSecret replaces the secret for the webhook with a newly generated one.
+//
+// The Secret is updated first, with the existing secret in the previous key,
+// so that receivers have the new secret before the git host is signing with
+// it. If the webhook can't be updated, the Secret is reverted to the existing
+// secret.
+func (r *ReconcileWebhookSecret) rotateSecret(ctx context.Context, logger logr.Logger, client git.HooksClient, ws *v1alpha1.WebhookSecret, s *corev1.Secret, hookURL string) (reconcile.Result, error) {
+	rotated, err := r.secretFactory.RotateSecret(ws, s)
+	if err != nil {
+		setCondition(ws, v1alpha1.SecretReadyCondition, metav1.ConditionFalse, reasonSecretFailed, err.Error())
+		return reconcile.Result{}, err
+	}
+
+	logger.Info("Rotating the secret", "id", ws.Status.WebhookID, "hookURL", hookURL)
+	if err := r.updateRotatedSecret(ctx, ws, rotated); err != nil {
+		return reconcile.Result{}, err
+	}
+	hookID, err := client.Update(ctx, ws.Status.WebhookID, hookURL, string(rotated.Data[secretKey(ws)]), ws.Spec.Events)
+	if err != nil {
+		r.revertRotatedSecret(ctx, logger, ws, s, rotated)
+		return reconcile.Result{}, r.webhookFailed(ws, err)
+	}
+
+	now := metav1.Now()
+	ws.Status.LastRotationTime = &now
+	ws.Status.LastRotationRequest = ws.ObjectMeta.Annotations[v1alpha1.RotateAnnotation]
+	logger.Info("Secret rotated", "id", hookID, "hookURL", hookURL)
+	r.recorder.Eventf(ws, corev1.EventTypeNormal, reasonSecretRotated, "Rotated the secret for webhook %s", hookID)
+	setCondition(ws, v1alpha1.SecretReadyCondition, metav1.ConditionTrue, reasonSecretRotated, "")
+	setCondition(ws, v1alpha1.WebhookReadyCondition, metav1.ConditionTrue, reasonWebhookUpdated, "")
+	if err := r.updateWebhookStatus(ctx, ws, hookID, hookURL); err != nil {
+		return reconcile.Result{}, err
+	}
+	return reconcile.Result{RequeueAfter: requeueAfter(ws, rotated, now.Time)}, nil
+}
+
+// updateRotatedSecret writes the rotated secret to the Secret, recording any
+// failure in the SecretReady condition and as an event.
+func (r *ReconcileWebhookSecret) updateRotatedSecret(ctx context.Context, ws *v1alpha1.WebhookSecret, rotated *corev1.Secret) error {
+	if err := r.kubeClient.Update(ctx, rotated); err != nil {
+		setCondition(ws, v1alpha1.SecretReadyCondition, metav1.ConditionFalse, reasonSecretFailed, err.Error())
+		r.recorder.Eventf(ws, corev1.EventTypeWarning, reasonSecretFailed, "Failed to update Secret %s: %s", rotated.Name, err)
+		return err
+	}
+	return nil
+}
+
+// revertRotatedSecret restores the data from before the rotation to a Secret
+// that was updated before the webhooks, because the webhooks couldn't be
+// updated.
+func (r *ReconcileWebhookSecret) revertRotatedSecret(ctx context.Context, logger logr.Logger, ws *v1alpha1.WebhookSecret, s, rotated *corev1.Secret) {
+	logger.Info("Reverting the Secret", "Secret.Namespace", s.Namespace, "Secret.Name", s.Name)
+	reverted := rotated.DeepCopy()
+	reverted.Data = s.DeepCopy().Data
+	if err := r.kubeClient.Update(ctx, reverted); err != nil {
+		logger.Error(err, "failed to revert the Secret", "Secret.Namespace", s.Namespace, "Secret.Name", s.Name)
+		r.recorder.Eventf(ws, corev1.EventTypeWarning, reasonSecretFailed, "Failed to revert Secret %s: %s", s.Name, err)
+	}
+}
+
+// prunePreviousSecret removes the previous secret from the Secret, returning
+// the updated Secret.
+func (r *ReconcileWebhookSecret) prunePreviousSecret(ctx context.Context, logger logr.Logger, ws *v1alpha1.WebhookSecret, s *corev1.Secret) (*corev1.Secret, error) {
+	pruned := s.DeepCopy()
+	delete(pruned.Data, previousKey(ws))
+	if err := r.kubeClient.Update(ctx, pruned); err != nil {
+		setCondition(ws, v1alpha1.SecretReadyCondition, metav1.ConditionFalse, reasonSecretFailed, err.Error())
+		r.recorder.Eventf(ws, corev1.EventTypeWarning, reasonSecretFailed, "Failed to update Secret %s: %s", s.Name, err)
+		return nil, err
+	}
+	logger.Info("Previous secret removed", "Secret.Namespace", s.Namespace, "Secret.Name", s.Name)
+	r.recorder.Eventf(ws, corev1.EventTypeNormal, reasonSecretPruned, "Removed the previous secret from Secret %s", s.Name)
+	return pruned, nil
+}
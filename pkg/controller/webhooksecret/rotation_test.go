@@ -0,0 +1,145 @@
+package webhooksecret
+
+import (
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	v1alpha1 "github.com/bigkevmcd/webhook-secret-operator/pkg/apis/apps/v1alpha1"
+)
+
+func TestRotationDue(t *testing.T) {
+	now := time.Date(2020, time.July, 1, 12, 0, 0, 0, time.UTC)
+	created := metav1.NewTime(now.Add(-48 * time.Hour))
+	rotated := metav1.NewTime(now.Add(-2 * time.Hour))
+
+	tests := []struct {
+		name     string
+		rotation *v1alpha1.RotationSpec
+		status   v1alpha1.WebhookSecretStatus
+		annotate string
+		want     bool
+	}{
+		{"no rotation", nil, v1alpha1.WebhookSecretStatus{}, "", false},
+		{"no interval", &v1alpha1.RotationSpec{}, v1alpha1.WebhookSecretStatus{}, "", false},
+		{"due since creation", makeRotation(24 * time.Hour), v1alpha1.WebhookSecretStatus{}, "", true},
+		{"not due since last rotation", makeRotation(24 * time.Hour), v1alpha1.WebhookSecretStatus{LastRotationTime: &rotated}, "", false},
+		{"due since last rotation", makeRotation(time.Hour), v1alpha1.WebhookSecretStatus{LastRotationTime: &rotated}, "", true},
+		{"requested", nil, v1alpha1.WebhookSecretStatus{}, "1", true},
+		{"already requested", nil, v1alpha1.WebhookSecretStatus{LastRotationRequest: "1"}, "1", false},
+		{"requested again", nil, v1alpha1.WebhookSecretStatus{LastRotationRequest: "1"}, "2", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(rt *testing.T) {
+			ws := makeWebhookSecret(v1alpha1.HookRoute{HookURL: testHookEndpoint})
+			ws.Spec.Rotation = tt.rotation
+			ws.Status = tt.status
+			if tt.annotate != "" {
+				ws.ObjectMeta.Annotations = map[string]string{v1alpha1.RotateAnnotation: tt.annotate}
+			}
+			s := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{CreationTimestamp: created}}
+
+			if got := rotationDue(ws, s, now); got != tt.want {
+				rt.Errorf("rotationDue() got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRequeueAfter(t *testing.T) {
+	now := time.Date(2020, time.July, 1, 12, 0, 0, 0, time.UTC)
+	rotated := metav1.NewTime(now.Add(-2 * time.Hour))
+
+	tests := []struct {
+		name     string
+		rotation *v1alpha1.RotationSpec
+		want     time.Duration
+	}{
+		{"no rotation", nil, hookResyncPeriod},
+		{"rotation after resync", makeRotation(24 * time.Hour), hookResyncPeriod},
+		{"rotation before resync", makeRotation(2*time.Hour + 5*time.Minute), 5 * time.Minute},
+		{"rotation overdue", makeRotation(time.Hour), time.Second},
+		{"previous expires before resync", makeGracePeriod(2*time.Hour + 10*time.Minute), 10 * time.Minute},
+		{"previous expires after resync", makeGracePeriod(24 * time.Hour), hookResyncPeriod},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(rt *testing.T) {
+			ws := makeWebhookSecret(v1alpha1.HookRoute{HookURL: testHookEndpoint})
+			ws.Spec.Rotation = tt.rotation
+			ws.Status.LastRotationTime = &rotated
+			s := &corev1.Secret{}
+			if tt.rotation != nil && tt.rotation.GracePeriod != nil {
+				s.Data = map[string][]byte{"token.previous": []byte("old-secret")}
+			}
+
+			if got := requeueAfter(ws, s, now); got != tt.want {
+				rt.Errorf("requeueAfter() got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGracePeriod(t *testing.T) {
+	tests := []struct {
+		name     string
+		rotation *v1alpha1.RotationSpec
+		want     time.Duration
+	}{
+		{"no rotation", nil, minimumGracePeriod},
+		{"no grace period", makeRotation(24 * time.Hour), minimumGracePeriod},
+		{"short grace period", makeGracePeriod(time.Minute), minimumGracePeriod},
+		{"grace period", makeGracePeriod(time.Hour), time.Hour},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(rt *testing.T) {
+			ws := makeWebhookSecret(v1alpha1.HookRoute{HookURL: testHookEndpoint})
+			ws.Spec.Rotation = tt.rotation
+
+			if got := gracePeriod(ws); got != tt.want {
+				rt.Errorf("gracePeriod() got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPreviousExpired(t *testing.T) {
+	now := time.Date(2020, time.July, 1, 12, 0, 0, 0, time.UTC)
+	rotated := metav1.NewTime(now.Add(-2 * time.Hour))
+
+	tests := []struct {
+		name     string
+		rotation *v1alpha1.RotationSpec
+		data     map[string][]byte
+		want     bool
+	}{
+		{"no previous secret", makeGracePeriod(time.Hour), map[string][]byte{"token": []byte("secret")}, false},
+		{"within grace period", makeGracePeriod(3 * time.Hour), map[string][]byte{"token.previous": []byte("secret")}, false},
+		{"after grace period", makeGracePeriod(time.Hour), map[string][]byte{"token.previous": []byte("secret")}, true},
+		{"no grace period", nil, map[string][]byte{"token.previous": []byte("secret")}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(rt *testing.T) {
+			ws := makeWebhookSecret(v1alpha1.HookRoute{HookURL: testHookEndpoint})
+			ws.Spec.Rotation = tt.rotation
+			ws.Status.LastRotationTime = &rotated
+
+			if got := previousExpired(ws, &corev1.Secret{Data: tt.data}, now); got != tt.want {
+				rt.Errorf("previousExpired() got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func makeGracePeriod(d time.Duration) *v1alpha1.RotationSpec {
+	return &v1alpha1.RotationSpec{GracePeriod: &metav1.Duration{Duration: d}}
+}
+
+func makeRotation(d time.Duration) *v1alpha1.RotationSpec {
+	return &v1alpha1.RotationSpec{Interval: &metav1.Duration{Duration: d}}
+}
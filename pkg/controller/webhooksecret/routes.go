@@ -0,0 +1,43 @@
+package webhooksecret
+
+import (
+	"reflect"
+
+	routev1 "github.com/openshift/api/route/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+
+	v1alpha1 "github.com/bigkevmcd/webhook-secret-operator/pkg/apis/apps/v1alpha1"
+)
+
+// routeRefIndexKey is the field index for WebhookSecrets by the Route that
+// they reference.
+const routeRefIndexKey = "spec.webhookURL.routeRef"
+
+// indexRouteRef is a client.IndexerFunc that returns the namespaced name of the
+// Route referenced by a WebhookSecret.
+func indexRouteRef(o runtime.Object) []string {
+	ws, ok := o.(*v1alpha1.WebhookSecret)
+	if !ok || ws.Spec.WebhookURL.RouteRef == nil {
+		return nil
+	}
+	return []string{ws.Spec.WebhookURL.RouteRef.NamespacedName().String()}
+}
+
+// routeURLChanged is a predicate that ignores updates to Routes that don't
+// change the URL calculated for the Route.
+var routeURLChanged = predicate.Funcs{
+	UpdateFunc: func(e event.UpdateEvent) bool {
+		oldRoute, ok := e.ObjectOld.(*routev1.Route)
+		if !ok {
+			return true
+		}
+		newRoute, ok := e.ObjectNew.(*routev1.Route)
+		if !ok {
+			return true
+		}
+		return oldRoute.Spec.Host != newRoute.Spec.Host ||
+			!reflect.DeepEqual(oldRoute.Spec.TLS, newRoute.Spec.TLS)
+	},
+}
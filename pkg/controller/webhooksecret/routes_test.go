@@ -0,0 +1,84 @@
+package webhooksecret
+
+import (
+	"reflect"
+	"testing"
+
+	routev1 "github.com/openshift/api/route/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	v1alpha1 "github.com/bigkevmcd/webhook-secret-operator/pkg/apis/apps/v1alpha1"
+	"github.com/bigkevmcd/webhook-secret-operator/test"
+)
+
+var testRouteID = types.NamespacedName{Name: "my-test-route", Namespace: "route-test"}
+
+func TestIndexRouteRef(t *testing.T) {
+	indexTests := []struct {
+		name string
+		ws   *v1alpha1.WebhookSecret
+		want []string
+	}{
+		{"hook URL", makeWebhookSecret(v1alpha1.HookRoute{HookURL: testHookEndpoint}), nil},
+		{"route ref", makeWebhookSecret(v1alpha1.HookRoute{RouteRef: &v1alpha1.RouteReference{Name: "my-test-route", Namespace: "route-test"}}), []string{"route-test/my-test-route"}},
+	}
+
+	for _, tt := range indexTests {
+		t.Run(tt.name, func(rt *testing.T) {
+			if got := indexRouteRef(tt.ws); !reflect.DeepEqual(got, tt.want) {
+				rt.Errorf("got %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRouteMapper(t *testing.T) {
+	referencing := makeWebhookSecret(v1alpha1.HookRoute{
+		RouteRef: &v1alpha1.RouteReference{Name: "my-test-route", Namespace: "route-test"},
+	})
+	other := makeWebhookSecret(v1alpha1.HookRoute{
+		RouteRef: &v1alpha1.RouteReference{Name: "other-route", Namespace: "route-test"},
+	})
+	other.Name = "other-webhook-secret"
+	static := makeWebhookSecret(v1alpha1.HookRoute{HookURL: testHookEndpoint})
+	static.Name = "static-webhook-secret"
+	cl, _ := makeReconciler(t, referencing, referencing, other, static)
+	route := test.MakeRoute(testRouteID)
+
+	m := &refMapper{kubeClient: cl, indexKey: routeRefIndexKey, index: indexRouteRef}
+	requests := m.Map(handler.MapObject{Meta: route, Object: route})
+
+	want := []reconcile.Request{makeReconcileRequest()}
+	if !reflect.DeepEqual(requests, want) {
+		t.Fatalf("got %#v, want %#v", requests, want)
+	}
+}
+
+func TestRouteURLChanged(t *testing.T) {
+	changeTests := []struct {
+		name     string
+		newRoute *routev1.Route
+		want     bool
+	}{
+		{"no change", test.MakeRoute(testRouteID), false},
+		{"host changed", test.MakeRoute(testRouteID, test.Host("new.example.com")), true},
+		{"tls removed", test.MakeRoute(testRouteID, func(r *routev1.Route) { r.Spec.TLS = nil }), true},
+		{"labels changed", test.MakeRoute(testRouteID, func(r *routev1.Route) { r.ObjectMeta.Labels = map[string]string{"test": "value"} }), false},
+	}
+
+	for _, tt := range changeTests {
+		t.Run(tt.name, func(rt *testing.T) {
+			oldRoute := test.MakeRoute(testRouteID)
+			got := routeURLChanged.Update(event.UpdateEvent{
+				MetaOld: oldRoute, ObjectOld: oldRoute,
+				MetaNew: tt.newRoute, ObjectNew: tt.newRoute,
+			})
+			if got != tt.want {
+				rt.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
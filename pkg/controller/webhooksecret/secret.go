@@ -23,10 +23,6 @@ func (s *secretFactory) CreateSecret(cr *v1alpha1.WebhookSecret) (*corev1.Secret
 	if err != nil {
 		return nil, err
 	}
-	key := "token"
-	if cr.Spec.Key != "" {
-		key = cr.Spec.Key
-	}
 	return &corev1.Secret{
 		TypeMeta: metav1.TypeMeta{
 			APIVersion: "v1",
@@ -37,11 +33,46 @@ func (s *secretFactory) CreateSecret(cr *v1alpha1.WebhookSecret) (*corev1.Secret
 			Namespace: cr.ObjectMeta.Namespace,
 		},
 		Data: map[string][]byte{
-			key: []byte(token),
+			secretKey(cr): []byte(token),
 		},
 	}, nil
 }
 
+// RotateSecret returns a copy of the existing Secret with a newly generated
+// token, the existing token is kept in the previous key.
+func (s *secretFactory) RotateSecret(cr *v1alpha1.WebhookSecret, existing *corev1.Secret) (*corev1.Secret, error) {
+	token, err := s.stringGenerator()
+	if err != nil {
+		return nil, err
+	}
+	rotated := existing.DeepCopy()
+	if rotated.Data == nil {
+		rotated.Data = map[string][]byte{}
+	}
+	if len(existing.Data[secretKey(cr)]) > 0 {
+		rotated.Data[previousKey(cr)] = existing.Data[secretKey(cr)]
+	} else {
+		delete(rotated.Data, previousKey(cr))
+	}
+	rotated.Data[secretKey(cr)] = []byte(token)
+	return rotated, nil
+}
+
+// secretKey returns the key within the generated Secret that the token is
+// stored in.
+func secretKey(cr *v1alpha1.WebhookSecret) string {
+	if cr.Spec.Key != "" {
+		return cr.Spec.Key
+	}
+	return "token"
+}
+
+// previousKey returns the key within the generated Secret that the previous
+// token is kept in after the secret is rotated.
+func previousKey(cr *v1alpha1.WebhookSecret) string {
+	return secretKey(cr) + ".previous"
+}
+
 const charset = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789$:#!^&"
 
 func generateSecureString() (string, error) {
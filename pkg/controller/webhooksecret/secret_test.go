@@ -2,6 +2,7 @@ package webhooksecret
 
 import (
 	"testing"
+	"time"
 
 	v1alpha1 "github.com/bigkevmcd/webhook-secret-operator/pkg/apis/apps/v1alpha1"
 	"github.com/google/go-cmp/cmp"
@@ -45,3 +46,84 @@ func TestCreateSecret(t *testing.T) {
 		t.Fatalf("incorrect secret generated:\n%s", diff)
 	}
 }
+
+func TestRotateSecret(t *testing.T) {
+	ws := &v1alpha1.WebhookSecret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "my-test-webhook-secret",
+			Namespace: "test-ns",
+		},
+	}
+	existing := &corev1.Secret{
+		TypeMeta: secretTypeMeta,
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            "my-test-webhook-secret",
+			Namespace:       "test-ns",
+			ResourceVersion: "1",
+		},
+		Data: map[string][]byte{
+			"token": []byte("secret"),
+		},
+	}
+	want := existing.DeepCopy()
+	want.Data["token"] = []byte("new-secret")
+	want.Data["token.previous"] = []byte("secret")
+	sf := secretFactory{
+		stringGenerator: func() (string, error) {
+			return "new-secret", nil
+		},
+	}
+
+	secret, err := sf.RotateSecret(ws, existing)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(want, secret); diff != "" {
+		t.Fatalf("incorrect secret generated:\n%s", diff)
+	}
+	if v := string(existing.Data["token"]); v != "secret" {
+		t.Fatalf("existing secret was modified, got %#v", v)
+	}
+}
+
+func TestRotateSecretWithGracePeriod(t *testing.T) {
+	ws := &v1alpha1.WebhookSecret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "my-test-webhook-secret",
+			Namespace: "test-ns",
+		},
+		Spec: v1alpha1.WebhookSecretSpec{
+			Key: "custom",
+			Rotation: &v1alpha1.RotationSpec{
+				GracePeriod: &metav1.Duration{Duration: time.Hour},
+			},
+		},
+	}
+	existing := &corev1.Secret{
+		TypeMeta: secretTypeMeta,
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "my-test-webhook-secret",
+			Namespace: "test-ns",
+		},
+		Data: map[string][]byte{
+			"custom":          []byte("secret"),
+			"custom.previous": []byte("older-secret"),
+		},
+	}
+	want := existing.DeepCopy()
+	want.Data["custom"] = []byte("new-secret")
+	want.Data["custom.previous"] = []byte("secret")
+	sf := secretFactory{
+		stringGenerator: func() (string, error) {
+			return "new-secret", nil
+		},
+	}
+
+	secret, err := sf.RotateSecret(ws, existing)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(want, secret); diff != "" {
+		t.Fatalf("incorrect secret generated:\n%s", diff)
+	}
+}
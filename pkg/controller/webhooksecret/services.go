@@ -0,0 +1,44 @@
+package webhooksecret
+
+import (
+	"reflect"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+
+	v1alpha1 "github.com/bigkevmcd/webhook-secret-operator/pkg/apis/apps/v1alpha1"
+)
+
+// serviceRefIndexKey is the field index for WebhookSecrets by the Service
+// that they reference.
+const serviceRefIndexKey = "spec.webhookURL.serviceRef"
+
+// indexServiceRef is a client.IndexerFunc that returns the namespaced name of
+// the Service referenced by a WebhookSecret.
+func indexServiceRef(o runtime.Object) []string {
+	ws, ok := o.(*v1alpha1.WebhookSecret)
+	if !ok || ws.Spec.WebhookURL.ServiceRef == nil {
+		return nil
+	}
+	return []string{ws.Spec.WebhookURL.ServiceRef.NamespacedName().String()}
+}
+
+// serviceURLChanged is a predicate that ignores updates to Services that don't
+// change the type, ports or load balancer, which the URL is calculated from.
+var serviceURLChanged = predicate.Funcs{
+	UpdateFunc: func(e event.UpdateEvent) bool {
+		oldService, ok := e.ObjectOld.(*corev1.Service)
+		if !ok {
+			return true
+		}
+		newService, ok := e.ObjectNew.(*corev1.Service)
+		if !ok {
+			return true
+		}
+		return oldService.Spec.Type != newService.Spec.Type ||
+			!reflect.DeepEqual(oldService.Spec.Ports, newService.Spec.Ports) ||
+			!reflect.DeepEqual(oldService.Status.LoadBalancer, newService.Status.LoadBalancer)
+	},
+}
@@ -0,0 +1,185 @@
+package webhooksecret
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	logf "sigs.k8s.io/controller-runtime/pkg/runtime/log"
+
+	v1alpha1 "github.com/bigkevmcd/webhook-secret-operator/pkg/apis/apps/v1alpha1"
+	"github.com/bigkevmcd/webhook-secret-operator/pkg/routes"
+	"github.com/bigkevmcd/webhook-secret-operator/test"
+)
+
+var testServiceID = types.NamespacedName{Name: "my-test-service", Namespace: "service-test"}
+
+func TestIndexServiceRef(t *testing.T) {
+	indexTests := []struct {
+		name string
+		ws   *v1alpha1.WebhookSecret
+		want []string
+	}{
+		{"hook URL", makeWebhookSecret(v1alpha1.HookRoute{HookURL: testHookEndpoint}), nil},
+		{"service ref", makeWebhookSecret(v1alpha1.HookRoute{ServiceRef: &v1alpha1.ServiceReference{Name: "my-test-service", Namespace: "service-test"}}), []string{"service-test/my-test-service"}},
+	}
+
+	for _, tt := range indexTests {
+		t.Run(tt.name, func(rt *testing.T) {
+			if got := indexServiceRef(tt.ws); !reflect.DeepEqual(got, tt.want) {
+				rt.Errorf("got %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestServiceMapper(t *testing.T) {
+	referencing := makeWebhookSecret(v1alpha1.HookRoute{
+		ServiceRef: &v1alpha1.ServiceReference{Name: "my-test-service", Namespace: "service-test"},
+	})
+	other := makeWebhookSecret(v1alpha1.HookRoute{
+		ServiceRef: &v1alpha1.ServiceReference{Name: "other-service", Namespace: "service-test"},
+	})
+	other.Name = "other-webhook-secret"
+	cl, _ := makeReconciler(t, referencing, referencing, other)
+	svc := test.MakeService(testServiceID)
+
+	m := &refMapper{kubeClient: cl, indexKey: serviceRefIndexKey, index: indexServiceRef}
+	requests := m.Map(handler.MapObject{Meta: svc, Object: svc})
+
+	want := []reconcile.Request{makeReconcileRequest()}
+	if !reflect.DeepEqual(requests, want) {
+		t.Fatalf("got %#v, want %#v", requests, want)
+	}
+}
+
+func TestServiceURLChanged(t *testing.T) {
+	changeTests := []struct {
+		name       string
+		newService *corev1.Service
+		want       bool
+	}{
+		{"no change", test.MakeService(testServiceID, test.ServicePort("", 80, nil)), false},
+		{"load balancer provisioned", test.MakeService(testServiceID, test.ServicePort("", 80, nil), test.LoadBalancerIP("192.0.2.10")), true},
+		{"port changed", test.MakeService(testServiceID, test.ServicePort("", 8080, nil)), true},
+		{"labels changed", test.MakeService(testServiceID, test.ServicePort("", 80, nil), func(s *corev1.Service) { s.ObjectMeta.Labels = map[string]string{"test": "value"} }), false},
+	}
+
+	for _, tt := range changeTests {
+		t.Run(tt.name, func(rt *testing.T) {
+			oldService := test.MakeService(testServiceID, test.ServicePort("", 80, nil))
+			got := serviceURLChanged.Update(event.UpdateEvent{
+				MetaOld: oldService, ObjectOld: oldService,
+				MetaNew: tt.newService, ObjectNew: tt.newService,
+			})
+			if got != tt.want {
+				rt.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// If using a LoadBalancer Service, then the URL for the webhook should be
+// calculated from the load balancer.
+func TestWebhookSecretControllerWithServiceRef(t *testing.T) {
+	logf.SetLogger(logf.ZapLogger(true))
+	ws := makeWebhookSecret(v1alpha1.HookRoute{
+		ServiceRef: &v1alpha1.ServiceReference{
+			Name:      "my-test-service",
+			Namespace: "service-test",
+			Path:      "/el-listener",
+		},
+	})
+	_, r := makeReconciler(
+		t, ws, ws, makeTestSecret(testAuthSecretName),
+		test.MakeService(testServiceID, test.ServicePort("", 8080, nil), test.LoadBalancerHostname("lb.example.com")))
+	req := makeReconcileRequest()
+
+	_, err := r.Reconcile(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ws = &v1alpha1.WebhookSecret{}
+	if err := r.kubeClient.Get(context.Background(), req.NamespacedName, ws); err != nil {
+		t.Fatal(err)
+	}
+	assertCondition(t, ws, v1alpha1.RouteResolvedCondition, metav1.ConditionTrue, reasonServiceResolved)
+	r.gitClientFactory.(*stubClientFactory).client.assertHookCreated("http://lb.example.com:8080/el-listener", stubSecret)
+}
+
+// If the Service is waiting for a load balancer, then the WebhookSecret should
+// be requeued, and the webhook created when the load balancer is provisioned.
+func TestWebhookSecretControllerWithServiceRefWaitingForLoadBalancer(t *testing.T) {
+	logf.SetLogger(logf.ZapLogger(true))
+	ws := makeWebhookSecret(v1alpha1.HookRoute{
+		ServiceRef: &v1alpha1.ServiceReference{Name: "my-test-service", Namespace: "service-test"},
+	})
+	svc := test.MakeService(testServiceID, test.ServicePort("", 80, nil))
+	_, r := makeReconciler(t, ws, ws, makeTestSecret(testAuthSecretName), svc)
+	req := makeReconcileRequest()
+
+	res, err := r.Reconcile(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.RequeueAfter != pendingRequeueDelay {
+		t.Fatalf("got RequeueAfter %v, want %v", res.RequeueAfter, pendingRequeueDelay)
+	}
+
+	ws = &v1alpha1.WebhookSecret{}
+	if err := r.kubeClient.Get(context.Background(), req.NamespacedName, ws); err != nil {
+		t.Fatal(err)
+	}
+	assertCondition(t, ws, v1alpha1.RouteResolvedCondition, metav1.ConditionFalse, reasonServicePending)
+	assertEvents(t, r.recorder, "Normal SecretCreated Created Secret test-webhook-secret")
+	r.gitClientFactory.(*stubClientFactory).client.assertNoHookCreated()
+
+	test.LoadBalancerIP("192.0.2.10")(svc)
+	if err := r.kubeClient.Update(context.Background(), svc); err != nil {
+		t.Fatal(err)
+	}
+	_, err = r.Reconcile(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ws = &v1alpha1.WebhookSecret{}
+	if err := r.kubeClient.Get(context.Background(), req.NamespacedName, ws); err != nil {
+		t.Fatal(err)
+	}
+	assertCondition(t, ws, v1alpha1.RouteResolvedCondition, metav1.ConditionTrue, reasonServiceResolved)
+	r.gitClientFactory.(*stubClientFactory).client.assertHookCreated("http://192.0.2.10/", stubSecret)
+}
+
+// If the Service doesn't exist, then the WebhookSecret should reflect the
+// error.
+func TestWebhookSecretControllerWithServiceRefAndServiceMissing(t *testing.T) {
+	logf.SetLogger(logf.ZapLogger(true))
+	ws := makeWebhookSecret(v1alpha1.HookRoute{
+		ServiceRef: &v1alpha1.ServiceReference{Name: "my-test-service", Namespace: "service-test"},
+	})
+	_, r := makeReconciler(t, ws, ws, makeTestSecret(testAuthSecretName))
+	req := makeReconcileRequest()
+
+	_, err := r.Reconcile(req)
+	if !routes.IsNotFound(err) {
+		t.Fatalf("expected a not found error, got %v", err)
+	}
+
+	ws = &v1alpha1.WebhookSecret{}
+	if err := r.kubeClient.Get(context.Background(), req.NamespacedName, ws); err != nil {
+		t.Fatal(err)
+	}
+	assertCondition(t, ws, v1alpha1.RouteResolvedCondition, metav1.ConditionFalse, reasonServiceNotFound)
+	assertEvents(t, r.recorder,
+		"Normal SecretCreated Created Secret test-webhook-secret",
+		"Warning ServiceNotFound Service service-test/my-test-service was not found")
+	r.gitClientFactory.(*stubClientFactory).client.assertNoHookCreated()
+}
@@ -0,0 +1,80 @@
+package webhooksecret
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"text/template"
+
+	v1alpha1 "github.com/bigkevmcd/webhook-secret-operator/pkg/apis/apps/v1alpha1"
+	"github.com/bigkevmcd/webhook-secret-operator/pkg/git"
+)
+
+// urlTemplateData is the data that the urlTemplate of a WebhookSecret is
+// executed with.
+type urlTemplateData struct {
+	// URL is the URL calculated from the other fields of the HookRoute, it's
+	// empty if there are none.
+	URL         string
+	Repo        git.Repository
+	Name        string
+	Namespace   string
+	Labels      map[string]string
+	Annotations map[string]string
+}
+
+// expandURLTemplate executes the urlTemplate of the WebhookSecret for the
+// repo, with the URL calculated from the other fields of the HookRoute.
+func (r *ReconcileWebhookSecret) expandURLTemplate(ws *v1alpha1.WebhookSecret, repo v1alpha1.Repo, baseURL string) (string, error) {
+	driver := repo.Driver
+	if driver == "" {
+		// Unknown drivers are parsed as owner/name.
+		driver, _ = git.NewDriverIdentifier(r.providerLoader.Registry()).Identify(repo.URL)
+	}
+	parse := git.ParseRepository
+	if repo.Scope != "" && repo.Scope != v1alpha1.RepositoryScope {
+		parse = git.ParseOwner
+	}
+	parsed, err := parse(driver, repo.URL)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse the repository for the urlTemplate: %w", err)
+	}
+	return expandURLTemplate(ws.Spec.WebhookURL.URLTemplate, urlTemplateData{
+		URL:         baseURL,
+		Repo:        parsed,
+		Name:        ws.Name,
+		Namespace:   ws.Namespace,
+		Labels:      ws.Labels,
+		Annotations: ws.Annotations,
+	})
+}
+
+// expandURLTemplate executes the template with the data, if the result is a
+// relative URL, it's resolved against the URL in the data.
+//
+// The result must be an absolute http or https URL.
+func expandURLTemplate(tmpl string, data urlTemplateData) (string, error) {
+	t, err := template.New("urlTemplate").Option("missingkey=error").Parse(tmpl)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse the urlTemplate: %w", err)
+	}
+	var b strings.Builder
+	if err := t.Execute(&b, data); err != nil {
+		return "", fmt.Errorf("failed to execute the urlTemplate: %w", err)
+	}
+	expanded, err := url.Parse(strings.TrimSpace(b.String()))
+	if err != nil {
+		return "", fmt.Errorf("the urlTemplate produced an invalid URL: %w", err)
+	}
+	if data.URL != "" {
+		base, err := url.Parse(data.URL)
+		if err != nil {
+			return "", fmt.Errorf("failed to parse the hook URL %q: %w", data.URL, err)
+		}
+		expanded = base.ResolveReference(expanded)
+	}
+	if (expanded.Scheme != "http" && expanded.Scheme != "https") || expanded.Host == "" {
+		return "", fmt.Errorf("the urlTemplate produced %q, which is not an absolute http or https URL", expanded)
+	}
+	return expanded.String(), nil
+}
@@ -0,0 +1,145 @@
+package webhooksecret
+
+import (
+	"context"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	logf "sigs.k8s.io/controller-runtime/pkg/runtime/log"
+
+	v1alpha1 "github.com/bigkevmcd/webhook-secret-operator/pkg/apis/apps/v1alpha1"
+	"github.com/bigkevmcd/webhook-secret-operator/pkg/git"
+	"github.com/bigkevmcd/webhook-secret-operator/test"
+)
+
+func TestExpandURLTemplate(t *testing.T) {
+	data := urlTemplateData{
+		URL:         "https://hooks.example.com/el-listener",
+		Repo:        git.Repository{Host: "github.com", Owner: "my-org", Name: "my-repo"},
+		Name:        "my-webhook-secret",
+		Namespace:   "my-ns",
+		Labels:      map[string]string{"team": "my-team"},
+		Annotations: map[string]string{},
+	}
+	templateTests := []struct {
+		name    string
+		tmpl    string
+		want    string
+		wantErr string
+	}{
+		{"absolute path", "/hooks/{{ .Repo.Owner }}/{{ .Repo.Name }}?ns={{ .Namespace }}", "https://hooks.example.com/hooks/my-org/my-repo?ns=my-ns", ""},
+		{"relative path", "hooks/{{ .Repo.Name }}", "https://hooks.example.com/hooks/my-repo", ""},
+		{"query only", "?name={{ .Name }}", "https://hooks.example.com/el-listener?name=my-webhook-secret", ""},
+		{"using the URL", "{{ .URL }}/{{ .Labels.team }}", "https://hooks.example.com/el-listener/my-team", ""},
+		{"absolute URL", "http://other.example.com/{{ .Repo.Host }}", "http://other.example.com/github.com", ""},
+		{"missing key", "/{{ .Annotations.missing }}", "", "failed to execute the urlTemplate"},
+		{"unknown field", "/{{ .Unknown }}", "", "failed to execute the urlTemplate"},
+		{"invalid template", "/{{ .Repo.Name ", "", "failed to parse the urlTemplate"},
+		{"not http", "ftp://example.com/", "", `the urlTemplate produced "ftp://example.com/", which is not an absolute http or https URL`},
+	}
+
+	for _, tt := range templateTests {
+		t.Run(tt.name, func(rt *testing.T) {
+			got, err := expandURLTemplate(tt.tmpl, data)
+			if !test.MatchError(rt, tt.wantErr, err) {
+				rt.Fatalf("error failed to match, got %#v, want %s", err, tt.wantErr)
+			}
+			if got != tt.want {
+				rt.Fatalf("got %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExpandURLTemplateWithoutURL(t *testing.T) {
+	data := urlTemplateData{Repo: git.Repository{Owner: "my-org", Name: "my-repo"}}
+
+	_, err := expandURLTemplate("/hooks/{{ .Repo.Name }}", data)
+	if !test.MatchError(t, "not an absolute http or https URL", err) {
+		t.Fatalf("got incorrect error %v", err)
+	}
+
+	got, err := expandURLTemplate("https://hooks.example.com/{{ .Repo.Owner }}/{{ .Repo.Name }}", data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != "https://hooks.example.com/my-org/my-repo" {
+		t.Fatalf("got %s, want https://hooks.example.com/my-org/my-repo", got)
+	}
+}
+
+// If the HookRoute has a urlTemplate, then it should be expanded with the
+// calculated URL to get the URL for the webhook.
+func TestWebhookSecretControllerWithURLTemplate(t *testing.T) {
+	logf.SetLogger(logf.ZapLogger(true))
+	ws := makeWebhookSecret(v1alpha1.HookRoute{
+		HookURL:     testHookEndpoint,
+		URLTemplate: "/hooks/{{ .Repo.Owner }}/{{ .Repo.Name }}?ns={{ .Namespace }}",
+	})
+	_, r := makeReconciler(t, ws, ws, makeTestSecret(testAuthSecretName))
+	req := makeReconcileRequest()
+
+	_, err := r.Reconcile(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ws = &v1alpha1.WebhookSecret{}
+	if err := r.kubeClient.Get(context.Background(), req.NamespacedName, ws); err != nil {
+		t.Fatal(err)
+	}
+	want := "https://example.com/hooks/example/example?ns=test-webhook-ns"
+	if ws.Status.HookURL != want {
+		t.Fatalf("got hook URL %s, want %s", ws.Status.HookURL, want)
+	}
+	assertCondition(t, ws, v1alpha1.RouteResolvedCondition, metav1.ConditionTrue, reasonHookURL)
+	r.gitClientFactory.(*stubClientFactory).client.assertHookCreated(want, stubSecret)
+}
+
+// A urlTemplate can be used without any other way to calculate the URL.
+func TestWebhookSecretControllerWithOnlyURLTemplate(t *testing.T) {
+	logf.SetLogger(logf.ZapLogger(true))
+	ws := makeWebhookSecret(v1alpha1.HookRoute{
+		URLTemplate: "https://hooks.example.com/{{ .Repo.Name }}",
+	})
+	_, r := makeReconciler(t, ws, ws, makeTestSecret(testAuthSecretName))
+	req := makeReconcileRequest()
+
+	_, err := r.Reconcile(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ws = &v1alpha1.WebhookSecret{}
+	if err := r.kubeClient.Get(context.Background(), req.NamespacedName, ws); err != nil {
+		t.Fatal(err)
+	}
+	assertCondition(t, ws, v1alpha1.RouteResolvedCondition, metav1.ConditionTrue, reasonURLTemplate)
+	r.gitClientFactory.(*stubClientFactory).client.assertHookCreated("https://hooks.example.com/example", stubSecret)
+}
+
+// If the urlTemplate doesn't produce a valid URL, then the WebhookSecret
+// should reflect the error.
+func TestWebhookSecretControllerWithInvalidURLTemplate(t *testing.T) {
+	logf.SetLogger(logf.ZapLogger(true))
+	ws := makeWebhookSecret(v1alpha1.HookRoute{
+		URLTemplate: "/hooks/{{ .Repo.Name }}",
+	})
+	_, r := makeReconciler(t, ws, ws, makeTestSecret(testAuthSecretName))
+	req := makeReconcileRequest()
+
+	_, err := r.Reconcile(req)
+	if !test.MatchError(t, "not an absolute http or https URL", err) {
+		t.Fatalf("got incorrect error %v", err)
+	}
+
+	ws = &v1alpha1.WebhookSecret{}
+	if err := r.kubeClient.Get(context.Background(), req.NamespacedName, ws); err != nil {
+		t.Fatal(err)
+	}
+	assertCondition(t, ws, v1alpha1.RouteResolvedCondition, metav1.ConditionFalse, reasonURLTemplateInvalid)
+	assertEvents(t, r.recorder,
+		"Normal SecretCreated Created Secret test-webhook-secret",
+		`Warning URLTemplateInvalid the urlTemplate produced "/hooks/example", which is not an absolute http or https URL`)
+	r.gitClientFactory.(*stubClientFactory).client.assertNoHookCreated()
+}
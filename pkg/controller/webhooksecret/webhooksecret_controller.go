@@ -3,12 +3,16 @@ package webhooksecret
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"github.com/go-logr/logr"
+	routev1 "github.com/openshift/api/route/v1"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
@@ -19,7 +23,9 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/source"
 
 	v1alpha1 "github.com/bigkevmcd/webhook-secret-operator/pkg/apis/apps/v1alpha1"
+	"github.com/bigkevmcd/webhook-secret-operator/pkg/controller/common"
 	"github.com/bigkevmcd/webhook-secret-operator/pkg/git"
+	"github.com/bigkevmcd/webhook-secret-operator/pkg/providers"
 	"github.com/bigkevmcd/webhook-secret-operator/pkg/routes"
 	"github.com/bigkevmcd/webhook-secret-operator/pkg/secrets"
 )
@@ -28,27 +34,46 @@ var log = logf.Log.WithName("controller_webhooksecret")
 
 const webhookFinalizer = "webhooksecrets.finalizer"
 
+// hookResyncPeriod is how often the webhook is checked against the git host,
+// to detect changes that were made outside of the operator.
+const hookResyncPeriod = 15 * time.Minute
+
+// pendingRequeueDelay is how long to wait before checking again for the URL of
+// an object that is waiting for one, e.g. a Service waiting for a load
+// balancer.
+const pendingRequeueDelay = 10 * time.Second
+
 // Add creates a new WebhookSecret Controller and adds it to the Manager. The Manager will set fields on the Controller
 // and Start it when the Manager is Started.
 func Add(mgr manager.Manager) error {
-	return add(mgr, newReconciler(mgr))
+	r := newReconciler(mgr)
+	return add(mgr, r, r.providerLoader.ConfigMap())
 }
 
 // newReconciler returns a new reconcile.Reconciler
-func newReconciler(mgr manager.Manager) reconcile.Reconciler {
-	cf := git.NewClientFactory(git.NewDriverIdentifier())
+func newReconciler(mgr manager.Manager) *ReconcileWebhookSecret {
+	registry := git.NewProviderRegistry()
+	cf := git.NewClientFactory(git.NewDriverIdentifier(registry), registry)
 	return &ReconcileWebhookSecret{
-		kubeClient:       mgr.GetClient(),
-		scheme:           mgr.GetScheme(),
-		secretFactory:    &secretFactory{stringGenerator: generateSecureString},
-		gitClientFactory: cf,
-		authSecretGetter: secrets.New(mgr.GetClient()),
-		routeGetter:      routes.New(mgr.GetClient()),
+		kubeClient:           mgr.GetClient(),
+		scheme:               mgr.GetScheme(),
+		secretFactory:        &secretFactory{stringGenerator: generateSecureString},
+		gitClientFactory:     cf,
+		authSecretGetter:     secrets.New(mgr.GetClient()),
+		routeGetter:          routes.New(mgr.GetClient()),
+		ingressGetter:        routes.NewIngressGetter(mgr.GetClient()),
+		httpRouteGetter:      routes.NewHTTPRouteGetter(mgr.GetClient()),
+		serviceGetter:        routes.NewServiceGetter(mgr.GetClient()),
+		knativeServiceGetter: routes.NewKnativeServiceGetter(mgr.GetClient()),
+		eventListenerGetter:  routes.NewEventListenerGetter(mgr.GetClient()),
+		recorder:             mgr.GetEventRecorderFor("webhooksecret-controller"),
+
+		providerLoader: providers.NewLoader(mgr.GetClient(), registry, providers.ConfigMapID()),
 	}
 }
 
 // add adds a new Controller to mgr with r as the reconcile.Reconciler
-func add(mgr manager.Manager, r reconcile.Reconciler) error {
+func add(mgr manager.Manager, r reconcile.Reconciler, providersConfigMap types.NamespacedName) error {
 	// Create a new controller
 	c, err := controller.New("webhooksecret-controller", mgr, controller.Options{Reconciler: r})
 	if err != nil {
@@ -67,6 +92,62 @@ func add(mgr manager.Manager, r reconcile.Reconciler) error {
 	if err != nil {
 		return err
 	}
+
+	err = watchRefs(mgr, c, &routev1.Route{}, routeRefIndexKey, indexRouteRef, routeURLChanged)
+	if err != nil {
+		return err
+	}
+
+	err = watchOptionalRefs(mgr, c, routes.IngressGVK, ingressRefIndexKey, indexIngressRef, ingressURLChanged)
+	if err != nil {
+		return err
+	}
+
+	err = watchOptionalRefs(mgr, c, routes.HTTPRouteGVK, httpRouteRefIndexKey, indexHTTPRouteRef, httpRouteURLChanged)
+	if err != nil {
+		return err
+	}
+
+	err = watchRefs(mgr, c, &corev1.Service{}, serviceRefIndexKey, indexServiceRef, serviceURLChanged)
+	if err != nil {
+		return err
+	}
+
+	err = watchOptionalRefs(mgr, c, routes.KnativeServiceGVK, knativeServiceRefIndexKey, indexKnativeServiceRef, knativeServiceURLChanged)
+	if err != nil {
+		return err
+	}
+
+	err = watchOptionalRefs(mgr, c, routes.EventListenerGVK, eventListenerRefIndexKey, indexEventListenerRef, eventListenerChanged)
+	if err != nil {
+		return err
+	}
+
+	err = watchEventListenerServices(mgr, c)
+	if err != nil {
+		return err
+	}
+
+	err = watchRefs(mgr, c, &corev1.Secret{}, authSecretRefIndexKey, indexAuthSecretRef, authSecretChanged)
+	if err != nil {
+		return err
+	}
+
+	err = c.Watch(&source.Kind{Type: &v1alpha1.AuthSecretGrant{}}, &handler.EnqueueRequestsFromMapFunc{
+		ToRequests: &grantMapper{kubeClient: mgr.GetClient()},
+	})
+	if err != nil {
+		return err
+	}
+
+	if providersConfigMap.Name != "" {
+		err = c.Watch(&source.Kind{Type: &corev1.ConfigMap{}}, &handler.EnqueueRequestsFromMapFunc{
+			ToRequests: &providersMapper{kubeClient: mgr.GetClient(), configMap: providersConfigMap},
+		})
+		if err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
@@ -77,8 +158,16 @@ type ReconcileWebhookSecret struct {
 	secretFactory    *secretFactory
 	gitClientFactory git.ClientFactory
 
-	authSecretGetter secrets.SecretGetter
-	routeGetter      routes.RouteGetter
+	authSecretGetter     secrets.SecretGetter
+	routeGetter          routes.RouteGetter
+	ingressGetter        routes.IngressGetter
+	httpRouteGetter      routes.HTTPRouteGetter
+	serviceGetter        routes.ServiceGetter
+	knativeServiceGetter routes.KnativeServiceGetter
+	eventListenerGetter  routes.EventListenerGetter
+	recorder             record.EventRecorder
+
+	providerLoader *providers.Loader
 }
 
 // Reconcile reads that state of the cluster for a WebhookSecret object and makes changes based on the state read
@@ -89,6 +178,10 @@ func (r *ReconcileWebhookSecret) Reconcile(request reconcile.Request) (reconcile
 
 	ctx := context.Background()
 
+	if err := r.providerLoader.Load(ctx); err != nil {
+		reqLogger.Error(err, "failed to load the providers ConfigMap, using the existing providers")
+	}
+
 	// Fetch the WebhookSecret instance
 	instance := &v1alpha1.WebhookSecret{}
 	err := r.kubeClient.Get(ctx, request.NamespacedName, instance)
@@ -108,7 +201,11 @@ func (r *ReconcileWebhookSecret) Reconcile(request reconcile.Request) (reconcile
 		}
 	} else {
 		if containsString(instance.ObjectMeta.Finalizers, webhookFinalizer) {
-			if err := r.deleteWebhook(ctx, reqLogger, instance); err != nil {
+			if len(instance.Spec.Repos) > 0 || len(instance.Status.Webhooks) > 0 {
+				if err := r.deleteRepoWebhooks(ctx, reqLogger, instance); err != nil {
+					reqLogger.Error(err, "failed to delete the webhooks")
+				}
+			} else if err := r.deleteWebhook(ctx, reqLogger, instance); err != nil {
 				reqLogger.Error(err, "failed to delete the webhook")
 			}
 			instance.ObjectMeta.Finalizers = removeString(instance.ObjectMeta.Finalizers, webhookFinalizer)
@@ -119,44 +216,110 @@ func (r *ReconcileWebhookSecret) Reconcile(request reconcile.Request) (reconcile
 		return reconcile.Result{}, nil
 	}
 
-	secret, err := r.secretFactory.CreateSecret(instance)
+	result, err := r.reconcileWebhookSecret(ctx, reqLogger, instance)
+	if err == nil {
+		err = r.configureInterceptor(ctx, instance)
+	}
+	if routes.IsPending(err) {
+		reqLogger.Info("Waiting for the hook URL", "reason", err.Error())
+		result, err = reconcile.Result{RequeueAfter: pendingRequeueDelay}, nil
+	}
+	if statusErr := r.updateStatus(ctx, instance); statusErr != nil {
+		reqLogger.Error(statusErr, "failed to update the WebhookSecret status")
+		if err == nil {
+			return reconcile.Result{}, fmt.Errorf("failed to update the WebhookSecret status: %s", statusErr)
+		}
+	}
+	return result, err
+}
+
+func (r *ReconcileWebhookSecret) reconcileWebhookSecret(ctx context.Context, logger logr.Logger, ws *v1alpha1.WebhookSecret) (reconcile.Result, error) {
+	if err := validateRepos(ws); err != nil {
+		setCondition(ws, v1alpha1.WebhookReadyCondition, metav1.ConditionFalse, reasonReposInvalid, err.Error())
+		r.recorder.Event(ws, corev1.EventTypeWarning, reasonReposInvalid, err.Error())
+		return reconcile.Result{}, err
+	}
+	if err := validateWebhookURL(ws); err != nil {
+		setCondition(ws, v1alpha1.RouteResolvedCondition, metav1.ConditionFalse, reasonWebhookURLInvalid, err.Error())
+		r.recorder.Event(ws, corev1.EventTypeWarning, reasonWebhookURLInvalid, err.Error())
+		return reconcile.Result{}, err
+	}
+	// The webhooks were created for a list of repos, that has been replaced
+	// with a single repo.
+	if len(ws.Spec.Repos) == 0 && len(ws.Status.Webhooks) > 0 {
+		ws.Status.Webhooks = r.deleteUnlistedWebhooks(ctx, logger, ws, ws.Status.Webhooks)
+	}
+	secret, err := r.secretFactory.CreateSecret(ws)
 	if err != nil {
+		setCondition(ws, v1alpha1.SecretReadyCondition, metav1.ConditionFalse, reasonSecretFailed, err.Error())
 		return reconcile.Result{}, err
 	}
-	if err := controllerutil.SetControllerReference(instance, secret, r.scheme); err != nil {
+	if err := controllerutil.SetControllerReference(ws, secret, r.scheme); err != nil {
 		return reconcile.Result{}, err
 	}
 
 	found := &corev1.Secret{}
 	err = r.kubeClient.Get(ctx, types.NamespacedName{Name: secret.Name, Namespace: secret.Namespace}, found)
 	if err != nil && errors.IsNotFound(err) {
-		// TODO: this also needs to check for the webhook state.
-		return r.reconcileNewSecret(ctx, reqLogger, instance, secret)
+		if len(ws.Spec.Repos) > 0 {
+			if err := r.createSecret(ctx, ws, secret); err != nil {
+				return reconcile.Result{}, err
+			}
+			return r.reconcileRepos(ctx, logger, ws, secret, true)
+		}
+		return r.reconcileNewSecret(ctx, logger, ws, secret)
 	} else if err != nil {
+		setCondition(ws, v1alpha1.SecretReadyCondition, metav1.ConditionFalse, reasonSecretFailed, err.Error())
 		return reconcile.Result{}, err
 	}
+	setCondition(ws, v1alpha1.SecretReadyCondition, metav1.ConditionTrue, reasonSecretExists, "")
+	if len(ws.Spec.Repos) > 0 {
+		return r.reconcileRepos(ctx, logger, ws, found, false)
+	}
+	return r.reconcileExistingSecret(ctx, logger, ws, found)
+}
 
-	reqLogger.Info("Skip reconcile: Secret already exists", "Secret.Namespace", found.Namespace, "Secret.Name", found.Name)
-	return reconcile.Result{}, nil
+// updateStatus records the conditions and the generation that was reconciled
+// in the status of the WebhookSecret.
+func (r *ReconcileWebhookSecret) updateStatus(ctx context.Context, ws *v1alpha1.WebhookSecret) error {
+	ws.Status.ObservedGeneration = ws.Generation
+	setReadyCondition(ws)
+	return r.kubeClient.Status().Update(ctx, ws)
 }
 
 func (r *ReconcileWebhookSecret) authenticatedClient(ctx context.Context, ws *v1alpha1.WebhookSecret) (git.HooksClient, error) {
-	authToken, err := r.authSecretGetter.SecretToken(ctx, types.NamespacedName{Name: ws.Spec.AuthSecretRef.Name, Namespace: ws.ObjectMeta.Namespace})
-	if errors.IsNotFound(err) {
-		log.Error(err, fmt.Sprintf("secret %s/%s was not found", ws.Spec.AuthSecretRef.Name, ws.ObjectMeta.Namespace))
-		return nil, err
-	}
+	return r.authenticatedRepoClient(ctx, ws, ws.Spec.Repo)
+}
+
+// authenticatedRepoClient returns a client for the repo, authenticated with the
+// credentials from the WebhookSecret's auth secret.
+func (r *ReconcileWebhookSecret) authenticatedRepoClient(ctx context.Context, ws *v1alpha1.WebhookSecret, repo v1alpha1.Repo) (git.HooksClient, error) {
+	auth := r.authResolver()
+	creds, err := auth.Credentials(ctx, ws, &ws.Status.Conditions, "WebhookSecret", ws.Spec.AuthSecretRef)
 	if err != nil {
-		log.Error(err, "failed to get the authentication token")
-		return nil, fmt.Errorf("could not get authentication token from %s/%s: %s", ws.Spec.AuthSecretRef.Name, ws.ObjectMeta.Namespace, err)
+		log.Error(err, "failed to resolve the authentication secret")
+		return nil, err
 	}
-	client, err := r.gitClientFactory.ClientForRepo(ws.Spec.Repo, authToken)
+	client, err := r.gitClientFactory.ClientForRepo(repo, creds)
 	if err != nil {
-		return nil, fmt.Errorf("could not get client from %s: %s", ws.Spec.Repo.URL, err)
+		reason := reasonAuthSecretInvalid
+		if git.IsUnknownDriver(err) {
+			reason = reasonUnknownDriver
+		}
+		if git.IsUnsupportedScope(err) {
+			reason = reasonUnsupportedScope
+		}
+		return nil, auth.Failed(ws, &ws.Status.Conditions, reason, fmt.Errorf("could not get client from %s: %w", repo.URL, err))
 	}
+	auth.Resolved(ws, &ws.Status.Conditions)
 	return client, nil
 }
 
+// authResolver returns a resolver for the credentials in auth secrets.
+func (r *ReconcileWebhookSecret) authResolver() common.AuthResolver {
+	return common.AuthResolver{Reader: r.kubeClient, Secrets: r.authSecretGetter, Recorder: r.recorder}
+}
+
 func (r *ReconcileWebhookSecret) deleteWebhook(ctx context.Context, reqLogger logr.Logger, ws *v1alpha1.WebhookSecret) error {
 	client, err := r.authenticatedClient(ctx, ws)
 	if errors.IsNotFound(err) {
@@ -165,74 +328,275 @@ func (r *ReconcileWebhookSecret) deleteWebhook(ctx context.Context, reqLogger lo
 	}
 
 	if err != nil {
-		return fmt.Errorf("could not get authentication token from %s/%s: %s", ws.Spec.AuthSecretRef.Name, ws.ObjectMeta.Namespace, err)
+		return fmt.Errorf("could not get authentication token from %s: %s", authSecretID(ws), err)
 	}
 	err = client.Delete(ctx, ws.Status.WebhookID)
-	if err != nil && !git.IsNotFound(err) {
+	if git.IsNotFound(err) {
+		reqLogger.Info("Webhook was already deleted", "id", ws.Status.WebhookID)
+		return nil
+	}
+	if err != nil {
+		r.recordSCMError(ws, err)
 		return err
 	}
+	r.recorder.Eventf(ws, corev1.EventTypeNormal, reasonWebhookDeleted, "Deleted webhook %s", ws.Status.WebhookID)
 	return nil
 }
 
 // TODO: improve the error messages.
 func (r *ReconcileWebhookSecret) reconcileNewSecret(ctx context.Context, logger logr.Logger, ws *v1alpha1.WebhookSecret, s *corev1.Secret) (reconcile.Result, error) {
-	// TODO: split this up into creating the secret, and updating the git host.
+	// The previous hook was created with a secret that no longer exists.
+	if ws.Status.WebhookID != "" {
+		if err := r.deleteWebhook(ctx, logger, ws); err != nil {
+			return reconcile.Result{}, err
+		}
+		ws.Status.WebhookID = ""
+		if err := r.kubeClient.Status().Update(ctx, ws); err != nil {
+			log.Error(err, "failed to update WebhookSecret status")
+			return reconcile.Result{}, fmt.Errorf("failed to update status after deleting a Webhook: %s", err)
+		}
+	}
+
+	if err := r.createSecret(ctx, ws, s); err != nil {
+		return reconcile.Result{}, err
+	}
+
+	hookID, hookURL, err := r.createWebhook(ctx, logger, ws, string(s.Data[secretKey(ws)]))
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+	setCondition(ws, v1alpha1.WebhookReadyCondition, metav1.ConditionTrue, reasonWebhookCreated, "")
+	if err := r.updateWebhookStatus(ctx, ws, hookID, hookURL); err != nil {
+		return reconcile.Result{}, err
+	}
+	return reconcile.Result{RequeueAfter: requeueAfter(ws, s, time.Now())}, nil
+}
+
+// createSecret creates the Secret, and records it in the status of the
+// WebhookSecret.
+func (r *ReconcileWebhookSecret) createSecret(ctx context.Context, ws *v1alpha1.WebhookSecret, s *corev1.Secret) error {
 	log.Info("Creating a new Secret", "Secret.Namespace", s.Namespace, "Secret.Name", s.Name)
 	err := r.kubeClient.Create(ctx, s)
 	if err != nil {
-		return reconcile.Result{}, fmt.Errorf("failed to create a secret: %s", err)
+		setCondition(ws, v1alpha1.SecretReadyCondition, metav1.ConditionFalse, reasonSecretFailed, err.Error())
+		r.recorder.Eventf(ws, corev1.EventTypeWarning, reasonSecretFailed, "Failed to create Secret %s: %s", s.Name, err)
+		return fmt.Errorf("failed to create a secret: %s", err)
 	}
+	setCondition(ws, v1alpha1.SecretReadyCondition, metav1.ConditionTrue, reasonSecretCreated, "")
+	r.recorder.Eventf(ws, corev1.EventTypeNormal, reasonSecretCreated, "Created Secret %s", s.Name)
 	ws.Status.SecretRef = v1alpha1.WebhookSecretRef{
 		Name: s.Name,
 	}
+	now := metav1.Now()
+	ws.Status.LastRotationTime = &now
+	ws.Status.LastRotationRequest = ws.ObjectMeta.Annotations[v1alpha1.RotateAnnotation]
 	err = r.kubeClient.Status().Update(ctx, ws)
 	if err != nil {
 		log.Error(err, "failed to update WebhookSecret status")
-		return reconcile.Result{}, fmt.Errorf("failed to update status after creating a secret: %s", err)
+		return fmt.Errorf("failed to update status after creating a secret: %s", err)
 	}
+	return nil
+}
 
-	// TODO: work out how to get the secret string without having to grab it from the
-	// Data.
-	hookID, err := r.createWebhook(ctx, logger, ws, string(s.Data["token"]))
+// reconcileExistingSecret ensures that the webhook exists in the git host, and
+// matches the WebhookSecret, recreating the webhook if it's missing or has
+// drifted.
+func (r *ReconcileWebhookSecret) reconcileExistingSecret(ctx context.Context, logger logr.Logger, ws *v1alpha1.WebhookSecret, s *corev1.Secret) (reconcile.Result, error) {
+	hookURL, err := r.hookURL(ctx, ws)
 	if err != nil {
 		return reconcile.Result{}, err
 	}
-	ws.Status.WebhookID = hookID
-	err = r.kubeClient.Status().Update(ctx, ws)
+	client, err := r.authenticatedClient(ctx, ws)
 	if err != nil {
+		return reconcile.Result{}, err
+	}
+	secret := string(s.Data[secretKey(ws)])
+
+	if ws.Status.WebhookID != "" && ws.Status.HookURL != "" && ws.Status.HookURL != hookURL {
+		logger.Info("Hook URL changed, updating", "id", ws.Status.WebhookID, "oldHookURL", ws.Status.HookURL, "hookURL", hookURL)
+		hookID, err := client.Update(ctx, ws.Status.WebhookID, hookURL, secret, ws.Spec.Events)
+		if err != nil {
+			return reconcile.Result{}, r.webhookFailed(ws, err)
+		}
+		logger.Info("Hook updated", "id", hookID, "hookURL", hookURL)
+		r.recorder.Eventf(ws, corev1.EventTypeNormal, reasonWebhookUpdated, "Updated webhook %s to %s", hookID, hookURL)
+		setCondition(ws, v1alpha1.WebhookReadyCondition, metav1.ConditionTrue, reasonWebhookUpdated, "")
+		if err := r.updateWebhookStatus(ctx, ws, hookID, hookURL); err != nil {
+			return reconcile.Result{}, err
+		}
+		return reconcile.Result{RequeueAfter: requeueAfter(ws, s, time.Now())}, nil
+	}
+
+	hookID, create, err := r.removeStaleWebhooks(ctx, logger, client, ws, ws.Status.WebhookID, hookURL, secret)
+	if err != nil {
+		return reconcile.Result{}, r.webhookFailed(ws, err)
+	}
+	if !create && hookID != ws.Status.WebhookID {
+		setCondition(ws, v1alpha1.WebhookReadyCondition, metav1.ConditionTrue, reasonWebhookAdopted, "")
+		if err := r.updateWebhookStatus(ctx, ws, hookID, hookURL); err != nil {
+			return reconcile.Result{}, err
+		}
+		return reconcile.Result{RequeueAfter: requeueAfter(ws, s, time.Now())}, nil
+	}
+	if !create {
+		if rotationDue(ws, s, time.Now()) {
+			return r.rotateSecret(ctx, logger, client, ws, s, hookURL)
+		}
+		if previousExpired(ws, s, time.Now()) {
+			if s, err = r.prunePreviousSecret(ctx, logger, ws, s); err != nil {
+				return reconcile.Result{}, err
+			}
+		}
+		logger.Info("Skip reconcile: Webhook is up to date", "Secret.Namespace", s.Namespace, "Secret.Name", s.Name, "id", ws.Status.WebhookID)
+		setCondition(ws, v1alpha1.WebhookReadyCondition, metav1.ConditionTrue, reasonWebhookVerified, "")
+		now := metav1.Now()
+		ws.Status.LastSyncTime = &now
+		return reconcile.Result{RequeueAfter: requeueAfter(ws, s, now.Time)}, nil
+	}
+
+	hookID, err = client.Create(ctx, hookURL, secret, ws.Spec.Events)
+	if err != nil {
+		return reconcile.Result{}, r.webhookFailed(ws, err)
+	}
+	logger.Info("Hook created", "id", hookID, "hookURL", hookURL)
+	r.recorder.Eventf(ws, corev1.EventTypeNormal, reasonWebhookCreated, "Created webhook %s for %s", hookID, hookURL)
+	setCondition(ws, v1alpha1.WebhookReadyCondition, metav1.ConditionTrue, reasonWebhookCreated, "")
+	if err := r.updateWebhookStatus(ctx, ws, hookID, hookURL); err != nil {
+		return reconcile.Result{}, err
+	}
+	return reconcile.Result{RequeueAfter: requeueAfter(ws, s, time.Now())}, nil
+}
+
+// updateWebhookStatus records the webhook in the status of the WebhookSecret.
+func (r *ReconcileWebhookSecret) updateWebhookStatus(ctx context.Context, ws *v1alpha1.WebhookSecret, hookID, hookURL string) error {
+	now := metav1.Now()
+	ws.Status.WebhookID = hookID
+	ws.Status.HookURL = hookURL
+	ws.Status.LastSyncTime = &now
+	if err := r.kubeClient.Status().Update(ctx, ws); err != nil {
 		log.Error(err, "Failed to update WebhookSecret status")
-		return reconcile.Result{}, fmt.Errorf("failed to update status after creating a Webhook: %s", err)
+		return fmt.Errorf("failed to update status after creating a Webhook: %s", err)
+	}
+	return nil
+}
+
+// removeStaleWebhooks checks the webhook recorded in the status against the git
+// host, and returns the ID of the webhook to keep, and true if a new webhook
+// needs to be created.
+//
+// Webhooks that have drifted are removed from the git host.
+//
+// If there's no webhook recorded in the status, a webhook with the hookURL and
+// events is adopted, as it was most likely created by an earlier reconcile that
+// failed to record it, and it's updated with the secret. Other webhooks
+// pointing at the hookURL are left alone, as they weren't created by this
+// WebhookSecret, and a Warning event is recorded for each of them.
+func (r *ReconcileWebhookSecret) removeStaleWebhooks(ctx context.Context, logger logr.Logger, client git.HooksClient, ws *v1alpha1.WebhookSecret, hookID, hookURL, secret string) (string, bool, error) {
+	if hookID == "" {
+		return r.adoptWebhook(ctx, logger, client, ws, hookURL, secret)
+	}
+
+	hook, err := client.Get(ctx, hookID)
+	if git.IsNotFound(err) {
+		logger.Info("Hook not found", "id", hookID)
+		return "", true, nil
+	}
+	if err != nil {
+		return "", false, err
+	}
+	if hook.Matches(hookURL, ws.Spec.Events) {
+		return hookID, false, nil
+	}
+	logger.Info("Hook has drifted, removing", "id", hook.ID, "hookURL", hook.URL)
+	err = client.Delete(ctx, hook.ID)
+	if git.IsNotFound(err) {
+		return "", true, nil
 	}
-	return reconcile.Result{}, nil
+	if err != nil {
+		return "", false, err
+	}
+	r.recorder.Eventf(ws, corev1.EventTypeNormal, reasonWebhookDeleted, "Deleted drifted webhook %s", hook.ID)
+	return "", true, nil
+}
+
+// adoptWebhook looks for an unrecorded webhook in the git host with the hookURL
+// and events, and updates it with the secret, returning the ID of the adopted
+// hook, or true if no webhook could be adopted and a new one needs to be
+// created.
+func (r *ReconcileWebhookSecret) adoptWebhook(ctx context.Context, logger logr.Logger, client git.HooksClient, ws *v1alpha1.WebhookSecret, hookURL, secret string) (string, bool, error) {
+	hooks, err := client.List(ctx)
+	if err != nil {
+		return "", false, err
+	}
+	var adopt *git.Hook
+	for _, h := range hooks {
+		if adopt == nil && h.Matches(hookURL, ws.Spec.Events) {
+			adopt = h
+			continue
+		}
+		if h.URL != hookURL {
+			continue
+		}
+		logger.Info("Found unrecorded hook, leaving it in place", "id", h.ID, "hookURL", hookURL)
+		r.recorder.Eventf(ws, corev1.EventTypeWarning, reasonWebhookExists, "Webhook %s for %s was not created by this WebhookSecret, leaving it in place", h.ID, hookURL)
+	}
+	if adopt == nil {
+		return "", true, nil
+	}
+	hookID, err := client.Update(ctx, adopt.ID, hookURL, secret, ws.Spec.Events)
+	if err != nil {
+		return "", false, err
+	}
+	logger.Info("Adopted unrecorded hook", "id", hookID, "hookURL", hookURL)
+	r.recorder.Eventf(ws, corev1.EventTypeNormal, reasonWebhookAdopted, "Adopted webhook %s for %s", hookID, hookURL)
+	return hookID, false, nil
 }
 
-func (r *ReconcileWebhookSecret) createWebhook(ctx context.Context, logger logr.Logger, ws *v1alpha1.WebhookSecret, secret string) (string, error) {
-	hookURL, err := r.hookURL(ctx, ws.Spec.WebhookURL)
+// createWebhook creates a webhook in the git host, returning the ID of the new
+// hook, and the URL it was created with.
+func (r *ReconcileWebhookSecret) createWebhook(ctx context.Context, logger logr.Logger, ws *v1alpha1.WebhookSecret, secret string) (string, string, error) {
+	hookURL, err := r.hookURL(ctx, ws)
 	if err != nil {
 		log.Error(err, "Failed to get the URL for route")
-		return "", err
+		return "", "", err
 	}
 
 	client, err := r.authenticatedClient(ctx, ws)
 	if err != nil {
-		return "", err
+		return "", "", err
 	}
-	hookID, err := client.Create(ctx, hookURL, secret)
+	hookID, err := client.Create(ctx, hookURL, secret, ws.Spec.Events)
 	if err != nil {
-		return "", err
+		return "", "", r.webhookFailed(ws, err)
 	}
 	logger.Info("Hook created", "id", hookID, "hookURL", hookURL)
-	return hookID, nil
+	r.recorder.Eventf(ws, corev1.EventTypeNormal, reasonWebhookCreated, "Created webhook %s for %s", hookID, hookURL)
+	return hookID, hookURL, nil
+}
+
+// webhookFailed records the error in the WebhookReady condition, and as an
+// event.
+func (r *ReconcileWebhookSecret) webhookFailed(ws *v1alpha1.WebhookSecret, err error) error {
+	setCondition(ws, v1alpha1.WebhookReadyCondition, metav1.ConditionFalse, scmErrorReason(err), err.Error())
+	r.recordSCMError(ws, err)
+	return err
 }
 
-func (r *ReconcileWebhookSecret) hookURL(ctx context.Context, u v1alpha1.HookRoute) (string, error) {
-	if u.HookURL != "" {
-		return u.HookURL, nil
+// recordSCMError records a Warning event for an error from the git host,
+// including the HTTP status code if there is one.
+func (r *ReconcileWebhookSecret) recordSCMError(ws *v1alpha1.WebhookSecret, err error) {
+	if status := git.ErrorStatus(err); status != 0 {
+		r.recorder.Eventf(ws, corev1.EventTypeWarning, scmErrorReason(err), "%s (HTTP status %d)", err, status)
+		return
 	}
-	hookURL, err := r.routeGetter.RouteURL(ctx, u.RouteRef.NamespacedName(), u.RouteRef.Path)
-	if err != nil {
-		log.Error(err, "Failed to get the URL for route")
-		return "", err
+	r.recorder.Event(ws, corev1.EventTypeWarning, scmErrorReason(err), err.Error())
+}
+
+// scmErrorReason returns the reason for an error from the git host, requests
+// that were denied for the lack of a permission have a distinct reason.
+func scmErrorReason(err error) string {
+	if git.IsPermissionDenied(err) {
+		return reasonWebhookDenied
 	}
-	return hookURL, nil
+	return reasonWebhookFailed
 }
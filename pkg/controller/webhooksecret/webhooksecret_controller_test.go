@@ -10,6 +10,9 @@ import (
 	"time"
 
 	"github.com/bigkevmcd/webhook-secret-operator/pkg/routes"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"github.com/jenkins-x/go-scm/scm"
 	routev1 "github.com/openshift/api/route/v1"
 	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
@@ -17,6 +20,7 @@ import (
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
@@ -24,6 +28,7 @@ import (
 
 	v1alpha1 "github.com/bigkevmcd/webhook-secret-operator/pkg/apis/apps/v1alpha1"
 	"github.com/bigkevmcd/webhook-secret-operator/pkg/git"
+	"github.com/bigkevmcd/webhook-secret-operator/pkg/providers"
 	"github.com/bigkevmcd/webhook-secret-operator/pkg/secrets"
 	"github.com/bigkevmcd/webhook-secret-operator/test"
 )
@@ -40,6 +45,8 @@ const (
 	testAuthToken              = "test-auth-token"
 )
 
+var testProvidersConfigMap = types.NamespacedName{Name: providers.ConfigMapName, Namespace: "operator-ns"}
+
 // Reconciling a simple WebhookSecret should create a Secret, and create a
 // webhook in the repository pointing to the HookURL in the WebhookSecret.
 func TestWebhookSecretControllerWithAHookURL(t *testing.T) {
@@ -78,10 +85,154 @@ func TestWebhookSecretControllerWithAHookURL(t *testing.T) {
 		t.Errorf("status does not have the correct WebhookID, got %#v, want %#v",
 			ws.Status.WebhookID, testWebhookID)
 	}
+	if ws.Status.HookURL != testHookEndpoint {
+		t.Errorf("status does not have the correct HookURL, got %#v, want %#v",
+			ws.Status.HookURL, testHookEndpoint)
+	}
+	if ws.Status.LastSyncTime == nil {
+		t.Error("status does not have a LastSyncTime")
+	}
+	if ws.Status.ObservedGeneration != ws.Generation {
+		t.Errorf("status does not have the correct ObservedGeneration, got %d, want %d",
+			ws.Status.ObservedGeneration, ws.Generation)
+	}
+	assertCondition(t, ws, v1alpha1.SecretReadyCondition, metav1.ConditionTrue, reasonSecretCreated)
+	assertCondition(t, ws, v1alpha1.WebhookReadyCondition, metav1.ConditionTrue, reasonWebhookCreated)
+	assertCondition(t, ws, v1alpha1.AuthResolvedCondition, metav1.ConditionTrue, reasonAuthResolved)
+	assertCondition(t, ws, v1alpha1.RouteResolvedCondition, metav1.ConditionTrue, reasonHookURL)
+	assertCondition(t, ws, v1alpha1.ReadyCondition, metav1.ConditionTrue, reasonReady)
+	assertEvents(t, r.recorder,
+		"Normal SecretCreated Created Secret test-webhook-secret",
+		"Normal WebhookCreated Created webhook 1234567 for https://example.com/")
 	r.gitClientFactory.(*stubClientFactory).client.
 		assertHookCreated(testHookEndpoint, stubSecret)
 }
 
+// If the auth secret is missing, the WebhookSecret should reflect the error.
+func TestWebhookSecretControllerWithMissingAuthSecret(t *testing.T) {
+	logf.SetLogger(logf.ZapLogger(true))
+	ws := makeWebhookSecret(v1alpha1.HookRoute{
+		HookURL: testHookEndpoint,
+	})
+	_, r := makeReconciler(t, ws, ws)
+	req := makeReconcileRequest()
+
+	_, err := r.Reconcile(req)
+	if !apierrors.IsNotFound(err) {
+		t.Fatalf("expected a not found error, got %v", err)
+	}
+	ws = &v1alpha1.WebhookSecret{}
+	err = r.kubeClient.Get(context.Background(), req.NamespacedName, ws)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertCondition(t, ws, v1alpha1.AuthResolvedCondition, metav1.ConditionFalse, reasonAuthSecretNotFound)
+	assertCondition(t, ws, v1alpha1.ReadyCondition, metav1.ConditionFalse, reasonAuthSecretNotFound)
+	assertEvents(t, r.recorder,
+		"Normal SecretCreated Created Secret test-webhook-secret",
+		"Warning AuthSecretNotFound Auth secret test-webhook-ns/auth-secret was not found")
+	r.gitClientFactory.(*stubClientFactory).client.assertNoHookCreated()
+}
+
+// If the auth secret doesn't contain credentials, the WebhookSecret should
+// reflect the error.
+func TestWebhookSecretControllerWithMalformedAuthSecret(t *testing.T) {
+	logf.SetLogger(logf.ZapLogger(true))
+	ws := makeWebhookSecret(v1alpha1.HookRoute{
+		HookURL: testHookEndpoint,
+	})
+	ws.Spec.AuthSecretRef.Key = "github-token"
+	_, r := makeReconciler(t, ws, ws, makeTestSecret("auth-secret"))
+	req := makeReconcileRequest()
+
+	_, err := r.Reconcile(req)
+	if !secrets.IsInvalidSecret(err) {
+		t.Fatalf("expected an invalid secret error, got %v", err)
+	}
+	ws = &v1alpha1.WebhookSecret{}
+	err = r.kubeClient.Get(context.Background(), req.NamespacedName, ws)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertCondition(t, ws, v1alpha1.AuthResolvedCondition, metav1.ConditionFalse, reasonAuthSecretMalformed)
+	assertCondition(t, ws, v1alpha1.ReadyCondition, metav1.ConditionFalse, reasonAuthSecretMalformed)
+	assertEvents(t, r.recorder,
+		"Normal SecretCreated Created Secret test-webhook-secret",
+		"Warning AuthSecretMalformed secret invalid, no 'github-token' key in test-webhook-ns/auth-secret")
+	r.gitClientFactory.(*stubClientFactory).client.assertNoHookCreated()
+}
+
+// If the git host rejects the webhook, the failure should be recorded with the
+// HTTP status.
+func TestWebhookSecretControllerFailingToCreateTheWebhook(t *testing.T) {
+	logf.SetLogger(logf.ZapLogger(true))
+	ws := makeWebhookSecret(v1alpha1.HookRoute{
+		HookURL: testHookEndpoint,
+	})
+	_, r := makeReconciler(t, ws, ws, makeTestSecret(testAuthSecretName))
+	r.gitClientFactory.(*stubClientFactory).client.createErr = git.SCMError{Status: http.StatusForbidden}
+	req := makeReconcileRequest()
+
+	_, err := r.Reconcile(req)
+	if git.ErrorStatus(err) != http.StatusForbidden {
+		t.Fatalf("expected a forbidden error, got %v", err)
+	}
+	ws = &v1alpha1.WebhookSecret{}
+	err = r.kubeClient.Get(context.Background(), req.NamespacedName, ws)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertCondition(t, ws, v1alpha1.WebhookReadyCondition, metav1.ConditionFalse, reasonWebhookFailed)
+	assertEvents(t, r.recorder,
+		"Normal SecretCreated Created Secret test-webhook-secret",
+		"Warning WebhookFailed  (HTTP status 403)")
+}
+
+// Organization and group hooks need more permissions than repository hooks,
+// requests that are denied have a distinct reason.
+func TestWebhookSecretControllerWithPermissionDenied(t *testing.T) {
+	logf.SetLogger(logf.ZapLogger(true))
+	ws := makeWebhookSecret(v1alpha1.HookRoute{
+		HookURL: testHookEndpoint,
+	})
+	ws.Spec.Repo = v1alpha1.Repo{URL: "https://github.com/example", Scope: v1alpha1.OrganizationScope}
+	_, r := makeReconciler(t, ws, ws, makeTestSecret(testAuthSecretName))
+	r.gitClientFactory.(*stubClientFactory).client.createErr = git.SCMError{Status: http.StatusForbidden, Permission: "needs admin:org_hook"}
+	req := makeReconcileRequest()
+
+	_, err := r.Reconcile(req)
+	if !git.IsPermissionDenied(err) {
+		t.Fatalf("expected a permission error, got %v", err)
+	}
+	ws = &v1alpha1.WebhookSecret{}
+	err = r.kubeClient.Get(context.Background(), req.NamespacedName, ws)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertCondition(t, ws, v1alpha1.WebhookReadyCondition, metav1.ConditionFalse, reasonWebhookDenied)
+	assertEvents(t, r.recorder,
+		"Normal SecretCreated Created Secret test-webhook-secret",
+		"Warning WebhookPermissionDenied , needs admin:org_hook (HTTP status 403)")
+}
+
+// The events in the WebhookSecret should be used when creating the webhook.
+func TestWebhookSecretControllerWithEvents(t *testing.T) {
+	logf.SetLogger(logf.ZapLogger(true))
+	ws := makeWebhookSecret(v1alpha1.HookRoute{
+		HookURL: testHookEndpoint,
+	})
+	events := []v1alpha1.HookEvent{v1alpha1.PushEvent, v1alpha1.PullRequestEvent}
+	ws.Spec.Events = events
+	_, r := makeReconciler(t, ws, ws, makeTestSecret(testAuthSecretName))
+	req := makeReconcileRequest()
+
+	_, err := r.Reconcile(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r.gitClientFactory.(*stubClientFactory).client.assertHookEvents(testHookEndpoint, events)
+}
+
 // Reconciling a simple WebhookSecret should create a Secret, and create a
 // webhook in the repository pointing to the URL of the Route referenced in the
 // WebhookSecret.
@@ -196,19 +347,481 @@ func TestWebhookSecretControllerWithRouteRefAndRouteMissing(t *testing.T) {
 	if ws.Status.SecretRef.Name != s.Name {
 		t.Fatalf("got incorrect secret in status, got %#v, want %#v", ws.Status.SecretRef.Name, s.Name)
 	}
+	assertCondition(t, ws, v1alpha1.SecretReadyCondition, metav1.ConditionTrue, reasonSecretCreated)
+	assertCondition(t, ws, v1alpha1.RouteResolvedCondition, metav1.ConditionFalse, reasonRouteNotFound)
+	assertCondition(t, ws, v1alpha1.ReadyCondition, metav1.ConditionFalse, reasonRouteNotFound)
 	r.gitClientFactory.(*stubClientFactory).client.assertNoHookCreated()
 }
 
 // When reconciling a WebhookSecret, if we have the secret already, but no
-// Status.WebhookID, then we should create the webhook.
+// Status.WebhookID, then we should create the webhook, leaving any existing
+// hook with the same URL in place, as it wasn't created by the WebhookSecret.
 func TestWebhookSecretControllerSecretButNoHook(t *testing.T) {
-	t.Skip()
+	logf.SetLogger(logf.ZapLogger(true))
+	ws := makeWebhookSecret(v1alpha1.HookRoute{
+		HookURL: testHookEndpoint,
+	})
+	_, r := makeReconciler(t, ws, ws, makeTestSecret(testAuthSecretName), makeGeneratedSecret(stubSecret))
+	hc := r.gitClientFactory.(*stubClientFactory).client
+	hc.addHook(&git.Hook{ID: "unrecorded", URL: testHookEndpoint, Events: []string{"issues"}, Active: true, Driver: scm.DriverGithub})
+	req := makeReconcileRequest()
+
+	_, err := r.Reconcile(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ws = &v1alpha1.WebhookSecret{}
+	err = r.kubeClient.Get(context.Background(), req.NamespacedName, ws)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ws.Status.WebhookID != testWebhookID {
+		t.Errorf("status does not have the correct WebhookID, got %#v, want %#v",
+			ws.Status.WebhookID, testWebhookID)
+	}
+	hc.assertNoHookDeleted()
+	hc.assertHookCreated(testHookEndpoint, stubSecret)
+	assertEvents(t, r.recorder,
+		"Warning WebhookExists Webhook unrecorded for "+testHookEndpoint+" was not created by this WebhookSecret, leaving it in place",
+		"Normal WebhookCreated Created webhook "+testWebhookID+" for "+testHookEndpoint)
+}
+
+func TestWebhookSecretControllerSecretAndUnrecordedHook(t *testing.T) {
+	logf.SetLogger(logf.ZapLogger(true))
+	ws := makeWebhookSecret(v1alpha1.HookRoute{
+		HookURL: testHookEndpoint,
+	})
+	_, r := makeReconciler(t, ws, ws, makeTestSecret(testAuthSecretName), makeGeneratedSecret(stubSecret))
+	hc := r.gitClientFactory.(*stubClientFactory).client
+	hc.addHook(&git.Hook{ID: "unrecorded", URL: testHookEndpoint, Events: []string{"push"}, Active: true, Driver: scm.DriverGithub})
+	req := makeReconcileRequest()
+
+	_, err := r.Reconcile(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ws = &v1alpha1.WebhookSecret{}
+	err = r.kubeClient.Get(context.Background(), req.NamespacedName, ws)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ws.Status.WebhookID != testWebhookID {
+		t.Errorf("status does not have the correct WebhookID, got %#v, want %#v",
+			ws.Status.WebhookID, testWebhookID)
+	}
+	hc.assertNoHookCreated()
+	hc.assertNoHookDeleted()
+	hc.assertHookUpdated("unrecorded", testHookEndpoint, stubSecret)
+	assertCondition(t, ws, v1alpha1.WebhookReadyCondition, metav1.ConditionTrue, reasonWebhookAdopted)
+	assertEvents(t, r.recorder,
+		"Normal WebhookAdopted Adopted webhook "+testWebhookID+" for "+testHookEndpoint)
+}
+
+// If the status can't be updated after creating the webhook, the next
+// reconciliation adopts the webhook rather than creating another one.
+func TestWebhookSecretControllerFailedStatusUpdateAfterCreate(t *testing.T) {
+	logf.SetLogger(logf.ZapLogger(true))
+	ws := makeWebhookSecret(v1alpha1.HookRoute{
+		HookURL: testHookEndpoint,
+	})
+	cl, r := makeReconciler(t, ws, ws, makeTestSecret(testAuthSecretName), makeGeneratedSecret(stubSecret))
+	hc := r.gitClientFactory.(*stubClientFactory).client
+	r.kubeClient = statusFailingClient{Client: cl, err: errors.New("status update failed")}
+	req := makeReconcileRequest()
+
+	_, err := r.Reconcile(req)
+	if !test.MatchError(t, "failed to update status after creating a Webhook: status update failed", err) {
+		t.Fatalf("got %s", err)
+	}
+	hc.assertHookCreated(testHookEndpoint, stubSecret)
+
+	r.kubeClient = cl
+	hc.created = make(map[string]string)
+	_, err = r.Reconcile(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ws = &v1alpha1.WebhookSecret{}
+	err = cl.Get(context.Background(), req.NamespacedName, ws)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ws.Status.WebhookID != testWebhookID {
+		t.Errorf("status does not have the correct WebhookID, got %#v, want %#v",
+			ws.Status.WebhookID, testWebhookID)
+	}
+	hc.assertNoHookCreated()
+	hc.assertHookUpdated(testWebhookID, testHookEndpoint, stubSecret)
+	if l := len(hc.hooks); l != 1 {
+		t.Fatalf("got %d hooks, want 1", l)
+	}
 }
 
 // We're watching the Secret, when it's deleted, we should recreate the Secret,
-// and update the Hook's secret (either remove and create, or update).
+// and replace the Hook.
 func TestWebhookSecretDeletedWebhookSecretDeletedSecret(t *testing.T) {
-	t.Skip()
+	logf.SetLogger(logf.ZapLogger(true))
+	ws := makeWebhookSecret(v1alpha1.HookRoute{
+		HookURL: testHookEndpoint,
+	})
+	ws.Status.WebhookID = "old-hook"
+	_, r := makeReconciler(t, ws, ws, makeTestSecret(testAuthSecretName))
+	hc := r.gitClientFactory.(*stubClientFactory).client
+	req := makeReconcileRequest()
+
+	_, err := r.Reconcile(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ws = &v1alpha1.WebhookSecret{}
+	err = r.kubeClient.Get(context.Background(), req.NamespacedName, ws)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ws.Status.WebhookID != testWebhookID {
+		t.Errorf("status does not have the correct WebhookID, got %#v, want %#v",
+			ws.Status.WebhookID, testWebhookID)
+	}
+	hc.assertHookDeleted("old-hook")
+	hc.assertHookCreated(testHookEndpoint, stubSecret)
+}
+
+// If the hook recorded in the status no longer exists in the git host, it
+// should be recreated.
+func TestWebhookSecretControllerWithMissingHook(t *testing.T) {
+	logf.SetLogger(logf.ZapLogger(true))
+	ws := makeWebhookSecret(v1alpha1.HookRoute{
+		HookURL: testHookEndpoint,
+	})
+	ws.Status.WebhookID = "old-hook"
+	_, r := makeReconciler(t, ws, ws, makeTestSecret(testAuthSecretName), makeGeneratedSecret(stubSecret))
+	hc := r.gitClientFactory.(*stubClientFactory).client
+	req := makeReconcileRequest()
+
+	_, err := r.Reconcile(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ws = &v1alpha1.WebhookSecret{}
+	err = r.kubeClient.Get(context.Background(), req.NamespacedName, ws)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ws.Status.WebhookID != testWebhookID {
+		t.Errorf("status does not have the correct WebhookID, got %#v, want %#v",
+			ws.Status.WebhookID, testWebhookID)
+	}
+	hc.assertHookCreated(testHookEndpoint, stubSecret)
+}
+
+// If the hook recorded in the status has been changed in the git host, it
+// should be replaced with the existing secret.
+func TestWebhookSecretControllerWithDriftedHook(t *testing.T) {
+	logf.SetLogger(logf.ZapLogger(true))
+	ws := makeWebhookSecret(v1alpha1.HookRoute{
+		HookURL: testHookEndpoint,
+	})
+	ws.Spec.Events = []v1alpha1.HookEvent{v1alpha1.PushEvent, v1alpha1.PullRequestEvent}
+	ws.Status.WebhookID = "old-hook"
+	_, r := makeReconciler(t, ws, ws, makeTestSecret(testAuthSecretName), makeGeneratedSecret("existing-secret"))
+	hc := r.gitClientFactory.(*stubClientFactory).client
+	hc.addHook(&git.Hook{ID: "old-hook", URL: testHookEndpoint, Events: []string{"push"}, Active: true, Driver: scm.DriverGithub})
+	req := makeReconcileRequest()
+
+	_, err := r.Reconcile(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ws = &v1alpha1.WebhookSecret{}
+	err = r.kubeClient.Get(context.Background(), req.NamespacedName, ws)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ws.Status.WebhookID != testWebhookID {
+		t.Errorf("status does not have the correct WebhookID, got %#v, want %#v",
+			ws.Status.WebhookID, testWebhookID)
+	}
+	hc.assertHookDeleted("old-hook")
+	hc.assertHookCreated(testHookEndpoint, "existing-secret")
+	assertEvents(t, r.recorder,
+		"Normal WebhookDeleted Deleted drifted webhook old-hook",
+		"Normal WebhookCreated Created webhook 1234567 for https://example.com/")
+}
+
+// If the host of the referenced Route changes, the existing hook should be
+// updated to point at the new URL, keeping the same secret.
+func TestWebhookSecretControllerWithChangedRouteHost(t *testing.T) {
+	logf.SetLogger(logf.ZapLogger(true))
+	ws := makeWebhookSecret(v1alpha1.HookRoute{
+		RouteRef: &v1alpha1.RouteReference{
+			Name:      "my-test-route",
+			Namespace: "route-test",
+		},
+	})
+	ws.Status.WebhookID = "old-hook"
+	ws.Status.HookURL = "https://old.example.com/"
+	_, r := makeReconciler(t, ws, ws, makeTestSecret(testAuthSecretName), makeGeneratedSecret("existing-secret"),
+		test.MakeRoute(types.NamespacedName{Name: "my-test-route", Namespace: "route-test"}, test.Host("new.example.com")))
+	hc := r.gitClientFactory.(*stubClientFactory).client
+	req := makeReconcileRequest()
+
+	_, err := r.Reconcile(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ws = &v1alpha1.WebhookSecret{}
+	err = r.kubeClient.Get(context.Background(), req.NamespacedName, ws)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ws.Status.WebhookID != testWebhookID {
+		t.Errorf("status does not have the correct WebhookID, got %#v, want %#v",
+			ws.Status.WebhookID, testWebhookID)
+	}
+	if ws.Status.HookURL != "https://new.example.com/" {
+		t.Errorf("status does not have the correct HookURL, got %#v, want %#v",
+			ws.Status.HookURL, "https://new.example.com/")
+	}
+	hc.assertHookUpdated("old-hook", "https://new.example.com/", "existing-secret")
+	hc.assertNoHookCreated()
+	assertEvents(t, r.recorder,
+		"Normal WebhookUpdated Updated webhook 1234567 to https://new.example.com/")
+}
+
+// If the hook recorded in the status matches the WebhookSecret, nothing should
+// be changed.
+func TestWebhookSecretControllerWithMatchingHook(t *testing.T) {
+	logf.SetLogger(logf.ZapLogger(true))
+	ws := makeWebhookSecret(v1alpha1.HookRoute{
+		HookURL: testHookEndpoint,
+	})
+	ws.Status.WebhookID = testWebhookID
+	_, r := makeReconciler(t, ws, ws, makeTestSecret(testAuthSecretName), makeGeneratedSecret(stubSecret))
+	hc := r.gitClientFactory.(*stubClientFactory).client
+	hc.addHook(&git.Hook{ID: testWebhookID, URL: testHookEndpoint, Events: []string{"push"}, Active: true, Driver: scm.DriverGithub})
+	req := makeReconcileRequest()
+
+	_, err := r.Reconcile(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hc.assertNoHookCreated()
+	hc.assertNoHookDeleted()
+	assertEvents(t, r.recorder)
+}
+
+// If the secret is due to be rotated, the Secret should be updated with a new
+// secret, keeping the existing secret for the minimum grace period, before
+// the webhook is updated.
+func TestWebhookSecretControllerWithRotationDue(t *testing.T) {
+	logf.SetLogger(logf.ZapLogger(true))
+	ws := makeWebhookSecret(v1alpha1.HookRoute{
+		HookURL: testHookEndpoint,
+	})
+	ws.Spec.Rotation = &v1alpha1.RotationSpec{Interval: &metav1.Duration{Duration: 24 * time.Hour}}
+	lastRotation := metav1.NewTime(time.Now().Add(-25 * time.Hour))
+	ws.Status.LastRotationTime = &lastRotation
+	ws.Status.WebhookID = "old-hook"
+	_, r := makeReconciler(t, ws, ws, makeTestSecret(testAuthSecretName), makeGeneratedSecret("existing-secret"))
+	hc := r.gitClientFactory.(*stubClientFactory).client
+	hc.addHook(&git.Hook{ID: "old-hook", URL: testHookEndpoint, Events: []string{"push"}, Active: true, Driver: scm.DriverGithub})
+	req := makeReconcileRequest()
+
+	res, err := r.Reconcile(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.RequeueAfter != minimumGracePeriod {
+		t.Errorf("got RequeueAfter %v, want %v", res.RequeueAfter, minimumGracePeriod)
+	}
+	ws = &v1alpha1.WebhookSecret{}
+	err = r.kubeClient.Get(context.Background(), req.NamespacedName, ws)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ws.Status.WebhookID != testWebhookID {
+		t.Errorf("status does not have the correct WebhookID, got %#v, want %#v",
+			ws.Status.WebhookID, testWebhookID)
+	}
+	if !ws.Status.LastRotationTime.After(lastRotation.Time) {
+		t.Errorf("LastRotationTime was not updated, got %v", ws.Status.LastRotationTime)
+	}
+	assertSecretToken(t, r.kubeClient, stubSecret)
+	assertCondition(t, ws, v1alpha1.SecretReadyCondition, metav1.ConditionTrue, reasonSecretRotated)
+	assertEvents(t, r.recorder, "Normal SecretRotated Rotated the secret for webhook 1234567")
+	hc.assertHookUpdated("old-hook", testHookEndpoint, stubSecret)
+	hc.assertNoHookCreated()
+}
+
+// Changing the rotate annotation should rotate the secret immediately, but only
+// once for each value.
+func TestWebhookSecretControllerWithRotateAnnotation(t *testing.T) {
+	logf.SetLogger(logf.ZapLogger(true))
+	ws := makeWebhookSecret(v1alpha1.HookRoute{
+		HookURL: testHookEndpoint,
+	})
+	ws.ObjectMeta.Annotations = map[string]string{v1alpha1.RotateAnnotation: "2020-07-01"}
+	ws.Status.WebhookID = testWebhookID
+	_, r := makeReconciler(t, ws, ws, makeTestSecret(testAuthSecretName), makeGeneratedSecret("existing-secret"))
+	hc := r.gitClientFactory.(*stubClientFactory).client
+	hc.addHook(&git.Hook{ID: testWebhookID, URL: testHookEndpoint, Events: []string{"push"}, Active: true, Driver: scm.DriverGithub})
+	req := makeReconcileRequest()
+
+	_, err := r.Reconcile(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ws = &v1alpha1.WebhookSecret{}
+	err = r.kubeClient.Get(context.Background(), req.NamespacedName, ws)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ws.Status.LastRotationRequest != "2020-07-01" {
+		t.Errorf("LastRotationRequest got %#v, want %#v", ws.Status.LastRotationRequest, "2020-07-01")
+	}
+	assertSecretToken(t, r.kubeClient, stubSecret)
+	hc.assertHookUpdated(testWebhookID, testHookEndpoint, stubSecret)
+
+	hc.updated = map[string]string{}
+	_, err = r.Reconcile(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if l := len(hc.updated); l != 0 {
+		t.Fatalf("%d hooks were updated", l)
+	}
+}
+
+// With a grace period, the previous secret should be kept in the Secret when
+// the secret is rotated, and removed when the grace period has passed.
+func TestWebhookSecretControllerWithRotationGracePeriod(t *testing.T) {
+	logf.SetLogger(logf.ZapLogger(true))
+	ws := makeWebhookSecret(v1alpha1.HookRoute{
+		HookURL: testHookEndpoint,
+	})
+	ws.ObjectMeta.Annotations = map[string]string{v1alpha1.RotateAnnotation: "1"}
+	ws.Spec.Rotation = &v1alpha1.RotationSpec{GracePeriod: &metav1.Duration{Duration: time.Hour}}
+	ws.Status.WebhookID = testWebhookID
+	_, r := makeReconciler(t, ws, ws, makeTestSecret(testAuthSecretName), makeGeneratedSecret("existing-secret"))
+	hc := r.gitClientFactory.(*stubClientFactory).client
+	hc.addHook(&git.Hook{ID: testWebhookID, URL: testHookEndpoint, Events: []string{"push"}, Active: true, Driver: scm.DriverGithub})
+	req := makeReconcileRequest()
+
+	res, err := r.Reconcile(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.RequeueAfter > time.Hour {
+		t.Errorf("got RequeueAfter %v, want less than the grace period", res.RequeueAfter)
+	}
+	assertSecretData(t, r.kubeClient, map[string][]byte{
+		"token":          []byte(stubSecret),
+		"token.previous": []byte("existing-secret"),
+	})
+
+	ws = &v1alpha1.WebhookSecret{}
+	err = r.kubeClient.Get(context.Background(), req.NamespacedName, ws)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expired := metav1.NewTime(time.Now().Add(-2 * time.Hour))
+	ws.Status.LastRotationTime = &expired
+	if err := r.kubeClient.Status().Update(context.Background(), ws); err != nil {
+		t.Fatal(err)
+	}
+	assertEvents(t, r.recorder, "Normal SecretRotated Rotated the secret for webhook 1234567")
+
+	_, err = r.Reconcile(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertSecretData(t, r.kubeClient, map[string][]byte{
+		"token": []byte(stubSecret),
+	})
+	assertEvents(t, r.recorder, "Normal SecretPruned Removed the previous secret from Secret test-webhook-secret")
+}
+
+// Even without a grace period, both secrets should be in the Secret before the
+// webhook is updated, so that receivers accept deliveries signed with the new
+// secret.
+func TestWebhookSecretControllerWithRotationUpdatesSecretFirst(t *testing.T) {
+	logf.SetLogger(logf.ZapLogger(true))
+	ws := makeWebhookSecret(v1alpha1.HookRoute{
+		HookURL: testHookEndpoint,
+	})
+	ws.ObjectMeta.Annotations = map[string]string{v1alpha1.RotateAnnotation: "1"}
+	ws.Status.WebhookID = testWebhookID
+	_, r := makeReconciler(t, ws, ws, makeTestSecret(testAuthSecretName), makeGeneratedSecret("existing-secret"))
+	hc := r.gitClientFactory.(*stubClientFactory).client
+	hc.addHook(&git.Hook{ID: testWebhookID, URL: testHookEndpoint, Events: []string{"push"}, Active: true, Driver: scm.DriverGithub})
+	hc.onUpdate = func() {
+		assertSecretData(t, r.kubeClient, map[string][]byte{
+			"token":          []byte(stubSecret),
+			"token.previous": []byte("existing-secret"),
+		})
+	}
+
+	_, err := r.Reconcile(makeReconcileRequest())
+	if err != nil {
+		t.Fatal(err)
+	}
+	hc.assertHookUpdated(testWebhookID, testHookEndpoint, stubSecret)
+}
+
+// If the webhook can't be updated, the Secret should be reverted to the
+// existing secret.
+func TestWebhookSecretControllerWithRotationRevertsSecret(t *testing.T) {
+	logf.SetLogger(logf.ZapLogger(true))
+	ws := makeWebhookSecret(v1alpha1.HookRoute{
+		HookURL: testHookEndpoint,
+	})
+	ws.ObjectMeta.Annotations = map[string]string{v1alpha1.RotateAnnotation: "1"}
+	ws.Spec.Rotation = &v1alpha1.RotationSpec{GracePeriod: &metav1.Duration{Duration: time.Hour}}
+	ws.Status.WebhookID = testWebhookID
+	_, r := makeReconciler(t, ws, ws, makeTestSecret(testAuthSecretName), makeGeneratedSecret("existing-secret"))
+	hc := r.gitClientFactory.(*stubClientFactory).client
+	hc.addHook(&git.Hook{ID: testWebhookID, URL: testHookEndpoint, Events: []string{"push"}, Active: true, Driver: scm.DriverGithub})
+	hc.updateErr = git.SCMError{Status: http.StatusInternalServerError}
+
+	_, err := r.Reconcile(makeReconcileRequest())
+	if git.ErrorStatus(err) != http.StatusInternalServerError {
+		t.Fatalf("expected a webhook error, got %#v", err)
+	}
+	assertSecretData(t, r.kubeClient, map[string][]byte{
+		"token": []byte("existing-secret"),
+	})
+	loaded := &v1alpha1.WebhookSecret{}
+	if err := r.kubeClient.Get(context.TODO(), makeReconcileRequest().NamespacedName, loaded); err != nil {
+		t.Fatal(err)
+	}
+	if loaded.Status.LastRotationRequest != "" {
+		t.Fatalf("rotation was recorded, got %#v", loaded.Status.LastRotationRequest)
+	}
+}
+
+// If the secret isn't due to be rotated, the Secret should not be changed.
+func TestWebhookSecretControllerWithRotationNotDue(t *testing.T) {
+	logf.SetLogger(logf.ZapLogger(true))
+	ws := makeWebhookSecret(v1alpha1.HookRoute{
+		HookURL: testHookEndpoint,
+	})
+	ws.Spec.Rotation = &v1alpha1.RotationSpec{Interval: &metav1.Duration{Duration: 24 * time.Hour}}
+	lastRotation := metav1.NewTime(time.Now().Add(-1 * time.Hour))
+	ws.Status.LastRotationTime = &lastRotation
+	ws.Status.WebhookID = testWebhookID
+	_, r := makeReconciler(t, ws, ws, makeTestSecret(testAuthSecretName), makeGeneratedSecret("existing-secret"))
+	hc := r.gitClientFactory.(*stubClientFactory).client
+	hc.addHook(&git.Hook{ID: testWebhookID, URL: testHookEndpoint, Events: []string{"push"}, Active: true, Driver: scm.DriverGithub})
+	req := makeReconcileRequest()
+
+	_, err := r.Reconcile(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertSecretToken(t, r.kubeClient, "existing-secret")
+	if l := len(hc.updated); l != 0 {
+		t.Fatalf("%d hooks were updated", l)
+	}
 }
 
 // When a WebhookSecret is deleted, it should cleanup the webhook in the
@@ -240,6 +853,7 @@ func TestWebhookSecretControllerDeletedWebhookSecret(t *testing.T) {
 		t.Fatalf("secret still exists %v", err)
 	}
 	r.gitClientFactory.(*stubClientFactory).client.assertHookDeleted(testWebhookID)
+	assertEvents(t, r.recorder, "Normal WebhookDeleted Deleted webhook 1234567")
 }
 
 // When a WebhookSecret is deleted, if the webhook can't be deleted, this should
@@ -267,6 +881,7 @@ func TestWebhookSecretControllerFailingToDeleteTheWebhook(t *testing.T) {
 	if err != nil {
 		t.Fatal(err)
 	}
+	assertEvents(t, r.recorder)
 }
 
 // When deleting the WebhookSecret, if the secret no longer exists, we won't be
@@ -300,7 +915,7 @@ func makeWebhookSecret(r v1alpha1.HookRoute) *v1alpha1.WebhookSecret {
 			Repo: v1alpha1.Repo{
 				URL: testRepoURL,
 			},
-			AuthSecretRef: v1alpha1.WebhookSecretRef{
+			AuthSecretRef: v1alpha1.AuthSecretRef{
 				Name: testAuthSecretName,
 			},
 			WebhookURL: r,
@@ -321,13 +936,19 @@ func makeReconcileRequest() reconcile.Request {
 type stubClientFactory struct {
 	client    *stubHookClient
 	authToken string
+	// repoClients are the clients for specific repository URLs, client is
+	// used for all other repositories.
+	repoClients map[string]*stubHookClient
 }
 
 // TODO: ensure that this can fail to find a client.
-func (s stubClientFactory) ClientForRepo(r v1alpha1.Repo, token string) (git.HooksClient, error) {
-	if token != s.authToken {
+func (s stubClientFactory) ClientForRepo(r v1alpha1.Repo, creds git.Credentials) (git.HooksClient, error) {
+	if creds.Token != s.authToken {
 		return nil, errors.New("failed to authenticate")
 	}
+	if c, ok := s.repoClients[r.URL]; ok {
+		return c, nil
+	}
 	return s.client, nil
 }
 
@@ -335,7 +956,10 @@ func newStubHookClient(t *testing.T, repo, hookID string) *stubHookClient {
 	return &stubHookClient{
 		hookID:  hookID,
 		repo:    repo,
+		hooks:   make(map[string]*git.Hook),
 		created: make(map[string]string),
+		updated: make(map[string]string),
+		events:  make(map[string][]v1alpha1.HookEvent),
 		deleted: make(map[string]string),
 		t:       t,
 	}
@@ -347,25 +971,105 @@ type stubHookClient struct {
 	t         *testing.T
 	repo      string
 	hookID    string
+	hooks     map[string]*git.Hook
 	created   map[string]string
+	updated   map[string]string
+	events    map[string][]v1alpha1.HookEvent
 	deleted   map[string]string
+	createErr error
+	updateErr error
 	deleteErr error
+	// onUpdate is called before a hook is updated.
+	onUpdate func()
 }
 
 // TODO: revisit use of s.repo
-func (s *stubHookClient) Create(ctx context.Context, hookURL, secret string) (string, error) {
+func (s *stubHookClient) Create(ctx context.Context, hookURL, secret string, events []v1alpha1.HookEvent) (string, error) {
+	if s.createErr != nil {
+		return "", s.createErr
+	}
 	s.created[key(s.repo, hookURL)] = secret
+	s.events[key(s.repo, hookURL)] = events
+	s.addHook(&git.Hook{ID: s.hookID, URL: hookURL, Events: hookEvents(events), Active: true, Driver: scm.DriverGithub})
+	return s.hookID, nil
+}
+
+func (s *stubHookClient) Update(ctx context.Context, hookID, hookURL, secret string, events []v1alpha1.HookEvent) (string, error) {
+	if s.onUpdate != nil {
+		s.onUpdate()
+	}
+	if s.updateErr != nil {
+		return "", s.updateErr
+	}
+	s.updated[key(s.repo, hookID, hookURL)] = secret
+	s.events[key(s.repo, hookURL)] = events
 	return s.hookID, nil
 }
 
+func (s *stubHookClient) Get(ctx context.Context, hookID string) (*git.Hook, error) {
+	h, ok := s.hooks[hookID]
+	if !ok {
+		return nil, git.SCMError{Status: http.StatusNotFound}
+	}
+	return h, nil
+}
+
+func (s *stubHookClient) List(ctx context.Context) ([]*git.Hook, error) {
+	hooks := []*git.Hook{}
+	for _, h := range s.hooks {
+		hooks = append(hooks, h)
+	}
+	return hooks, nil
+}
+
 func (s *stubHookClient) Delete(ctx context.Context, hookID string) error {
 	if s.deleteErr != nil {
 		return s.deleteErr
 	}
 	s.deleted[s.repo] = hookID
+	delete(s.hooks, hookID)
 	return nil
 }
 
+func (s *stubHookClient) addHook(h *git.Hook) {
+	s.hooks[h.ID] = h
+}
+
+// hookEvents returns the names that GitHub reports for simple events.
+func hookEvents(events []v1alpha1.HookEvent) []string {
+	if len(events) == 0 {
+		return []string{"push"}
+	}
+	names := []string{}
+	for _, e := range events {
+		names = append(names, string(e))
+	}
+	return names
+}
+
+// statusFailingClient is a client that fails to update the status of
+// resources.
+type statusFailingClient struct {
+	client.Client
+	err error
+}
+
+func (c statusFailingClient) Status() client.StatusWriter {
+	return failingStatusWriter{err: c.err}
+}
+
+type failingStatusWriter struct {
+	err error
+}
+
+func (w failingStatusWriter) Update(ctx context.Context, obj runtime.Object, opts ...client.UpdateOption) error {
+	return w.err
+}
+
+func (w failingStatusWriter) Patch(ctx context.Context, obj runtime.Object, patch client.Patch, opts ...client.PatchOption) error {
+	return w.err
+}
+
 func (s *stubHookClient) assertHookCreated(hookURL, wantSecret string) {
 	s.t.Helper()
 	secret := s.created[key(s.repo, hookURL)]
@@ -374,6 +1078,22 @@ func (s *stubHookClient) assertHookCreated(hookURL, wantSecret string) {
 	}
 }
 
+func (s *stubHookClient) assertHookUpdated(hookID, hookURL, wantSecret string) {
+	s.t.Helper()
+	secret := s.updated[key(s.repo, hookID, hookURL)]
+	if secret != wantSecret {
+		s.t.Fatalf("hook update failed: got %#v, want %#v", secret, wantSecret)
+	}
+}
+
+func (s *stubHookClient) assertHookEvents(hookURL string, want []v1alpha1.HookEvent) {
+	s.t.Helper()
+	events := s.events[key(s.repo, hookURL)]
+	if !reflect.DeepEqual(events, want) {
+		s.t.Fatalf("hook events failed: got %#v, want %#v", events, want)
+	}
+}
+
 func (s *stubHookClient) assertHookDeleted(wantHookID string) {
 	deletedID := s.deleted[s.repo]
 	if deletedID != wantHookID {
@@ -381,6 +1101,13 @@ func (s *stubHookClient) assertHookDeleted(wantHookID string) {
 	}
 }
 
+func (s *stubHookClient) assertNoHookDeleted() {
+	s.t.Helper()
+	if l := len(s.deleted); l != 0 {
+		s.t.Fatalf("%d hooks were deleted", l)
+	}
+}
+
 func (s *stubHookClient) assertNoHookCreated() {
 	s.t.Helper()
 	if l := len(s.created); l != 0 {
@@ -408,10 +1135,72 @@ func makeTestSecret(n string) *corev1.Secret {
 	}
 }
 
+func assertCondition(t *testing.T, ws *v1alpha1.WebhookSecret, ct v1alpha1.ConditionType, status metav1.ConditionStatus, reason string) {
+	t.Helper()
+	c := findCondition(ws, ct)
+	if c == nil {
+		t.Fatalf("condition %s not found in %#v", ct, ws.Status.Conditions)
+	}
+	if c.Status != status || c.Reason != reason {
+		t.Fatalf("condition %s got %s/%s, want %s/%s", ct, c.Status, c.Reason, status, reason)
+	}
+}
+
+func assertSecretToken(t *testing.T, cl client.Client, want string) {
+	t.Helper()
+	s := &corev1.Secret{}
+	err := cl.Get(context.Background(), types.NamespacedName{Name: testWebhookSecretName, Namespace: testWebhookSecretNamespace}, s)
+	if err != nil {
+		t.Fatalf("get secret: %v", err)
+	}
+	if token := string(s.Data["token"]); token != want {
+		t.Fatalf("secret token got %#v, want %#v", token, want)
+	}
+}
+
+func assertSecretData(t *testing.T, cl client.Client, want map[string][]byte) {
+	t.Helper()
+	s := &corev1.Secret{}
+	err := cl.Get(context.Background(), types.NamespacedName{Name: testWebhookSecretName, Namespace: testWebhookSecretNamespace}, s)
+	if err != nil {
+		t.Fatalf("get secret: %v", err)
+	}
+	if diff := cmp.Diff(want, s.Data); diff != "" {
+		t.Fatalf("secret data failed:\n%s", diff)
+	}
+}
+
+// assertEvents checks that exactly the wanted events were recorded, in order.
+func assertEvents(t *testing.T, r record.EventRecorder, want ...string) {
+	t.Helper()
+	events := r.(*record.FakeRecorder).Events
+	got := []string{}
+	for len(events) > 0 {
+		got = append(got, <-events)
+	}
+	if diff := cmp.Diff(want, got, cmpopts.EquateEmpty()); diff != "" {
+		t.Fatalf("events failed:\n%s", diff)
+	}
+}
+
+func makeGeneratedSecret(token string) *corev1.Secret {
+	return &corev1.Secret{
+		TypeMeta: secretTypeMeta,
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      testWebhookSecretName,
+			Namespace: testWebhookSecretNamespace,
+		},
+		Data: map[string][]byte{
+			"token": []byte(token),
+		},
+	}
+}
+
 func makeReconciler(t *testing.T, ws *v1alpha1.WebhookSecret, objs ...runtime.Object) (client.Client, *ReconcileWebhookSecret) {
 	t.Helper()
 	s := scheme.Scheme
-	s.AddKnownTypes(v1alpha1.SchemeGroupVersion, ws)
+	s.AddKnownTypes(v1alpha1.SchemeGroupVersion, ws, &v1alpha1.WebhookSecretList{},
+		&v1alpha1.AuthSecretGrant{}, &v1alpha1.AuthSecretGrantList{})
 	if err := routev1.AddToScheme(s); err != nil {
 		t.Fatal(err)
 	}
@@ -425,8 +1214,16 @@ func makeReconciler(t *testing.T, ws *v1alpha1.WebhookSecret, objs ...runtime.Ob
 				return stubSecret, nil
 			},
 		},
-		routeGetter:      routes.New(cl),
-		gitClientFactory: &stubClientFactory{client: newStubHookClient(t, testRepo, testWebhookID), authToken: testAuthToken},
-		authSecretGetter: secrets.New(cl),
+		routeGetter:          routes.New(cl),
+		ingressGetter:        routes.NewIngressGetter(cl),
+		httpRouteGetter:      routes.NewHTTPRouteGetter(cl),
+		serviceGetter:        routes.NewServiceGetter(cl),
+		knativeServiceGetter: routes.NewKnativeServiceGetter(cl),
+		eventListenerGetter:  routes.NewEventListenerGetter(cl),
+		gitClientFactory:     &stubClientFactory{client: newStubHookClient(t, testRepo, testWebhookID), authToken: testAuthToken},
+		authSecretGetter:     secrets.New(cl),
+		recorder:             record.NewFakeRecorder(20),
+
+		providerLoader: providers.NewLoader(cl, git.NewProviderRegistry(), testProvidersConfigMap),
 	}
 }
@@ -0,0 +1,231 @@
+package webhooksecretset
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	v1alpha1 "github.com/bigkevmcd/webhook-secret-operator/pkg/apis/apps/v1alpha1"
+	"github.com/bigkevmcd/webhook-secret-operator/pkg/git"
+)
+
+// maxNameLength is the longest name that a WebhookSecret can have, the
+// generated Secret has the same name.
+const maxNameLength = 253
+
+// reconcileChildren ensures that there's a WebhookSecret for each of the
+// repos, and deletes the WebhookSecrets for repositories that no longer
+// match.
+//
+// A failure for one repository doesn't stop the other repositories from being
+// reconciled, the failures are recorded as events and returned together.
+//
+// The WebhookSecrets are controlled by the WebhookSecretSet, so they're
+// garbage collected when it's deleted, and the webhooks are deleted by the
+// WebhookSecrets' finalizers.
+func (r *ReconcileWebhookSecretSet) reconcileChildren(ctx context.Context, logger logr.Logger, set *v1alpha1.WebhookSecretSet, repos []*git.RemoteRepository) error {
+	existing, err := r.children(ctx, set)
+	if err != nil {
+		return err
+	}
+	failed := []string{}
+	childFailed := func(err error) {
+		r.recorder.Event(set, corev1.EventTypeWarning, reasonWebhookSecretFailed, err.Error())
+		failed = append(failed, err.Error())
+	}
+	created := []v1alpha1.SetWebhookSecret{}
+	for _, repo := range repos {
+		child := newChild(set, repo)
+		if err := controllerutil.SetControllerReference(set, child, r.scheme); err != nil {
+			return err
+		}
+		found, ok := existing[repo.URL]
+		delete(existing, repo.URL)
+		if !ok {
+			logger.Info("Creating a WebhookSecret", "name", child.Name, "repo", repo.URL)
+			if err := r.kubeClient.Create(ctx, child); err != nil {
+				childFailed(fmt.Errorf("failed to create WebhookSecret %s for %s: %w", child.Name, repo.URL, err))
+				continue
+			}
+			r.recorder.Eventf(set, corev1.EventTypeNormal, reasonWebhookSecretCreated, "Created WebhookSecret %s for %s", child.Name, repo.URL)
+		} else if updateChild(found, child) {
+			logger.Info("Updating a WebhookSecret", "name", found.Name, "repo", repo.URL)
+			child = found
+			if err := r.kubeClient.Update(ctx, found); err != nil {
+				childFailed(fmt.Errorf("failed to update WebhookSecret %s for %s: %w", found.Name, repo.URL, err))
+			} else {
+				r.recorder.Eventf(set, corev1.EventTypeNormal, reasonWebhookSecretUpdated, "Updated WebhookSecret %s for %s", found.Name, repo.URL)
+			}
+		} else {
+			child = found
+		}
+		created = append(created, v1alpha1.SetWebhookSecret{Name: child.Name, RepoURL: repo.URL})
+	}
+
+	unmatched := []string{}
+	for repoURL := range existing {
+		unmatched = append(unmatched, repoURL)
+	}
+	sort.Strings(unmatched)
+	for _, repoURL := range unmatched {
+		child := existing[repoURL]
+		logger.Info("Deleting a WebhookSecret", "name", child.Name, "repo", repoURL)
+		if err := r.kubeClient.Delete(ctx, child); err != nil && !errors.IsNotFound(err) {
+			childFailed(fmt.Errorf("failed to delete WebhookSecret %s for %s: %w", child.Name, repoURL, err))
+			created = append(created, v1alpha1.SetWebhookSecret{Name: child.Name, RepoURL: repoURL})
+			continue
+		}
+		r.recorder.Eventf(set, corev1.EventTypeNormal, reasonWebhookSecretDeleted, "Deleted WebhookSecret %s, %s no longer matches", child.Name, repoURL)
+	}
+	set.Status.WebhookSecrets = created
+
+	if len(failed) > 0 {
+		return fmt.Errorf("failed to reconcile the WebhookSecrets for %d repositories: %s", len(failed), strings.Join(failed, "; "))
+	}
+	return nil
+}
+
+// children returns the WebhookSecrets that are controlled by the
+// WebhookSecretSet, by the URL of their repository.
+func (r *ReconcileWebhookSecretSet) children(ctx context.Context, set *v1alpha1.WebhookSecretSet) (map[string]*v1alpha1.WebhookSecret, error) {
+	list := &v1alpha1.WebhookSecretList{}
+	if err := r.kubeClient.List(ctx, list, client.InNamespace(set.Namespace)); err != nil {
+		return nil, fmt.Errorf("failed to list WebhookSecrets in %s: %w", set.Namespace, err)
+	}
+	children := map[string]*v1alpha1.WebhookSecret{}
+	for i := range list.Items {
+		ws := &list.Items[i]
+		if metav1.IsControlledBy(ws, set) {
+			children[ws.Spec.Repo.URL] = ws
+		}
+	}
+	return children, nil
+}
+
+// newChild returns the WebhookSecret for a repository, from the template of
+// the WebhookSecretSet.
+//
+// Any repo or repos in the template are replaced with the repository, with
+// the driver and endpoint of the owner.
+func newChild(set *v1alpha1.WebhookSecretSet, repo *git.RemoteRepository) *v1alpha1.WebhookSecret {
+	spec := set.Spec.Template.Spec.DeepCopy()
+	spec.Repo = v1alpha1.Repo{
+		URL:      repo.URL,
+		Driver:   set.Spec.Owner.Driver,
+		Endpoint: set.Spec.Owner.Endpoint,
+	}
+	spec.Repos = nil
+	meta := set.Spec.Template.Metadata.DeepCopy()
+	return &v1alpha1.WebhookSecret{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: v1alpha1.SchemeGroupVersion.String(),
+			Kind:       "WebhookSecret",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        childName(set, repo),
+			Namespace:   set.Namespace,
+			Labels:      meta.Labels,
+			Annotations: meta.Annotations,
+		},
+		Spec: *spec,
+	}
+}
+
+// updateChild updates the existing WebhookSecret with the spec, labels and
+// annotations from the template, returning true if it was changed.
+//
+// Labels and annotations that aren't in the template are kept, so that the
+// RotateAnnotation can be set on a WebhookSecret.
+func updateChild(existing, child *v1alpha1.WebhookSecret) bool {
+	changed := false
+	if !equality.Semantic.DeepEqual(existing.Spec, child.Spec) {
+		existing.Spec = child.Spec
+		changed = true
+	}
+	if mergeStrings(&existing.Labels, child.Labels) {
+		changed = true
+	}
+	if mergeStrings(&existing.Annotations, child.Annotations) {
+		changed = true
+	}
+	return changed
+}
+
+// mergeStrings sets the values in the map, returning true if any of them
+// changed.
+func mergeStrings(m *map[string]string, values map[string]string) bool {
+	changed := false
+	for k, v := range values {
+		if existing, ok := (*m)[k]; ok && existing == v {
+			continue
+		}
+		if *m == nil {
+			*m = map[string]string{}
+		}
+		(*m)[k] = v
+		changed = true
+	}
+	return changed
+}
+
+// childName returns the name of the WebhookSecret for a repository, which is
+// the name of the WebhookSecretSet and the path of the repository within the
+// owner, e.g. my-set-service-a for service-a.
+//
+// Paths that aren't valid in a Kubernetes name, e.g. team/Service_A, are
+// normalised, and names that would be too long are truncated, in both cases a
+// hash of the repository's URL is added so that different repositories can't
+// get the same name.
+func childName(set *v1alpha1.WebhookSecretSet, repo *git.RemoteRepository) string {
+	relative := relativeName(set, repo)
+	name := set.Name + "-" + dnsName(relative)
+	if dnsName(relative) == relative && len(name) <= maxNameLength {
+		return name
+	}
+	hash := fmt.Sprintf("%x", sha256.Sum256([]byte(repo.URL)))[:8]
+	if len(name) > maxNameLength-len(hash)-1 {
+		name = strings.TrimRight(name[:maxNameLength-len(hash)-1], "-.")
+	}
+	return name + "-" + hash
+}
+
+// relativeName returns the path of the repository within the owner, GitLab
+// projects can be in subgroups of the owner.
+//
+// The owner in the URL might not have the same case as the git host reports.
+func relativeName(set *v1alpha1.WebhookSecretSet, repo *git.RemoteRepository) string {
+	owner, err := git.ParseOwner(set.Spec.Owner.Driver, set.Spec.Owner.URL)
+	if err != nil {
+		return repo.Name
+	}
+	prefix := owner.Owner + "/"
+	if len(repo.FullName) <= len(prefix) || !strings.EqualFold(repo.FullName[:len(prefix)], prefix) {
+		return repo.Name
+	}
+	return repo.FullName[len(prefix):]
+}
+
+// dnsName returns the name in lower case, with the characters that aren't
+// allowed in Kubernetes names replaced with "-".
+func dnsName(s string) string {
+	name := strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9':
+			return r
+		case r >= 'A' && r <= 'Z':
+			return r - 'A' + 'a'
+		}
+		return '-'
+	}, s)
+	return strings.Trim(name, "-")
+}
@@ -0,0 +1,83 @@
+package webhooksecretset
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	v1alpha1 "github.com/bigkevmcd/webhook-secret-operator/pkg/apis/apps/v1alpha1"
+	"github.com/bigkevmcd/webhook-secret-operator/pkg/git"
+)
+
+func TestChildName(t *testing.T) {
+	nameTests := []struct {
+		ownerURL string
+		repo     *git.RemoteRepository
+		want     string
+	}{
+		{"https://github.com/my-org", &git.RemoteRepository{Name: "service-a", FullName: "my-org/service-a", URL: "https://github.com/my-org/service-a"}, "test-set-service-a"},
+		{"https://github.com/My-Org", &git.RemoteRepository{Name: "Service_A", FullName: "my-org/Service_A", URL: "https://github.com/my-org/Service_A"}, "test-set-service-a-755723a2"},
+		{"https://gitlab.com/group/sub", &git.RemoteRepository{Name: "api.v2", FullName: "group/sub/team/api.v2", URL: "https://gitlab.com/group/sub/team/api.v2"}, "test-set-team-api-v2-bed6aea5"},
+		{"https://gitlab.com/group/sub", &git.RemoteRepository{Name: "api", FullName: "other/api", URL: "https://gitlab.com/other/api"}, "test-set-api"},
+	}
+
+	for _, tt := range nameTests {
+		t.Run(tt.repo.FullName, func(rt *testing.T) {
+			set := makeWebhookSecretSet()
+			set.Spec.Owner.URL = tt.ownerURL
+			if got := childName(set, tt.repo); got != tt.want {
+				rt.Errorf("childName() got %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestChildNameTruncatesLongNames(t *testing.T) {
+	set := makeWebhookSecretSet()
+	long := strings.Repeat("a", 300)
+	first := childName(set, &git.RemoteRepository{Name: long, FullName: "my-org/" + long, URL: "https://github.com/my-org/" + long})
+	second := childName(set, &git.RemoteRepository{Name: long + "b", FullName: "my-org/" + long + "b", URL: "https://github.com/my-org/" + long + "b"})
+
+	if len(first) > maxNameLength {
+		t.Fatalf("got a name with %d characters, want no more than %d", len(first), maxNameLength)
+	}
+	if first == second {
+		t.Fatalf("truncated names are not unique, got %#v", first)
+	}
+}
+
+func TestChildNameWithNormalisedNames(t *testing.T) {
+	set := makeWebhookSecretSet()
+	first := childName(set, &git.RemoteRepository{Name: "service-a", FullName: "my-org/service-a", URL: "https://github.com/my-org/service-a"})
+	second := childName(set, &git.RemoteRepository{Name: "Service_A", FullName: "my-org/Service_A", URL: "https://github.com/my-org/Service_A"})
+
+	if first == second {
+		t.Fatalf("normalised names are not unique, got %#v", first)
+	}
+}
+
+func TestUpdateChild(t *testing.T) {
+	set := makeWebhookSecretSet()
+	set.Spec.Template.Metadata.Annotations = map[string]string{"team": "a"}
+	repo := &git.RemoteRepository{Name: "service-a", FullName: "my-org/service-a", URL: "https://github.com/my-org/service-a"}
+	existing := newChild(set, repo)
+	existing.Annotations[v1alpha1.RotateAnnotation] = "1"
+
+	if updateChild(existing, newChild(set, repo)) {
+		t.Fatal("child was updated with no changes")
+	}
+
+	set.Spec.Template.Spec.Events = []v1alpha1.HookEvent{v1alpha1.PushEvent}
+	set.Spec.Template.Metadata.Annotations["team"] = "b"
+	if !updateChild(existing, newChild(set, repo)) {
+		t.Fatal("child was not updated")
+	}
+	if diff := cmp.Diff(set.Spec.Template.Spec.Events, existing.Spec.Events); diff != "" {
+		t.Fatalf("events were not updated:\n%s", diff)
+	}
+	want := map[string]string{"team": "b", v1alpha1.RotateAnnotation: "1"}
+	if diff := cmp.Diff(want, existing.Annotations); diff != "" {
+		t.Fatalf("annotations were not merged:\n%s", diff)
+	}
+}
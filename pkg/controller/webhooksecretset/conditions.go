@@ -0,0 +1,52 @@
+package webhooksecretset
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	v1alpha1 "github.com/bigkevmcd/webhook-secret-operator/pkg/apis/apps/v1alpha1"
+	"github.com/bigkevmcd/webhook-secret-operator/pkg/controller/common"
+)
+
+// Reasons for the conditions and events on a WebhookSecretSet.
+const (
+	reasonAuthResolved         = common.ReasonAuthResolved
+	reasonAuthSecretNotFound   = common.ReasonAuthSecretNotFound
+	reasonAuthSecretInvalid    = common.ReasonAuthSecretInvalid
+	reasonAuthSecretMalformed  = common.ReasonAuthSecretMalformed
+	reasonAuthSecretNotGranted = common.ReasonAuthSecretNotGranted
+	reasonUnknownDriver        = "UnknownDriver"
+	reasonUnsupportedListing   = "UnsupportedListing"
+	reasonSelectorInvalid      = "SelectorInvalid"
+	reasonReposListed          = "ReposListed"
+	reasonListFailed           = "ListFailed"
+	reasonListDenied           = "ListPermissionDenied"
+	reasonWebhookSecretCreated = "WebhookSecretCreated"
+	reasonWebhookSecretUpdated = "WebhookSecretUpdated"
+	reasonWebhookSecretDeleted = "WebhookSecretDeleted"
+	reasonWebhookSecretFailed  = "WebhookSecretFailed"
+	reasonReady                = common.ReasonReady
+	reasonPending              = common.ReasonPending
+)
+
+// readyConditions are the conditions that must all be true for the
+// WebhookSecretSet to be Ready.
+var readyConditions = []v1alpha1.ConditionType{
+	v1alpha1.AuthResolvedCondition,
+	v1alpha1.ReposListedCondition,
+}
+
+// setCondition adds or updates a condition on the WebhookSecretSet.
+func setCondition(set *v1alpha1.WebhookSecretSet, t v1alpha1.ConditionType, status metav1.ConditionStatus, reason, message string) {
+	common.SetCondition(&set.Status.Conditions, set.Generation, t, status, reason, message)
+}
+
+// findCondition returns the condition with the type, or nil if it's not set.
+func findCondition(set *v1alpha1.WebhookSecretSet, t v1alpha1.ConditionType) *v1alpha1.Condition {
+	return common.FindCondition(set.Status.Conditions, t)
+}
+
+// setReadyCondition sets the Ready condition from the other conditions, in
+// the same way as for a WebhookSecret.
+func setReadyCondition(set *v1alpha1.WebhookSecretSet) {
+	common.SetReadyCondition(&set.Status.Conditions, set.Generation, readyConditions)
+}
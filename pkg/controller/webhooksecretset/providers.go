@@ -0,0 +1,39 @@
+package webhooksecretset
+
+import (
+	"context"
+
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	v1alpha1 "github.com/bigkevmcd/webhook-secret-operator/pkg/apis/apps/v1alpha1"
+)
+
+// providersMapper maps changes to the providers ConfigMap to all
+// WebhookSecretSets, as any of them could have an owner on a self-hosted git
+// server.
+type providersMapper struct {
+	kubeClient client.Client
+	configMap  types.NamespacedName
+}
+
+// Map implements the handler.Mapper interface.
+func (m *providersMapper) Map(obj handler.MapObject) []reconcile.Request {
+	if obj.Meta.GetName() != m.configMap.Name || obj.Meta.GetNamespace() != m.configMap.Namespace {
+		return nil
+	}
+	list := &v1alpha1.WebhookSecretSetList{}
+	if err := m.kubeClient.List(context.TODO(), list); err != nil {
+		log.Error(err, "failed to list WebhookSecretSets for the providers ConfigMap")
+		return nil
+	}
+	requests := []reconcile.Request{}
+	for _, set := range list.Items {
+		requests = append(requests, reconcile.Request{
+			NamespacedName: types.NamespacedName{Name: set.Name, Namespace: set.Namespace},
+		})
+	}
+	return requests
+}
@@ -0,0 +1,67 @@
+package webhooksecretset
+
+import (
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"github.com/bigkevmcd/webhook-secret-operator/pkg/git"
+	"github.com/bigkevmcd/webhook-secret-operator/pkg/providers"
+)
+
+var _ handler.Mapper = (*providersMapper)(nil)
+
+// The providers for self-hosted git servers are loaded before the
+// repositories are listed.
+func TestWebhookSecretSetControllerLoadsProviders(t *testing.T) {
+	set := makeWebhookSecretSet()
+	_, r := makeReconciler(t, set, makeTestSecret(testAuthSecretName),
+		makeProvidersConfigMap("- host: github.example.com\n  driver: github\n"))
+
+	if _, err := r.Reconcile(makeReconcileRequest()); err != nil {
+		t.Fatal(err)
+	}
+
+	want := git.Provider{Host: "github.example.com", Driver: "github"}
+	if p, ok := r.providerLoader.Registry().Lookup("github.example.com"); !ok || p != want {
+		t.Fatalf("Lookup() got %#v, want %#v", p, want)
+	}
+}
+
+func TestProvidersMapper(t *testing.T) {
+	set := makeWebhookSecretSet()
+	cl, _ := makeReconciler(t, set)
+	m := &providersMapper{kubeClient: cl, configMap: testProvidersConfigMap}
+
+	cm := makeProvidersConfigMap("")
+	requests := m.Map(handler.MapObject{Meta: cm, Object: cm})
+	want := []reconcile.Request{makeReconcileRequest()}
+	if !reflect.DeepEqual(requests, want) {
+		t.Fatalf("got %#v, want %#v", requests, want)
+	}
+
+	cm.Name = "other-config-map"
+	if requests := m.Map(handler.MapObject{Meta: cm, Object: cm}); len(requests) != 0 {
+		t.Fatalf("got %#v for an unrelated ConfigMap", requests)
+	}
+}
+
+func makeProvidersConfigMap(data string) *corev1.ConfigMap {
+	return &corev1.ConfigMap{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "v1",
+			Kind:       "ConfigMap",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      testProvidersConfigMap.Name,
+			Namespace: testProvidersConfigMap.Namespace,
+		},
+		Data: map[string]string{
+			providers.Key: data,
+		},
+	}
+}
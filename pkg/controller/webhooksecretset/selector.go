@@ -0,0 +1,73 @@
+package webhooksecretset
+
+import (
+	"fmt"
+	"regexp"
+
+	v1alpha1 "github.com/bigkevmcd/webhook-secret-operator/pkg/apis/apps/v1alpha1"
+	"github.com/bigkevmcd/webhook-secret-operator/pkg/git"
+)
+
+// repoSelector matches repositories against a RepoSelector.
+type repoSelector struct {
+	name       *regexp.Regexp
+	topics     []string
+	visibility string
+	archived   bool
+}
+
+// newRepoSelector returns a repoSelector, or an error if the name isn't a
+// valid regular expression.
+func newRepoSelector(s v1alpha1.RepoSelector) (*repoSelector, error) {
+	rs := &repoSelector{topics: s.Topics, visibility: s.Visibility, archived: s.Archived}
+	if s.Name != "" {
+		re, err := regexp.Compile(s.Name)
+		if err != nil {
+			return nil, fmt.Errorf("invalid selector name %#v: %w", s.Name, err)
+		}
+		rs.name = re
+	}
+	return rs, nil
+}
+
+// Matches returns true if the repository matches all the fields of the
+// selector.
+//
+// Archived repositories only match if the selector selects them.
+func (s *repoSelector) Matches(r *git.RemoteRepository) bool {
+	if r.Archived && !s.archived {
+		return false
+	}
+	if s.name != nil && !s.name.MatchString(r.Name) {
+		return false
+	}
+	if s.visibility != "" && s.visibility != r.Visibility {
+		return false
+	}
+	for _, t := range s.topics {
+		if !containsString(r.Topics, t) {
+			return false
+		}
+	}
+	return true
+}
+
+// Select returns the repositories that match the selector.
+func (s *repoSelector) Select(repos []*git.RemoteRepository) []*git.RemoteRepository {
+	matched := []*git.RemoteRepository{}
+	for _, r := range repos {
+		if s.Matches(r) {
+			matched = append(matched, r)
+		}
+	}
+	return matched
+}
+
+func containsString(c []string, s string) bool {
+	for _, item := range c {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
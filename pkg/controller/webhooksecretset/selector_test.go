@@ -0,0 +1,57 @@
+package webhooksecretset
+
+import (
+	"testing"
+
+	v1alpha1 "github.com/bigkevmcd/webhook-secret-operator/pkg/apis/apps/v1alpha1"
+	"github.com/bigkevmcd/webhook-secret-operator/pkg/git"
+	"github.com/bigkevmcd/webhook-secret-operator/test"
+)
+
+func TestRepoSelectorMatches(t *testing.T) {
+	repo := &git.RemoteRepository{
+		Name:       "service-a",
+		FullName:   "my-org/service-a",
+		URL:        "https://github.com/my-org/service-a",
+		Visibility: "private",
+		Topics:     []string{"tekton", "go"},
+	}
+	archived := &git.RemoteRepository{Name: "service-b", Visibility: "private", Archived: true}
+
+	selectorTests := []struct {
+		name     string
+		selector v1alpha1.RepoSelector
+		repo     *git.RemoteRepository
+		want     bool
+	}{
+		{"empty selector", v1alpha1.RepoSelector{}, repo, true},
+		{"matching name", v1alpha1.RepoSelector{Name: "^service-"}, repo, true},
+		{"unmatched name", v1alpha1.RepoSelector{Name: "^api-"}, repo, false},
+		{"matching topics", v1alpha1.RepoSelector{Topics: []string{"tekton", "go"}}, repo, true},
+		{"missing topic", v1alpha1.RepoSelector{Topics: []string{"tekton", "rust"}}, repo, false},
+		{"matching visibility", v1alpha1.RepoSelector{Visibility: "private"}, repo, true},
+		{"unmatched visibility", v1alpha1.RepoSelector{Visibility: "public"}, repo, false},
+		{"archived repo", v1alpha1.RepoSelector{}, archived, false},
+		{"selecting archived repos", v1alpha1.RepoSelector{Archived: true}, archived, true},
+	}
+
+	for _, tt := range selectorTests {
+		t.Run(tt.name, func(rt *testing.T) {
+			s, err := newRepoSelector(tt.selector)
+			if err != nil {
+				rt.Fatal(err)
+			}
+			if got := s.Matches(tt.repo); got != tt.want {
+				rt.Errorf("Matches() got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewRepoSelectorWithInvalidName(t *testing.T) {
+	_, err := newRepoSelector(v1alpha1.RepoSelector{Name: "service-("})
+
+	if !test.MatchError(t, "invalid selector name", err) {
+		t.Fatalf("expected an invalid selector error, got %#v", err)
+	}
+}
@@ -0,0 +1,227 @@
+package webhooksecretset
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	v1alpha1 "github.com/bigkevmcd/webhook-secret-operator/pkg/apis/apps/v1alpha1"
+	"github.com/bigkevmcd/webhook-secret-operator/pkg/controller/common"
+	"github.com/bigkevmcd/webhook-secret-operator/pkg/git"
+	"github.com/bigkevmcd/webhook-secret-operator/pkg/providers"
+	"github.com/bigkevmcd/webhook-secret-operator/pkg/secrets"
+)
+
+var log = logf.Log.WithName("controller_webhooksecretset")
+
+// defaultResyncInterval is how often the repositories are listed if the
+// WebhookSecretSet doesn't have a resync interval, git hosts don't notify
+// the operator when repositories are created.
+const defaultResyncInterval = 15 * time.Minute
+
+// Add creates a new WebhookSecretSet Controller and adds it to the Manager. The Manager will set fields on the Controller
+// and Start it when the Manager is Started.
+func Add(mgr manager.Manager) error {
+	r := newReconciler(mgr)
+	return add(mgr, r, r.providerLoader.ConfigMap())
+}
+
+// newReconciler returns a new reconcile.Reconciler
+func newReconciler(mgr manager.Manager) *ReconcileWebhookSecretSet {
+	registry := git.NewProviderRegistry()
+	return &ReconcileWebhookSecretSet{
+		kubeClient:       mgr.GetClient(),
+		scheme:           mgr.GetScheme(),
+		listerFactory:    git.NewClientFactory(git.NewDriverIdentifier(registry), registry),
+		authSecretGetter: secrets.New(mgr.GetClient()),
+		recorder:         mgr.GetEventRecorderFor("webhooksecretset-controller"),
+		providerLoader:   providers.NewLoader(mgr.GetClient(), registry, providers.ConfigMapID()),
+	}
+}
+
+// add adds a new Controller to mgr with r as the reconcile.Reconciler
+//
+// Only changes to the spec of a WebhookSecretSet, deleted WebhookSecrets and
+// changes to the providers ConfigMap are reconciled, so that the repositories
+// aren't listed each time that the status of one of them changes.
+func add(mgr manager.Manager, r reconcile.Reconciler, providersConfigMap types.NamespacedName) error {
+	c, err := controller.New("webhooksecretset-controller", mgr, controller.Options{Reconciler: r})
+	if err != nil {
+		return err
+	}
+
+	err = c.Watch(&source.Kind{Type: &v1alpha1.WebhookSecretSet{}}, &handler.EnqueueRequestForObject{},
+		predicate.GenerationChangedPredicate{})
+	if err != nil {
+		return err
+	}
+
+	err = c.Watch(&source.Kind{Type: &v1alpha1.WebhookSecret{}}, &handler.EnqueueRequestForOwner{
+		IsController: true,
+		OwnerType:    &v1alpha1.WebhookSecretSet{},
+	}, childDeleted)
+	if err != nil {
+		return err
+	}
+
+	if providersConfigMap.Name != "" {
+		return c.Watch(&source.Kind{Type: &corev1.ConfigMap{}}, &handler.EnqueueRequestsFromMapFunc{
+			ToRequests: &providersMapper{kubeClient: mgr.GetClient(), configMap: providersConfigMap},
+		})
+	}
+	return nil
+}
+
+// childDeleted is a predicate that ignores all events for WebhookSecrets
+// except deletions.
+var childDeleted = predicate.Funcs{
+	CreateFunc:  func(event.CreateEvent) bool { return false },
+	UpdateFunc:  func(event.UpdateEvent) bool { return false },
+	GenericFunc: func(event.GenericEvent) bool { return false },
+}
+
+// ReconcileWebhookSecretSet reconciles a WebhookSecretSet object
+type ReconcileWebhookSecretSet struct {
+	kubeClient       client.Client
+	scheme           *runtime.Scheme
+	listerFactory    git.RepoListerFactory
+	authSecretGetter secrets.SecretGetter
+	recorder         record.EventRecorder
+	providerLoader   *providers.Loader
+}
+
+// Reconcile lists the repositories for a WebhookSecretSet, and ensures that
+// there's a WebhookSecret for each of the repositories that match the
+// selector.
+//
+// The WebhookSecretSet is requeued to list the repositories again after the
+// resync interval.
+func (r *ReconcileWebhookSecretSet) Reconcile(request reconcile.Request) (reconcile.Result, error) {
+	reqLogger := log.WithValues("Request.Namespace", request.Namespace, "Request.Name", request.Name)
+	reqLogger.Info("Reconciling WebhookSecretSet")
+
+	ctx := context.Background()
+
+	if err := r.providerLoader.Load(ctx); err != nil {
+		reqLogger.Error(err, "failed to load the providers ConfigMap, using the existing providers")
+	}
+
+	instance := &v1alpha1.WebhookSecretSet{}
+	err := r.kubeClient.Get(ctx, request.NamespacedName, instance)
+	if err != nil {
+		if !errors.IsNotFound(err) {
+			return reconcile.Result{}, err
+		}
+		return reconcile.Result{}, nil
+	}
+
+	// The WebhookSecrets are garbage collected.
+	if !instance.ObjectMeta.DeletionTimestamp.IsZero() {
+		return reconcile.Result{}, nil
+	}
+
+	err = r.reconcileWebhookSecretSet(ctx, reqLogger, instance)
+	if statusErr := r.updateStatus(ctx, instance); statusErr != nil {
+		reqLogger.Error(statusErr, "failed to update the WebhookSecretSet status")
+	}
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+	return reconcile.Result{RequeueAfter: resyncInterval(instance)}, nil
+}
+
+func (r *ReconcileWebhookSecretSet) reconcileWebhookSecretSet(ctx context.Context, logger logr.Logger, set *v1alpha1.WebhookSecretSet) error {
+	selector, err := newRepoSelector(set.Spec.Selector)
+	if err != nil {
+		setCondition(set, v1alpha1.ReposListedCondition, metav1.ConditionFalse, reasonSelectorInvalid, err.Error())
+		r.recorder.Event(set, corev1.EventTypeWarning, reasonSelectorInvalid, err.Error())
+		return err
+	}
+	lister, err := r.authenticatedLister(ctx, set)
+	if err != nil {
+		return err
+	}
+	repos, err := lister.ListRepositories(ctx)
+	if err != nil {
+		reason := reasonListFailed
+		if git.IsPermissionDenied(err) {
+			reason = reasonListDenied
+		}
+		if status := git.ErrorStatus(err); status != 0 {
+			err = fmt.Errorf("%w (HTTP status %d)", err, status)
+		}
+		setCondition(set, v1alpha1.ReposListedCondition, metav1.ConditionFalse, reason, err.Error())
+		r.recorder.Event(set, corev1.EventTypeWarning, reason, err.Error())
+		return err
+	}
+	matched := selector.Select(repos)
+	now := metav1.Now()
+	set.Status.LastSyncTime = &now
+	set.Status.MatchedRepos = len(matched)
+	setCondition(set, v1alpha1.ReposListedCondition, metav1.ConditionTrue, reasonReposListed,
+		fmt.Sprintf("%d of %d repositories match the selector", len(matched), len(repos)))
+	return r.reconcileChildren(ctx, logger, set, matched)
+}
+
+// authenticatedLister returns a client that lists the repositories of the
+// owner, authenticated with the credentials from the auth secret in the
+// template.
+func (r *ReconcileWebhookSecretSet) authenticatedLister(ctx context.Context, set *v1alpha1.WebhookSecretSet) (git.RepoLister, error) {
+	auth := r.authResolver()
+	creds, err := auth.Credentials(ctx, set, &set.Status.Conditions, "WebhookSecretSet", set.Spec.Template.Spec.AuthSecretRef)
+	if err != nil {
+		return nil, err
+	}
+	lister, err := r.listerFactory.ListerForOwner(set.Spec.Owner, creds)
+	if err != nil {
+		reason := reasonAuthSecretInvalid
+		if git.IsUnknownDriver(err) {
+			reason = reasonUnknownDriver
+		}
+		if git.IsUnsupportedListing(err) {
+			reason = reasonUnsupportedListing
+		}
+		return nil, auth.Failed(set, &set.Status.Conditions, reason, fmt.Errorf("could not get client from %s: %w", set.Spec.Owner.URL, err))
+	}
+	auth.Resolved(set, &set.Status.Conditions)
+	return lister, nil
+}
+
+// authResolver returns a resolver for the credentials in auth secrets.
+func (r *ReconcileWebhookSecretSet) authResolver() common.AuthResolver {
+	return common.AuthResolver{Reader: r.kubeClient, Secrets: r.authSecretGetter, Recorder: r.recorder}
+}
+
+// updateStatus records the conditions and the generation that was reconciled
+// in the status of the WebhookSecretSet.
+func (r *ReconcileWebhookSecretSet) updateStatus(ctx context.Context, set *v1alpha1.WebhookSecretSet) error {
+	set.Status.ObservedGeneration = set.Generation
+	setReadyCondition(set)
+	return r.kubeClient.Status().Update(ctx, set)
+}
+
+// resyncInterval returns how long to wait before listing the repositories
+// again.
+func resyncInterval(set *v1alpha1.WebhookSecretSet) time.Duration {
+	if set.Spec.ResyncInterval != nil && set.Spec.ResyncInterval.Duration > 0 {
+		return set.Spec.ResyncInterval.Duration
+	}
+	return defaultResyncInterval
+}
@@ -0,0 +1,408 @@
+package webhooksecretset
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	logf "sigs.k8s.io/controller-runtime/pkg/runtime/log"
+
+	v1alpha1 "github.com/bigkevmcd/webhook-secret-operator/pkg/apis/apps/v1alpha1"
+	"github.com/bigkevmcd/webhook-secret-operator/pkg/controller/common"
+	"github.com/bigkevmcd/webhook-secret-operator/pkg/git"
+	"github.com/bigkevmcd/webhook-secret-operator/pkg/providers"
+	"github.com/bigkevmcd/webhook-secret-operator/pkg/secrets"
+	"github.com/bigkevmcd/webhook-secret-operator/test"
+)
+
+const (
+	testSetName        = "test-set"
+	testSetNamespace   = "test-set-ns"
+	testOwnerURL       = "https://github.com/my-org"
+	testAuthSecretName = "test-auth-secret"
+	testAuthToken      = "test-auth-token"
+	testHookEndpoint   = "https://example.com/"
+)
+
+var testProvidersConfigMap = types.NamespacedName{Name: providers.ConfigMapName, Namespace: "operator-ns"}
+
+var (
+	serviceA = &git.RemoteRepository{Name: "service-a", FullName: "my-org/service-a", URL: "https://github.com/my-org/service-a", Visibility: "public"}
+	serviceB = &git.RemoteRepository{Name: "service-b", FullName: "my-org/service-b", URL: "https://github.com/my-org/service-b", Visibility: "public"}
+	website  = &git.RemoteRepository{Name: "website", FullName: "my-org/website", URL: "https://github.com/my-org/website", Visibility: "public"}
+)
+
+// Reconciling a WebhookSecretSet should create a WebhookSecret for each of the
+// repositories that match the selector.
+func TestWebhookSecretSetControllerCreatesWebhookSecrets(t *testing.T) {
+	logf.SetLogger(logf.ZapLogger(true))
+	set := makeWebhookSecretSet()
+	set.Spec.Selector.Name = "^service-"
+	set.Spec.Template.Metadata.Labels = map[string]string{"team": "a"}
+	cl, r := makeReconciler(t, set, makeTestSecret(testAuthSecretName))
+	r.listerFactory.(*stubListerFactory).repos = []*git.RemoteRepository{serviceA, serviceB, website}
+
+	res, err := r.Reconcile(makeReconcileRequest())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.RequeueAfter != defaultResyncInterval {
+		t.Fatalf("got RequeueAfter %v, want %v", res.RequeueAfter, defaultResyncInterval)
+	}
+
+	assertChildren(t, cl, map[string]string{
+		"test-set-service-a": serviceA.URL,
+		"test-set-service-b": serviceB.URL,
+	})
+	child := &v1alpha1.WebhookSecret{}
+	if err := cl.Get(context.TODO(), types.NamespacedName{Name: "test-set-service-a", Namespace: testSetNamespace}, child); err != nil {
+		t.Fatal(err)
+	}
+	want := v1alpha1.WebhookSecretSpec{
+		Repo:          v1alpha1.Repo{URL: serviceA.URL},
+		AuthSecretRef: v1alpha1.AuthSecretRef{Name: testAuthSecretName},
+		WebhookURL:    v1alpha1.HookRoute{HookURL: testHookEndpoint},
+	}
+	if diff := cmp.Diff(want, child.Spec); diff != "" {
+		t.Fatalf("incorrect WebhookSecret spec:\n%s", diff)
+	}
+	if child.Labels["team"] != "a" {
+		t.Fatalf("template labels were not applied, got %#v", child.Labels)
+	}
+
+	set = loadWebhookSecretSet(t, cl)
+	assertCondition(t, set, v1alpha1.ReposListedCondition, metav1.ConditionTrue, reasonReposListed)
+	assertCondition(t, set, v1alpha1.ReadyCondition, metav1.ConditionTrue, reasonReady)
+	if set.Status.MatchedRepos != 2 {
+		t.Fatalf("got %d matched repos, want 2", set.Status.MatchedRepos)
+	}
+	wantStatus := []v1alpha1.SetWebhookSecret{
+		{Name: "test-set-service-a", RepoURL: serviceA.URL},
+		{Name: "test-set-service-b", RepoURL: serviceB.URL},
+	}
+	if diff := cmp.Diff(wantStatus, set.Status.WebhookSecrets); diff != "" {
+		t.Fatalf("incorrect status:\n%s", diff)
+	}
+	if set.Status.LastSyncTime == nil {
+		t.Fatal("LastSyncTime was not set")
+	}
+	assertEvents(t, r.recorder,
+		"Normal WebhookSecretCreated Created WebhookSecret test-set-service-a for https://github.com/my-org/service-a",
+		"Normal WebhookSecretCreated Created WebhookSecret test-set-service-b for https://github.com/my-org/service-b")
+}
+
+// WebhookSecrets for repositories that no longer match are deleted, and
+// WebhookSecrets for new repositories are created.
+func TestWebhookSecretSetControllerGarbageCollectsWebhookSecrets(t *testing.T) {
+	logf.SetLogger(logf.ZapLogger(true))
+	set := makeWebhookSecretSet()
+	unrelated := &v1alpha1.WebhookSecret{
+		ObjectMeta: metav1.ObjectMeta{Name: "unrelated", Namespace: testSetNamespace},
+		Spec:       v1alpha1.WebhookSecretSpec{Repo: v1alpha1.Repo{URL: website.URL}},
+	}
+	cl, r := makeReconciler(t, set, makeTestSecret(testAuthSecretName),
+		makeChild(t, set, serviceA), makeChild(t, set, website), unrelated)
+	r.listerFactory.(*stubListerFactory).repos = []*git.RemoteRepository{serviceA, serviceB}
+
+	_, err := r.Reconcile(makeReconcileRequest())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assertChildren(t, cl, map[string]string{
+		"test-set-service-a": serviceA.URL,
+		"test-set-service-b": serviceB.URL,
+		"unrelated":          website.URL,
+	})
+	assertEvents(t, r.recorder,
+		"Normal WebhookSecretCreated Created WebhookSecret test-set-service-b for https://github.com/my-org/service-b",
+		"Normal WebhookSecretDeleted Deleted WebhookSecret test-set-website, https://github.com/my-org/website no longer matches")
+}
+
+// A WebhookSecret that can't be created doesn't stop the other repositories
+// from being reconciled.
+func TestWebhookSecretSetControllerContinuesAfterFailures(t *testing.T) {
+	logf.SetLogger(logf.ZapLogger(true))
+	set := makeWebhookSecretSet()
+	conflicting := &v1alpha1.WebhookSecret{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-set-service-a", Namespace: testSetNamespace},
+		Spec:       v1alpha1.WebhookSecretSpec{Repo: v1alpha1.Repo{URL: "https://github.com/other-org/service-a"}},
+	}
+	cl, r := makeReconciler(t, set, makeTestSecret(testAuthSecretName), makeChild(t, set, website), conflicting)
+	r.listerFactory.(*stubListerFactory).repos = []*git.RemoteRepository{serviceA, serviceB}
+
+	_, err := r.Reconcile(makeReconcileRequest())
+	if !test.MatchError(t, "failed to reconcile the WebhookSecrets for 1 repositories", err) {
+		t.Fatalf("expected a WebhookSecret error, got %#v", err)
+	}
+
+	assertChildren(t, cl, map[string]string{
+		"test-set-service-a": "https://github.com/other-org/service-a",
+		"test-set-service-b": serviceB.URL,
+	})
+	set = loadWebhookSecretSet(t, cl)
+	wantStatus := []v1alpha1.SetWebhookSecret{
+		{Name: "test-set-service-b", RepoURL: serviceB.URL},
+	}
+	if diff := cmp.Diff(wantStatus, set.Status.WebhookSecrets); diff != "" {
+		t.Fatalf("incorrect status:\n%s", diff)
+	}
+}
+
+// Changes to the template are applied to the existing WebhookSecrets.
+func TestWebhookSecretSetControllerUpdatesWebhookSecrets(t *testing.T) {
+	logf.SetLogger(logf.ZapLogger(true))
+	set := makeWebhookSecretSet()
+	child := makeChild(t, set, serviceA)
+	set.Spec.Template.Spec.Events = []v1alpha1.HookEvent{v1alpha1.PushEvent}
+	cl, r := makeReconciler(t, set, makeTestSecret(testAuthSecretName), child)
+	r.listerFactory.(*stubListerFactory).repos = []*git.RemoteRepository{serviceA}
+
+	_, err := r.Reconcile(makeReconcileRequest())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	updated := &v1alpha1.WebhookSecret{}
+	if err := cl.Get(context.TODO(), types.NamespacedName{Name: child.Name, Namespace: testSetNamespace}, updated); err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(set.Spec.Template.Spec.Events, updated.Spec.Events); diff != "" {
+		t.Fatalf("events were not updated:\n%s", diff)
+	}
+	assertEvents(t, r.recorder,
+		"Normal WebhookSecretUpdated Updated WebhookSecret test-set-service-a for https://github.com/my-org/service-a")
+}
+
+func TestWebhookSecretSetControllerWithResyncInterval(t *testing.T) {
+	logf.SetLogger(logf.ZapLogger(true))
+	set := makeWebhookSecretSet()
+	set.Spec.ResyncInterval = &metav1.Duration{Duration: time.Hour}
+	_, r := makeReconciler(t, set, makeTestSecret(testAuthSecretName))
+
+	res, err := r.Reconcile(makeReconcileRequest())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if res.RequeueAfter != time.Hour {
+		t.Fatalf("got RequeueAfter %v, want %v", res.RequeueAfter, time.Hour)
+	}
+}
+
+// If the repositories can't be listed, the existing WebhookSecrets are kept.
+func TestWebhookSecretSetControllerFailingToListRepositories(t *testing.T) {
+	logf.SetLogger(logf.ZapLogger(true))
+	set := makeWebhookSecretSet()
+	cl, r := makeReconciler(t, set, makeTestSecret(testAuthSecretName), makeChild(t, set, serviceA))
+	r.listerFactory.(*stubListerFactory).listErr = git.SCMError{Status: http.StatusForbidden, Permission: "needs the repo scope"}
+
+	_, err := r.Reconcile(makeReconcileRequest())
+	if !git.IsPermissionDenied(err) {
+		t.Fatalf("expected a permission error, got %v", err)
+	}
+
+	assertChildren(t, cl, map[string]string{"test-set-service-a": serviceA.URL})
+	set = loadWebhookSecretSet(t, cl)
+	assertCondition(t, set, v1alpha1.ReposListedCondition, metav1.ConditionFalse, reasonListDenied)
+	assertCondition(t, set, v1alpha1.ReadyCondition, metav1.ConditionFalse, reasonListDenied)
+	assertEvents(t, r.recorder, "Warning ListPermissionDenied , needs the repo scope (HTTP status 403)")
+}
+
+func TestWebhookSecretSetControllerWithMissingAuthSecret(t *testing.T) {
+	logf.SetLogger(logf.ZapLogger(true))
+	set := makeWebhookSecretSet()
+	cl, r := makeReconciler(t, set)
+
+	_, err := r.Reconcile(makeReconcileRequest())
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+
+	set = loadWebhookSecretSet(t, cl)
+	assertCondition(t, set, v1alpha1.AuthResolvedCondition, metav1.ConditionFalse, reasonAuthSecretNotFound)
+	assertCondition(t, set, v1alpha1.ReadyCondition, metav1.ConditionFalse, reasonAuthSecretNotFound)
+}
+
+func TestWebhookSecretSetControllerWithUngrantedAuthSecret(t *testing.T) {
+	logf.SetLogger(logf.ZapLogger(true))
+	set := makeWebhookSecretSet()
+	set.Spec.Template.Spec.AuthSecretRef.Namespace = "platform"
+	cl, r := makeReconciler(t, set)
+
+	_, err := r.Reconcile(makeReconcileRequest())
+	if !common.IsNotGranted(err) {
+		t.Fatalf("expected a not granted error, got %v", err)
+	}
+
+	set = loadWebhookSecretSet(t, cl)
+	assertCondition(t, set, v1alpha1.AuthResolvedCondition, metav1.ConditionFalse, reasonAuthSecretNotGranted)
+}
+
+func TestWebhookSecretSetControllerWithInvalidSelector(t *testing.T) {
+	logf.SetLogger(logf.ZapLogger(true))
+	set := makeWebhookSecretSet()
+	set.Spec.Selector.Name = "service-("
+	cl, r := makeReconciler(t, set, makeTestSecret(testAuthSecretName))
+
+	_, err := r.Reconcile(makeReconcileRequest())
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+
+	set = loadWebhookSecretSet(t, cl)
+	assertCondition(t, set, v1alpha1.ReposListedCondition, metav1.ConditionFalse, reasonSelectorInvalid)
+}
+
+type stubListerFactory struct {
+	authToken string
+	repos     []*git.RemoteRepository
+	listErr   error
+}
+
+func (s *stubListerFactory) ListerForOwner(owner v1alpha1.Repo, creds git.Credentials) (git.RepoLister, error) {
+	if creds.Token != s.authToken {
+		return nil, errors.New("failed to authenticate")
+	}
+	return s, nil
+}
+
+func (s *stubListerFactory) ListRepositories(ctx context.Context) ([]*git.RemoteRepository, error) {
+	return s.repos, s.listErr
+}
+
+func makeReconciler(t *testing.T, set *v1alpha1.WebhookSecretSet, objs ...runtime.Object) (client.Client, *ReconcileWebhookSecretSet) {
+	t.Helper()
+	s := scheme.Scheme
+	s.AddKnownTypes(v1alpha1.SchemeGroupVersion, set, &v1alpha1.WebhookSecretSetList{},
+		&v1alpha1.WebhookSecret{}, &v1alpha1.WebhookSecretList{},
+		&v1alpha1.AuthSecretGrant{}, &v1alpha1.AuthSecretGrantList{})
+
+	cl := fake.NewFakeClient(append([]runtime.Object{set}, objs...)...)
+	return cl, &ReconcileWebhookSecretSet{
+		kubeClient:       cl,
+		scheme:           s,
+		listerFactory:    &stubListerFactory{authToken: testAuthToken},
+		authSecretGetter: secrets.New(cl),
+		recorder:         record.NewFakeRecorder(20),
+		providerLoader:   providers.NewLoader(cl, git.NewProviderRegistry(), testProvidersConfigMap),
+	}
+}
+
+func makeWebhookSecretSet() *v1alpha1.WebhookSecretSet {
+	return &v1alpha1.WebhookSecretSet{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: v1alpha1.SchemeGroupVersion.String(),
+			Kind:       "WebhookSecretSet",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      testSetName,
+			Namespace: testSetNamespace,
+			UID:       types.UID("test-set-uid"),
+		},
+		Spec: v1alpha1.WebhookSecretSetSpec{
+			Owner: v1alpha1.Repo{URL: testOwnerURL},
+			Template: v1alpha1.WebhookSecretTemplate{
+				Spec: v1alpha1.WebhookSecretSpec{
+					AuthSecretRef: v1alpha1.AuthSecretRef{Name: testAuthSecretName},
+					WebhookURL:    v1alpha1.HookRoute{HookURL: testHookEndpoint},
+				},
+			},
+		},
+	}
+}
+
+// makeChild returns a WebhookSecret that was created by the WebhookSecretSet
+// for the repository.
+func makeChild(t *testing.T, set *v1alpha1.WebhookSecretSet, repo *git.RemoteRepository) *v1alpha1.WebhookSecret {
+	t.Helper()
+	child := newChild(set, repo)
+	if err := controllerutil.SetControllerReference(set, child, scheme.Scheme); err != nil {
+		t.Fatal(err)
+	}
+	return child
+}
+
+func makeTestSecret(n string) *corev1.Secret {
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      n,
+			Namespace: testSetNamespace,
+		},
+		Data: map[string][]byte{
+			"token": []byte(testAuthToken),
+		},
+	}
+}
+
+func makeReconcileRequest() reconcile.Request {
+	return reconcile.Request{
+		NamespacedName: types.NamespacedName{
+			Name:      testSetName,
+			Namespace: testSetNamespace,
+		},
+	}
+}
+
+func loadWebhookSecretSet(t *testing.T, cl client.Client) *v1alpha1.WebhookSecretSet {
+	t.Helper()
+	set := &v1alpha1.WebhookSecretSet{}
+	if err := cl.Get(context.TODO(), makeReconcileRequest().NamespacedName, set); err != nil {
+		t.Fatal(err)
+	}
+	return set
+}
+
+// assertChildren checks the names and repository URLs of the WebhookSecrets
+// in the namespace.
+func assertChildren(t *testing.T, cl client.Client, want map[string]string) {
+	t.Helper()
+	list := &v1alpha1.WebhookSecretList{}
+	if err := cl.List(context.TODO(), list, client.InNamespace(testSetNamespace)); err != nil {
+		t.Fatal(err)
+	}
+	got := map[string]string{}
+	for _, ws := range list.Items {
+		got[ws.Name] = ws.Spec.Repo.URL
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Fatalf("incorrect WebhookSecrets:\n%s", diff)
+	}
+}
+
+func assertCondition(t *testing.T, set *v1alpha1.WebhookSecretSet, ct v1alpha1.ConditionType, status metav1.ConditionStatus, reason string) {
+	t.Helper()
+	c := findCondition(set, ct)
+	if c == nil {
+		t.Fatalf("condition %s not found in %#v", ct, set.Status.Conditions)
+	}
+	if c.Status != status || c.Reason != reason {
+		t.Fatalf("condition %s got %s/%s, want %s/%s", ct, c.Status, c.Reason, status, reason)
+	}
+}
+
+func assertEvents(t *testing.T, r record.EventRecorder, want ...string) {
+	t.Helper()
+	events := r.(*record.FakeRecorder).Events
+	got := []string{}
+	for len(events) > 0 {
+		got = append(got, <-events)
+	}
+	if diff := cmp.Diff(want, got, cmpopts.EquateEmpty()); diff != "" {
+		t.Fatalf("events failed:\n%s", diff)
+	}
+}
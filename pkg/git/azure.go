@@ -0,0 +1,355 @@
+package git
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	v1alpha1 "github.com/bigkevmcd/webhook-secret-operator/pkg/apis/apps/v1alpha1"
+)
+
+const (
+	azureAPIVersion = "6.0"
+
+	// azureBasicAuthUsername is the username that Azure DevOps sends with
+	// the secret as the password, in the Authorization header of each
+	// delivery, as Azure DevOps doesn't sign the payload.
+	azureBasicAuthUsername = "webhook-secret-operator"
+)
+
+// AzureHooksClient is an implementation of HooksClient that manages service
+// hook subscriptions in Azure DevOps.
+//
+// Azure DevOps subscriptions have a single event type, so a hook is made up of
+// a subscription for each of the events, and the hook ID is the IDs of the
+// subscriptions, separated by commas.
+type AzureHooksClient struct {
+	Client *http.Client
+	// BaseURL is the URL of the organisation or collection, e.g.
+	// https://dev.azure.com/myorg/.
+	BaseURL *url.URL
+	Project string
+	Repo    string
+}
+
+// NewAzure creates and returns a new AzureHooksClient for the repository with
+// the provided URL.
+//
+// The HTTP client is responsible for authenticating requests.
+//
+// If the endpoint is not empty, it's used as the URL of the organisation or
+// collection, instead of the URL calculated from the repository URL.
+func NewAzure(c *http.Client, repoURL, endpoint string) (*AzureHooksClient, error) {
+	base, project, repo, err := parseAzureURL(repoURL)
+	if err != nil {
+		return nil, err
+	}
+	if endpoint != "" {
+		base, err = url.Parse(endpoint)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse the endpoint %q: %w", endpoint, err)
+		}
+	}
+	if !strings.HasSuffix(base.Path, "/") {
+		base.Path = base.Path + "/"
+	}
+	if c == nil {
+		c = http.DefaultClient
+	}
+	return &AzureHooksClient{Client: c, BaseURL: base, Project: project, Repo: repo}, nil
+}
+
+// Create creates a subscription for each of the events, delivered to the
+// hookURL with the secret as the basic-auth password.
+//
+// If any of the subscriptions can't be created, the subscriptions that were
+// created are removed.
+func (c *AzureHooksClient) Create(ctx context.Context, hookURL, secret string, events []v1alpha1.HookEvent) (string, error) {
+	eventTypes, err := azureEventTypes(events)
+	if err != nil {
+		return "", err
+	}
+	repo, err := c.repository(ctx)
+	if err != nil {
+		return "", err
+	}
+	ids := []string{}
+	for _, eventType := range eventTypes {
+		in := &azureSubscription{
+			PublisherID:      "tfs",
+			EventType:        eventType,
+			ResourceVersion:  "1.0",
+			ConsumerID:       "webHooks",
+			ConsumerActionID: "httpRequest",
+			PublisherInputs: map[string]string{
+				"projectId":  repo.Project.ID,
+				"repository": repo.ID,
+			},
+			ConsumerInputs: map[string]string{
+				"url":               hookURL,
+				"basicAuthUsername": azureBasicAuthUsername,
+				"basicAuthPassword": secret,
+			},
+		}
+		out := &azureSubscription{}
+		if err := c.do(ctx, http.MethodPost, "_apis/hooks/subscriptions", in, out); err != nil {
+			for _, id := range ids {
+				_ = c.do(ctx, http.MethodDelete, "_apis/hooks/subscriptions/"+id, nil, nil)
+			}
+			return "", azureError(err, fmt.Sprintf("failed to create hook in repo %s", c.Repo))
+		}
+		ids = append(ids, out.ID)
+	}
+	return strings.Join(ids, ","), nil
+}
+
+// Update changes the URL, secret and events of a hook, returning the ID of the
+// updated hook.
+//
+// As with the go-scm drivers, a new hook is created before the existing hook
+// is removed, so the ID changes, but no events are lost, and the new hook is
+// removed if the existing hook can't be.
+func (c *AzureHooksClient) Update(ctx context.Context, hookID, hookURL, secret string, events []v1alpha1.HookEvent) (string, error) {
+	newID, err := c.Create(ctx, hookURL, secret, events)
+	if err != nil {
+		return "", err
+	}
+	if err := c.Delete(ctx, hookID); err != nil && !IsNotFound(err) {
+		_ = c.Delete(ctx, newID)
+		return "", err
+	}
+	return newID, nil
+}
+
+// Get fetches the subscriptions for a hook.
+//
+// If any of the subscriptions is missing, a NotFound error is returned.
+func (c *AzureHooksClient) Get(ctx context.Context, hookID string) (*Hook, error) {
+	hook := &Hook{ID: hookID, Active: true, Driver: DriverAzure}
+	for _, id := range strings.Split(hookID, ",") {
+		s := &azureSubscription{}
+		if err := c.do(ctx, http.MethodGet, "_apis/hooks/subscriptions/"+id, nil, s); err != nil {
+			return nil, azureError(err, fmt.Sprintf("failed to get hook %s in repo %s", hookID, c.Repo))
+		}
+		if hook.URL == "" {
+			hook.URL = s.ConsumerInputs["url"]
+		}
+		if s.ConsumerInputs["url"] != hook.URL || s.Status != "enabled" {
+			hook.Active = false
+		}
+		hook.Events = append(hook.Events, s.EventType)
+	}
+	return hook, nil
+}
+
+// List fetches the webhook subscriptions for the repository, each subscription
+// is returned as a separate hook.
+func (c *AzureHooksClient) List(ctx context.Context) ([]*Hook, error) {
+	repo, err := c.repository(ctx)
+	if err != nil {
+		return nil, err
+	}
+	out := &azureSubscriptionList{}
+	if err := c.do(ctx, http.MethodGet, "_apis/hooks/subscriptions?consumerId=webHooks&publisherId=tfs", nil, out); err != nil {
+		return nil, azureError(err, fmt.Sprintf("failed to list hooks in repo %s", c.Repo))
+	}
+	hooks := []*Hook{}
+	for _, s := range out.Value {
+		if s.PublisherInputs["repository"] != repo.ID {
+			continue
+		}
+		hooks = append(hooks, &Hook{
+			ID:     s.ID,
+			URL:    s.ConsumerInputs["url"],
+			Events: []string{s.EventType},
+			Active: s.Status == "enabled",
+			Driver: DriverAzure,
+		})
+	}
+	return hooks, nil
+}
+
+// Delete removes the subscriptions for a hook.
+//
+// A NotFound error is only returned if none of the subscriptions exist.
+func (c *AzureHooksClient) Delete(ctx context.Context, hookID string) error {
+	var notFound error
+	deleted := false
+	for _, id := range strings.Split(hookID, ",") {
+		err := azureError(c.do(ctx, http.MethodDelete, "_apis/hooks/subscriptions/"+id, nil, nil),
+			fmt.Sprintf("failed to delete hook in repo %s", c.Repo))
+		if IsNotFound(err) {
+			notFound = err
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		deleted = true
+	}
+	if !deleted {
+		return notFound
+	}
+	return nil
+}
+
+// repository looks up the IDs of the repository and its project, which are
+// needed to create subscriptions.
+func (c *AzureHooksClient) repository(ctx context.Context) (*azureRepository, error) {
+	out := &azureRepository{}
+	path := fmt.Sprintf("%s/_apis/git/repositories/%s", url.PathEscape(c.Project), url.PathEscape(c.Repo))
+	if err := c.do(ctx, http.MethodGet, path, nil, out); err != nil {
+		return nil, azureError(err, fmt.Sprintf("failed to get repo %s", c.Repo))
+	}
+	return out, nil
+}
+
+func (c *AzureHooksClient) do(ctx context.Context, method, path string, in, out interface{}) error {
+	u, err := c.BaseURL.Parse(path)
+	if err != nil {
+		return err
+	}
+	q := u.Query()
+	q.Set("api-version", azureAPIVersion)
+	u.RawQuery = q.Encode()
+
+	var body bytes.Buffer
+	if in != nil {
+		if err := json.NewEncoder(&body).Encode(in); err != nil {
+			return err
+		}
+	}
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), &body)
+	if err != nil {
+		return err
+	}
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	req.Header.Set("Accept", "application/json")
+	res, err := c.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if isErrorStatus(res.StatusCode) {
+		return SCMError{msg: http.StatusText(res.StatusCode), Status: res.StatusCode}
+	}
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(res.Body).Decode(out)
+}
+
+// azureError replaces the message for HTTP errors, keeping the status.
+func azureError(err error, msg string) error {
+	if e, ok := err.(SCMError); ok {
+		return SCMError{msg: msg, Status: e.Status}
+	}
+	return err
+}
+
+// azureEventTypes returns the Azure DevOps event types for the events, in the
+// order that they were requested.
+func azureEventTypes(events []v1alpha1.HookEvent) ([]string, error) {
+	if len(events) == 0 {
+		events = defaultEvents
+	}
+	seen := map[string]bool{}
+	eventTypes := []string{}
+	for _, e := range events {
+		if !supportsEvent(DriverAzure, e) {
+			return nil, unsupportedEventError{driver: DriverAzure, event: e}
+		}
+		for _, t := range driverEvents[DriverAzure][e] {
+			if !seen[t] {
+				seen[t] = true
+				eventTypes = append(eventTypes, t)
+			}
+		}
+	}
+	return eventTypes, nil
+}
+
+// isAzureURL returns true if the URL is for an Azure DevOps repository, either
+// in Azure DevOps Services, or an on-premises Azure DevOps Server, which has
+// "_git" in the path of repository URLs.
+func isAzureURL(u *url.URL) bool {
+	if u.Host == "dev.azure.com" || strings.HasSuffix(u.Host, ".visualstudio.com") {
+		return true
+	}
+	for _, s := range pathSegments(u.Path) {
+		if s == "_git" {
+			return true
+		}
+	}
+	return false
+}
+
+// parseAzureURL splits an Azure DevOps repository URL into the URL of the
+// organisation or collection, the project, and the repository.
+//
+// Repository URLs are in the format
+// https://dev.azure.com/{organisation}/{project}/_git/{repo} or
+// https://{server}/{collection}/{project}/_git/{repo}, if the repository
+// has the same name as the project, the project can be omitted.
+func parseAzureURL(repoURL string) (*url.URL, string, string, error) {
+	parsed, err := parseRepoURL(repoURL)
+	if err != nil {
+		return nil, "", "", err
+	}
+	segments := pathSegments(parsed.Path)
+	gitIndex := -1
+	for i, s := range segments {
+		if s == "_git" {
+			gitIndex = i
+			break
+		}
+	}
+	if gitIndex == -1 || len(segments) < gitIndex+2 {
+		return nil, "", "", fmt.Errorf("failed to parse Azure DevOps repo from URL %#v", repoURL)
+	}
+	repo := strings.TrimSuffix(segments[gitIndex+1], ".git")
+	// dev.azure.com URLs always start with the organisation.
+	collectionSegments := 0
+	if parsed.Host == "dev.azure.com" {
+		collectionSegments = 1
+	}
+	project := repo
+	base := segments[:gitIndex]
+	if gitIndex > collectionSegments {
+		project = segments[gitIndex-1]
+		base = segments[:gitIndex-1]
+	}
+	return &url.URL{Scheme: parsed.Scheme, Host: parsed.Host, Path: "/" + strings.Join(base, "/")}, project, repo, nil
+}
+
+type azureRepository struct {
+	ID      string `json:"id"`
+	Name    string `json:"name"`
+	Project struct {
+		ID   string `json:"id"`
+		Name string `json:"name"`
+	} `json:"project"`
+}
+
+type azureSubscription struct {
+	ID               string            `json:"id,omitempty"`
+	Status           string            `json:"status,omitempty"`
+	PublisherID      string            `json:"publisherId"`
+	EventType        string            `json:"eventType"`
+	ResourceVersion  string            `json:"resourceVersion"`
+	ConsumerID       string            `json:"consumerId"`
+	ConsumerActionID string            `json:"consumerActionId"`
+	PublisherInputs  map[string]string `json:"publisherInputs"`
+	ConsumerInputs   map[string]string `json:"consumerInputs"`
+}
+
+type azureSubscriptionList struct {
+	Count int                 `json:"count"`
+	Value []azureSubscription `json:"value"`
+}
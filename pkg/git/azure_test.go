@@ -0,0 +1,347 @@
+package git
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/jenkins-x/go-scm/scm/transport"
+	"gopkg.in/h2non/gock.v1"
+
+	v1alpha1 "github.com/bigkevmcd/webhook-secret-operator/pkg/apis/apps/v1alpha1"
+	"github.com/bigkevmcd/webhook-secret-operator/test"
+)
+
+var _ HooksClient = (*AzureHooksClient)(nil)
+
+const azureAPI = "https://dev.azure.com"
+
+func TestParseAzureURL(t *testing.T) {
+	urlTests := []struct {
+		repoURL     string
+		wantBase    string
+		wantProject string
+		wantRepo    string
+		wantErr     string
+	}{
+		{"https://dev.azure.com/myorg/myproject/_git/myrepo", "https://dev.azure.com/myorg", "myproject", "myrepo", ""},
+		{"https://myorg@dev.azure.com/myorg/myproject/_git/myrepo", "https://dev.azure.com/myorg", "myproject", "myrepo", ""},
+		{"https://dev.azure.com/myorg/_git/myrepo", "https://dev.azure.com/myorg", "myrepo", "myrepo", ""},
+		{"https://myorg.visualstudio.com/myproject/_git/myrepo", "https://myorg.visualstudio.com/", "myproject", "myrepo", ""},
+		{"https://tfs.example.com/tfs/DefaultCollection/myproject/_git/myrepo", "https://tfs.example.com/tfs/DefaultCollection", "myproject", "myrepo", ""},
+		{"https://dev.azure.com/myorg/myproject", "", "", "", "failed to parse Azure DevOps repo"},
+	}
+
+	for _, tt := range urlTests {
+		t.Run(tt.repoURL, func(rt *testing.T) {
+			base, project, repo, err := parseAzureURL(tt.repoURL)
+			if !test.MatchError(rt, tt.wantErr, err) {
+				rt.Fatalf("error failed to match, got %#v, want %s", err, tt.wantErr)
+			}
+			if tt.wantErr != "" {
+				return
+			}
+			if base.String() != tt.wantBase {
+				rt.Errorf("base got %s, want %s", base, tt.wantBase)
+			}
+			if project != tt.wantProject {
+				rt.Errorf("project got %s, want %s", project, tt.wantProject)
+			}
+			if repo != tt.wantRepo {
+				rt.Errorf("repo got %s, want %s", repo, tt.wantRepo)
+			}
+		})
+	}
+}
+
+func TestAzureCreate(t *testing.T) {
+	defer gock.Off()
+	mockAzureRepository()
+	for i, eventType := range []string{"git.push", "git.pullrequest.created", "git.pullrequest.updated", "git.pullrequest.merged"} {
+		gock.New(azureAPI).
+			Post("/myorg/_apis/hooks/subscriptions").
+			MatchParam("api-version", azureAPIVersion).
+			BasicAuth("", "authtoken").
+			MatchType("json").
+			JSON(map[string]interface{}{
+				"publisherId":      "tfs",
+				"eventType":        eventType,
+				"resourceVersion":  "1.0",
+				"consumerId":       "webHooks",
+				"consumerActionId": "httpRequest",
+				"publisherInputs": map[string]string{
+					"projectId":  "project-id",
+					"repository": "repo-id",
+				},
+				"consumerInputs": map[string]string{
+					"url":               "https://example.com/testing",
+					"basicAuthUsername": "webhook-secret-operator",
+					"basicAuthPassword": "t0ps3cr3t",
+				},
+			}).
+			Reply(http.StatusOK).
+			JSON(map[string]string{"id": []string{"sub-1", "sub-2", "sub-3", "sub-4"}[i]})
+	}
+	client := makeAzureClient(t)
+
+	hookID, err := client.Create(context.TODO(), "https://example.com/testing", "t0ps3cr3t",
+		[]v1alpha1.HookEvent{v1alpha1.PushEvent, v1alpha1.PullRequestEvent})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if hookID != "sub-1,sub-2,sub-3,sub-4" {
+		t.Fatalf("Create() got %#v", hookID)
+	}
+	if !gock.IsDone() {
+		t.Fatalf("pending requests: %#v", gock.Pending())
+	}
+}
+
+func TestAzureCreateWithUnsupportedEvent(t *testing.T) {
+	client := makeAzureClient(t)
+
+	_, err := client.Create(context.TODO(), "https://example.com/testing", "t0ps3cr3t",
+		[]v1alpha1.HookEvent{v1alpha1.TagEvent})
+
+	if !IsUnsupportedEvent(err) {
+		t.Fatalf("Create() failed with %#v", err)
+	}
+	if !test.MatchError(t, `event "tag" is not supported by driver azure`, err) {
+		t.Fatalf("got incorrect error %s", err)
+	}
+}
+
+func TestAzureCreateRemovesSubscriptionsOnFailure(t *testing.T) {
+	defer gock.Off()
+	mockAzureRepository()
+	gock.New(azureAPI).
+		Post("/myorg/_apis/hooks/subscriptions").
+		Reply(http.StatusOK).
+		JSON(map[string]string{"id": "sub-1"})
+	gock.New(azureAPI).
+		Post("/myorg/_apis/hooks/subscriptions").
+		Reply(http.StatusForbidden)
+	gock.New(azureAPI).
+		Delete("/myorg/_apis/hooks/subscriptions/sub-1").
+		Reply(http.StatusNoContent)
+	client := makeAzureClient(t)
+
+	_, err := client.Create(context.TODO(), "https://example.com/testing", "t0ps3cr3t",
+		[]v1alpha1.HookEvent{v1alpha1.PullRequestEvent})
+
+	if ErrorStatus(err) != http.StatusForbidden {
+		t.Fatalf("Create() failed with %#v", err)
+	}
+	if !test.MatchError(t, "failed to create hook in repo myrepo", err) {
+		t.Fatalf("got incorrect error %s", err)
+	}
+	if !gock.IsDone() {
+		t.Fatalf("pending requests: %#v", gock.Pending())
+	}
+}
+
+func TestAzureUpdateWithFailingDelete(t *testing.T) {
+	defer gock.Off()
+	mockAzureRepository()
+	gock.New(azureAPI).
+		Post("/myorg/_apis/hooks/subscriptions").
+		Reply(http.StatusOK).
+		JSON(map[string]string{"id": "sub-2"})
+	gock.New(azureAPI).
+		Delete("/myorg/_apis/hooks/subscriptions/sub-1").
+		Reply(http.StatusInternalServerError)
+	gock.New(azureAPI).
+		Delete("/myorg/_apis/hooks/subscriptions/sub-2").
+		Reply(http.StatusNoContent)
+	client := makeAzureClient(t)
+
+	hookID, err := client.Update(context.TODO(), "sub-1", "https://example.com/testing", "t0ps3cr3t",
+		[]v1alpha1.HookEvent{v1alpha1.PushEvent})
+
+	if ErrorStatus(err) != http.StatusInternalServerError {
+		t.Fatalf("Update() failed with %#v", err)
+	}
+	if hookID != "" {
+		t.Fatalf("Update() got %#v", hookID)
+	}
+	if !gock.IsDone() {
+		t.Fatalf("the new hook was not removed, pending requests: %#v", gock.Pending())
+	}
+}
+
+func TestAzureGet(t *testing.T) {
+	defer gock.Off()
+	mockAzureSubscription("sub-1", "git.pullrequest.created", "enabled")
+	mockAzureSubscription("sub-2", "git.pullrequest.updated", "enabled")
+	mockAzureSubscription("sub-3", "git.pullrequest.merged", "enabled")
+	client := makeAzureClient(t)
+
+	hook, err := client.Get(context.TODO(), "sub-1,sub-2,sub-3")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := &Hook{
+		ID:     "sub-1,sub-2,sub-3",
+		URL:    "https://example.com/testing",
+		Events: []string{"git.pullrequest.created", "git.pullrequest.updated", "git.pullrequest.merged"},
+		Active: true,
+		Driver: DriverAzure,
+	}
+	if diff := cmp.Diff(want, hook); diff != "" {
+		t.Fatalf("Get() failed:\n%s", diff)
+	}
+	if !hook.Matches("https://example.com/testing", []v1alpha1.HookEvent{v1alpha1.PullRequestEvent}) {
+		t.Fatal("hook does not match the pull_request event")
+	}
+}
+
+func TestAzureGetWithDisabledSubscription(t *testing.T) {
+	defer gock.Off()
+	mockAzureSubscription("sub-1", "git.push", "disabledByUser")
+	client := makeAzureClient(t)
+
+	hook, err := client.Get(context.TODO(), "sub-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if hook.Active {
+		t.Fatal("disabled subscription is active")
+	}
+}
+
+func TestAzureGetWithNotFoundResponse(t *testing.T) {
+	defer gock.Off()
+	mockAzureSubscription("sub-1", "git.push", "enabled")
+	gock.New(azureAPI).
+		Get("/myorg/_apis/hooks/subscriptions/sub-2").
+		Reply(http.StatusNotFound)
+	client := makeAzureClient(t)
+
+	_, err := client.Get(context.TODO(), "sub-1,sub-2")
+
+	if !IsNotFound(err) {
+		t.Fatalf("Get() failed with %#v", err)
+	}
+}
+
+func TestAzureList(t *testing.T) {
+	defer gock.Off()
+	mockAzureRepository()
+	gock.New(azureAPI).
+		Get("/myorg/_apis/hooks/subscriptions").
+		MatchParam("consumerId", "webHooks").
+		MatchParam("publisherId", "tfs").
+		Reply(http.StatusOK).
+		JSON(map[string]interface{}{
+			"count": 2,
+			"value": []map[string]interface{}{
+				azureSubscriptionResponse("sub-1", "git.push", "enabled"),
+				{
+					"id":              "sub-2",
+					"eventType":       "git.push",
+					"status":          "enabled",
+					"publisherInputs": map[string]string{"repository": "other-repo-id"},
+					"consumerInputs":  map[string]string{"url": "https://example.com/other"},
+				},
+			},
+		})
+	client := makeAzureClient(t)
+
+	hooks, err := client.List(context.TODO())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []*Hook{
+		{
+			ID:     "sub-1",
+			URL:    "https://example.com/testing",
+			Events: []string{"git.push"},
+			Active: true,
+			Driver: DriverAzure,
+		},
+	}
+	if diff := cmp.Diff(want, hooks); diff != "" {
+		t.Fatalf("List() failed:\n%s", diff)
+	}
+}
+
+func TestAzureDelete(t *testing.T) {
+	defer gock.Off()
+	gock.New(azureAPI).
+		Delete("/myorg/_apis/hooks/subscriptions/sub-1").
+		Reply(http.StatusNoContent)
+	gock.New(azureAPI).
+		Delete("/myorg/_apis/hooks/subscriptions/sub-2").
+		Reply(http.StatusNotFound)
+	client := makeAzureClient(t)
+
+	if err := client.Delete(context.TODO(), "sub-1,sub-2"); err != nil {
+		t.Fatal(err)
+	}
+	if !gock.IsDone() {
+		t.Fatalf("pending requests: %#v", gock.Pending())
+	}
+}
+
+func TestAzureDeleteWithNotFoundResponse(t *testing.T) {
+	defer gock.Off()
+	gock.New(azureAPI).
+		Delete("/myorg/_apis/hooks/subscriptions/sub-1").
+		Reply(http.StatusNotFound)
+	client := makeAzureClient(t)
+
+	err := client.Delete(context.TODO(), "sub-1")
+
+	if !IsNotFound(err) {
+		t.Fatalf("Delete() failed with %#v", err)
+	}
+}
+
+func makeAzureClient(t *testing.T) *AzureHooksClient {
+	t.Helper()
+	c, err := NewAzure(&http.Client{Transport: &transport.BasicAuth{Password: "authtoken"}},
+		"https://dev.azure.com/myorg/myproject/_git/myrepo", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func mockAzureRepository() {
+	gock.New(azureAPI).
+		Get("/myorg/myproject/_apis/git/repositories/myrepo").
+		Reply(http.StatusOK).
+		JSON(map[string]interface{}{
+			"id":      "repo-id",
+			"name":    "myrepo",
+			"project": map[string]string{"id": "project-id", "name": "myproject"},
+		})
+}
+
+func mockAzureSubscription(id, eventType, status string) {
+	gock.New(azureAPI).
+		Get("/myorg/_apis/hooks/subscriptions/" + id).
+		Reply(http.StatusOK).
+		JSON(azureSubscriptionResponse(id, eventType, status))
+}
+
+func azureSubscriptionResponse(id, eventType, status string) map[string]interface{} {
+	return map[string]interface{}{
+		"id":        id,
+		"eventType": eventType,
+		"status":    status,
+		"publisherInputs": map[string]string{
+			"projectId":  "project-id",
+			"repository": "repo-id",
+		},
+		"consumerInputs": map[string]string{
+			"url":               "https://example.com/testing",
+			"basicAuthUsername": "webhook-secret-operator",
+		},
+	}
+}
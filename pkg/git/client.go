@@ -3,8 +3,11 @@ package git
 import (
 	"context"
 	"fmt"
+	"net/url"
 
 	"github.com/jenkins-x/go-scm/scm"
+
+	v1alpha1 "github.com/bigkevmcd/webhook-secret-operator/pkg/apis/apps/v1alpha1"
 )
 
 // New creates and returns a new SCMHooksClient.
@@ -18,15 +21,26 @@ type SCMHooksClient struct {
 	Repo   string
 }
 
+const listPageSize = 100
+
+// hookName is the name given to hooks, for the drivers that support names.
+const hookName = "webhook-secret-operator"
+
 // TODO: this should accept a logr and log out creations.
 
-// Create creates a new repository webhook.
+// Create creates a new repository webhook, subscribed to the provided events.
+//
+// If no events are provided, the hook is subscribed to push events.
 //
 // If an HTTP error is returned by the upstream service, an error with the
 // response status code is returned.
-func (c *SCMHooksClient) Create(ctx context.Context, hookURL, secret string) (string, error) {
+func (c *SCMHooksClient) Create(ctx context.Context, hookURL, secret string, events []v1alpha1.HookEvent) (string, error) {
+	hookEvents, native, err := convertEvents(c.Client.Driver, events)
+	if err != nil {
+		return "", err
+	}
 	hook, r, err := c.Client.Repositories.CreateHook(ctx, c.Repo,
-		&scm.HookInput{Target: hookURL, Secret: secret, Events: scm.HookEvents{Push: true}})
+		&scm.HookInput{Name: hookName, Target: hookURL, Secret: secret, Events: hookEvents, NativeEvents: native})
 	if r != nil && isErrorStatus(r.Status) {
 		return "", SCMError{msg: fmt.Sprintf("failed to create hook in repo %s", c.Repo), Status: r.Status}
 	}
@@ -36,6 +50,70 @@ func (c *SCMHooksClient) Create(ctx context.Context, hookURL, secret string) (st
 	return hook.ID, nil
 }
 
+// Update changes the URL, secret and events of an existing repository webhook,
+// and returns the ID of the updated hook.
+//
+// The version of go-scm in use doesn't support updating hooks, so a new hook
+// is created before the existing hook is removed, this means that the ID
+// changes, but no events are lost.
+//
+// If the existing hook can't be removed, the new hook is removed, so that
+// retrying doesn't leave duplicate hooks in the repository.
+//
+// If an HTTP error is returned by the upstream service, an error with the
+// response status code is returned.
+func (c *SCMHooksClient) Update(ctx context.Context, hookID, hookURL, secret string, events []v1alpha1.HookEvent) (string, error) {
+	newID, err := c.Create(ctx, hookURL, secret, events)
+	if err != nil {
+		return "", err
+	}
+	if err := c.Delete(ctx, hookID); err != nil && !IsNotFound(err) {
+		_ = c.Delete(ctx, newID)
+		return "", err
+	}
+	return newID, nil
+}
+
+// Get fetches a repository webhook.
+//
+// If an HTTP error is returned by the upstream service, an error with the
+// response status code is returned.
+func (c *SCMHooksClient) Get(ctx context.Context, hookID string) (*Hook, error) {
+	hook, r, err := c.Client.Repositories.FindHook(ctx, c.Repo, hookID)
+	if r != nil && isErrorStatus(r.Status) {
+		return nil, SCMError{msg: fmt.Sprintf("failed to get hook %s in repo %s", hookID, c.Repo), Status: r.Status}
+	}
+	if err != nil {
+		return nil, err
+	}
+	return c.convertHook(hook), nil
+}
+
+// List fetches all the webhooks for the repository.
+//
+// If an HTTP error is returned by the upstream service, an error with the
+// response status code is returned.
+func (c *SCMHooksClient) List(ctx context.Context) ([]*Hook, error) {
+	hooks := []*Hook{}
+	opts := scm.ListOptions{Page: 1, Size: listPageSize}
+	for {
+		page, r, err := c.Client.Repositories.ListHooks(ctx, c.Repo, opts)
+		if r != nil && isErrorStatus(r.Status) {
+			return nil, SCMError{msg: fmt.Sprintf("failed to list hooks in repo %s", c.Repo), Status: r.Status}
+		}
+		if err != nil {
+			return nil, err
+		}
+		for _, h := range page {
+			hooks = append(hooks, c.convertHook(h))
+		}
+		if r == nil || r.Page.Next == 0 || r.Page.Next == opts.Page {
+			return hooks, nil
+		}
+		opts.Page = r.Page.Next
+	}
+}
+
 // Delete removes a repository webhook.
 //
 // If an HTTP error is returned by the upstream service, an error with the
@@ -43,7 +121,7 @@ func (c *SCMHooksClient) Create(ctx context.Context, hookURL, secret string) (st
 func (c *SCMHooksClient) Delete(ctx context.Context, hookID string) error {
 	r, err := c.Client.Repositories.DeleteHook(ctx, c.Repo, hookID)
 	if r != nil && isErrorStatus(r.Status) {
-		return SCMError{msg: fmt.Sprintf("failed to create hook in repo %s", c.Repo), Status: r.Status}
+		return SCMError{msg: fmt.Sprintf("failed to delete hook in repo %s", c.Repo), Status: r.Status}
 	}
 	if err != nil {
 		return err
@@ -51,6 +129,38 @@ func (c *SCMHooksClient) Delete(ctx context.Context, hookID string) error {
 	return nil
 }
 
+func (c *SCMHooksClient) convertHook(h *scm.Hook) *Hook {
+	return &Hook{
+		ID:     h.ID,
+		URL:    c.hookURL(h.Target),
+		Events: h.Events,
+		Active: h.Active,
+		Driver: c.Client.Driver,
+	}
+}
+
+// hookURL returns the URL that a hook was created with.
+//
+// The Bitbucket Cloud and Gitea drivers add the secret as a query parameter to
+// the URL, this removes it so that the URL can be compared with the URL
+// that the hook was created with.
+func (c *SCMHooksClient) hookURL(target string) string {
+	if c.Client.Driver != scm.DriverBitbucket && c.Client.Driver != scm.DriverGitea {
+		return target
+	}
+	parsed, err := url.Parse(target)
+	if err != nil {
+		return target
+	}
+	params := parsed.Query()
+	if _, ok := params["secret"]; !ok {
+		return target
+	}
+	params.Del("secret")
+	parsed.RawQuery = params.Encode()
+	return parsed.String()
+}
+
 func isErrorStatus(i int) bool {
 	return i >= 400
 }
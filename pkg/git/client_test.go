@@ -3,9 +3,13 @@ package git
 import (
 	"context"
 	"net/http"
+	"regexp"
 	"testing"
 
+	v1alpha1 "github.com/bigkevmcd/webhook-secret-operator/pkg/apis/apps/v1alpha1"
 	"github.com/bigkevmcd/webhook-secret-operator/test"
+	"github.com/google/go-cmp/cmp"
+	"github.com/jenkins-x/go-scm/scm"
 	"github.com/jenkins-x/go-scm/scm/factory"
 	"gopkg.in/h2non/gock.v1"
 )
@@ -35,7 +39,7 @@ func TestCreate(t *testing.T) {
 	}
 	client := New(scmClient, "Codertocat/Hello-World")
 
-	webhookID, err := client.Create(context.TODO(), "https://example.com/testing", "t0ps3cr3t")
+	webhookID, err := client.Create(context.TODO(), "https://example.com/testing", "t0ps3cr3t", nil)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -45,6 +49,57 @@ func TestCreate(t *testing.T) {
 	}
 }
 
+func TestCreateWithEvents(t *testing.T) {
+	defer gock.Off()
+	gock.New("https://api.github.com").
+		Post("/repos/Codertocat/Hello-World/hooks").
+		MatchHeader("Authorization", "Bearer authtoken").
+		MatchType("json").
+		JSON(map[string]interface{}{
+			"name":   "web",
+			"active": true,
+			"events": []string{"release", "push", "pull_request"},
+			"config": map[string]string{
+				"url":          "https://example.com/testing",
+				"content_type": "json",
+				"secret":       "t0ps3cr3t",
+			}}).
+		Reply(http.StatusCreated).
+		Type("application/json").
+		File("testdata/hook_created.json")
+
+	scmClient, err := factory.NewClient("github", "", "authtoken")
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := New(scmClient, "Codertocat/Hello-World")
+
+	webhookID, err := client.Create(context.TODO(), "https://example.com/testing", "t0ps3cr3t",
+		[]v1alpha1.HookEvent{v1alpha1.PushEvent, v1alpha1.PullRequestEvent, v1alpha1.ReleaseEvent})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "12345678"
+	if webhookID != "12345678" {
+		t.Fatalf("got a different WebHookID back: %#v, want %#v", webhookID, want)
+	}
+}
+
+func TestCreateWithUnsupportedEvent(t *testing.T) {
+	scmClient, err := factory.NewClient("gitlab", "", "authtoken")
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := New(scmClient, "Codertocat/Hello-World")
+
+	_, err = client.Create(context.TODO(), "https://example.com/testing", "t0ps3cr3t",
+		[]v1alpha1.HookEvent{v1alpha1.DeploymentEvent})
+
+	if !IsUnsupportedEvent(err) {
+		t.Fatalf("failed with %#v", err)
+	}
+}
+
 func TestCreateWithNotFoundResponse(t *testing.T) {
 	defer gock.Off()
 	gock.New("https://api.github.com").
@@ -69,7 +124,7 @@ func TestCreateWithNotFoundResponse(t *testing.T) {
 	}
 	client := New(scmClient, "Codertocat/Hello-World")
 
-	_, err = client.Create(context.TODO(), "https://example.com/testing", "t0ps3cr3t")
+	_, err = client.Create(context.TODO(), "https://example.com/testing", "t0ps3cr3t", nil)
 
 	if !IsNotFound(err) {
 		t.Fatalf("failed with %#v", err)
@@ -83,12 +138,220 @@ func TestCreateUnableToConnect(t *testing.T) {
 	}
 	client := New(scmClient, "Codertocat/Hello-World")
 
-	_, err = client.Create(context.TODO(), "pipelines.yaml", "master")
+	_, err = client.Create(context.TODO(), "pipelines.yaml", "master", nil)
 	if !test.MatchError(t, "connection refused", err) {
 		t.Fatal(err)
 	}
 }
 
+func TestUpdate(t *testing.T) {
+	defer gock.Off()
+	gock.New("https://api.github.com").
+		Post("/repos/Codertocat/Hello-World/hooks").
+		MatchHeader("Authorization", "Bearer authtoken").
+		MatchType("json").
+		JSON(map[string]interface{}{
+			"name":   "web",
+			"active": true,
+			"events": []string{"push"},
+			"config": map[string]string{
+				"url":          "https://example.com/new",
+				"content_type": "json",
+				"secret":       "t0ps3cr3t",
+			}}).
+		Reply(http.StatusCreated).
+		Type("application/json").
+		File("testdata/hook_created.json")
+	gock.New("https://api.github.com").
+		Delete("/repos/Codertocat/Hello-World/hooks/1234567").
+		MatchHeader("Authorization", "Bearer authtoken").
+		Reply(http.StatusNoContent)
+
+	scmClient, err := factory.NewClient("github", "", "authtoken")
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := New(scmClient, "Codertocat/Hello-World")
+
+	webhookID, err := client.Update(context.TODO(), "1234567", "https://example.com/new", "t0ps3cr3t", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "12345678"
+	if webhookID != want {
+		t.Fatalf("got a different WebHookID back: %#v, want %#v", webhookID, want)
+	}
+	if !gock.IsDone() {
+		t.Fatal("the hook was not replaced")
+	}
+}
+
+func TestUpdateWithMissingHook(t *testing.T) {
+	defer gock.Off()
+	gock.New("https://api.github.com").
+		Post("/repos/Codertocat/Hello-World/hooks").
+		MatchHeader("Authorization", "Bearer authtoken").
+		Reply(http.StatusCreated).
+		Type("application/json").
+		File("testdata/hook_created.json")
+	gock.New("https://api.github.com").
+		Delete("/repos/Codertocat/Hello-World/hooks/1234567").
+		MatchHeader("Authorization", "Bearer authtoken").
+		Reply(http.StatusNotFound)
+
+	scmClient, err := factory.NewClient("github", "", "authtoken")
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := New(scmClient, "Codertocat/Hello-World")
+
+	webhookID, err := client.Update(context.TODO(), "1234567", "https://example.com/new", "t0ps3cr3t", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if webhookID != "12345678" {
+		t.Fatalf("got a different WebHookID back: %#v, want %#v", webhookID, "12345678")
+	}
+}
+
+func TestUpdateWithFailingDelete(t *testing.T) {
+	defer gock.Off()
+	gock.New("https://api.github.com").
+		Post("/repos/Codertocat/Hello-World/hooks").
+		MatchHeader("Authorization", "Bearer authtoken").
+		Reply(http.StatusCreated).
+		Type("application/json").
+		File("testdata/hook_created.json")
+	gock.New("https://api.github.com").
+		Delete("/repos/Codertocat/Hello-World/hooks/1234567").
+		MatchHeader("Authorization", "Bearer authtoken").
+		Reply(http.StatusInternalServerError)
+	gock.New("https://api.github.com").
+		Delete("/repos/Codertocat/Hello-World/hooks/12345678").
+		MatchHeader("Authorization", "Bearer authtoken").
+		Reply(http.StatusNoContent)
+
+	scmClient, err := factory.NewClient("github", "", "authtoken")
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := New(scmClient, "Codertocat/Hello-World")
+
+	webhookID, err := client.Update(context.TODO(), "1234567", "https://example.com/new", "t0ps3cr3t", nil)
+	if ErrorStatus(err) != http.StatusInternalServerError {
+		t.Fatalf("Update() failed with %#v", err)
+	}
+	if webhookID != "" {
+		t.Fatalf("got a WebHookID back: %#v", webhookID)
+	}
+	if !gock.IsDone() {
+		t.Fatalf("the new hook was not removed, pending requests: %#v", gock.Pending())
+	}
+}
+
+func TestGet(t *testing.T) {
+	defer gock.Off()
+	gock.New("https://api.github.com").
+		Get("/repos/Codertocat/Hello-World/hooks/12345678").
+		MatchHeader("Authorization", "Bearer authtoken").
+		Reply(http.StatusOK).
+		Type("application/json").
+		File("testdata/hook_created.json")
+
+	scmClient, err := factory.NewClient("github", "", "authtoken")
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := New(scmClient, "Codertocat/Hello-World")
+
+	hook, err := client.Get(context.TODO(), "12345678")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &Hook{
+		ID:     "12345678",
+		URL:    "https://example.com/webhook",
+		Events: []string{"push", "pull_request"},
+		Active: true,
+		Driver: scm.DriverGithub,
+	}
+	if diff := cmp.Diff(want, hook); diff != "" {
+		t.Fatalf("incorrect hook returned:\n%s", diff)
+	}
+}
+
+func TestGetWithNotFoundResponse(t *testing.T) {
+	defer gock.Off()
+	gock.New("https://api.github.com").
+		Get("/repos/Codertocat/Hello-World/hooks/12345678").
+		MatchHeader("Authorization", "Bearer authtoken").
+		Reply(http.StatusNotFound).
+		Type("application/json")
+
+	scmClient, err := factory.NewClient("github", "", "authtoken")
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := New(scmClient, "Codertocat/Hello-World")
+
+	_, err = client.Get(context.TODO(), "12345678")
+
+	if !IsNotFound(err) {
+		t.Fatalf("failed with %#v", err)
+	}
+}
+
+func TestList(t *testing.T) {
+	defer gock.Off()
+	gock.New("https://api.github.com").
+		Get("/repos/Codertocat/Hello-World/hooks").
+		MatchParam("page", "1").
+		MatchHeader("Authorization", "Bearer authtoken").
+		Reply(http.StatusOK).
+		Type("application/json").
+		SetHeader("Link", `<https://api.github.com/repos/Codertocat/Hello-World/hooks?page=2&per_page=100>; rel="next"`).
+		File("testdata/hooks.json")
+	gock.New("https://api.github.com").
+		Get("/repos/Codertocat/Hello-World/hooks").
+		MatchParam("page", "2").
+		MatchHeader("Authorization", "Bearer authtoken").
+		Reply(http.StatusOK).
+		Type("application/json").
+		BodyString("[]")
+
+	scmClient, err := factory.NewClient("github", "", "authtoken")
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := New(scmClient, "Codertocat/Hello-World")
+
+	hooks, err := client.List(context.TODO())
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []*Hook{
+		{
+			ID:     "12345678",
+			URL:    "https://example.com/webhook",
+			Events: []string{"push", "pull_request"},
+			Active: true,
+			Driver: scm.DriverGithub,
+		},
+		{
+			ID:     "87654321",
+			URL:    "https://example.com/other",
+			Events: []string{"push"},
+			Driver: scm.DriverGithub,
+		},
+	}
+	if diff := cmp.Diff(want, hooks); diff != "" {
+		t.Fatalf("incorrect hooks returned:\n%s", diff)
+	}
+	if !gock.IsDone() {
+		t.Fatal("not all pages were requested")
+	}
+}
+
 func TestDelete(t *testing.T) {
 	defer gock.Off()
 	gock.New("https://api.github.com").
@@ -127,3 +390,160 @@ func TestDeleteWithNotFoundResponse(t *testing.T) {
 		t.Fatalf("failed with %#v", err)
 	}
 }
+
+func TestDriverHooks(t *testing.T) {
+	driverTests := []struct {
+		driver     string
+		endpoint   string
+		apiURL     string
+		repo       string
+		hooksPath  string
+		createBody map[string]interface{}
+		fixture    string
+		wantID     string
+	}{
+		{
+			driver:    "bitbucket",
+			apiURL:    "https://api.bitbucket.org",
+			repo:      "myorg/myrepo",
+			hooksPath: "/2.0/repositories/myorg/myrepo/hooks",
+			createBody: map[string]interface{}{
+				"description": "webhook-secret-operator",
+				"url":         "https://example.com/testing?secret=t0ps3cr3t",
+				"active":      true,
+				"events":      []string{"repo:push"},
+			},
+			fixture: "testdata/bitbucket_hook.json",
+			wantID:  "{d4d7ff6b-26a4-4c39-b6a9-ea21b4c4bd0d}",
+		},
+		{
+			driver:    "bitbucketcloud",
+			apiURL:    "https://api.bitbucket.org",
+			repo:      "myorg/myrepo",
+			hooksPath: "/2.0/repositories/myorg/myrepo/hooks",
+			createBody: map[string]interface{}{
+				"description": "webhook-secret-operator",
+				"url":         "https://example.com/testing?secret=t0ps3cr3t",
+				"active":      true,
+				"events":      []string{"repo:push"},
+			},
+			fixture: "testdata/bitbucket_hook.json",
+			wantID:  "{d4d7ff6b-26a4-4c39-b6a9-ea21b4c4bd0d}",
+		},
+		{
+			driver:    "stash",
+			endpoint:  "https://bitbucket.example.com",
+			apiURL:    "https://bitbucket.example.com",
+			repo:      "PROJ/myrepo",
+			hooksPath: "/rest/api/1.0/projects/PROJ/repos/myrepo/webhooks",
+			createBody: map[string]interface{}{
+				"name":          "webhook-secret-operator",
+				"url":           "https://example.com/testing",
+				"active":        true,
+				"events":        []string{"repo:refs_changed"},
+				"configuration": map[string]string{"secret": "t0ps3cr3t"},
+			},
+			fixture: "testdata/stash_hook.json",
+			wantID:  "10",
+		},
+		{
+			driver:    "gitea",
+			endpoint:  "https://gitea.example.com",
+			apiURL:    "https://gitea.example.com",
+			repo:      "myorg/myrepo",
+			hooksPath: "/api/v1/repos/myorg/myrepo/hooks",
+			createBody: map[string]interface{}{
+				"id":     0,
+				"type":   "gitea",
+				"active": true,
+				"events": []string{"push"},
+				"config": map[string]string{
+					"url":          "https://example.com/testing?secret=t0ps3cr3t",
+					"content_type": "json",
+					"secret":       "t0ps3cr3t",
+				},
+			},
+			fixture: "testdata/gitea_hook.json",
+			wantID:  "20",
+		},
+		{
+			driver:    "gogs",
+			endpoint:  "https://gogs.example.com",
+			apiURL:    "https://gogs.example.com",
+			repo:      "myorg/myrepo",
+			hooksPath: "/api/v1/repos/myorg/myrepo/hooks",
+			createBody: map[string]interface{}{
+				"id":     0,
+				"type":   "gogs",
+				"active": true,
+				"events": []string{"push"},
+				"config": map[string]string{
+					"url":          "https://example.com/testing",
+					"content_type": "json",
+					"secret":       "t0ps3cr3t",
+				},
+			},
+			fixture: "testdata/gogs_hook.json",
+			wantID:  "30",
+		},
+	}
+
+	for _, tt := range driverTests {
+		t.Run(tt.driver, func(rt *testing.T) {
+			defer gock.Off()
+			hookPath := tt.hooksPath + "/" + regexp.QuoteMeta(tt.wantID)
+			gock.New(tt.apiURL).
+				Post(tt.hooksPath).
+				MatchType("json").
+				JSON(tt.createBody).
+				Reply(http.StatusCreated).
+				Type("application/json").
+				File(tt.fixture)
+			gock.New(tt.apiURL).
+				Get(hookPath).
+				Reply(http.StatusOK).
+				Type("application/json").
+				File(tt.fixture)
+			gock.New(tt.apiURL).
+				Delete(hookPath).
+				Reply(http.StatusNoContent)
+			gock.New(tt.apiURL).
+				Get(hookPath).
+				Reply(http.StatusNotFound).
+				Type("application/json").
+				BodyString("{}")
+
+			scmClient, err := factory.NewClient(tt.driver, tt.endpoint, "authtoken")
+			if err != nil {
+				rt.Fatal(err)
+			}
+			client := New(scmClient, tt.repo)
+			ctx := context.TODO()
+
+			hookID, err := client.Create(ctx, "https://example.com/testing", "t0ps3cr3t", nil)
+			if err != nil {
+				rt.Fatal(err)
+			}
+			if hookID != tt.wantID {
+				rt.Fatalf("Create() got %#v, want %#v", hookID, tt.wantID)
+			}
+			hook, err := client.Get(ctx, hookID)
+			if err != nil {
+				rt.Fatal(err)
+			}
+			if !hook.Matches("https://example.com/testing", nil) {
+				rt.Fatalf("hook %#v does not match the created hook", hook)
+			}
+			if err := client.Delete(ctx, hookID); err != nil {
+				rt.Fatal(err)
+			}
+			_, err = client.Get(ctx, hookID)
+			if !IsNotFound(err) {
+				rt.Fatalf("Get() after Delete() failed with %#v", err)
+			}
+			if !gock.IsDone() {
+				rt.Fatalf("pending requests: %#v", gock.Pending())
+			}
+		})
+	}
+}
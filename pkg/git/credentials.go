@@ -0,0 +1,140 @@
+package git
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/clientcredentials"
+)
+
+// Credentials are used to authenticate requests to a git host's API.
+//
+// Only one of the token, the username and password, the GitHub App or the
+// OAuth2 client should be set.
+type Credentials struct {
+	// Token is a personal access token, or an OAuth2 access token.
+	Token string
+	// Username and Password are sent with basic auth.
+	Username string
+	Password string
+	// GitHubApp is used to mint short-lived installation tokens.
+	GitHubApp *GitHubApp
+	// OAuth2 is used to request access tokens with the client credentials
+	// flow.
+	OAuth2 *OAuth2Client
+}
+
+// GitHubApp identifies an installation of a GitHub App, and the private key
+// that is used to sign requests for installation tokens.
+type GitHubApp struct {
+	AppID          int64
+	InstallationID int64
+	// PrivateKey is the PEM encoded RSA private key for the App.
+	PrivateKey []byte
+}
+
+// OAuth2Client is an OAuth2 client that can request access tokens with the
+// client credentials flow.
+type OAuth2Client struct {
+	ClientID     string
+	ClientSecret string
+	TokenURL     string
+	Scopes       []string
+}
+
+// tokenCacheIdleTime is how long a token source is kept after it was last
+// used, this is longer than the tokens are valid for, so that sources for
+// credentials that have been replaced in the auth secret, are removed once
+// their tokens have expired.
+const tokenCacheIdleTime = 2 * time.Hour
+
+// tokenCache keeps token sources, so that tokens are reused until they're
+// about to expire, rather than being requested for each client.
+type tokenCache struct {
+	mu      sync.Mutex
+	sources map[string]*cachedTokenSource
+	// now is used to track when the token sources were last used, if it's nil,
+	// time.Now is used.
+	now func() time.Time
+}
+
+type cachedTokenSource struct {
+	source   oauth2.TokenSource
+	lastUsed time.Time
+}
+
+// appTokenSource returns the cached token source for the App installation at
+// the API baseURL, creating it if necessary.
+func (c *tokenCache) appTokenSource(client *http.Client, baseURL *url.URL, app GitHubApp) (oauth2.TokenSource, error) {
+	keyHash := sha256.Sum256(app.PrivateKey)
+	cacheKey := fmt.Sprintf("app|%s|%d|%d|%x", baseURL, app.AppID, app.InstallationID, keyHash)
+	return c.tokenSource(cacheKey, func() (oauth2.TokenSource, error) {
+		src, err := newGitHubAppTokenSource(client, baseURL, app)
+		if err != nil {
+			return nil, err
+		}
+		return oauth2.ReuseTokenSource(nil, src), nil
+	})
+}
+
+// clientCredentialsTokenSource returns the cached token source for the OAuth2
+// client, creating it if necessary.
+//
+// New access tokens are requested when the current token expires.
+func (c *tokenCache) clientCredentialsTokenSource(client *http.Client, o OAuth2Client) (oauth2.TokenSource, error) {
+	secretHash := sha256.Sum256([]byte(o.ClientSecret))
+	cacheKey := fmt.Sprintf("oauth2|%s|%s|%s|%x", o.TokenURL, o.ClientID, strings.Join(o.Scopes, " "), secretHash)
+	return c.tokenSource(cacheKey, func() (oauth2.TokenSource, error) {
+		cfg := clientcredentials.Config{
+			ClientID:     o.ClientID,
+			ClientSecret: o.ClientSecret,
+			TokenURL:     o.TokenURL,
+			Scopes:       o.Scopes,
+		}
+		ctx := context.WithValue(context.Background(), oauth2.HTTPClient, client)
+		return cfg.TokenSource(ctx), nil
+	})
+}
+
+// tokenSource returns the cached token source for the key, creating it if
+// necessary, token sources that have been idle for longer than the
+// tokenCacheIdleTime are removed.
+func (c *tokenCache) tokenSource(key string, create func() (oauth2.TokenSource, error)) (oauth2.TokenSource, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := time.Now()
+	if c.now != nil {
+		now = c.now()
+	}
+	c.evict(now)
+	if cached, ok := c.sources[key]; ok {
+		cached.lastUsed = now
+		return cached.source, nil
+	}
+	ts, err := create()
+	if err != nil {
+		return nil, err
+	}
+	if c.sources == nil {
+		c.sources = map[string]*cachedTokenSource{}
+	}
+	c.sources[key] = &cachedTokenSource{source: ts, lastUsed: now}
+	return ts, nil
+}
+
+// evict removes the token sources that haven't been used within the
+// tokenCacheIdleTime.
+func (c *tokenCache) evict(now time.Time) {
+	for k, v := range c.sources {
+		if now.Sub(v.lastUsed) > tokenCacheIdleTime {
+			delete(c.sources, k)
+		}
+	}
+}
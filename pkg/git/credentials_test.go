@@ -0,0 +1,164 @@
+package git
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"golang.org/x/oauth2"
+	"gopkg.in/h2non/gock.v1"
+
+	v1alpha1 "github.com/bigkevmcd/webhook-secret-operator/pkg/apis/apps/v1alpha1"
+)
+
+func TestClientForRepoWithBasicAuth(t *testing.T) {
+	defer gock.Off()
+	gock.New("https://bitbucket.example.com").
+		Get("/rest/api/1.0/projects/PROJ/repos/myrepo/webhooks/10").
+		BasicAuth("jdoe", "t0ps3cr3t").
+		Reply(http.StatusOK).
+		Type("application/json").
+		File("testdata/stash_hook.json")
+	factory := NewClientFactory(NewDriverIdentifier(nil), nil)
+
+	client, err := factory.ClientForRepo(v1alpha1.Repo{URL: "https://bitbucket.example.com/scm/PROJ/myrepo.git"},
+		Credentials{Username: "jdoe", Password: "t0ps3cr3t"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.Get(context.TODO(), "10"); err != nil {
+		t.Fatal(err)
+	}
+
+	if !gock.IsDone() {
+		t.Fatalf("pending requests: %#v", gock.Pending())
+	}
+}
+
+func TestClientForRepoWithAzureToken(t *testing.T) {
+	defer gock.Off()
+	mockAzureSubscription("sub-1", "git.push", "enabled")
+	gock.Observe(func(r *http.Request, _ gock.Mock) {
+		if _, password, _ := r.BasicAuth(); password != "authtoken" {
+			t.Errorf("request was not authenticated with the token")
+		}
+	})
+	defer gock.Observe(nil)
+	factory := NewClientFactory(NewDriverIdentifier(nil), nil)
+
+	client, err := factory.ClientForRepo(v1alpha1.Repo{URL: "https://dev.azure.com/myorg/myproject/_git/myrepo"},
+		Credentials{Token: "authtoken"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.Get(context.TODO(), "sub-1"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestClientForRepoWithOAuth2ClientCredentials(t *testing.T) {
+	var mu sync.Mutex
+	tokenRequests := 0
+	authorizations := []string{}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/oauth/token", func(w http.ResponseWriter, r *http.Request) {
+		if id, secret, _ := r.BasicAuth(); id != "my-client" || secret != "t0ps3cr3t" {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+		if r.FormValue("grant_type") != "client_credentials" || r.FormValue("scope") != "repository:admin webhook" {
+			http.Error(w, "Bad Request", http.StatusBadRequest)
+			return
+		}
+		mu.Lock()
+		tokenRequests++
+		mu.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"access_token": "access-token",
+			"token_type":   "bearer",
+			"expires_in":   3600,
+		})
+	})
+	mux.HandleFunc("/api/v3/repos/myorg/myrepo/hooks/1", func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		authorizations = append(authorizations, r.Header.Get("Authorization"))
+		mu.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"id":     1,
+			"active": true,
+			"config": map[string]string{"url": "https://example.com/testing"},
+		})
+	})
+	ts := httptest.NewServer(mux)
+	defer ts.Close()
+	providers := NewProviderRegistry(Provider{Host: strings.TrimPrefix(ts.URL, "http://"), Driver: "github", Endpoint: ts.URL})
+	factory := NewClientFactory(NewDriverIdentifier(providers), providers)
+	creds := Credentials{
+		OAuth2: &OAuth2Client{
+			ClientID:     "my-client",
+			ClientSecret: "t0ps3cr3t",
+			TokenURL:     ts.URL + "/oauth/token",
+			Scopes:       []string{"repository:admin", "webhook"},
+		},
+	}
+
+	for i := 0; i < 2; i++ {
+		client, err := factory.ClientForRepo(v1alpha1.Repo{URL: ts.URL + "/myorg/myrepo.git"}, creds)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := client.Get(context.TODO(), "1"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if tokenRequests != 1 {
+		t.Fatalf("got %d token requests, want 1", tokenRequests)
+	}
+	for _, a := range authorizations {
+		if a != "Bearer access-token" {
+			t.Fatalf("hook request authorization got %#v", a)
+		}
+	}
+}
+
+func TestTokenCacheEvictsIdleTokenSources(t *testing.T) {
+	now := time.Date(2020, time.June, 1, 12, 0, 0, 0, time.UTC)
+	c := &tokenCache{now: func() time.Time { return now }}
+	created := 0
+	create := func() (oauth2.TokenSource, error) {
+		created++
+		return oauth2.StaticTokenSource(&oauth2.Token{AccessToken: "test-token"}), nil
+	}
+
+	for _, key := range []string{"old-credentials", "new-credentials"} {
+		if _, err := c.tokenSource(key, create); err != nil {
+			t.Fatal(err)
+		}
+	}
+	now = now.Add(tokenCacheIdleTime)
+	if _, err := c.tokenSource("new-credentials", create); err != nil {
+		t.Fatal(err)
+	}
+	now = now.Add(time.Minute)
+	if _, err := c.tokenSource("new-credentials", create); err != nil {
+		t.Fatal(err)
+	}
+
+	if created != 2 {
+		t.Errorf("got %d token sources created, want 2", created)
+	}
+	if _, ok := c.sources["old-credentials"]; ok {
+		t.Error("idle token source was not evicted")
+	}
+	if _, ok := c.sources["new-credentials"]; !ok {
+		t.Error("token source in use was evicted")
+	}
+}
@@ -2,35 +2,68 @@ package git
 
 import (
 	"fmt"
-	"net/url"
+	"strings"
 )
 
 // URLDriverIdentifier is an implementation of the DriverIdentifier interface
-// that looks up hosts in a map.
+// that looks up hosts in a ProviderRegistry, and then a map of well-known
+// hosts.
 type URLDriverIdentifier struct {
-	hosts map[string]string
+	hosts     map[string]string
+	providers *ProviderRegistry
 }
 
 func (u *URLDriverIdentifier) Identify(repoURL string) (string, error) {
-	parsed, err := url.Parse(repoURL)
+	parsed, err := parseRepoURL(repoURL)
 	if err != nil {
-		return "", fmt.Errorf("failed to parse the repoURL %q: %w", repoURL, err)
+		return "", err
+	}
+	if p, ok := u.providers.Lookup(parsed.Host); ok {
+		return p.Driver, nil
 	}
 	d, ok := u.hosts[parsed.Host]
 	if ok {
 		return d, nil
 	}
+	if isBitbucketServerPath(parsed.Path) {
+		return "stash", nil
+	}
+	if isAzureURL(parsed) {
+		return "azure", nil
+	}
 	return "", unknownDriverError{url: repoURL}
 }
 
 // NewDriverIdentifier creates and returns a new URLDriverIdentifier.
-func NewDriverIdentifier() *URLDriverIdentifier {
+//
+// The providers can be nil, in which case only well-known hosts are
+// identified.
+func NewDriverIdentifier(providers *ProviderRegistry) *URLDriverIdentifier {
 	return &URLDriverIdentifier{
 		hosts: map[string]string{
-			"github.com": "github",
-			"gitlab.com": "gitlab",
+			"github.com":    "github",
+			"gitlab.com":    "gitlab",
+			"bitbucket.org": "bitbucket",
+			"dev.azure.com": "azure",
 		},
+		providers: providers,
+	}
+}
+
+// isBitbucketServerPath returns true if the path is in one of the formats
+// that only Bitbucket Server uses, e.g. /scm/PROJECT/repo.git or
+// /projects/PROJECT/repos/repo.
+func isBitbucketServerPath(p string) bool {
+	segments := pathSegments(p)
+	for i, s := range segments {
+		if s == "scm" && len(segments) == i+3 && strings.HasSuffix(p, ".git") {
+			return true
+		}
+		if (s == "projects" || s == "users") && len(segments) >= i+4 && segments[i+2] == "repos" {
+			return true
+		}
 	}
+	return false
 }
 
 type unknownDriverError struct {
@@ -1,6 +1,9 @@
 package git
 
-import "net/http"
+import (
+	"errors"
+	"net/http"
+)
 
 // IsNotFound returns true if the error represents a NotFound response from an
 // upstream service.
@@ -9,11 +12,34 @@ func IsNotFound(err error) bool {
 	return ok && e.Status == http.StatusNotFound
 }
 
+// ErrorStatus returns the HTTP status code from an error returned by an
+// upstream service, or 0 if the error didn't come from an HTTP response.
+func ErrorStatus(err error) int {
+	var e SCMError
+	if errors.As(err, &e) {
+		return e.Status
+	}
+	return 0
+}
+
+// IsPermissionDenied returns true if the error represents a request that was
+// denied because the credentials don't have a permission that's needed.
+func IsPermissionDenied(err error) bool {
+	var e SCMError
+	return errors.As(err, &e) && e.Permission != ""
+}
+
 type SCMError struct {
 	msg    string
 	Status int
+	// Permission describes the permission that's needed, if the request was
+	// denied by the upstream service.
+	Permission string
 }
 
 func (s SCMError) Error() string {
+	if s.Permission != "" {
+		return s.msg + ", " + s.Permission
+	}
 	return s.msg
 }
@@ -0,0 +1,45 @@
+package git
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+func TestErrorStatus(t *testing.T) {
+	statusTests := []struct {
+		err  error
+		want int
+	}{
+		{SCMError{msg: "not found", Status: http.StatusNotFound}, http.StatusNotFound},
+		{fmt.Errorf("wrapped: %w", SCMError{msg: "forbidden", Status: http.StatusForbidden}), http.StatusForbidden},
+		{errors.New("connection refused"), 0},
+		{nil, 0},
+	}
+
+	for _, tt := range statusTests {
+		if got := ErrorStatus(tt.err); got != tt.want {
+			t.Errorf("ErrorStatus(%v) got %d, want %d", tt.err, got, tt.want)
+		}
+	}
+}
+
+func TestIsPermissionDenied(t *testing.T) {
+	deniedTests := []struct {
+		err  error
+		want bool
+	}{
+		{SCMError{msg: "forbidden", Status: http.StatusForbidden, Permission: "needs admin"}, true},
+		{fmt.Errorf("wrapped: %w", SCMError{msg: "forbidden", Status: http.StatusForbidden, Permission: "needs admin"}), true},
+		{SCMError{msg: "forbidden", Status: http.StatusForbidden}, false},
+		{errors.New("connection refused"), false},
+		{nil, false},
+	}
+
+	for _, tt := range deniedTests {
+		if got := IsPermissionDenied(tt.err); got != tt.want {
+			t.Errorf("IsPermissionDenied(%v) got %v, want %v", tt.err, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,168 @@
+package git
+
+import (
+	"fmt"
+
+	"github.com/jenkins-x/go-scm/scm"
+
+	v1alpha1 "github.com/bigkevmcd/webhook-secret-operator/pkg/apis/apps/v1alpha1"
+)
+
+// DriverAzure identifies Azure DevOps, which go-scm doesn't have a driver for.
+const DriverAzure scm.Driver = 100
+
+// driverName returns the name of the driver.
+func driverName(d scm.Driver) string {
+	if d == DriverAzure {
+		return "azure"
+	}
+	return d.String()
+}
+
+// defaultEvents is used when no events are requested for a hook.
+var defaultEvents = []v1alpha1.HookEvent{v1alpha1.PushEvent}
+
+// driverEvents maps the events that each driver can subscribe hooks to, to
+// the names that the driver reports for the events on a hook.
+var driverEvents = map[scm.Driver]map[v1alpha1.HookEvent][]string{
+	scm.DriverGithub: {
+		v1alpha1.PushEvent:               {"push"},
+		v1alpha1.PullRequestEvent:        {"pull_request"},
+		v1alpha1.PullRequestCommentEvent: {"pull_request_review_comment", "issue_comment"},
+		v1alpha1.BranchEvent:             {"create", "delete"},
+		v1alpha1.TagEvent:                {"create", "delete"},
+		v1alpha1.IssuesEvent:             {"issues"},
+		v1alpha1.IssueCommentEvent:       {"issue_comment"},
+		v1alpha1.ReleaseEvent:            {"release"},
+		v1alpha1.DeploymentEvent:         {"deployment"},
+	},
+	scm.DriverGitlab: {
+		v1alpha1.PushEvent:               {"push"},
+		v1alpha1.PullRequestEvent:        {"merge"},
+		v1alpha1.PullRequestCommentEvent: {"comment"},
+		v1alpha1.BranchEvent:             {"push"},
+		v1alpha1.TagEvent:                {"tag"},
+		v1alpha1.IssuesEvent:             {"issues"},
+		v1alpha1.IssueCommentEvent:       {"comment"},
+	},
+	scm.DriverGitea: {
+		v1alpha1.PushEvent:               {"push"},
+		v1alpha1.PullRequestEvent:        {"pull_request"},
+		v1alpha1.PullRequestCommentEvent: {"issue_comment"},
+		v1alpha1.BranchEvent:             {"create", "delete"},
+		v1alpha1.TagEvent:                {"create", "delete"},
+		v1alpha1.IssuesEvent:             {"issues"},
+		v1alpha1.IssueCommentEvent:       {"issue_comment"},
+		v1alpha1.ReleaseEvent:            {"release"},
+	},
+	scm.DriverGogs: {
+		v1alpha1.PushEvent:               {"push"},
+		v1alpha1.PullRequestEvent:        {"pull_request"},
+		v1alpha1.PullRequestCommentEvent: {"issue_comment"},
+		v1alpha1.BranchEvent:             {"create", "delete"},
+		v1alpha1.TagEvent:                {"create", "delete"},
+		v1alpha1.IssuesEvent:             {"issues"},
+		v1alpha1.IssueCommentEvent:       {"issue_comment"},
+		v1alpha1.ReleaseEvent:            {"release"},
+	},
+	scm.DriverStash: {
+		v1alpha1.PushEvent:               {"repo:refs_changed"},
+		v1alpha1.PullRequestEvent:        {"pr:declined", "pr:modified", "pr:deleted", "pr:opened", "pr:merged"},
+		v1alpha1.PullRequestCommentEvent: {"pr:comment:added", "pr:comment:deleted", "pr:comment:edited"},
+		v1alpha1.BranchEvent:             {"repo:refs_changed"},
+		v1alpha1.TagEvent:                {"repo:refs_changed"},
+	},
+	DriverAzure: {
+		v1alpha1.PushEvent:               {"git.push"},
+		v1alpha1.PullRequestEvent:        {"git.pullrequest.created", "git.pullrequest.updated", "git.pullrequest.merged"},
+		v1alpha1.PullRequestCommentEvent: {"ms.vss-code.git-pullrequest-comment-event"},
+	},
+	scm.DriverBitbucket: {
+		v1alpha1.PushEvent: {"repo:push"},
+		v1alpha1.PullRequestEvent: {
+			"pullrequest:updated", "pullrequest:unapproved", "pullrequest:approved",
+			"pullrequest:rejected", "pullrequest:fulfilled", "pullrequest:created",
+		},
+		v1alpha1.PullRequestCommentEvent: {
+			"pullrequest:comment_created", "pullrequest:comment_updated", "pullrequest:comment_deleted",
+		},
+		v1alpha1.IssuesEvent:       {"issues", "issue:created", "issue:updated"},
+		v1alpha1.IssueCommentEvent: {"issue:comment_created"},
+	},
+}
+
+// convertEvents translates the events into the go-scm representation for the
+// driver.
+//
+// If no events are provided, the hook is subscribed to push events.
+//
+// Events that go-scm can't represent are returned as native events, using
+// the driver's name for the event.
+//
+// If the driver doesn't support one of the events, an error is returned.
+func convertEvents(driver scm.Driver, events []v1alpha1.HookEvent) (scm.HookEvents, []string, error) {
+	if len(events) == 0 {
+		events = defaultEvents
+	}
+	hookEvents := scm.HookEvents{}
+	var native []string
+	for _, e := range events {
+		if !supportsEvent(driver, e) {
+			return scm.HookEvents{}, nil, unsupportedEventError{driver: driver, event: e}
+		}
+		switch e {
+		case v1alpha1.PushEvent:
+			hookEvents.Push = true
+		case v1alpha1.PullRequestEvent:
+			hookEvents.PullRequest = true
+		case v1alpha1.PullRequestCommentEvent:
+			hookEvents.PullRequestComment = true
+		case v1alpha1.BranchEvent:
+			hookEvents.Branch = true
+		case v1alpha1.TagEvent:
+			hookEvents.Tag = true
+		case v1alpha1.IssuesEvent:
+			hookEvents.Issue = true
+		case v1alpha1.IssueCommentEvent:
+			hookEvents.IssueComment = true
+		default:
+			native = append(native, driverEvents[driver][e]...)
+		}
+	}
+	return hookEvents, native, nil
+}
+
+// eventNames returns the set of names that the driver reports for the events.
+func eventNames(driver scm.Driver, events []v1alpha1.HookEvent) map[string]bool {
+	if len(events) == 0 {
+		events = defaultEvents
+	}
+	names := map[string]bool{}
+	for _, e := range events {
+		for _, n := range driverEvents[driver][e] {
+			names[n] = true
+		}
+	}
+	return names
+}
+
+func supportsEvent(driver scm.Driver, e v1alpha1.HookEvent) bool {
+	_, ok := driverEvents[driver][e]
+	return ok
+}
+
+type unsupportedEventError struct {
+	driver scm.Driver
+	event  v1alpha1.HookEvent
+}
+
+func (e unsupportedEventError) Error() string {
+	return fmt.Sprintf("event %q is not supported by driver %s", e.event, driverName(e.driver))
+}
+
+// IsUnsupportedEvent returns true if the provided error means that a hook
+// was requested with an event that the driver can't subscribe to.
+func IsUnsupportedEvent(err error) bool {
+	_, ok := err.(unsupportedEventError)
+	return ok
+}
@@ -0,0 +1,73 @@
+package git
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/jenkins-x/go-scm/scm"
+
+	v1alpha1 "github.com/bigkevmcd/webhook-secret-operator/pkg/apis/apps/v1alpha1"
+	"github.com/bigkevmcd/webhook-secret-operator/test"
+)
+
+func TestConvertEvents(t *testing.T) {
+	eventTests := []struct {
+		name       string
+		driver     scm.Driver
+		events     []v1alpha1.HookEvent
+		wantEvents scm.HookEvents
+		wantNative []string
+		wantErr    string
+	}{
+		{
+			name:       "no events defaults to push",
+			driver:     scm.DriverGithub,
+			wantEvents: scm.HookEvents{Push: true},
+		},
+		{
+			name:       "push and pull_request",
+			driver:     scm.DriverGitlab,
+			events:     []v1alpha1.HookEvent{v1alpha1.PushEvent, v1alpha1.PullRequestEvent},
+			wantEvents: scm.HookEvents{Push: true, PullRequest: true},
+		},
+		{
+			name:       "comments and tags",
+			driver:     scm.DriverGitea,
+			events:     []v1alpha1.HookEvent{v1alpha1.TagEvent, v1alpha1.IssueCommentEvent, v1alpha1.PullRequestCommentEvent},
+			wantEvents: scm.HookEvents{Tag: true, IssueComment: true, PullRequestComment: true},
+		},
+		{
+			name:       "native events",
+			driver:     scm.DriverGithub,
+			events:     []v1alpha1.HookEvent{v1alpha1.ReleaseEvent, v1alpha1.DeploymentEvent},
+			wantNative: []string{"release", "deployment"},
+		},
+		{
+			name:    "unsupported event",
+			driver:  scm.DriverStash,
+			events:  []v1alpha1.HookEvent{v1alpha1.PushEvent, v1alpha1.IssuesEvent},
+			wantErr: `event "issues" is not supported by driver stash`,
+		},
+		{
+			name:    "unknown event",
+			driver:  scm.DriverGithub,
+			events:  []v1alpha1.HookEvent{"unknown"},
+			wantErr: `event "unknown" is not supported by driver github`,
+		},
+	}
+
+	for _, tt := range eventTests {
+		t.Run(tt.name, func(rt *testing.T) {
+			events, native, err := convertEvents(tt.driver, tt.events)
+			if !test.MatchError(rt, tt.wantErr, err) {
+				rt.Errorf("error failed to match, got %#v, want %s", err, tt.wantErr)
+			}
+			if diff := cmp.Diff(tt.wantEvents, events); diff != "" {
+				rt.Errorf("events failed to match:\n%s", diff)
+			}
+			if diff := cmp.Diff(tt.wantNative, native); diff != "" {
+				rt.Errorf("native events failed to match:\n%s", diff)
+			}
+		})
+	}
+}
@@ -0,0 +1,135 @@
+package git
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"golang.org/x/oauth2"
+)
+
+// installationTokenMargin is how long before an installation token expires
+// that a new token is requested.
+const installationTokenMargin = 5 * time.Minute
+
+// appJWTLifetime is how long the JWTs that are exchanged for installation
+// tokens are valid for, GitHub allows up to 10 minutes.
+const appJWTLifetime = 9 * time.Minute
+
+// githubAppTokenSource is an oauth2.TokenSource that exchanges a JWT, signed
+// with a GitHub App's private key, for an installation token.
+type githubAppTokenSource struct {
+	client  *http.Client
+	baseURL *url.URL
+	app     GitHubApp
+	key     *rsa.PrivateKey
+	now     func() time.Time
+}
+
+// newGitHubAppTokenSource creates and returns a token source that requests
+// installation tokens from the GitHub API at baseURL.
+func newGitHubAppTokenSource(c *http.Client, baseURL *url.URL, app GitHubApp) (*githubAppTokenSource, error) {
+	key, err := parsePrivateKey(app.PrivateKey)
+	if err != nil {
+		return nil, err
+	}
+	return &githubAppTokenSource{client: c, baseURL: baseURL, app: app, key: key, now: time.Now}, nil
+}
+
+// Token implements the oauth2.TokenSource interface.
+//
+// The token expires before GitHub expires it, so that it's refreshed before
+// it can expire during a request.
+func (s *githubAppTokenSource) Token() (*oauth2.Token, error) {
+	jwt, err := signAppJWT(s.app.AppID, s.key, s.now())
+	if err != nil {
+		return nil, err
+	}
+	u, err := s.baseURL.Parse(fmt.Sprintf("app/installations/%d/access_tokens", s.app.InstallationID))
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequest(http.MethodPost, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+jwt)
+	req.Header.Set("Accept", "application/vnd.github.v3+json")
+	res, err := s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to request an installation token: %w", err)
+	}
+	defer res.Body.Close()
+	if isErrorStatus(res.StatusCode) {
+		return nil, SCMError{
+			msg:    fmt.Sprintf("failed to request an installation token for installation %d", s.app.InstallationID),
+			Status: res.StatusCode,
+		}
+	}
+	var body struct {
+		Token     string    `json:"token"`
+		ExpiresAt time.Time `json:"expires_at"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&body); err != nil {
+		return nil, fmt.Errorf("failed to decode the installation token: %w", err)
+	}
+	return &oauth2.Token{
+		AccessToken: body.Token,
+		Expiry:      body.ExpiresAt.Add(-installationTokenMargin),
+	}, nil
+}
+
+// signAppJWT returns a JWT, signed with RS256, that authenticates as the App.
+//
+// The issued at time is backdated to allow for clock drift.
+func signAppJWT(appID int64, key *rsa.PrivateKey, now time.Time) (string, error) {
+	header, err := json.Marshal(map[string]string{"alg": "RS256", "typ": "JWT"})
+	if err != nil {
+		return "", err
+	}
+	claims, err := json.Marshal(map[string]interface{}{
+		"iat": now.Add(-time.Minute).Unix(),
+		"exp": now.Add(appJWTLifetime).Unix(),
+		"iss": strconv.FormatInt(appID, 10),
+	})
+	if err != nil {
+		return "", err
+	}
+	unsigned := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(claims)
+	digest := sha256.Sum256([]byte(unsigned))
+	sig, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+	if err != nil {
+		return "", fmt.Errorf("failed to sign the GitHub App JWT: %w", err)
+	}
+	return unsigned + "." + base64.RawURLEncoding.EncodeToString(sig), nil
+}
+
+// parsePrivateKey parses a PEM encoded PKCS#1 or PKCS#8 RSA private key.
+func parsePrivateKey(b []byte) (*rsa.PrivateKey, error) {
+	block, _ := pem.Decode(b)
+	if block == nil {
+		return nil, fmt.Errorf("no PEM encoded private key found")
+	}
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse the private key: %w", err)
+	}
+	key, ok := parsed.(*rsa.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("the private key is not an RSA key")
+	}
+	return key, nil
+}
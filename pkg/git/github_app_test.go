@@ -0,0 +1,342 @@
+package git
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	v1alpha1 "github.com/bigkevmcd/webhook-secret-operator/pkg/apis/apps/v1alpha1"
+	"github.com/bigkevmcd/webhook-secret-operator/test"
+)
+
+const (
+	testAppID          = 1234
+	testInstallationID = 5678
+)
+
+func TestSignAppJWT(t *testing.T) {
+	key := generateKey(t)
+	now := time.Date(2020, time.July, 1, 12, 0, 0, 0, time.UTC)
+
+	jwt, err := signAppJWT(testAppID, key, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	claims := verifyJWT(t, jwt, &key.PublicKey)
+	want := map[string]interface{}{
+		"iat": float64(now.Add(-time.Minute).Unix()),
+		"exp": float64(now.Add(appJWTLifetime).Unix()),
+		"iss": "1234",
+	}
+	for k, v := range want {
+		if claims[k] != v {
+			t.Errorf("claim %s got %#v, want %#v", k, claims[k], v)
+		}
+	}
+}
+
+func TestParsePrivateKey(t *testing.T) {
+	key := generateKey(t)
+	pkcs8, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	keyTests := []struct {
+		name    string
+		pem     []byte
+		wantErr string
+	}{
+		{"PKCS#1", encodeKey(key), ""},
+		{"PKCS#8", pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: pkcs8}), ""},
+		{"not PEM", []byte("not a key"), "no PEM encoded private key found"},
+		{"not a key", pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: []byte("junk")}), "failed to parse the private key"},
+	}
+
+	for _, tt := range keyTests {
+		t.Run(tt.name, func(rt *testing.T) {
+			parsed, err := parsePrivateKey(tt.pem)
+			if !test.MatchError(rt, tt.wantErr, err) {
+				rt.Fatalf("error failed to match, got %#v, want %s", err, tt.wantErr)
+			}
+			if tt.wantErr == "" && !parsed.Equal(key) {
+				rt.Fatal("parsed key does not match")
+			}
+		})
+	}
+}
+
+func TestGitHubAppTokenSource(t *testing.T) {
+	key := generateKey(t)
+	now := time.Now()
+	stub := newStubGitHub(t, &key.PublicKey, now.Add(time.Hour))
+	defer stub.Close()
+	src, err := newGitHubAppTokenSource(http.DefaultClient, stub.baseURL(t),
+		GitHubApp{AppID: testAppID, InstallationID: testInstallationID, PrivateKey: encodeKey(key)})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	token, err := src.Token()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if token.AccessToken != "installation-token-1" {
+		t.Fatalf("got token %#v", token.AccessToken)
+	}
+	if want := now.Add(time.Hour - installationTokenMargin); !token.Expiry.Equal(want.Truncate(time.Second)) {
+		t.Fatalf("token expiry got %s, want %s", token.Expiry, want)
+	}
+}
+
+func TestGitHubAppTokenSourceWithErrorResponse(t *testing.T) {
+	key := generateKey(t)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "Not Found", http.StatusNotFound)
+	}))
+	defer ts.Close()
+	u, _ := url.Parse(ts.URL + "/")
+	src, err := newGitHubAppTokenSource(http.DefaultClient, u,
+		GitHubApp{AppID: testAppID, InstallationID: testInstallationID, PrivateKey: encodeKey(key)})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = src.Token()
+
+	if !IsNotFound(err) {
+		t.Fatalf("Token() failed with %#v", err)
+	}
+	if !test.MatchError(t, "failed to request an installation token for installation 5678", err) {
+		t.Fatalf("got incorrect error %s", err)
+	}
+}
+
+func TestTokenCacheAppReusesTokens(t *testing.T) {
+	key := generateKey(t)
+	stub := newStubGitHub(t, &key.PublicKey, time.Now().Add(time.Hour))
+	defer stub.Close()
+	app := GitHubApp{AppID: testAppID, InstallationID: testInstallationID, PrivateKey: encodeKey(key)}
+	cache := &tokenCache{}
+
+	for i := 0; i < 2; i++ {
+		ts, err := cache.appTokenSource(http.DefaultClient, stub.baseURL(t), app)
+		if err != nil {
+			t.Fatal(err)
+		}
+		token, err := ts.Token()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if token.AccessToken != "installation-token-1" {
+			t.Fatalf("got token %#v", token.AccessToken)
+		}
+	}
+	if c := stub.tokenRequests(); c != 1 {
+		t.Fatalf("got %d token requests, want 1", c)
+	}
+}
+
+func TestTokenCacheAppRefreshesTokens(t *testing.T) {
+	key := generateKey(t)
+	// The token expires within the margin, so it's already due a refresh.
+	stub := newStubGitHub(t, &key.PublicKey, time.Now().Add(installationTokenMargin/2))
+	defer stub.Close()
+	app := GitHubApp{AppID: testAppID, InstallationID: testInstallationID, PrivateKey: encodeKey(key)}
+	cache := &tokenCache{}
+	ts, err := cache.appTokenSource(http.DefaultClient, stub.baseURL(t), app)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 1; i <= 2; i++ {
+		token, err := ts.Token()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := fmt.Sprintf("installation-token-%d", i); token.AccessToken != want {
+			t.Fatalf("got token %#v, want %#v", token.AccessToken, want)
+		}
+	}
+}
+
+func TestClientForRepoWithGitHubApp(t *testing.T) {
+	key := generateKey(t)
+	stub := newStubGitHub(t, &key.PublicKey, time.Now().Add(time.Hour))
+	defer stub.Close()
+	host := strings.TrimPrefix(stub.URL, "http://")
+	providers := NewProviderRegistry(Provider{Host: host, Driver: "github", Endpoint: stub.URL})
+	factory := NewClientFactory(NewDriverIdentifier(providers), providers)
+	creds := Credentials{
+		GitHubApp: &GitHubApp{AppID: testAppID, InstallationID: testInstallationID, PrivateKey: encodeKey(key)},
+	}
+
+	client, err := factory.ClientForRepo(v1alpha1.Repo{URL: stub.URL + "/myorg/myrepo.git"}, creds)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hook, err := client.Get(context.TODO(), "1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if hook.URL != "https://example.com/testing" {
+		t.Fatalf("got hook %#v", hook)
+	}
+	if got := stub.hookAuthorization(); got != "Bearer installation-token-1" {
+		t.Fatalf("hook request authorization got %#v", got)
+	}
+}
+
+func TestClientForRepoWithGitHubAppAndOtherDriver(t *testing.T) {
+	factory := NewClientFactory(NewDriverIdentifier(nil), nil)
+	creds := Credentials{GitHubApp: &GitHubApp{AppID: testAppID}}
+
+	_, err := factory.ClientForRepo(v1alpha1.Repo{URL: "https://gitlab.com/myorg/myrepo.git"}, creds)
+
+	if !test.MatchError(t, "GitHub App credentials can't be used with the gitlab driver", err) {
+		t.Fatalf("got incorrect error %#v", err)
+	}
+}
+
+// stubGitHub is a local stand-in for the GitHub API that issues installation
+// tokens when presented with a valid JWT, and serves a single hook.
+type stubGitHub struct {
+	*httptest.Server
+	t         *testing.T
+	publicKey *rsa.PublicKey
+	expiresAt time.Time
+
+	mu        sync.Mutex
+	tokens    int
+	hookAuthz string
+}
+
+func newStubGitHub(t *testing.T, pub *rsa.PublicKey, expiresAt time.Time) *stubGitHub {
+	s := &stubGitHub{t: t, publicKey: pub, expiresAt: expiresAt}
+	mux := http.NewServeMux()
+	mux.HandleFunc(fmt.Sprintf("/api/v3/app/installations/%d/access_tokens", testInstallationID), s.accessTokens)
+	mux.HandleFunc("/api/v3/repos/myorg/myrepo/hooks/1", s.hook)
+	s.Server = httptest.NewServer(mux)
+	return s
+}
+
+func (s *stubGitHub) baseURL(t *testing.T) *url.URL {
+	u, err := url.Parse(s.URL + "/api/v3/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return u
+}
+
+func (s *stubGitHub) accessTokens(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method Not Allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	claims := verifyJWT(s.t, strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer "), s.publicKey)
+	if claims["iss"] != "1234" {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	s.mu.Lock()
+	s.tokens++
+	token := fmt.Sprintf("installation-token-%d", s.tokens)
+	s.mu.Unlock()
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	_ = json.NewEncoder(w).Encode(map[string]string{
+		"token":      token,
+		"expires_at": s.expiresAt.UTC().Format(time.RFC3339),
+	})
+}
+
+func (s *stubGitHub) hook(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	s.hookAuthz = r.Header.Get("Authorization")
+	s.mu.Unlock()
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{
+		"id":     1,
+		"active": true,
+		"events": []string{"push"},
+		"config": map[string]string{"url": "https://example.com/testing"},
+	})
+}
+
+func (s *stubGitHub) tokenRequests() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.tokens
+}
+
+func (s *stubGitHub) hookAuthorization() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.hookAuthz
+}
+
+// verifyJWT checks the RS256 signature of the JWT, and returns the claims.
+func verifyJWT(t *testing.T, jwt string, pub *rsa.PublicKey) map[string]interface{} {
+	t.Helper()
+	parts := strings.Split(jwt, ".")
+	if len(parts) != 3 {
+		t.Fatalf("invalid JWT %#v", jwt)
+	}
+	header := map[string]string{}
+	decodeJWTPart(t, parts[0], &header)
+	if header["alg"] != "RS256" {
+		t.Fatalf("got alg %#v, want RS256", header["alg"])
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		t.Fatal(err)
+	}
+	digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	if err := rsa.VerifyPKCS1v15(pub, crypto.SHA256, digest[:], sig); err != nil {
+		t.Fatalf("failed to verify the JWT signature: %s", err)
+	}
+	claims := map[string]interface{}{}
+	decodeJWTPart(t, parts[1], &claims)
+	return claims
+}
+
+func decodeJWTPart(t *testing.T, s string, v interface{}) {
+	t.Helper()
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := json.Unmarshal(b, v); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func generateKey(t *testing.T) *rsa.PrivateKey {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+func encodeKey(key *rsa.PrivateKey) []byte {
+	return pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+}
@@ -0,0 +1,206 @@
+package git
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+
+	"github.com/jenkins-x/go-scm/scm"
+
+	v1alpha1 "github.com/bigkevmcd/webhook-secret-operator/pkg/apis/apps/v1alpha1"
+)
+
+// githubOrgPermission is the permission that's needed to manage organization
+// hooks.
+const githubOrgPermission = "managing organization hooks requires an organization owner, and a token with the admin:org_hook scope"
+
+// githubReposPermission is the permission that's needed to list the private
+// repositories in an organization.
+const githubReposPermission = "listing the repositories in an organization requires a member of the organization, and a token with the repo scope"
+
+// GitHubOrgHooksClient is an implementation of HooksClient that manages the
+// webhooks of a GitHub organization, which deliver the events for all the
+// repositories in the organization.
+type GitHubOrgHooksClient struct {
+	Client *scm.Client
+	Org    string
+}
+
+// NewGitHubOrg creates and returns a new GitHubOrgHooksClient.
+//
+// The go-scm client is used to authenticate the requests.
+func NewGitHubOrg(c *scm.Client, org string) *GitHubOrgHooksClient {
+	return &GitHubOrgHooksClient{Client: c, Org: org}
+}
+
+// Create creates a new organization webhook, subscribed to the provided
+// events.
+//
+// If no events are provided, the hook is subscribed to push events.
+func (c *GitHubOrgHooksClient) Create(ctx context.Context, hookURL, secret string, events []v1alpha1.HookEvent) (string, error) {
+	in, err := githubOrgHookInput(hookURL, secret, events)
+	if err != nil {
+		return "", err
+	}
+	out := &githubOrgHook{}
+	if err := doSCM(ctx, c.Client, http.MethodPost, c.path(""), in, out); err != nil {
+		return "", scopedError(err, fmt.Sprintf("failed to create hook in organization %s", c.Org), githubOrgPermission)
+	}
+	return strconv.Itoa(out.ID), nil
+}
+
+// Update changes the URL, secret and events of an organization webhook.
+//
+// GitHub supports editing hooks, so the ID doesn't change.
+func (c *GitHubOrgHooksClient) Update(ctx context.Context, hookID, hookURL, secret string, events []v1alpha1.HookEvent) (string, error) {
+	in, err := githubOrgHookInput(hookURL, secret, events)
+	if err != nil {
+		return "", err
+	}
+	out := &githubOrgHook{}
+	if err := doSCM(ctx, c.Client, http.MethodPatch, c.path(hookID), in, out); err != nil {
+		return "", scopedError(err, fmt.Sprintf("failed to update hook %s in organization %s", hookID, c.Org), githubOrgPermission)
+	}
+	return strconv.Itoa(out.ID), nil
+}
+
+// Get fetches an organization webhook.
+func (c *GitHubOrgHooksClient) Get(ctx context.Context, hookID string) (*Hook, error) {
+	out := &githubOrgHook{}
+	if err := doSCM(ctx, c.Client, http.MethodGet, c.path(hookID), nil, out); err != nil {
+		return nil, scopedError(err, fmt.Sprintf("failed to get hook %s in organization %s", hookID, c.Org), githubOrgPermission)
+	}
+	return out.convert(), nil
+}
+
+// List fetches all the webhooks for the organization.
+func (c *GitHubOrgHooksClient) List(ctx context.Context) ([]*Hook, error) {
+	hooks := []*Hook{}
+	for page := 1; ; page++ {
+		out := []githubOrgHook{}
+		path := fmt.Sprintf("%s?per_page=%d&page=%d", c.path(""), listPageSize, page)
+		if err := doSCM(ctx, c.Client, http.MethodGet, path, nil, &out); err != nil {
+			return nil, scopedError(err, fmt.Sprintf("failed to list hooks in organization %s", c.Org), githubOrgPermission)
+		}
+		for i := range out {
+			hooks = append(hooks, out[i].convert())
+		}
+		if len(out) < listPageSize {
+			return hooks, nil
+		}
+	}
+}
+
+// Delete removes an organization webhook.
+func (c *GitHubOrgHooksClient) Delete(ctx context.Context, hookID string) error {
+	if err := doSCM(ctx, c.Client, http.MethodDelete, c.path(hookID), nil, nil); err != nil {
+		return scopedError(err, fmt.Sprintf("failed to delete hook in organization %s", c.Org), githubOrgPermission)
+	}
+	return nil
+}
+
+// ListRepositories fetches all the repositories in the organization.
+//
+// GitHub Enterprise Server versions that don't report the visibility of
+// repositories are either public or private.
+func (c *GitHubOrgHooksClient) ListRepositories(ctx context.Context) ([]*RemoteRepository, error) {
+	repos := []*RemoteRepository{}
+	for page := 1; ; page++ {
+		out := []githubRepository{}
+		path := fmt.Sprintf("orgs/%s/repos?type=all&per_page=%d&page=%d", url.PathEscape(c.Org), listPageSize, page)
+		if err := doSCM(ctx, c.Client, http.MethodGet, path, nil, &out); err != nil {
+			return nil, scopedError(err, fmt.Sprintf("failed to list repositories in organization %s", c.Org), githubReposPermission)
+		}
+		for i := range out {
+			repos = append(repos, out[i].convert())
+		}
+		if len(out) < listPageSize {
+			return repos, nil
+		}
+	}
+}
+
+func (c *GitHubOrgHooksClient) path(hookID string) string {
+	p := fmt.Sprintf("orgs/%s/hooks", url.PathEscape(c.Org))
+	if hookID != "" {
+		p = p + "/" + url.PathEscape(hookID)
+	}
+	return p
+}
+
+// githubOrgHookInput returns the request to create or update a hook, with the
+// GitHub names for the events.
+func githubOrgHookInput(hookURL, secret string, events []v1alpha1.HookEvent) (*githubOrgHook, error) {
+	for _, e := range events {
+		if !supportsEvent(scm.DriverGithub, e) {
+			return nil, unsupportedEventError{driver: scm.DriverGithub, event: e}
+		}
+	}
+	names := []string{}
+	for n := range eventNames(scm.DriverGithub, events) {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	return &githubOrgHook{
+		Name:   "web",
+		Active: true,
+		Events: names,
+		Config: githubHookConfig{URL: hookURL, ContentType: "json", Secret: secret, InsecureSSL: "0"},
+	}, nil
+}
+
+type githubOrgHook struct {
+	ID     int              `json:"id,omitempty"`
+	Name   string           `json:"name,omitempty"`
+	Active bool             `json:"active"`
+	Events []string         `json:"events"`
+	Config githubHookConfig `json:"config"`
+}
+
+type githubHookConfig struct {
+	URL         string `json:"url"`
+	ContentType string `json:"content_type,omitempty"`
+	Secret      string `json:"secret,omitempty"`
+	InsecureSSL string `json:"insecure_ssl,omitempty"`
+}
+
+func (h *githubOrgHook) convert() *Hook {
+	return &Hook{
+		ID:     strconv.Itoa(h.ID),
+		URL:    h.Config.URL,
+		Events: h.Events,
+		Active: h.Active,
+		Driver: scm.DriverGithub,
+	}
+}
+
+type githubRepository struct {
+	Name       string   `json:"name"`
+	FullName   string   `json:"full_name"`
+	HTMLURL    string   `json:"html_url"`
+	Private    bool     `json:"private"`
+	Visibility string   `json:"visibility"`
+	Archived   bool     `json:"archived"`
+	Topics     []string `json:"topics"`
+}
+
+func (r *githubRepository) convert() *RemoteRepository {
+	visibility := r.Visibility
+	if visibility == "" {
+		visibility = "public"
+		if r.Private {
+			visibility = "private"
+		}
+	}
+	return &RemoteRepository{
+		Name:       r.Name,
+		FullName:   r.FullName,
+		URL:        r.HTMLURL,
+		Visibility: visibility,
+		Archived:   r.Archived,
+		Topics:     r.Topics,
+	}
+}
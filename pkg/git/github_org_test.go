@@ -0,0 +1,232 @@
+package git
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/jenkins-x/go-scm/scm"
+	"github.com/jenkins-x/go-scm/scm/factory"
+	"gopkg.in/h2non/gock.v1"
+
+	v1alpha1 "github.com/bigkevmcd/webhook-secret-operator/pkg/apis/apps/v1alpha1"
+)
+
+var _ HooksClient = (*GitHubOrgHooksClient)(nil)
+
+func TestGitHubOrgCreate(t *testing.T) {
+	defer gock.Off()
+	gock.New("https://api.github.com").
+		Post("/orgs/myorg/hooks").
+		MatchHeader("Authorization", "Bearer authtoken").
+		MatchType("json").
+		JSON(map[string]interface{}{
+			"name":   "web",
+			"active": true,
+			"events": []string{"issue_comment", "pull_request", "pull_request_review_comment"},
+			"config": map[string]string{
+				"url":          "https://example.com/testing",
+				"content_type": "json",
+				"secret":       "t0ps3cr3t",
+				"insecure_ssl": "0",
+			}}).
+		Reply(http.StatusCreated).
+		JSON(githubOrgHookResponse(12345678, "https://example.com/testing", "pull_request"))
+	client := makeGitHubOrgClient(t)
+
+	hookID, err := client.Create(context.TODO(), "https://example.com/testing", "t0ps3cr3t",
+		[]v1alpha1.HookEvent{v1alpha1.PullRequestEvent, v1alpha1.PullRequestCommentEvent})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hookID != "12345678" {
+		t.Fatalf("got %#v, want %#v", hookID, "12345678")
+	}
+}
+
+func TestGitHubOrgCreateWithForbiddenResponse(t *testing.T) {
+	defer gock.Off()
+	gock.New("https://api.github.com").
+		Post("/orgs/myorg/hooks").
+		Reply(http.StatusForbidden)
+	client := makeGitHubOrgClient(t)
+
+	_, err := client.Create(context.TODO(), "https://example.com/testing", "t0ps3cr3t", nil)
+
+	if !IsPermissionDenied(err) {
+		t.Fatalf("expected a permission error, got %#v", err)
+	}
+	want := "failed to create hook in organization myorg, managing organization hooks requires an organization owner, and a token with the admin:org_hook scope"
+	if err.Error() != want {
+		t.Fatalf("got %q, want %q", err, want)
+	}
+}
+
+func TestGitHubOrgUpdate(t *testing.T) {
+	defer gock.Off()
+	gock.New("https://api.github.com").
+		Patch("/orgs/myorg/hooks/12345678").
+		MatchType("json").
+		JSON(map[string]interface{}{
+			"name":   "web",
+			"active": true,
+			"events": []string{"push"},
+			"config": map[string]string{
+				"url":          "https://example.com/new",
+				"content_type": "json",
+				"secret":       "n3wsecr3t",
+				"insecure_ssl": "0",
+			}}).
+		Reply(http.StatusOK).
+		JSON(githubOrgHookResponse(12345678, "https://example.com/new", "push"))
+	client := makeGitHubOrgClient(t)
+
+	hookID, err := client.Update(context.TODO(), "12345678", "https://example.com/new", "n3wsecr3t", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hookID != "12345678" {
+		t.Fatalf("got %#v, want %#v", hookID, "12345678")
+	}
+}
+
+func TestGitHubOrgGet(t *testing.T) {
+	defer gock.Off()
+	gock.New("https://api.github.com").
+		Get("/orgs/myorg/hooks/12345678").
+		Reply(http.StatusOK).
+		JSON(githubOrgHookResponse(12345678, "https://example.com/testing", "push"))
+	client := makeGitHubOrgClient(t)
+
+	hook, err := client.Get(context.TODO(), "12345678")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := &Hook{
+		ID:     "12345678",
+		URL:    "https://example.com/testing",
+		Events: []string{"push"},
+		Active: true,
+		Driver: scm.DriverGithub,
+	}
+	if diff := cmp.Diff(want, hook); diff != "" {
+		t.Fatalf("Get() failed:\n%s", diff)
+	}
+}
+
+func TestGitHubOrgGetWithNotFoundResponse(t *testing.T) {
+	defer gock.Off()
+	gock.New("https://api.github.com").
+		Get("/orgs/myorg/hooks/12345678").
+		Reply(http.StatusNotFound)
+	client := makeGitHubOrgClient(t)
+
+	_, err := client.Get(context.TODO(), "12345678")
+
+	if !IsNotFound(err) {
+		t.Fatalf("Get() failed with %#v", err)
+	}
+}
+
+func TestGitHubOrgList(t *testing.T) {
+	defer gock.Off()
+	gock.New("https://api.github.com").
+		Get("/orgs/myorg/hooks").
+		MatchParam("page", "1").
+		Reply(http.StatusOK).
+		JSON([]map[string]interface{}{
+			githubOrgHookResponse(1, "https://example.com/one", "push"),
+			githubOrgHookResponse(2, "https://example.com/two", "pull_request"),
+		})
+	client := makeGitHubOrgClient(t)
+
+	hooks, err := client.List(context.TODO())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []*Hook{
+		{ID: "1", URL: "https://example.com/one", Events: []string{"push"}, Active: true, Driver: scm.DriverGithub},
+		{ID: "2", URL: "https://example.com/two", Events: []string{"pull_request"}, Active: true, Driver: scm.DriverGithub},
+	}
+	if diff := cmp.Diff(want, hooks); diff != "" {
+		t.Fatalf("List() failed:\n%s", diff)
+	}
+}
+
+func TestGitHubOrgListRepositories(t *testing.T) {
+	defer gock.Off()
+	gock.New("https://api.github.com").
+		Get("/orgs/myorg/repos").
+		MatchHeader("Authorization", "Bearer authtoken").
+		MatchParam("page", "1").
+		Reply(http.StatusOK).
+		JSON([]map[string]interface{}{
+			{"name": "service-a", "full_name": "myorg/service-a", "html_url": "https://github.com/myorg/service-a", "visibility": "internal", "topics": []string{"tekton"}},
+			{"name": "service-b", "full_name": "myorg/service-b", "html_url": "https://github.com/myorg/service-b", "private": true, "archived": true},
+		})
+	client := makeGitHubOrgClient(t)
+
+	repos, err := client.ListRepositories(context.TODO())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []*RemoteRepository{
+		{Name: "service-a", FullName: "myorg/service-a", URL: "https://github.com/myorg/service-a", Visibility: "internal", Topics: []string{"tekton"}},
+		{Name: "service-b", FullName: "myorg/service-b", URL: "https://github.com/myorg/service-b", Visibility: "private", Archived: true},
+	}
+	if diff := cmp.Diff(want, repos); diff != "" {
+		t.Fatalf("ListRepositories() failed:\n%s", diff)
+	}
+}
+
+func TestGitHubOrgListRepositoriesWithForbiddenResponse(t *testing.T) {
+	defer gock.Off()
+	gock.New("https://api.github.com").
+		Get("/orgs/myorg/repos").
+		Reply(http.StatusForbidden)
+	client := makeGitHubOrgClient(t)
+
+	_, err := client.ListRepositories(context.TODO())
+
+	if !IsPermissionDenied(err) {
+		t.Fatalf("expected a permission error, got %#v", err)
+	}
+}
+
+func TestGitHubOrgDelete(t *testing.T) {
+	defer gock.Off()
+	gock.New("https://api.github.com").
+		Delete("/orgs/myorg/hooks/12345678").
+		Reply(http.StatusNoContent)
+	client := makeGitHubOrgClient(t)
+
+	if err := client.Delete(context.TODO(), "12345678"); err != nil {
+		t.Fatal(err)
+	}
+	if !gock.IsDone() {
+		t.Fatalf("pending requests: %#v", gock.Pending())
+	}
+}
+
+func makeGitHubOrgClient(t *testing.T) *GitHubOrgHooksClient {
+	t.Helper()
+	scmClient, err := factory.NewClient("github", "", "authtoken")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return NewGitHubOrg(scmClient, "myorg")
+}
+
+func githubOrgHookResponse(id int, hookURL string, events ...string) map[string]interface{} {
+	return map[string]interface{}{
+		"id":     id,
+		"name":   "web",
+		"active": true,
+		"events": events,
+		"config": map[string]string{"url": hookURL, "content_type": "json"},
+	}
+}
@@ -0,0 +1,216 @@
+package git
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/jenkins-x/go-scm/scm"
+
+	v1alpha1 "github.com/bigkevmcd/webhook-secret-operator/pkg/apis/apps/v1alpha1"
+)
+
+// gitlabGroupPermission is the permission that's needed to manage group hooks.
+const gitlabGroupPermission = "managing group hooks requires the Owner role in the group, and a token with the api scope"
+
+// gitlabProjectsPermission is the permission that's needed to list the
+// private projects in a group.
+const gitlabProjectsPermission = "listing the projects in a group requires a member of the group, and a token with the read_api scope"
+
+// GitLabGroupHooksClient is an implementation of HooksClient that manages the
+// webhooks of a GitLab group, which deliver the events for all the projects
+// in the group and its subgroups.
+type GitLabGroupHooksClient struct {
+	Client *scm.Client
+	// Group is the full path of the group, e.g. group/subgroup.
+	Group string
+}
+
+// NewGitLabGroup creates and returns a new GitLabGroupHooksClient.
+//
+// The go-scm client is used to authenticate the requests.
+func NewGitLabGroup(c *scm.Client, group string) *GitLabGroupHooksClient {
+	return &GitLabGroupHooksClient{Client: c, Group: group}
+}
+
+// Create creates a new group webhook, subscribed to the provided events.
+//
+// If no events are provided, the hook is subscribed to push events.
+func (c *GitLabGroupHooksClient) Create(ctx context.Context, hookURL, secret string, events []v1alpha1.HookEvent) (string, error) {
+	in, err := gitlabGroupHookInput(hookURL, secret, events)
+	if err != nil {
+		return "", err
+	}
+	out := &gitlabGroupHook{}
+	if err := doSCM(ctx, c.Client, http.MethodPost, c.path(""), in, out); err != nil {
+		return "", scopedError(err, fmt.Sprintf("failed to create hook in group %s", c.Group), gitlabGroupPermission)
+	}
+	return strconv.Itoa(out.ID), nil
+}
+
+// Update changes the URL, secret and events of a group webhook.
+//
+// GitLab supports editing hooks, so the ID doesn't change.
+func (c *GitLabGroupHooksClient) Update(ctx context.Context, hookID, hookURL, secret string, events []v1alpha1.HookEvent) (string, error) {
+	in, err := gitlabGroupHookInput(hookURL, secret, events)
+	if err != nil {
+		return "", err
+	}
+	out := &gitlabGroupHook{}
+	if err := doSCM(ctx, c.Client, http.MethodPut, c.path(hookID), in, out); err != nil {
+		return "", scopedError(err, fmt.Sprintf("failed to update hook %s in group %s", hookID, c.Group), gitlabGroupPermission)
+	}
+	return strconv.Itoa(out.ID), nil
+}
+
+// Get fetches a group webhook.
+func (c *GitLabGroupHooksClient) Get(ctx context.Context, hookID string) (*Hook, error) {
+	out := &gitlabGroupHook{}
+	if err := doSCM(ctx, c.Client, http.MethodGet, c.path(hookID), nil, out); err != nil {
+		return nil, scopedError(err, fmt.Sprintf("failed to get hook %s in group %s", hookID, c.Group), gitlabGroupPermission)
+	}
+	return out.convert(), nil
+}
+
+// List fetches all the webhooks for the group.
+func (c *GitLabGroupHooksClient) List(ctx context.Context) ([]*Hook, error) {
+	hooks := []*Hook{}
+	for page := 1; ; page++ {
+		out := []gitlabGroupHook{}
+		path := fmt.Sprintf("%s?per_page=%d&page=%d", c.path(""), listPageSize, page)
+		if err := doSCM(ctx, c.Client, http.MethodGet, path, nil, &out); err != nil {
+			return nil, scopedError(err, fmt.Sprintf("failed to list hooks in group %s", c.Group), gitlabGroupPermission)
+		}
+		for i := range out {
+			hooks = append(hooks, out[i].convert())
+		}
+		if len(out) < listPageSize {
+			return hooks, nil
+		}
+	}
+}
+
+// Delete removes a group webhook.
+func (c *GitLabGroupHooksClient) Delete(ctx context.Context, hookID string) error {
+	if err := doSCM(ctx, c.Client, http.MethodDelete, c.path(hookID), nil, nil); err != nil {
+		return scopedError(err, fmt.Sprintf("failed to delete hook in group %s", c.Group), gitlabGroupPermission)
+	}
+	return nil
+}
+
+// ListRepositories fetches all the projects in the group and its subgroups.
+//
+// Older versions of GitLab report the topics of projects as the tag_list.
+func (c *GitLabGroupHooksClient) ListRepositories(ctx context.Context) ([]*RemoteRepository, error) {
+	repos := []*RemoteRepository{}
+	for page := 1; ; page++ {
+		out := []gitlabProject{}
+		path := fmt.Sprintf("api/v4/groups/%s/projects?include_subgroups=true&per_page=%d&page=%d", url.PathEscape(c.Group), listPageSize, page)
+		if err := doSCM(ctx, c.Client, http.MethodGet, path, nil, &out); err != nil {
+			return nil, scopedError(err, fmt.Sprintf("failed to list projects in group %s", c.Group), gitlabProjectsPermission)
+		}
+		for i := range out {
+			repos = append(repos, out[i].convert())
+		}
+		if len(out) < listPageSize {
+			return repos, nil
+		}
+	}
+}
+
+// path returns the API path for the hooks of the group, the group is
+// identified by its URL-encoded full path.
+func (c *GitLabGroupHooksClient) path(hookID string) string {
+	p := fmt.Sprintf("api/v4/groups/%s/hooks", url.PathEscape(c.Group))
+	if hookID != "" {
+		p = p + "/" + url.PathEscape(hookID)
+	}
+	return p
+}
+
+// gitlabGroupHookInput returns the request to create or update a hook.
+func gitlabGroupHookInput(hookURL, secret string, events []v1alpha1.HookEvent) (*gitlabGroupHook, error) {
+	for _, e := range events {
+		if !supportsEvent(scm.DriverGitlab, e) {
+			return nil, unsupportedEventError{driver: scm.DriverGitlab, event: e}
+		}
+	}
+	names := eventNames(scm.DriverGitlab, events)
+	return &gitlabGroupHook{
+		URL:                   hookURL,
+		Token:                 secret,
+		PushEvents:            names["push"],
+		IssuesEvents:          names["issues"],
+		MergeRequestsEvents:   names["merge"],
+		TagPushEvents:         names["tag"],
+		NoteEvents:            names["comment"],
+		EnableSSLVerification: true,
+	}, nil
+}
+
+type gitlabGroupHook struct {
+	ID                    int    `json:"id,omitempty"`
+	URL                   string `json:"url"`
+	Token                 string `json:"token,omitempty"`
+	PushEvents            bool   `json:"push_events"`
+	IssuesEvents          bool   `json:"issues_events"`
+	MergeRequestsEvents   bool   `json:"merge_requests_events"`
+	TagPushEvents         bool   `json:"tag_push_events"`
+	NoteEvents            bool   `json:"note_events"`
+	EnableSSLVerification bool   `json:"enable_ssl_verification"`
+}
+
+// convert returns the hook with the same event names as the go-scm GitLab
+// driver, GitLab hooks can't be disabled, so they're always active.
+func (h *gitlabGroupHook) convert() *Hook {
+	var events []string
+	if h.IssuesEvents {
+		events = append(events, "issues")
+	}
+	if h.TagPushEvents {
+		events = append(events, "tag")
+	}
+	if h.PushEvents {
+		events = append(events, "push")
+	}
+	if h.NoteEvents {
+		events = append(events, "comment")
+	}
+	if h.MergeRequestsEvents {
+		events = append(events, "merge")
+	}
+	return &Hook{
+		ID:     strconv.Itoa(h.ID),
+		URL:    h.URL,
+		Events: events,
+		Active: true,
+		Driver: scm.DriverGitlab,
+	}
+}
+
+type gitlabProject struct {
+	Path              string   `json:"path"`
+	PathWithNamespace string   `json:"path_with_namespace"`
+	WebURL            string   `json:"web_url"`
+	Visibility        string   `json:"visibility"`
+	Archived          bool     `json:"archived"`
+	Topics            []string `json:"topics"`
+	TagList           []string `json:"tag_list"`
+}
+
+func (p *gitlabProject) convert() *RemoteRepository {
+	topics := p.Topics
+	if len(topics) == 0 {
+		topics = p.TagList
+	}
+	return &RemoteRepository{
+		Name:       p.Path,
+		FullName:   p.PathWithNamespace,
+		URL:        p.WebURL,
+		Visibility: p.Visibility,
+		Archived:   p.Archived,
+		Topics:     topics,
+	}
+}
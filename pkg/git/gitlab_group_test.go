@@ -0,0 +1,194 @@
+package git
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/jenkins-x/go-scm/scm"
+	"github.com/jenkins-x/go-scm/scm/factory"
+	"gopkg.in/h2non/gock.v1"
+
+	v1alpha1 "github.com/bigkevmcd/webhook-secret-operator/pkg/apis/apps/v1alpha1"
+)
+
+var _ HooksClient = (*GitLabGroupHooksClient)(nil)
+
+func TestGitLabGroupCreate(t *testing.T) {
+	defer gock.Off()
+	gock.New("https://gitlab.com").
+		Post("/api/v4/groups/mygroup/mysubgroup/hooks").
+		MatchHeader("Private-Token", "authtoken").
+		MatchType("json").
+		JSON(map[string]interface{}{
+			"url":                     "https://example.com/testing",
+			"token":                   "t0ps3cr3t",
+			"push_events":             true,
+			"issues_events":           false,
+			"merge_requests_events":   true,
+			"tag_push_events":         false,
+			"note_events":             false,
+			"enable_ssl_verification": true,
+		}).
+		Reply(http.StatusCreated).
+		JSON(map[string]interface{}{"id": 42, "url": "https://example.com/testing", "push_events": true, "merge_requests_events": true})
+	client := makeGitLabGroupClient(t)
+
+	hookID, err := client.Create(context.TODO(), "https://example.com/testing", "t0ps3cr3t",
+		[]v1alpha1.HookEvent{v1alpha1.PushEvent, v1alpha1.PullRequestEvent})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hookID != "42" {
+		t.Fatalf("got %#v, want %#v", hookID, "42")
+	}
+}
+
+func TestGitLabGroupCreateWithUnsupportedEvent(t *testing.T) {
+	client := makeGitLabGroupClient(t)
+
+	_, err := client.Create(context.TODO(), "https://example.com/testing", "t0ps3cr3t", []v1alpha1.HookEvent{v1alpha1.ReleaseEvent})
+
+	if !IsUnsupportedEvent(err) {
+		t.Fatalf("expected an unsupported event error, got %#v", err)
+	}
+}
+
+func TestGitLabGroupCreateWithForbiddenResponse(t *testing.T) {
+	defer gock.Off()
+	gock.New("https://gitlab.com").
+		Post("/api/v4/groups/mygroup/mysubgroup/hooks").
+		Reply(http.StatusForbidden)
+	client := makeGitLabGroupClient(t)
+
+	_, err := client.Create(context.TODO(), "https://example.com/testing", "t0ps3cr3t", nil)
+
+	if !IsPermissionDenied(err) {
+		t.Fatalf("expected a permission error, got %#v", err)
+	}
+	if status := ErrorStatus(err); status != http.StatusForbidden {
+		t.Fatalf("got status %d, want %d", status, http.StatusForbidden)
+	}
+}
+
+func TestGitLabGroupUpdate(t *testing.T) {
+	defer gock.Off()
+	gock.New("https://gitlab.com").
+		Put("/api/v4/groups/mygroup/mysubgroup/hooks/42").
+		MatchType("json").
+		Reply(http.StatusOK).
+		JSON(map[string]interface{}{"id": 42, "url": "https://example.com/new", "push_events": true})
+	client := makeGitLabGroupClient(t)
+
+	hookID, err := client.Update(context.TODO(), "42", "https://example.com/new", "n3wsecr3t", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hookID != "42" {
+		t.Fatalf("got %#v, want %#v", hookID, "42")
+	}
+}
+
+func TestGitLabGroupGet(t *testing.T) {
+	defer gock.Off()
+	gock.New("https://gitlab.com").
+		Get("/api/v4/groups/mygroup/mysubgroup/hooks/42").
+		Reply(http.StatusOK).
+		JSON(map[string]interface{}{"id": 42, "url": "https://example.com/testing", "push_events": true, "merge_requests_events": true})
+	client := makeGitLabGroupClient(t)
+
+	hook, err := client.Get(context.TODO(), "42")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := &Hook{
+		ID:     "42",
+		URL:    "https://example.com/testing",
+		Events: []string{"push", "merge"},
+		Active: true,
+		Driver: scm.DriverGitlab,
+	}
+	if diff := cmp.Diff(want, hook); diff != "" {
+		t.Fatalf("Get() failed:\n%s", diff)
+	}
+	if !hook.Matches("https://example.com/testing", []v1alpha1.HookEvent{v1alpha1.PushEvent, v1alpha1.PullRequestEvent}) {
+		t.Fatal("hook does not match the push and pull_request events")
+	}
+}
+
+func TestGitLabGroupList(t *testing.T) {
+	defer gock.Off()
+	gock.New("https://gitlab.com").
+		Get("/api/v4/groups/mygroup/mysubgroup/hooks").
+		MatchParam("page", "1").
+		Reply(http.StatusOK).
+		JSON([]map[string]interface{}{
+			{"id": 1, "url": "https://example.com/one", "push_events": true},
+		})
+	client := makeGitLabGroupClient(t)
+
+	hooks, err := client.List(context.TODO())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []*Hook{
+		{ID: "1", URL: "https://example.com/one", Events: []string{"push"}, Active: true, Driver: scm.DriverGitlab},
+	}
+	if diff := cmp.Diff(want, hooks); diff != "" {
+		t.Fatalf("List() failed:\n%s", diff)
+	}
+}
+
+func TestGitLabGroupListRepositories(t *testing.T) {
+	defer gock.Off()
+	gock.New("https://gitlab.com").
+		Get("/api/v4/groups/mygroup/mysubgroup/projects").
+		MatchHeader("Private-Token", "authtoken").
+		MatchParam("include_subgroups", "true").
+		MatchParam("page", "1").
+		Reply(http.StatusOK).
+		JSON([]map[string]interface{}{
+			{"path": "service-a", "path_with_namespace": "mygroup/mysubgroup/service-a", "web_url": "https://gitlab.com/mygroup/mysubgroup/service-a", "visibility": "private", "topics": []string{"tekton"}},
+			{"path": "service-b", "path_with_namespace": "mygroup/mysubgroup/team/service-b", "web_url": "https://gitlab.com/mygroup/mysubgroup/team/service-b", "visibility": "public", "tag_list": []string{"legacy"}},
+		})
+	client := makeGitLabGroupClient(t)
+
+	repos, err := client.ListRepositories(context.TODO())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []*RemoteRepository{
+		{Name: "service-a", FullName: "mygroup/mysubgroup/service-a", URL: "https://gitlab.com/mygroup/mysubgroup/service-a", Visibility: "private", Topics: []string{"tekton"}},
+		{Name: "service-b", FullName: "mygroup/mysubgroup/team/service-b", URL: "https://gitlab.com/mygroup/mysubgroup/team/service-b", Visibility: "public", Topics: []string{"legacy"}},
+	}
+	if diff := cmp.Diff(want, repos); diff != "" {
+		t.Fatalf("ListRepositories() failed:\n%s", diff)
+	}
+}
+
+func TestGitLabGroupDeleteWithNotFoundResponse(t *testing.T) {
+	defer gock.Off()
+	gock.New("https://gitlab.com").
+		Delete("/api/v4/groups/mygroup/mysubgroup/hooks/42").
+		Reply(http.StatusNotFound)
+	client := makeGitLabGroupClient(t)
+
+	err := client.Delete(context.TODO(), "42")
+
+	if !IsNotFound(err) {
+		t.Fatalf("Delete() failed with %#v", err)
+	}
+}
+
+func makeGitLabGroupClient(t *testing.T) *GitLabGroupHooksClient {
+	t.Helper()
+	scmClient, err := factory.NewClient("gitlab", "", "authtoken")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return NewGitLabGroup(scmClient, "mygroup/mysubgroup")
+}
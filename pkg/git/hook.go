@@ -0,0 +1,42 @@
+package git
+
+import (
+	"github.com/jenkins-x/go-scm/scm"
+
+	v1alpha1 "github.com/bigkevmcd/webhook-secret-operator/pkg/apis/apps/v1alpha1"
+)
+
+// Hook is a webhook configured in a git host.
+//
+// Events are the names of the events as reported by the driver.
+type Hook struct {
+	ID     string
+	URL    string
+	Events []string
+	Active bool
+	Driver scm.Driver
+}
+
+// Matches returns true if the hook is active and delivers exactly the provided
+// events to the hookURL.
+//
+// Git hosts don't return the secret for a hook, so it can't be compared.
+func (h *Hook) Matches(hookURL string, events []v1alpha1.HookEvent) bool {
+	if !h.Active || h.URL != hookURL {
+		return false
+	}
+	want := eventNames(h.Driver, events)
+	got := map[string]bool{}
+	for _, e := range h.Events {
+		got[e] = true
+	}
+	if len(want) != len(got) {
+		return false
+	}
+	for e := range want {
+		if !got[e] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,67 @@
+package git
+
+import (
+	"testing"
+
+	"github.com/jenkins-x/go-scm/scm"
+
+	v1alpha1 "github.com/bigkevmcd/webhook-secret-operator/pkg/apis/apps/v1alpha1"
+)
+
+func TestHookMatches(t *testing.T) {
+	matchTests := []struct {
+		name    string
+		hook    Hook
+		hookURL string
+		events  []v1alpha1.HookEvent
+		want    bool
+	}{
+		{
+			name:    "default events",
+			hook:    Hook{URL: "https://example.com/", Events: []string{"push"}, Active: true, Driver: scm.DriverGithub},
+			hookURL: "https://example.com/",
+			want:    true,
+		},
+		{
+			name:    "different URL",
+			hook:    Hook{URL: "https://example.com/old", Events: []string{"push"}, Active: true, Driver: scm.DriverGithub},
+			hookURL: "https://example.com/",
+			want:    false,
+		},
+		{
+			name:    "inactive hook",
+			hook:    Hook{URL: "https://example.com/", Events: []string{"push"}, Driver: scm.DriverGithub},
+			hookURL: "https://example.com/",
+			want:    false,
+		},
+		{
+			name:    "events in a different order",
+			hook:    Hook{URL: "https://example.com/", Events: []string{"issue_comment", "push", "pull_request_review_comment"}, Active: true, Driver: scm.DriverGithub},
+			hookURL: "https://example.com/",
+			events:  []v1alpha1.HookEvent{v1alpha1.PushEvent, v1alpha1.PullRequestCommentEvent},
+			want:    true,
+		},
+		{
+			name:    "missing event",
+			hook:    Hook{URL: "https://example.com/", Events: []string{"push"}, Active: true, Driver: scm.DriverGitlab},
+			hookURL: "https://example.com/",
+			events:  []v1alpha1.HookEvent{v1alpha1.PushEvent, v1alpha1.PullRequestEvent},
+			want:    false,
+		},
+		{
+			name:    "extra event",
+			hook:    Hook{URL: "https://example.com/", Events: []string{"push", "merge"}, Active: true, Driver: scm.DriverGitlab},
+			hookURL: "https://example.com/",
+			events:  []v1alpha1.HookEvent{v1alpha1.PushEvent},
+			want:    false,
+		},
+	}
+
+	for _, tt := range matchTests {
+		t.Run(tt.name, func(rt *testing.T) {
+			if got := tt.hook.Matches(tt.hookURL, tt.events); got != tt.want {
+				rt.Errorf("Matches() got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -9,8 +9,23 @@ import (
 // ClientFactory is an interface for creating SCM clients based on the URL
 // to be fetched.
 type ClientFactory interface {
-	// ClientForRepo creates a new client, using the provided token for authentication.
-	ClientForRepo(repo v1alpha1.Repo, token string) (HooksClient, error)
+	// ClientForRepo creates a new client, using the provided credentials for authentication.
+	ClientForRepo(repo v1alpha1.Repo, creds Credentials) (HooksClient, error)
+}
+
+// RepoListerFactory is an interface for creating clients that list the
+// repositories of an organization or group.
+type RepoListerFactory interface {
+	// ListerForOwner creates a new client for the organization or group at
+	// the URL of the owner, using the provided credentials for authentication.
+	ListerForOwner(owner v1alpha1.Repo, creds Credentials) (RepoLister, error)
+}
+
+// RepoLister is the API for listing repositories.
+type RepoLister interface {
+	// ListRepositories fetches all the repositories in the organization or
+	// group.
+	ListRepositories(ctx context.Context) ([]*RemoteRepository, error)
 }
 
 // DriverIdentifer parses a URL and attempts to determine which go-scm driver to
@@ -21,8 +36,19 @@ type DriverIdentifier interface {
 
 // HooksClient is the API for managing hooks.
 type HooksClient interface {
-	// Create creates a new repository webhook.
-	Create(ctx context.Context, hookURL, secret string) (string, error)
+	// Create creates a new repository webhook subscribed to the events.
+	Create(ctx context.Context, hookURL, secret string, events []v1alpha1.HookEvent) (string, error)
+
+	// Update changes the URL, secret and events of a repository webhook,
+	// returning the ID of the updated webhook, which might not be the same
+	// as the original ID.
+	Update(ctx context.Context, hookID, hookURL, secret string, events []v1alpha1.HookEvent) (string, error)
+
+	// Get fetches a repository webhook.
+	Get(ctx context.Context, hookID string) (*Hook, error)
+
+	// List fetches all the webhooks in the repository.
+	List(ctx context.Context) ([]*Hook, error)
 
 	// Delete deletes a repository webhook.
 	Delete(ctx context.Context, hookID string) error
@@ -0,0 +1,124 @@
+package git
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"sync"
+
+	"github.com/jenkins-x/go-scm/scm"
+	"github.com/jenkins-x/go-scm/scm/transport"
+	"golang.org/x/oauth2"
+	"sigs.k8s.io/yaml"
+)
+
+// Provider describes a git host that can't be identified from its hostname,
+// for example, a self-hosted GitHub Enterprise or GitLab server.
+type Provider struct {
+	// Host is the hostname in repository URLs, e.g. github.example.com.
+	Host string `json:"host"`
+	// Driver is the go-scm driver to use for the host, e.g. github.
+	Driver string `json:"driver"`
+	// Endpoint is the URL of the API for the host.
+	Endpoint string `json:"endpoint,omitempty"`
+	// CABundle is a PEM encoded bundle of certificates to verify the
+	// API endpoint with.
+	CABundle string `json:"caBundle,omitempty"`
+}
+
+// ProviderRegistry maps hostnames to Providers.
+//
+// It is safe for concurrent use, and a nil ProviderRegistry has no providers.
+type ProviderRegistry struct {
+	mu        sync.RWMutex
+	providers map[string]Provider
+}
+
+// NewProviderRegistry creates and returns a ProviderRegistry with the
+// providers.
+func NewProviderRegistry(providers ...Provider) *ProviderRegistry {
+	r := &ProviderRegistry{}
+	r.Set(providers)
+	return r
+}
+
+// Set replaces the providers in the registry.
+func (r *ProviderRegistry) Set(providers []Provider) {
+	m := map[string]Provider{}
+	for _, p := range providers {
+		m[p.Host] = p
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.providers = m
+}
+
+// Lookup returns the Provider for a hostname.
+func (r *ProviderRegistry) Lookup(host string) (Provider, bool) {
+	if r == nil {
+		return Provider{}, false
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	p, ok := r.providers[host]
+	return p, ok
+}
+
+// ParseProviders parses a YAML list of Providers.
+func ParseProviders(b []byte) ([]Provider, error) {
+	var providers []Provider
+	if err := yaml.Unmarshal(b, &providers); err != nil {
+		return nil, fmt.Errorf("failed to parse providers: %w", err)
+	}
+	for i, p := range providers {
+		if p.Host == "" || p.Driver == "" {
+			return nil, fmt.Errorf("provider %d must have a host and a driver", i)
+		}
+		if p.CABundle != "" {
+			if _, err := certPool(p.CABundle); err != nil {
+				return nil, fmt.Errorf("provider %s: %w", p.Host, err)
+			}
+		}
+	}
+	return providers, nil
+}
+
+func certPool(caBundle string) (*x509.CertPool, error) {
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM([]byte(caBundle)) {
+		return nil, fmt.Errorf("no certificates found in the caBundle")
+	}
+	return pool, nil
+}
+
+// withCABundle configures the client to verify the server's certificate
+// against the certificates in the bundle, keeping the authentication that
+// go-scm configured.
+func withCABundle(c *scm.Client, caBundle string) error {
+	pool, err := certPool(caBundle)
+	if err != nil {
+		return err
+	}
+	base := caTransport(pool)
+	switch t := c.Client.Transport.(type) {
+	case *oauth2.Transport:
+		t.Base = base
+	case *transport.PrivateToken:
+		t.Base = base
+	case *transport.BasicAuth:
+		t.Base = base
+	default:
+		c.Client = &http.Client{Transport: base}
+	}
+	return nil
+}
+
+// caTransport returns a transport that verifies servers against the
+// certificates in the pool.
+func caTransport(pool *x509.CertPool) *http.Transport {
+	return &http.Transport{
+		Proxy:           http.ProxyFromEnvironment,
+		TLSClientConfig: &tls.Config{RootCAs: pool},
+	}
+}
@@ -0,0 +1,128 @@
+package git
+
+import (
+	"context"
+	"encoding/pem"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	v1alpha1 "github.com/bigkevmcd/webhook-secret-operator/pkg/apis/apps/v1alpha1"
+	"github.com/bigkevmcd/webhook-secret-operator/test"
+)
+
+func TestParseProviders(t *testing.T) {
+	parseTests := []struct {
+		name    string
+		data    string
+		want    []Provider
+		wantErr string
+	}{
+		{
+			name: "valid providers",
+			data: `
+- host: github.example.com
+  driver: github
+  endpoint: https://github.example.com/api/v3
+- host: gitlab.example.com
+  driver: gitlab
+`,
+			want: []Provider{
+				{Host: "github.example.com", Driver: "github", Endpoint: "https://github.example.com/api/v3"},
+				{Host: "gitlab.example.com", Driver: "gitlab"},
+			},
+		},
+		{
+			name:    "missing driver",
+			data:    "- host: github.example.com\n",
+			wantErr: "provider 0 must have a host and a driver",
+		},
+		{
+			name:    "invalid caBundle",
+			data:    "- host: github.example.com\n  driver: github\n  caBundle: not a certificate\n",
+			wantErr: "provider github.example.com: no certificates found",
+		},
+		{
+			name:    "invalid YAML",
+			data:    "host: github.example.com",
+			wantErr: "failed to parse providers",
+		},
+	}
+
+	for _, tt := range parseTests {
+		t.Run(tt.name, func(rt *testing.T) {
+			providers, err := ParseProviders([]byte(tt.data))
+			if !test.MatchError(rt, tt.wantErr, err) {
+				rt.Errorf("error failed to match, got %#v, want %s", err, tt.wantErr)
+			}
+			if diff := cmp.Diff(tt.want, providers); diff != "" {
+				rt.Errorf("ParseProviders() failed:\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestProviderRegistryLookup(t *testing.T) {
+	p := Provider{Host: "github.example.com", Driver: "github"}
+	r := NewProviderRegistry(p)
+
+	if got, ok := r.Lookup("github.example.com"); !ok || got != p {
+		t.Fatalf("Lookup() got %#v, %v, want %#v", got, ok, p)
+	}
+	r.Set(nil)
+	if _, ok := r.Lookup("github.example.com"); ok {
+		t.Fatal("Lookup() found a provider after the providers were replaced")
+	}
+	var nilRegistry *ProviderRegistry
+	if _, ok := nilRegistry.Lookup("github.example.com"); ok {
+		t.Fatal("Lookup() found a provider in a nil registry")
+	}
+}
+
+func TestClientForRepoWithCABundle(t *testing.T) {
+	body, err := ioutil.ReadFile("testdata/hook_created.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v3/repos/myorg/myrepo/hooks/12345678" {
+			http.NotFound(w, r)
+			return
+		}
+		if auth := r.Header.Get("Authorization"); auth != "Bearer test-token" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(body)
+	}))
+	defer ts.Close()
+	u, err := url.Parse(ts.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	caBundle := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: ts.Certificate().Raw})
+	providers := NewProviderRegistry(Provider{
+		Host:     u.Host,
+		Driver:   "github",
+		Endpoint: ts.URL,
+		CABundle: string(caBundle),
+	})
+	factory := NewClientFactory(NewDriverIdentifier(providers), providers)
+
+	client, err := factory.ClientForRepo(v1alpha1.Repo{URL: ts.URL + "/myorg/myrepo.git"}, Credentials{Token: "test-token"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	hook, err := client.Get(context.TODO(), "12345678")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hook.URL != "https://example.com/webhook" {
+		t.Fatalf("got hook URL %#v, want %#v", hook.URL, "https://example.com/webhook")
+	}
+}
@@ -0,0 +1,77 @@
+package git
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Repository is the location of a repository in a git host.
+type Repository struct {
+	// Host is the host from the repository URL.
+	Host string
+	// Owner is the organisation, user or project that owns the repository,
+	// GitLab subgroups are included, e.g. group/subgroup, and Azure DevOps
+	// repositories are owned by their project.
+	Owner string
+	// Name is the name of the repository, without any .git suffix.
+	Name string
+}
+
+// RemoteRepository is a repository that was listed from an organization or
+// group.
+type RemoteRepository struct {
+	// Name is the name of the repository, e.g. myrepo.
+	Name string
+	// FullName is the name including the owner, e.g. group/subgroup/myrepo.
+	FullName string
+	// URL is the web URL of the repository.
+	URL string
+	// Visibility is one of public, private or internal.
+	Visibility string
+	Archived   bool
+	Topics     []string
+}
+
+// FullName returns the owner and name of the repository, e.g. org/repo.
+func (r Repository) FullName() string {
+	return r.Owner + "/" + r.Name
+}
+
+// ParseRepository parses the location of the repository from the URL, in the
+// same way as the client for the driver does.
+func ParseRepository(driver, repoURL string) (Repository, error) {
+	parsed, err := parseRepoURL(repoURL)
+	if err != nil {
+		return Repository{}, err
+	}
+	if driver == "azure" {
+		_, project, repo, err := parseAzureURL(repoURL)
+		if err != nil {
+			return Repository{}, err
+		}
+		return Repository{Host: parsed.Host, Owner: project, Name: repo}, nil
+	}
+	fullName, err := repoFromURL(driver, repoURL)
+	if err != nil {
+		return Repository{}, err
+	}
+	i := strings.LastIndex(fullName, "/")
+	if i == -1 {
+		return Repository{}, fmt.Errorf("failed to parse the owner of the repo from URL %#v", repoURL)
+	}
+	return Repository{Host: parsed.Host, Owner: fullName[:i], Name: fullName[i+1:]}, nil
+}
+
+// ParseOwner parses the location of an organization or group from the URL,
+// the Repository has no Name.
+func ParseOwner(driver, ownerURL string) (Repository, error) {
+	parsed, err := parseRepoURL(ownerURL)
+	if err != nil {
+		return Repository{}, err
+	}
+	owner, err := ownerFromURL(ownerURL)
+	if err != nil {
+		return Repository{}, err
+	}
+	return Repository{Host: parsed.Host, Owner: owner}, nil
+}
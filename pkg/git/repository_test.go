@@ -0,0 +1,70 @@
+package git
+
+import (
+	"testing"
+
+	"github.com/bigkevmcd/webhook-secret-operator/test"
+)
+
+func TestParseRepository(t *testing.T) {
+	repoTests := []struct {
+		driver  string
+		repoURL string
+		want    Repository
+		wantErr string
+	}{
+		{"github", "https://github.com/myorg/myrepo.git", Repository{Host: "github.com", Owner: "myorg", Name: "myrepo"}, ""},
+		{"github", "git@github.com:myorg/myrepo.git", Repository{Host: "github.com", Owner: "myorg", Name: "myrepo"}, ""},
+		{"gitlab", "https://gitlab.com/mygroup/mysubgroup/myrepo.git", Repository{Host: "gitlab.com", Owner: "mygroup/mysubgroup", Name: "myrepo"}, ""},
+		{"stash", "https://bitbucket.example.com/scm/PROJ/myrepo.git", Repository{Host: "bitbucket.example.com", Owner: "PROJ", Name: "myrepo"}, ""},
+		{"azure", "https://dev.azure.com/myorg/myproject/_git/myrepo", Repository{Host: "dev.azure.com", Owner: "myproject", Name: "myrepo"}, ""},
+		{"", "https://git.example.com/myorg/myrepo", Repository{Host: "git.example.com", Owner: "myorg", Name: "myrepo"}, ""},
+		{"github", "https://github.com/myrepo", Repository{}, "failed to parse the owner of the repo"},
+		{"github", "ftp://github.com/myorg/myrepo", Repository{}, "unsupported repoURL scheme"},
+	}
+
+	for _, tt := range repoTests {
+		t.Run(tt.repoURL, func(rt *testing.T) {
+			repo, err := ParseRepository(tt.driver, tt.repoURL)
+			if !test.MatchError(rt, tt.wantErr, err) {
+				rt.Errorf("error failed to match, got %#v, want %s", err, tt.wantErr)
+			}
+			if repo != tt.want {
+				rt.Errorf("ParseRepository() got %#v, want %#v", repo, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseOwner(t *testing.T) {
+	ownerTests := []struct {
+		driver   string
+		ownerURL string
+		want     Repository
+		wantErr  string
+	}{
+		{"github", "https://github.com/myorg", Repository{Host: "github.com", Owner: "myorg"}, ""},
+		{"gitlab", "https://gitlab.com/mygroup/mysubgroup", Repository{Host: "gitlab.com", Owner: "mygroup/mysubgroup"}, ""},
+		{"github", "https://github.com/", Repository{}, "failed to parse owner from URL"},
+	}
+
+	for _, tt := range ownerTests {
+		t.Run(tt.ownerURL, func(rt *testing.T) {
+			owner, err := ParseOwner(tt.driver, tt.ownerURL)
+			if !test.MatchError(rt, tt.wantErr, err) {
+				rt.Errorf("error failed to match, got %#v, want %s", err, tt.wantErr)
+			}
+			if owner != tt.want {
+				rt.Errorf("ParseOwner() got %#v, want %#v", owner, tt.want)
+			}
+		})
+	}
+}
+
+func TestRepositoryFullName(t *testing.T) {
+	r := Repository{Host: "gitlab.com", Owner: "mygroup/mysubgroup", Name: "myrepo"}
+
+	if n := r.FullName(); n != "mygroup/mysubgroup/myrepo" {
+		t.Fatalf("got %s, want mygroup/mysubgroup/myrepo", n)
+	}
+}
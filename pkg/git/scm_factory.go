@@ -2,60 +2,276 @@ package git
 
 import (
 	"fmt"
+	"net/http"
 	"net/url"
 	"strings"
 
 	v1alpha1 "github.com/bigkevmcd/webhook-secret-operator/pkg/apis/apps/v1alpha1"
 	scmfactory "github.com/jenkins-x/go-scm/scm/factory"
+	"github.com/jenkins-x/go-scm/scm/transport"
+	"golang.org/x/oauth2"
 )
 
 // SCMHooksClientFactory is an implementation of the GitClientFactory interface that can
 // create clients based on go-scm.
 type SCMHooksClientFactory struct {
-	drivers DriverIdentifier
+	drivers   DriverIdentifier
+	providers *ProviderRegistry
+	tokens    tokenCache
 }
 
 // NewClientFactory creates and returns an SCMHookClientFactory.
-func NewClientFactory(d DriverIdentifier) *SCMHooksClientFactory {
-	return &SCMHooksClientFactory{drivers: d}
+//
+// The providers are used to find the API endpoint and CA bundle for hosts, if
+// the Repo doesn't have an endpoint.
+func NewClientFactory(d DriverIdentifier, p *ProviderRegistry) *SCMHooksClientFactory {
+	return &SCMHooksClientFactory{drivers: d, providers: p}
 }
 
-func (s *SCMHooksClientFactory) ClientForRepo(repo v1alpha1.Repo, token string) (HooksClient, error) {
+// ClientForRepo creates a client for the repo, or for the organization or
+// group hooks if the repo has a scope.
+//
+// GitHub App credentials can only be used with the github driver, the
+// installation tokens, and OAuth2 access tokens are cached by the factory until
+// they're about to expire.
+func (s *SCMHooksClientFactory) ClientForRepo(repo v1alpha1.Repo, creds Credentials) (HooksClient, error) {
+	driver, err := s.driverForRepo(repo)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkScope(driver, repo.Scope); err != nil {
+		return nil, err
+	}
+
+	provider, _ := s.providers.Lookup(hostFromURL(repo.URL))
+	endpoint := provider.Endpoint
+	if repo.Endpoint != "" {
+		endpoint = repo.Endpoint
+	}
+	if endpoint == "" {
+		endpoint = defaultEndpoint(driver, repo.URL)
+	}
+
+	if creds.GitHubApp != nil && driver != "github" {
+		return nil, fmt.Errorf("GitHub App credentials can't be used with the %s driver", driver)
+	}
+
+	base, err := baseTransport(provider.CABundle)
+	if err != nil {
+		return nil, fmt.Errorf("failed to configure the CA bundle for %s: %w", provider.Host, err)
+	}
+
+	if driver == "azure" {
+		return s.azureClient(repo.URL, endpoint, creds, base)
+	}
+
+	scmClient, err := scmfactory.NewClient(driver, endpoint, creds.Token)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create a git driver: %s", err)
+	}
+	auth, err := s.authTransport(creds, scmClient.BaseURL, base)
+	if err != nil {
+		return nil, err
+	}
+	if auth != nil {
+		scmClient.Client = &http.Client{Transport: auth}
+	} else if provider.CABundle != "" {
+		if err := withCABundle(scmClient, provider.CABundle); err != nil {
+			return nil, fmt.Errorf("failed to configure the CA bundle for %s: %w", provider.Host, err)
+		}
+	}
+	if !isRepositoryScope(repo.Scope) {
+		return scopedClient(scmClient, repo)
+	}
+	r, err := repoFromURL(driver, repo.URL)
+	if err != nil {
+		return nil, err
+	}
+	return New(scmClient, r), nil
+}
+
+// ListerForOwner creates a client that lists the repositories of the GitHub
+// organization or GitLab group at the URL of the owner.
+//
+// The client is authenticated in the same way as the clients for organization
+// and group hooks.
+func (s *SCMHooksClientFactory) ListerForOwner(owner v1alpha1.Repo, creds Credentials) (RepoLister, error) {
+	driver, err := s.driverForRepo(owner)
+	if err != nil {
+		return nil, err
+	}
+	scope, ok := ownerScope(driver)
+	if !ok {
+		return nil, unsupportedListingError{driver: driver}
+	}
+	owner.Scope = scope
+	client, err := s.ClientForRepo(owner, creds)
+	if err != nil {
+		return nil, err
+	}
+	lister, ok := client.(RepoLister)
+	if !ok {
+		return nil, unsupportedListingError{driver: driver}
+	}
+	return lister, nil
+}
+
+// driverForRepo returns the driver for the repo, the driver in the Repo is
+// used if it's set, otherwise it's identified from the URL.
+func (s *SCMHooksClientFactory) driverForRepo(repo v1alpha1.Repo) (string, error) {
 	// TODO: this should DEBUG log out the identification for URLs.
 	driver, err := s.drivers.Identify(repo.URL)
 
 	if err != nil && !IsUnknownDriver(err) {
-		return nil, err
+		return "", err
 	}
 
 	if err != nil && repo.Driver == "" {
-		return nil, err
+		return "", err
 	}
 
 	if repo.Driver != "" {
 		driver = repo.Driver
 	}
+	return driver, nil
+}
 
-	endpoint := ""
-	if repo.Endpoint != "" {
-		endpoint = repo.Endpoint
+// authTransport returns a transport that authenticates requests with the
+// credentials, wrapping the base transport.
+//
+// Tokens are configured by go-scm, so no transport is returned for them.
+func (s *SCMHooksClientFactory) authTransport(creds Credentials, baseURL *url.URL, base http.RoundTripper) (http.RoundTripper, error) {
+	switch {
+	case creds.GitHubApp != nil:
+		ts, err := s.tokens.appTokenSource(&http.Client{Transport: base}, baseURL, *creds.GitHubApp)
+		if err != nil {
+			return nil, fmt.Errorf("invalid GitHub App credentials: %w", err)
+		}
+		return &oauth2.Transport{Source: ts, Base: base}, nil
+	case creds.OAuth2 != nil:
+		ts, err := s.tokens.clientCredentialsTokenSource(&http.Client{Transport: base}, *creds.OAuth2)
+		if err != nil {
+			return nil, fmt.Errorf("invalid OAuth2 client credentials: %w", err)
+		}
+		return &oauth2.Transport{Source: ts, Base: base}, nil
+	case creds.Username != "" || creds.Password != "":
+		return &transport.BasicAuth{Base: base, Username: creds.Username, Password: creds.Password}, nil
 	}
+	return nil, nil
+}
 
-	scmClient, err := scmfactory.NewClient(driver, endpoint, token)
+// azureClient creates a client for Azure DevOps, which go-scm doesn't have a
+// driver for.
+//
+// Azure DevOps accepts personal access tokens as the password for basic auth.
+func (s *SCMHooksClientFactory) azureClient(repoURL, endpoint string, creds Credentials, base http.RoundTripper) (HooksClient, error) {
+	if creds.Token != "" {
+		creds = Credentials{Password: creds.Token}
+	}
+	auth, err := s.authTransport(creds, nil, base)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create a git driver: %s", err)
+		return nil, err
+	}
+	if auth == nil {
+		auth = base
 	}
-	r, err := repoFromURL(repo.URL)
+	return NewAzure(&http.Client{Transport: auth}, repoURL, endpoint)
+}
+
+// baseTransport returns a transport that verifies servers against the CA
+// bundle, or nil to use the default transport if there is no bundle.
+func baseTransport(caBundle string) (http.RoundTripper, error) {
+	if caBundle == "" {
+		return nil, nil
+	}
+	pool, err := certPool(caBundle)
 	if err != nil {
 		return nil, err
 	}
-	return New(scmClient, r), nil
+	return caTransport(pool), nil
+}
+
+// defaultEndpoint returns the API endpoint for drivers that are always
+// self-hosted, calculated from the repo URL.
+//
+// For Bitbucket Server, the endpoint includes any context path before the
+// "/scm/", "/projects/" or "/users/" part of the URL.
+func defaultEndpoint(driver, repoURL string) string {
+	parsed, err := parseRepoURL(repoURL)
+	if err != nil {
+		return ""
+	}
+	switch driver {
+	case "gitea", "gogs":
+		return parsed.Scheme + "://" + parsed.Host
+	case "stash", "bitbucketserver":
+		segments := pathSegments(parsed.Path)
+		for i, s := range segments {
+			if s == "scm" || s == "projects" || s == "users" {
+				return parsed.Scheme + "://" + parsed.Host + "/" + strings.Join(segments[:i], "/")
+			}
+		}
+		return parsed.Scheme + "://" + parsed.Host
+	}
+	return ""
 }
 
-func repoFromURL(s string) (string, error) {
-	parsed, err := url.Parse(s)
+func hostFromURL(s string) string {
+	parsed, err := parseRepoURL(s)
+	if err != nil {
+		return ""
+	}
+	return parsed.Host
+}
+
+// repoFromURL returns the name of the repository in the format that the driver
+// expects.
+//
+// Bitbucket Server URLs can be clone URLs, e.g. /scm/PROJECT/repo.git or
+// browse URLs, e.g. /projects/PROJECT/repos/repo/browse, and personal
+// repositories are in the ~user project.
+//
+// Bitbucket Server SSH URLs don't have a prefix, e.g.
+// ssh://git@bitbucket.example.com:7999/PROJECT/repo.git.
+//
+// Bitbucket Cloud repositories are always owner/repo.
+func repoFromURL(driver, s string) (string, error) {
+	parsed, err := parseRepoURL(s)
 	if err != nil {
 		return "", fmt.Errorf("failed to parse repo from URL %#v: %s", s, err)
 	}
-	return strings.TrimPrefix(strings.TrimSuffix(parsed.Path, ".git"), "/"), nil
+	segments := pathSegments(strings.TrimSuffix(parsed.Path, ".git"))
+	switch driver {
+	case "stash", "bitbucketserver":
+		for i, seg := range segments {
+			switch {
+			case seg == "scm" && len(segments) >= i+3:
+				return segments[i+1] + "/" + segments[i+2], nil
+			case seg == "projects" && len(segments) >= i+4 && segments[i+2] == "repos":
+				return segments[i+1] + "/" + segments[i+3], nil
+			case seg == "users" && len(segments) >= i+4 && segments[i+2] == "repos":
+				return "~" + segments[i+1] + "/" + segments[i+3], nil
+			}
+		}
+		if isSSHURL(s) && len(segments) == 2 {
+			return segments[0] + "/" + segments[1], nil
+		}
+		return "", fmt.Errorf("failed to parse Bitbucket Server repo from URL %#v", s)
+	case "bitbucket", "bitbucketcloud":
+		if len(segments) < 2 {
+			return "", fmt.Errorf("failed to parse Bitbucket repo from URL %#v", s)
+		}
+		return segments[0] + "/" + segments[1], nil
+	}
+	return strings.Join(segments, "/"), nil
+}
+
+func pathSegments(p string) []string {
+	segments := []string{}
+	for _, s := range strings.Split(p, "/") {
+		if s != "" {
+			segments = append(segments, s)
+		}
+	}
+	return segments
 }
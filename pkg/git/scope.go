@@ -0,0 +1,155 @@
+package git
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/jenkins-x/go-scm/scm"
+
+	v1alpha1 "github.com/bigkevmcd/webhook-secret-operator/pkg/apis/apps/v1alpha1"
+)
+
+// scopeDrivers is the driver that supports each of the hook scopes, other
+// than the repository scope.
+var scopeDrivers = map[v1alpha1.HookScope]string{
+	v1alpha1.OrganizationScope: "github",
+	v1alpha1.GroupScope:        "gitlab",
+}
+
+// isRepositoryScope returns true if the hook is created in a repository.
+func isRepositoryScope(s v1alpha1.HookScope) bool {
+	return s == "" || s == v1alpha1.RepositoryScope
+}
+
+// checkScope returns an error if the driver doesn't support hooks at the
+// scope.
+func checkScope(driver string, s v1alpha1.HookScope) error {
+	if isRepositoryScope(s) || scopeDrivers[s] == driver {
+		return nil
+	}
+	return unsupportedScopeError{driver: driver, scope: s}
+}
+
+// scopedClient creates a client for the organization or group hooks of the
+// repo, using the go-scm client for authentication.
+func scopedClient(c *scm.Client, repo v1alpha1.Repo) (HooksClient, error) {
+	name, err := ownerFromURL(repo.URL)
+	if err != nil {
+		return nil, err
+	}
+	switch repo.Scope {
+	case v1alpha1.OrganizationScope:
+		if strings.Contains(name, "/") {
+			return nil, fmt.Errorf("failed to parse GitHub organization from URL %#v", repo.URL)
+		}
+		return NewGitHubOrg(c, name), nil
+	case v1alpha1.GroupScope:
+		return NewGitLabGroup(c, name), nil
+	}
+	return nil, unsupportedScopeError{driver: c.Driver.String(), scope: repo.Scope}
+}
+
+// ownerFromURL returns the path of an organization or group URL, e.g.
+// https://gitlab.com/group/subgroup is group/subgroup.
+func ownerFromURL(s string) (string, error) {
+	parsed, err := parseRepoURL(s)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse owner from URL %#v: %s", s, err)
+	}
+	segments := pathSegments(parsed.Path)
+	if len(segments) == 0 {
+		return "", fmt.Errorf("failed to parse owner from URL %#v", s)
+	}
+	return strings.Join(segments, "/"), nil
+}
+
+// doSCM sends a request to the API of the go-scm client, which authenticates
+// the request, encoding in as the JSON body, and decoding the JSON response
+// into out.
+//
+// go-scm doesn't support organization or group hooks, so these are requested
+// directly.
+func doSCM(ctx context.Context, c *scm.Client, method, path string, in, out interface{}) error {
+	req := &scm.Request{Method: method, Path: path, Header: http.Header{}}
+	req.Header.Set("Accept", "application/json")
+	if in != nil {
+		body := &bytes.Buffer{}
+		if err := json.NewEncoder(body).Encode(in); err != nil {
+			return err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		req.Body = body
+	}
+	res, err := c.Do(ctx, req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if isErrorStatus(res.Status) {
+		return SCMError{msg: http.StatusText(res.Status), Status: res.Status}
+	}
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(res.Body).Decode(out)
+}
+
+// scopedError replaces the message for HTTP errors, keeping the status, and
+// describes the permission that's needed if the request was denied.
+func scopedError(err error, msg, permission string) error {
+	e, ok := err.(SCMError)
+	if !ok {
+		return err
+	}
+	scoped := SCMError{msg: msg, Status: e.Status}
+	if e.Status == http.StatusUnauthorized || e.Status == http.StatusForbidden {
+		scoped.Permission = permission
+	}
+	return scoped
+}
+
+type unsupportedScopeError struct {
+	driver string
+	scope  v1alpha1.HookScope
+}
+
+func (e unsupportedScopeError) Error() string {
+	return fmt.Sprintf("%s hooks are not supported by the %s driver", e.scope, e.driver)
+}
+
+// IsUnsupportedScope returns true if the provided error means that the driver
+// for a Repo can't create hooks at the scope of the Repo.
+func IsUnsupportedScope(err error) bool {
+	_, ok := err.(unsupportedScopeError)
+	return ok
+}
+
+// ownerScope returns the scope of the owners that the driver can list the
+// repositories of.
+func ownerScope(driver string) (v1alpha1.HookScope, bool) {
+	for s, d := range scopeDrivers {
+		if d == driver {
+			return s, true
+		}
+	}
+	return "", false
+}
+
+type unsupportedListingError struct {
+	driver string
+}
+
+func (e unsupportedListingError) Error() string {
+	return fmt.Sprintf("listing repositories is not supported by the %s driver", e.driver)
+}
+
+// IsUnsupportedListing returns true if the provided error means that the
+// driver can't list the repositories of an organization or group.
+func IsUnsupportedListing(err error) bool {
+	_, ok := err.(unsupportedListingError)
+	return ok
+}
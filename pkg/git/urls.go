@@ -0,0 +1,87 @@
+package git
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// parseRepoURL parses a repository URL into an https URL with the host and
+// the full path to the repository, without any credentials.
+//
+// As well as http(s) URLs, SSH URLs, e.g. ssh://git@gitlab.com:2222/org/repo.git
+// and scp-like URLs, e.g. git@github.com:org/repo.git are accepted, the SSH
+// port isn't kept, as it isn't the port that the API is served on.
+//
+// Azure DevOps SSH URLs, e.g. git@ssh.dev.azure.com:v3/org/project/repo are
+// converted to the equivalent https URL.
+func parseRepoURL(s string) (*url.URL, error) {
+	if isSCPURL(s) {
+		s = "ssh://" + strings.Replace(s, ":", "/", 1)
+	}
+	parsed, err := url.Parse(s)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse the repoURL %q: %w", s, err)
+	}
+	parsed.User = nil
+	switch parsed.Scheme {
+	case "ssh", "git+ssh", "ssh+git":
+		parsed.Scheme = "https"
+		parsed.Host = parsed.Hostname()
+	case "http", "https":
+	default:
+		return nil, fmt.Errorf("unsupported repoURL scheme %q in %q", parsed.Scheme, s)
+	}
+	parsed.RawQuery = ""
+	parsed.Fragment = ""
+	return normaliseAzureSSH(parsed), nil
+}
+
+// isSSHURL returns true if the URL is an SSH or scp-like URL.
+func isSSHURL(s string) bool {
+	if isSCPURL(s) {
+		return true
+	}
+	for _, prefix := range []string{"ssh://", "git+ssh://", "ssh+git://"} {
+		if strings.HasPrefix(s, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// isSCPURL returns true if the URL is in the scp-like format that git accepts,
+// [user@]host:path, where there's no slash before the first colon.
+func isSCPURL(s string) bool {
+	if strings.Contains(s, "://") {
+		return false
+	}
+	colon := strings.Index(s, ":")
+	if colon == -1 {
+		return false
+	}
+	slash := strings.Index(s, "/")
+	return slash == -1 || colon < slash
+}
+
+// normaliseAzureSSH converts Azure DevOps Services SSH URLs, e.g.
+// ssh.dev.azure.com/v3/org/project/repo to the https format,
+// dev.azure.com/org/project/_git/repo.
+func normaliseAzureSSH(u *url.URL) *url.URL {
+	if u.Host != "ssh.dev.azure.com" && u.Host != "vs-ssh.visualstudio.com" {
+		return u
+	}
+	segments := pathSegments(u.Path)
+	if len(segments) != 4 || segments[0] != "v3" {
+		return u
+	}
+	org, project, repo := segments[1], segments[2], segments[3]
+	if u.Host == "ssh.dev.azure.com" {
+		u.Host = "dev.azure.com"
+		u.Path = "/" + strings.Join([]string{org, project, "_git", repo}, "/")
+		return u
+	}
+	u.Host = org + ".visualstudio.com"
+	u.Path = "/" + strings.Join([]string{project, "_git", repo}, "/")
+	return u
+}
@@ -0,0 +1,87 @@
+package providers
+
+import (
+	"context"
+
+	"github.com/operator-framework/operator-sdk/pkg/k8sutil"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	"github.com/bigkevmcd/webhook-secret-operator/pkg/git"
+)
+
+var log = logf.Log.WithName("providers")
+
+// ConfigMapName is the name of the ConfigMap in the operator's namespace that
+// maps the hostnames of self-hosted git servers to drivers and API endpoints.
+const ConfigMapName = "webhook-secret-operator-providers"
+
+// Key is the key in the providers ConfigMap that contains the YAML list of
+// providers.
+const Key = "providers.yaml"
+
+// ConfigMapID returns the name of the providers ConfigMap in the operator's
+// namespace, the name is empty if the operator's namespace can't be
+// determined, e.g. when running locally.
+func ConfigMapID() types.NamespacedName {
+	ns, err := k8sutil.GetOperatorNamespace()
+	if err != nil {
+		log.Info("Unable to determine the operator namespace, the providers ConfigMap will not be used", "error", err.Error())
+		return types.NamespacedName{}
+	}
+	return types.NamespacedName{Name: ConfigMapName, Namespace: ns}
+}
+
+// Loader loads the providers from the providers ConfigMap into a registry.
+type Loader struct {
+	kubeClient client.Client
+	registry   *git.ProviderRegistry
+	configMap  types.NamespacedName
+}
+
+// NewLoader creates and returns a new Loader, that loads the providers from
+// the ConfigMap into the registry, if the ConfigMap has no name, the registry
+// is never changed.
+func NewLoader(c client.Client, registry *git.ProviderRegistry, configMap types.NamespacedName) *Loader {
+	return &Loader{kubeClient: c, registry: registry, configMap: configMap}
+}
+
+// ConfigMap returns the name of the ConfigMap that the providers are loaded
+// from.
+func (l *Loader) ConfigMap() types.NamespacedName {
+	return l.configMap
+}
+
+// Registry returns the registry that the providers are loaded into.
+func (l *Loader) Registry() *git.ProviderRegistry {
+	return l.registry
+}
+
+// Load replaces the providers in the registry with the providers from the
+// ConfigMap.
+//
+// If the ConfigMap doesn't exist, the registry is emptied, if it can't be
+// parsed, the existing providers are kept.
+func (l *Loader) Load(ctx context.Context) error {
+	if l.configMap.Name == "" {
+		return nil
+	}
+	cm := &corev1.ConfigMap{}
+	err := l.kubeClient.Get(ctx, l.configMap, cm)
+	if errors.IsNotFound(err) {
+		l.registry.Set(nil)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	providers, err := git.ParseProviders([]byte(cm.Data[Key]))
+	if err != nil {
+		return err
+	}
+	l.registry.Set(providers)
+	return nil
+}
@@ -0,0 +1,81 @@
+package providers
+
+import (
+	"context"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/bigkevmcd/webhook-secret-operator/pkg/git"
+	"github.com/bigkevmcd/webhook-secret-operator/test"
+)
+
+var testConfigMap = types.NamespacedName{Name: ConfigMapName, Namespace: "operator-ns"}
+
+func TestLoad(t *testing.T) {
+	cm := makeConfigMap("- host: github.example.com\n  driver: github\n")
+	cl := fake.NewFakeClient(cm)
+	l := NewLoader(cl, git.NewProviderRegistry(), testConfigMap)
+	ctx := context.Background()
+
+	if err := l.Load(ctx); err != nil {
+		t.Fatal(err)
+	}
+	want := git.Provider{Host: "github.example.com", Driver: "github"}
+	if p, ok := l.Registry().Lookup("github.example.com"); !ok || p != want {
+		t.Fatalf("Lookup() got %#v, want %#v", p, want)
+	}
+
+	cm.Data[Key] = "- host: github.example.com\n"
+	if err := cl.Update(ctx, cm); err != nil {
+		t.Fatal(err)
+	}
+	err := l.Load(ctx)
+	if !test.MatchError(t, "must have a host and a driver", err) {
+		t.Fatalf("failed to match error, got %v", err)
+	}
+	if _, ok := l.Registry().Lookup("github.example.com"); !ok {
+		t.Fatal("providers were replaced by an invalid ConfigMap")
+	}
+
+	if err := cl.Delete(ctx, cm); err != nil {
+		t.Fatal(err)
+	}
+	if err := l.Load(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := l.Registry().Lookup("github.example.com"); ok {
+		t.Fatal("providers were not removed when the ConfigMap was deleted")
+	}
+}
+
+func TestLoadWithNoConfigMap(t *testing.T) {
+	registry := git.NewProviderRegistry(git.Provider{Host: "github.example.com", Driver: "github"})
+	l := NewLoader(fake.NewFakeClient(), registry, types.NamespacedName{})
+
+	if err := l.Load(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := registry.Lookup("github.example.com"); !ok {
+		t.Fatal("providers were removed without a ConfigMap")
+	}
+}
+
+func makeConfigMap(data string) *corev1.ConfigMap {
+	return &corev1.ConfigMap{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: "v1",
+			Kind:       "ConfigMap",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      testConfigMap.Name,
+			Namespace: testConfigMap.Namespace,
+		},
+		Data: map[string]string{
+			Key: data,
+		},
+	}
+}
@@ -0,0 +1,33 @@
+package routes
+
+import (
+	"errors"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// IsNotFound returns true if the error, or an error that it wraps, is a
+// Kubernetes API not found error.
+func IsNotFound(err error) bool {
+	var status apierrors.APIStatus
+	return errors.As(err, &status) && status.Status().Reason == metav1.StatusReasonNotFound
+}
+
+// pendingError is returned when the object that the URL is calculated from
+// exists, but its URL hasn't been assigned yet, e.g. a LoadBalancer that is
+// still being provisioned.
+type pendingError struct {
+	msg string
+}
+
+func (e pendingError) Error() string {
+	return e.msg
+}
+
+// IsPending returns true if the error, or an error that it wraps, indicates
+// that the URL isn't available yet.
+func IsPending(err error) bool {
+	var pending pendingError
+	return errors.As(err, &pending)
+}
@@ -0,0 +1,33 @@
+package routes
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func TestIsNotFound(t *testing.T) {
+	notFound := apierrors.NewNotFound(schema.GroupResource{Group: "networking.k8s.io", Resource: "ingresses"}, "test-ingress")
+	errTests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"not found", notFound, true},
+		{"wrapped not found", fmt.Errorf("error getting ingress: %w", notFound), true},
+		{"forbidden", apierrors.NewForbidden(schema.GroupResource{Resource: "ingresses"}, "test-ingress", errors.New("denied")), false},
+		{"other error", errors.New("failed"), false},
+		{"nil", nil, false},
+	}
+
+	for _, tt := range errTests {
+		t.Run(tt.name, func(rt *testing.T) {
+			if got := IsNotFound(tt.err); got != tt.want {
+				rt.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,170 @@
+package routes
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+
+	routev1 "github.com/openshift/api/route/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// EventListenerGVK is the Tekton Triggers EventListener kind.
+var EventListenerGVK = schema.GroupVersionKind{Group: "triggers.tekton.dev", Version: "v1beta1", Kind: "EventListener"}
+
+// KubeEventListenerGetter is an implementation of EventListenerGetter.
+type KubeEventListenerGetter struct {
+	kubeClient client.Client
+}
+
+// NewEventListenerGetter creates and returns a KubeEventListenerGetter that
+// looks up Tekton EventListeners in k8s.
+func NewEventListenerGetter(c client.Client) *KubeEventListenerGetter {
+	return &KubeEventListenerGetter{
+		kubeClient: c,
+	}
+}
+
+// EventListenerURL looks for a namespaced EventListener, and returns the URL
+// of the Route or Ingress that exposes the EventListener's Service.
+//
+// A pending error is returned until Tekton has created the Service for the
+// EventListener, and the address in the status of the EventListener is only
+// reachable from inside the cluster, so it's an error if the Service isn't
+// exposed.
+func (k KubeEventListenerGetter) EventListenerURL(ctx context.Context, id types.NamespacedName, path string) (string, error) {
+	serviceName, err := k.EventListenerServiceName(ctx, id)
+	if err != nil {
+		return "", err
+	}
+	if serviceName == "" {
+		return "", pendingError{msg: fmt.Sprintf("eventlistener %s is waiting for an address", id)}
+	}
+
+	route, err := k.findRoute(ctx, id.Namespace, serviceName)
+	if err != nil {
+		return "", fmt.Errorf("eventlistener %s %s", id, err)
+	}
+	if route != nil {
+		return routeURL(route, path), nil
+	}
+	hookURL, err := k.findIngressURL(ctx, id.Namespace, serviceName, path)
+	if err != nil {
+		return "", fmt.Errorf("eventlistener %s %s", id, err)
+	}
+	if hookURL == "" {
+		return "", fmt.Errorf("eventlistener %s service %s is not exposed by a Route or Ingress", id, serviceName)
+	}
+	return hookURL, nil
+}
+
+// EventListenerServiceName looks for a namespaced EventListener, and returns
+// the name of the Service that Tekton created for it, or an empty string if
+// the Service hasn't been created yet.
+func (k KubeEventListenerGetter) EventListenerServiceName(ctx context.Context, id types.NamespacedName) (string, error) {
+	loaded := &unstructured.Unstructured{}
+	loaded.SetGroupVersionKind(EventListenerGVK)
+	if err := k.kubeClient.Get(ctx, id, loaded); err != nil {
+		return "", fmt.Errorf("error getting eventlistener %s: %w", id, err)
+	}
+	return eventListenerServiceName(loaded), nil
+}
+
+// eventListenerServiceName returns the name of the Service that Tekton created
+// for the EventListener, from the generated name in the status, or the host
+// of the address.
+func eventListenerServiceName(el *unstructured.Unstructured) string {
+	name, _, _ := unstructured.NestedString(el.Object, "status", "configuration", "generatedName")
+	if name != "" {
+		return name
+	}
+	address, _, _ := unstructured.NestedString(el.Object, "status", "address", "url")
+	if address == "" {
+		return ""
+	}
+	parsed, err := url.Parse(address)
+	if err != nil {
+		return ""
+	}
+	return strings.Split(parsed.Hostname(), ".")[0]
+}
+
+// findRoute returns the Route in the namespace that routes to the Service, or
+// nil if there's none, or the cluster doesn't serve Routes.
+func (k KubeEventListenerGetter) findRoute(ctx context.Context, ns, serviceName string) (*routev1.Route, error) {
+	list := &routev1.RouteList{}
+	err := k.kubeClient.List(ctx, list, client.InNamespace(ns))
+	if meta.IsNoMatchError(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error listing routes: %w", err)
+	}
+	found := []*routev1.Route{}
+	for i := range list.Items {
+		to := list.Items[i].Spec.To
+		if to.Kind == "Service" && to.Name == serviceName {
+			found = append(found, &list.Items[i])
+		}
+	}
+	switch len(found) {
+	case 0:
+		return nil, nil
+	case 1:
+		return found[0], nil
+	}
+	return nil, fmt.Errorf("service %s is exposed by %d Routes, use a routeRef to select one", serviceName, len(found))
+}
+
+// findIngressURL returns the URL for the Ingress rule in the namespace that
+// has a backend for the Service, or an empty string if there's none, or the
+// cluster doesn't serve Ingresses.
+//
+// If no path is provided, the path of the rule for the Service is used.
+func (k KubeEventListenerGetter) findIngressURL(ctx context.Context, ns, serviceName, path string) (string, error) {
+	list := &unstructured.UnstructuredList{}
+	list.SetGroupVersionKind(IngressGVK.GroupVersion().WithKind(IngressGVK.Kind + "List"))
+	err := k.kubeClient.List(ctx, list, client.InNamespace(ns))
+	if meta.IsNoMatchError(err) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("error listing ingresses: %w", err)
+	}
+	found := []string{}
+	for i := range list.Items {
+		spec := ingressSpec{}
+		if err := fromUnstructuredField(&list.Items[i], &spec, "spec"); err != nil {
+			return "", fmt.Errorf("error parsing ingress %s: %w", list.Items[i].GetName(), err)
+		}
+		for _, rule := range spec.Rules {
+			if rule.Host == "" || strings.HasPrefix(rule.Host, "*") || rule.HTTP == nil {
+				continue
+			}
+			for _, p := range rule.HTTP.Paths {
+				if p.Backend.Service == nil || p.Backend.Service.Name != serviceName {
+					continue
+				}
+				rulePath := path
+				if rulePath == "" {
+					rulePath = p.Path
+				}
+				found = append(found, ingressRuleURL(spec, rule, rulePath))
+			}
+		}
+	}
+	switch len(found) {
+	case 0:
+		return "", nil
+	case 1:
+		return found[0], nil
+	}
+	sort.Strings(found)
+	return "", fmt.Errorf("service %s is exposed by more than one Ingress rule (%s), use an ingressRef to select one", serviceName, strings.Join(found, ", "))
+}
@@ -0,0 +1,148 @@
+package routes
+
+import (
+	"context"
+	"testing"
+
+	routev1 "github.com/openshift/api/route/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/bigkevmcd/webhook-secret-operator/test"
+)
+
+var _ EventListenerGetter = (*KubeEventListenerGetter)(nil)
+
+var testEventListenerID = types.NamespacedName{Name: "test-listener", Namespace: "test-ns"}
+
+func TestEventListenerURL(t *testing.T) {
+	urlTests := []struct {
+		name    string
+		objs    []runtime.Object
+		path    string
+		want    string
+		wantErr string
+	}{
+		{"route", []runtime.Object{
+			test.MakeRoute(types.NamespacedName{Name: "el-route", Namespace: "test-ns"}, test.Host("hooks.example.com"), routeTo("el-test-listener")),
+		}, "", "https://hooks.example.com/", ""},
+		{"route with path", []runtime.Object{
+			test.MakeRoute(types.NamespacedName{Name: "el-route", Namespace: "test-ns"}, test.Host("hooks.example.com"), routeTo("el-test-listener")),
+		}, "/test/api", "https://hooks.example.com/test/api", ""},
+		{"route for another service", []runtime.Object{
+			test.MakeRoute(types.NamespacedName{Name: "el-route", Namespace: "test-ns"}, routeTo("el-other-listener")),
+		}, "", "", "eventlistener test-ns/test-listener service el-test-listener is not exposed by a Route or Ingress"},
+		{"route in another namespace", []runtime.Object{
+			test.MakeRoute(types.NamespacedName{Name: "el-route", Namespace: "other-ns"}, routeTo("el-test-listener")),
+		}, "", "", "eventlistener test-ns/test-listener service el-test-listener is not exposed by a Route or Ingress"},
+		{"multiple routes", []runtime.Object{
+			test.MakeRoute(types.NamespacedName{Name: "el-route", Namespace: "test-ns"}, routeTo("el-test-listener")),
+			test.MakeRoute(types.NamespacedName{Name: "el-route2", Namespace: "test-ns"}, routeTo("el-test-listener")),
+		}, "", "", "service el-test-listener is exposed by 2 Routes, use a routeRef to select one"},
+		{"ingress", []runtime.Object{
+			test.MakeIngress(types.NamespacedName{Name: "el-ingress", Namespace: "test-ns"},
+				test.IngressServiceRule("hooks.example.com", "/listener", "el-test-listener"), test.IngressTLS("hooks.example.com")),
+		}, "", "https://hooks.example.com/listener", ""},
+		{"ingress with path", []runtime.Object{
+			test.MakeIngress(types.NamespacedName{Name: "el-ingress", Namespace: "test-ns"},
+				test.IngressServiceRule("hooks.example.com", "/listener", "el-test-listener")),
+		}, "/test/api", "http://hooks.example.com/test/api", ""},
+		{"multiple ingress rules", []runtime.Object{
+			test.MakeIngress(types.NamespacedName{Name: "el-ingress", Namespace: "test-ns"},
+				test.IngressServiceRule("hooks.example.com", "/", "el-test-listener"),
+				test.IngressServiceRule("hooks.example.org", "/", "el-test-listener")),
+		}, "", "", `service el-test-listener is exposed by more than one Ingress rule \(http://hooks.example.com/, http://hooks.example.org/\), use an ingressRef to select one`},
+	}
+
+	for _, tt := range urlTests {
+		t.Run(tt.name, func(rt *testing.T) {
+			objs := append([]runtime.Object{test.MakeEventListener(testEventListenerID, test.EventListenerAddress("el-test-listener"))}, tt.objs...)
+			g := makeEventListenerGetter(rt, objs...)
+
+			hookURL, err := g.EventListenerURL(context.TODO(), testEventListenerID, tt.path)
+			if !test.MatchError(rt, tt.wantErr, err) {
+				rt.Fatalf("error failed to match, got %#v, want %s", err, tt.wantErr)
+			}
+			if hookURL != tt.want {
+				rt.Fatalf("got %s, want %s", hookURL, tt.want)
+			}
+		})
+	}
+}
+
+func TestEventListenerURLWhilePending(t *testing.T) {
+	g := makeEventListenerGetter(t, test.MakeEventListener(testEventListenerID))
+
+	_, err := g.EventListenerURL(context.TODO(), testEventListenerID, "")
+
+	if !IsPending(err) {
+		t.Fatalf("expected a pending error, got %v", err)
+	}
+}
+
+func TestEventListenerURLWithMissingEventListener(t *testing.T) {
+	g := makeEventListenerGetter(t)
+
+	_, err := g.EventListenerURL(context.TODO(), testEventListenerID, "")
+
+	if !IsNotFound(err) {
+		t.Fatalf("expected a not found error, got %v", err)
+	}
+}
+
+func TestEventListenerServiceName(t *testing.T) {
+	el := test.MakeEventListener(testEventListenerID)
+	el.Object["status"] = map[string]interface{}{
+		"address": map[string]interface{}{"url": "http://el-test-listener.test-ns.svc.cluster.local:8080"},
+	}
+
+	if name := eventListenerServiceName(el); name != "el-test-listener" {
+		t.Fatalf("got %s, want el-test-listener", name)
+	}
+}
+
+func TestKubeEventListenerGetterServiceName(t *testing.T) {
+	nameTests := []struct {
+		name string
+		el   *unstructured.Unstructured
+		want string
+	}{
+		{"with an address", test.MakeEventListener(testEventListenerID, test.EventListenerAddress("el-test-listener")), "el-test-listener"},
+		{"waiting for an address", test.MakeEventListener(testEventListenerID), ""},
+	}
+
+	for _, tt := range nameTests {
+		t.Run(tt.name, func(rt *testing.T) {
+			g := makeEventListenerGetter(rt, tt.el)
+
+			name, err := g.EventListenerServiceName(context.TODO(), testEventListenerID)
+			if err != nil {
+				rt.Fatal(err)
+			}
+			if name != tt.want {
+				rt.Fatalf("got %q, want %q", name, tt.want)
+			}
+		})
+	}
+}
+
+// makeEventListenerGetter registers the list kinds that the getter lists, the
+// fake client can't list unstructured objects of unregistered kinds.
+func makeEventListenerGetter(t *testing.T, o ...runtime.Object) *KubeEventListenerGetter {
+	t.Helper()
+	s := scheme.Scheme
+	if err := routev1.AddToScheme(s); err != nil {
+		t.Fatal(err)
+	}
+	s.AddKnownTypeWithName(IngressGVK.GroupVersion().WithKind("IngressList"), &unstructured.UnstructuredList{})
+	return NewEventListenerGetter(fake.NewFakeClientWithScheme(s, o...))
+}
+
+func routeTo(serviceName string) func(*routev1.Route) {
+	return func(r *routev1.Route) {
+		r.Spec.To = routev1.RouteTargetReference{Kind: "Service", Name: serviceName}
+	}
+}
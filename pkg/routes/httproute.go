@@ -0,0 +1,253 @@
+package routes
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const gatewayGroup = "gateway.networking.k8s.io"
+
+// HTTPRouteGVK is the Gateway API HTTPRoute kind.
+var HTTPRouteGVK = schema.GroupVersionKind{Group: gatewayGroup, Version: "v1", Kind: "HTTPRoute"}
+
+// GatewayGVK is the Gateway API Gateway kind.
+var GatewayGVK = schema.GroupVersionKind{Group: gatewayGroup, Version: "v1", Kind: "Gateway"}
+
+// KubeHTTPRouteGetter is an implementation of HTTPRouteGetter.
+type KubeHTTPRouteGetter struct {
+	kubeClient client.Client
+}
+
+// NewHTTPRouteGetter creates and returns a KubeHTTPRouteGetter that looks up
+// HTTPRoutes and their Gateways in k8s.
+func NewHTTPRouteGetter(c client.Client) *KubeHTTPRouteGetter {
+	return &KubeHTTPRouteGetter{
+		kubeClient: c,
+	}
+}
+
+// httpRouteSpec is the subset of the HTTPRoute spec that's needed to
+// calculate URLs.
+type httpRouteSpec struct {
+	ParentRefs []struct {
+		Group       *string `json:"group,omitempty"`
+		Kind        *string `json:"kind,omitempty"`
+		Namespace   *string `json:"namespace,omitempty"`
+		Name        string  `json:"name"`
+		SectionName *string `json:"sectionName,omitempty"`
+		Port        *int32  `json:"port,omitempty"`
+	} `json:"parentRefs,omitempty"`
+	Hostnames []string `json:"hostnames,omitempty"`
+	Rules     []struct {
+		Matches []struct {
+			Path *struct {
+				Value string `json:"value,omitempty"`
+			} `json:"path,omitempty"`
+		} `json:"matches,omitempty"`
+	} `json:"rules,omitempty"`
+}
+
+// gatewaySpec is the subset of the Gateway spec that's needed to calculate
+// URLs.
+type gatewaySpec struct {
+	Listeners []gatewayListener `json:"listeners,omitempty"`
+}
+
+// gatewayListener is the subset of a Gateway listener that's needed to
+// calculate URLs.
+type gatewayListener struct {
+	Name     string  `json:"name"`
+	Hostname *string `json:"hostname,omitempty"`
+	Port     int32   `json:"port"`
+	Protocol string  `json:"protocol"`
+	TLS      *struct {
+		Mode string `json:"mode,omitempty"`
+	} `json:"tls,omitempty"`
+}
+
+func (l gatewayListener) scheme() string {
+	if l.Protocol == "HTTPS" || l.TLS != nil {
+		return "https"
+	}
+	return "http"
+}
+
+// HTTPRouteURL looks for a namespaced HTTPRoute, and returns the URL for the
+// hostname, or the only hostname of the HTTPRoute if no hostname is provided.
+//
+// The scheme and port come from the listener of the parent Gateway, the URL is
+// https if the listener has TLS configured. If the HTTPRoute has no hostnames,
+// the listener's hostname, or the Gateway's address, is used.
+func (k KubeHTTPRouteGetter) HTTPRouteURL(ctx context.Context, id types.NamespacedName, hostname, path string) (string, error) {
+	route := &unstructured.Unstructured{}
+	route.SetGroupVersionKind(HTTPRouteGVK)
+	if err := k.kubeClient.Get(ctx, id, route); err != nil {
+		return "", fmt.Errorf("error getting httproute %s: %w", id, err)
+	}
+	spec := httpRouteSpec{}
+	if err := fromUnstructuredField(route, &spec, "spec"); err != nil {
+		return "", fmt.Errorf("error parsing httproute %s: %w", id, err)
+	}
+
+	host, err := routeHostname(spec.Hostnames, hostname)
+	if err != nil {
+		return "", fmt.Errorf("httproute %s %s", id, err)
+	}
+
+	for _, ref := range spec.ParentRefs {
+		if (ref.Group != nil && *ref.Group != gatewayGroup) || (ref.Kind != nil && *ref.Kind != "Gateway") {
+			continue
+		}
+		gatewayID := types.NamespacedName{Name: ref.Name, Namespace: id.Namespace}
+		if ref.Namespace != nil {
+			gatewayID.Namespace = *ref.Namespace
+		}
+		gateway := &unstructured.Unstructured{}
+		gateway.SetGroupVersionKind(GatewayGVK)
+		if err := k.kubeClient.Get(ctx, gatewayID, gateway); err != nil {
+			return "", fmt.Errorf("error getting gateway %s for httproute %s: %w", gatewayID, id, err)
+		}
+		gatewaySpec := gatewaySpec{}
+		if err := fromUnstructuredField(gateway, &gatewaySpec, "spec"); err != nil {
+			return "", fmt.Errorf("error parsing gateway %s: %w", gatewayID, err)
+		}
+		listener, ok := findListener(gatewaySpec.Listeners, ref.SectionName, ref.Port, host)
+		if !ok {
+			continue
+		}
+		if host == "" {
+			host = listenerHostname(listener, gateway)
+		}
+		if host == "" {
+			return "", fmt.Errorf("httproute %s has no hostnames, and gateway %s has no hostname or address", id, gatewayID)
+		}
+		if path == "" {
+			path = routePath(spec)
+		}
+		if path == "" {
+			path = "/"
+		}
+		scheme := listener.scheme()
+		if !isDefaultPort(scheme, listener.Port) {
+			host = net.JoinHostPort(host, strconv.Itoa(int(listener.Port)))
+		}
+		routeURL := url.URL{
+			Scheme: scheme,
+			Host:   host,
+			Path:   path,
+		}
+		return routeURL.String(), nil
+	}
+	return "", fmt.Errorf("httproute %s has no parent Gateway with an HTTP or HTTPS listener for the route", id)
+}
+
+// routeHostname returns the hostname if it's one of the route's hostnames, or
+// the only hostname if no hostname is provided, wildcard hostnames are
+// ignored.
+func routeHostname(hostnames []string, hostname string) (string, error) {
+	candidates := []string{}
+	for _, h := range hostnames {
+		if strings.HasPrefix(h, "*") {
+			continue
+		}
+		if hostname == "" || h == hostname {
+			candidates = append(candidates, h)
+		}
+	}
+	switch {
+	case len(candidates) == 1:
+		return candidates[0], nil
+	case len(candidates) > 1:
+		return "", fmt.Errorf("has more than one hostname, a hostname must be selected")
+	case hostname != "" && len(hostnames) > 0:
+		return "", fmt.Errorf("has no hostname %q", hostname)
+	}
+	return hostname, nil
+}
+
+// findListener returns the listener for the parent reference, HTTPS listeners
+// are preferred over HTTP listeners.
+func findListener(listeners []gatewayListener, sectionName *string, port *int32, host string) (gatewayListener, bool) {
+	var found *gatewayListener
+	for i := range listeners {
+		l := listeners[i]
+		if l.Protocol != "HTTP" && l.Protocol != "HTTPS" {
+			continue
+		}
+		if sectionName != nil && l.Name != *sectionName {
+			continue
+		}
+		if port != nil && l.Port != *port {
+			continue
+		}
+		if host != "" && l.Hostname != nil && !hostnameMatches(*l.Hostname, host) {
+			continue
+		}
+		if found == nil || (found.scheme() == "http" && l.scheme() == "https") {
+			found = &l
+		}
+	}
+	if found == nil {
+		return gatewayListener{}, false
+	}
+	return *found, true
+}
+
+// listenerHostname returns the hostname of the listener, or if it doesn't have
+// a specific hostname, the first address of the Gateway.
+func listenerHostname(l gatewayListener, gateway *unstructured.Unstructured) string {
+	if l.Hostname != nil && !strings.HasPrefix(*l.Hostname, "*") {
+		return *l.Hostname
+	}
+	addresses, _, _ := unstructured.NestedSlice(gateway.Object, "status", "addresses")
+	for _, a := range addresses {
+		if m, ok := a.(map[string]interface{}); ok {
+			if v, ok := m["value"].(string); ok && v != "" {
+				return v
+			}
+		}
+	}
+	return ""
+}
+
+// routePath returns the path of the route, if it has exactly one path match.
+func routePath(spec httpRouteSpec) string {
+	paths := []string{}
+	for _, r := range spec.Rules {
+		for _, m := range r.Matches {
+			if m.Path != nil && m.Path.Value != "" {
+				paths = append(paths, m.Path.Value)
+			}
+		}
+	}
+	if len(paths) == 1 {
+		return paths[0]
+	}
+	return ""
+}
+
+// hostnameMatches returns true if the host matches the pattern, which can be a
+// wildcard hostname, e.g. *.example.com.
+func hostnameMatches(pattern, host string) bool {
+	if pattern == host {
+		return true
+	}
+	if !strings.HasPrefix(pattern, "*.") {
+		return false
+	}
+	suffix := pattern[1:]
+	return strings.HasSuffix(host, suffix) && !strings.Contains(strings.TrimSuffix(host, suffix), ".")
+}
+
+func isDefaultPort(scheme string, port int32) bool {
+	return (scheme == "http" && port == 80) || (scheme == "https" && port == 443)
+}
@@ -0,0 +1,124 @@
+package routes
+
+import (
+	"context"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/bigkevmcd/webhook-secret-operator/test"
+)
+
+var _ HTTPRouteGetter = (*KubeHTTPRouteGetter)(nil)
+
+var (
+	testHTTPRouteID = types.NamespacedName{Name: "test-httproute", Namespace: "test-ns"}
+	testGatewayID   = types.NamespacedName{Name: "test-gateway", Namespace: "test-ns"}
+)
+
+func TestHTTPRouteURL(t *testing.T) {
+	urlTests := []struct {
+		name     string
+		objs     []runtime.Object
+		hostname string
+		path     string
+		want     string
+		wantErr  string
+	}{
+		{"HTTPS listener",
+			[]runtime.Object{test.MakeHTTPRoute(testHTTPRouteID), test.MakeGateway(testGatewayID)},
+			"", "", "https://example.com/", ""},
+		{"with path",
+			[]runtime.Object{test.MakeHTTPRoute(testHTTPRouteID), test.MakeGateway(testGatewayID)},
+			"", "/test/api", "https://example.com/test/api", ""},
+		{"single path match",
+			[]runtime.Object{
+				test.MakeHTTPRoute(testHTTPRouteID, test.Hostnames("example.com"), test.ParentRef("test-gateway", nil), test.PathMatch("/el")),
+				test.MakeGateway(testGatewayID)},
+			"", "", "https://example.com/el", ""},
+		{"HTTP listener on a custom port",
+			[]runtime.Object{test.MakeHTTPRoute(testHTTPRouteID), test.MakeGateway(testGatewayID, test.Listener("http", "HTTP", 8080, ""))},
+			"", "", "http://example.com:8080/", ""},
+		{"HTTPS preferred",
+			[]runtime.Object{
+				test.MakeHTTPRoute(testHTTPRouteID),
+				test.MakeGateway(testGatewayID, test.Listener("http", "HTTP", 80, ""), test.Listener("https", "HTTPS", 443, ""))},
+			"", "", "https://example.com/", ""},
+		{"section name",
+			[]runtime.Object{
+				test.MakeHTTPRoute(testHTTPRouteID, test.Hostnames("example.com"), test.ParentRef("test-gateway", map[string]interface{}{"sectionName": "http"})),
+				test.MakeGateway(testGatewayID, test.Listener("http", "HTTP", 80, ""), test.Listener("https", "HTTPS", 443, ""))},
+			"", "", "http://example.com/", ""},
+		{"listener for another hostname",
+			[]runtime.Object{
+				test.MakeHTTPRoute(testHTTPRouteID),
+				test.MakeGateway(testGatewayID, test.Listener("http", "HTTP", 80, ""), test.Listener("https", "HTTPS", 443, "other.example.com"))},
+			"", "", "http://example.com/", ""},
+		{"selected hostname",
+			[]runtime.Object{
+				test.MakeHTTPRoute(testHTTPRouteID, test.Hostnames("example.com", "hooks.example.com"), test.ParentRef("test-gateway", nil)),
+				test.MakeGateway(testGatewayID, test.Listener("https", "HTTPS", 443, "*.example.com"))},
+			"hooks.example.com", "", "https://hooks.example.com/", ""},
+		{"gateway in another namespace",
+			[]runtime.Object{
+				test.MakeHTTPRoute(testHTTPRouteID, test.Hostnames("example.com"), test.ParentRef("shared-gateway", map[string]interface{}{"namespace": "gateways"})),
+				test.MakeGateway(types.NamespacedName{Name: "shared-gateway", Namespace: "gateways"})},
+			"", "", "https://example.com/", ""},
+		{"listener hostname",
+			[]runtime.Object{
+				test.MakeHTTPRoute(testHTTPRouteID, test.ParentRef("test-gateway", nil)),
+				test.MakeGateway(testGatewayID, test.Listener("https", "HTTPS", 443, "gateway.example.com"))},
+			"", "", "https://gateway.example.com/", ""},
+		{"gateway address",
+			[]runtime.Object{
+				test.MakeHTTPRoute(testHTTPRouteID, test.ParentRef("test-gateway", nil)),
+				test.MakeGateway(testGatewayID, test.Listener("http", "HTTP", 80, ""), test.GatewayAddress("192.0.2.10"))},
+			"", "", "http://192.0.2.10/", ""},
+		{"multiple hostnames",
+			[]runtime.Object{
+				test.MakeHTTPRoute(testHTTPRouteID, test.Hostnames("example.com", "hooks.example.com"), test.ParentRef("test-gateway", nil)),
+				test.MakeGateway(testGatewayID)},
+			"", "", "", "httproute test-ns/test-httproute has more than one hostname, a hostname must be selected"},
+		{"unknown hostname",
+			[]runtime.Object{test.MakeHTTPRoute(testHTTPRouteID), test.MakeGateway(testGatewayID)},
+			"hooks.example.com", "", "", `httproute test-ns/test-httproute has no hostname "hooks.example.com"`},
+		{"no hostname or address",
+			[]runtime.Object{test.MakeHTTPRoute(testHTTPRouteID, test.ParentRef("test-gateway", nil)), test.MakeGateway(testGatewayID)},
+			"", "", "", "httproute test-ns/test-httproute has no hostnames, and gateway test-ns/test-gateway has no hostname or address"},
+		{"no HTTP listeners",
+			[]runtime.Object{test.MakeHTTPRoute(testHTTPRouteID), test.MakeGateway(testGatewayID, test.Listener("tcp", "TCP", 5432, ""))},
+			"", "", "", "httproute test-ns/test-httproute has no parent Gateway with an HTTP or HTTPS listener for the route"},
+		{"missing gateway",
+			[]runtime.Object{test.MakeHTTPRoute(testHTTPRouteID)},
+			"", "", "", `error getting gateway test-ns/test-gateway for httproute test-ns/test-httproute: .*not found`},
+	}
+
+	for _, tt := range urlTests {
+		t.Run(tt.name, func(rt *testing.T) {
+			g := NewHTTPRouteGetter(fake.NewFakeClient(tt.objs...))
+
+			hookURL, err := g.HTTPRouteURL(context.TODO(), testHTTPRouteID, tt.hostname, tt.path)
+			if !test.MatchError(rt, tt.wantErr, err) {
+				rt.Fatalf("error failed to match, got %#v, want %s", err, tt.wantErr)
+			}
+			if hookURL != tt.want {
+				rt.Fatalf("got %s, want %s", hookURL, tt.want)
+			}
+		})
+	}
+}
+
+func TestHTTPRouteURLWithMissingHTTPRoute(t *testing.T) {
+	g := NewHTTPRouteGetter(fake.NewFakeClient())
+
+	_, err := g.HTTPRouteURL(context.TODO(), testHTTPRouteID, "", "")
+
+	if !IsNotFound(err) {
+		t.Fatalf("expected a not found error, got %v", err)
+	}
+	if !test.MatchError(t, `error getting httproute test-ns/test-httproute: .*"test-httproute" not found`, err) {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,172 @@
+package routes
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// IngressGVK is the networking.k8s.io/v1 Ingress kind.
+//
+// Ingresses are loaded as unstructured objects, as the vendored k8s.io/api
+// doesn't include the v1 Ingress types.
+var IngressGVK = schema.GroupVersionKind{Group: "networking.k8s.io", Version: "v1", Kind: "Ingress"}
+
+// KubeIngressGetter is an implementation of IngressGetter.
+type KubeIngressGetter struct {
+	kubeClient client.Client
+}
+
+// NewIngressGetter creates and returns a KubeIngressGetter that looks up
+// Ingresses in k8s.
+func NewIngressGetter(c client.Client) *KubeIngressGetter {
+	return &KubeIngressGetter{
+		kubeClient: c,
+	}
+}
+
+// ingressSpec is the subset of the Ingress spec that's needed to calculate
+// URLs.
+type ingressSpec struct {
+	TLS []struct {
+		Hosts []string `json:"hosts,omitempty"`
+	} `json:"tls,omitempty"`
+	Rules []ingressRule `json:"rules,omitempty"`
+}
+
+type ingressRule struct {
+	Host string `json:"host,omitempty"`
+	HTTP *struct {
+		Paths []ingressPath `json:"paths"`
+	} `json:"http,omitempty"`
+}
+
+type ingressPath struct {
+	Path    string `json:"path,omitempty"`
+	Backend struct {
+		Service *struct {
+			Name string `json:"name"`
+		} `json:"service,omitempty"`
+	} `json:"backend"`
+}
+
+// IngressURL looks for a namespaced Ingress, and returns the URL for the rule
+// with the host, or the only rule with a host if no host is provided.
+//
+// The URL is https if the Ingress has TLS configured for the host, and if no
+// path is provided, the path of the rule is used if it has exactly one.
+func (k KubeIngressGetter) IngressURL(ctx context.Context, id types.NamespacedName, host, path string) (string, error) {
+	loaded := &unstructured.Unstructured{}
+	loaded.SetGroupVersionKind(IngressGVK)
+	err := k.kubeClient.Get(ctx, id, loaded)
+	if err != nil {
+		return "", fmt.Errorf("error getting ingress %s: %w", id, err)
+	}
+	spec := ingressSpec{}
+	if err := fromUnstructuredField(loaded, &spec, "spec"); err != nil {
+		return "", fmt.Errorf("error parsing ingress %s: %w", id, err)
+	}
+
+	rule, err := findIngressRule(spec.Rules, host)
+	if err != nil {
+		return "", fmt.Errorf("ingress %s %s", id, err)
+	}
+	if path == "" && rule.HTTP != nil && len(rule.HTTP.Paths) == 1 {
+		path = rule.HTTP.Paths[0].Path
+	}
+	return ingressRuleURL(spec, rule, path), nil
+}
+
+// ingressRuleURL returns the URL for the host of the rule, with the path.
+func ingressRuleURL(spec ingressSpec, rule ingressRule, path string) string {
+	scheme := "http"
+	if ingressHasTLS(spec, rule.Host) {
+		scheme = "https"
+	}
+	if path == "" {
+		path = "/"
+	}
+	u := url.URL{
+		Scheme: scheme,
+		Host:   rule.Host,
+		Path:   path,
+	}
+	return u.String()
+}
+
+// findIngressRule returns the rule for the host, or if the host is empty, the
+// only rule with a host.
+func findIngressRule(rules []ingressRule, host string) (ingressRule, error) {
+	candidates := []ingressRule{}
+	for _, r := range rules {
+		if r.Host == "" || strings.HasPrefix(r.Host, "*") {
+			continue
+		}
+		if host == "" || r.Host == host {
+			candidates = append(candidates, r)
+		}
+	}
+	switch {
+	case len(candidates) == 1:
+		return candidates[0], nil
+	case host != "":
+		return ingressRule{}, fmt.Errorf("has no rule for host %q", host)
+	case len(candidates) == 0:
+		return ingressRule{}, fmt.Errorf("has no rules with a host")
+	}
+	return ingressRule{}, fmt.Errorf("has rules for more than one host, a host must be selected")
+}
+
+// ingressHasTLS returns true if the TLS configuration for the Ingress covers
+// the host, a TLS entry without hosts covers all hosts.
+func ingressHasTLS(spec ingressSpec, host string) bool {
+	for _, tls := range spec.TLS {
+		if len(tls.Hosts) == 0 {
+			return true
+		}
+		for _, h := range tls.Hosts {
+			if hostnameMatches(h, host) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// IngressServiceNames returns the names of the Services that are backends for
+// the rules of the Ingress.
+func IngressServiceNames(u *unstructured.Unstructured) ([]string, error) {
+	spec := ingressSpec{}
+	if err := fromUnstructuredField(u, &spec, "spec"); err != nil {
+		return nil, err
+	}
+	names := []string{}
+	for _, rule := range spec.Rules {
+		if rule.HTTP == nil {
+			continue
+		}
+		for _, p := range rule.HTTP.Paths {
+			if p.Backend.Service != nil {
+				names = append(names, p.Backend.Service.Name)
+			}
+		}
+	}
+	return names, nil
+}
+
+// fromUnstructuredField converts the field at the path in the unstructured
+// object into v.
+func fromUnstructuredField(u *unstructured.Unstructured, v interface{}, fields ...string) error {
+	m, _, err := unstructured.NestedMap(u.Object, fields...)
+	if err != nil {
+		return err
+	}
+	return runtime.DefaultUnstructuredConverter.FromUnstructured(m, v)
+}
@@ -0,0 +1,87 @@
+package routes
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/bigkevmcd/webhook-secret-operator/test"
+)
+
+var _ IngressGetter = (*KubeIngressGetter)(nil)
+
+var testIngressID = types.NamespacedName{Name: "test-ingress", Namespace: "test-ns"}
+
+func TestIngressURL(t *testing.T) {
+	urlTests := []struct {
+		name    string
+		ingress runtime.Object
+		host    string
+		path    string
+		want    string
+		wantErr string
+	}{
+		{"single rule with TLS", test.MakeIngress(testIngressID), "", "", "https://example.com/", ""},
+		{"with path", test.MakeIngress(testIngressID), "", "/test/api", "https://example.com/test/api", ""},
+		{"without TLS", test.MakeIngress(testIngressID, test.IngressRule("example.com")), "", "", "http://example.com/", ""},
+		{"TLS for another host", test.MakeIngress(testIngressID, test.IngressRule("example.com"), test.IngressTLS("other.example.com")), "", "", "http://example.com/", ""},
+		{"TLS without hosts", test.MakeIngress(testIngressID, test.IngressRule("example.com"), test.IngressTLS()), "", "", "https://example.com/", ""},
+		{"wildcard TLS", test.MakeIngress(testIngressID, test.IngressRule("hooks.example.com"), test.IngressTLS("*.example.com")), "", "", "https://hooks.example.com/", ""},
+		{"single path in rule", test.MakeIngress(testIngressID, test.IngressRule("example.com", "/el")), "", "", "http://example.com/el", ""},
+		{"multiple paths in rule", test.MakeIngress(testIngressID, test.IngressRule("example.com", "/el", "/other")), "", "", "http://example.com/", ""},
+		{"selected host",
+			test.MakeIngress(testIngressID, test.IngressRule("example.com"), test.IngressRule("hooks.example.com", "/hooks"), test.IngressTLS("hooks.example.com")),
+			"hooks.example.com", "", "https://hooks.example.com/hooks", ""},
+		{"multiple hosts", test.MakeIngress(testIngressID, test.IngressRule("example.com"), test.IngressRule("hooks.example.com")),
+			"", "", "", "ingress test-ns/test-ingress has rules for more than one host, a host must be selected"},
+		{"unknown host", test.MakeIngress(testIngressID), "hooks.example.com", "", "",
+			`ingress test-ns/test-ingress has no rule for host "hooks.example.com"`},
+		{"no hosts", test.MakeIngress(testIngressID, test.IngressRule(""), test.IngressRule("*.example.com")), "", "", "",
+			"ingress test-ns/test-ingress has no rules with a host"},
+	}
+
+	for _, tt := range urlTests {
+		t.Run(tt.name, func(rt *testing.T) {
+			g := NewIngressGetter(fake.NewFakeClient(tt.ingress))
+
+			hookURL, err := g.IngressURL(context.TODO(), testIngressID, tt.host, tt.path)
+			if !test.MatchError(rt, tt.wantErr, err) {
+				rt.Fatalf("error failed to match, got %#v, want %s", err, tt.wantErr)
+			}
+			if hookURL != tt.want {
+				rt.Fatalf("got %s, want %s", hookURL, tt.want)
+			}
+		})
+	}
+}
+
+func TestIngressURLWithMissingIngress(t *testing.T) {
+	g := NewIngressGetter(fake.NewFakeClient())
+
+	_, err := g.IngressURL(context.TODO(), testIngressID, "", "")
+
+	if !test.MatchError(t, `error getting ingress test-ns/test-ingress: .*"test-ingress" not found`, err) {
+		t.Fatal(err)
+	}
+}
+
+func TestIngressServiceNames(t *testing.T) {
+	ingress := test.MakeIngress(testIngressID,
+		test.IngressRule("example.com", "/"),
+		test.IngressServiceRule("hooks.example.com", "/", "el-test-listener"),
+		test.IngressServiceRule("hooks.example.org", "/", "other-service"))
+
+	names, err := IngressServiceNames(ingress)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{"el-test-listener", "other-service"}
+	if !reflect.DeepEqual(names, want) {
+		t.Fatalf("got %#v, want %#v", names, want)
+	}
+}
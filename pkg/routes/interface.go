@@ -12,3 +12,43 @@ type RouteGetter interface {
 	// element to the URL.
 	RouteURL(context.Context, types.NamespacedName, string) (string, error)
 }
+
+// IngressGetter implementations get the URL for Kubernetes Ingresses.
+type IngressGetter interface {
+	// IngressURL gets the full URL to access this Ingress, for the rule with
+	// an optional host, adding an optional path element to the URL.
+	IngressURL(ctx context.Context, id types.NamespacedName, host, path string) (string, error)
+}
+
+// HTTPRouteGetter implementations get the URL for Gateway API HTTPRoutes.
+type HTTPRouteGetter interface {
+	// HTTPRouteURL gets the full URL to access this HTTPRoute, for an optional
+	// hostname, adding an optional path element to the URL.
+	HTTPRouteURL(ctx context.Context, id types.NamespacedName, hostname, path string) (string, error)
+}
+
+// ServiceGetter implementations get the URL for LoadBalancer Services.
+type ServiceGetter interface {
+	// ServiceURL gets the full URL to access this Service through its load
+	// balancer, on an optional port, adding an optional path element to the
+	// URL.
+	ServiceURL(ctx context.Context, id types.NamespacedName, port int32, path string) (string, error)
+}
+
+// KnativeServiceGetter implementations get the URL for Knative Services.
+type KnativeServiceGetter interface {
+	// KnativeServiceURL gets the full URL to access this Knative Service,
+	// adding an optional path element to the URL.
+	KnativeServiceURL(ctx context.Context, id types.NamespacedName, path string) (string, error)
+}
+
+// EventListenerGetter implementations get the URL for Tekton EventListeners.
+type EventListenerGetter interface {
+	// EventListenerURL gets the full URL to access this EventListener from
+	// outside the cluster, adding an optional path element to the URL.
+	EventListenerURL(ctx context.Context, id types.NamespacedName, path string) (string, error)
+
+	// EventListenerServiceName gets the name of the Service for this
+	// EventListener, or an empty string if it hasn't been created yet.
+	EventListenerServiceName(ctx context.Context, id types.NamespacedName) (string, error)
+}
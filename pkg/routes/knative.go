@@ -0,0 +1,57 @@
+package routes
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// KnativeServiceGVK is the Knative Serving Service kind.
+var KnativeServiceGVK = schema.GroupVersionKind{Group: "serving.knative.dev", Version: "v1", Kind: "Service"}
+
+// KubeKnativeServiceGetter is an implementation of KnativeServiceGetter.
+type KubeKnativeServiceGetter struct {
+	kubeClient client.Client
+}
+
+// NewKnativeServiceGetter creates and returns a KubeKnativeServiceGetter that
+// looks up Knative Services in k8s.
+func NewKnativeServiceGetter(c client.Client) *KubeKnativeServiceGetter {
+	return &KubeKnativeServiceGetter{
+		kubeClient: c,
+	}
+}
+
+// KnativeServiceURL looks for a namespaced Knative Service, and returns the
+// URL from its status, with the path added to it.
+//
+// A pending error is returned until Knative has assigned the URL.
+func (k KubeKnativeServiceGetter) KnativeServiceURL(ctx context.Context, id types.NamespacedName, path string) (string, error) {
+	loaded := &unstructured.Unstructured{}
+	loaded.SetGroupVersionKind(KnativeServiceGVK)
+	err := k.kubeClient.Get(ctx, id, loaded)
+	if err != nil {
+		return "", fmt.Errorf("error getting knative service %s: %w", id, err)
+	}
+	status, _, _ := unstructured.NestedString(loaded.Object, "status", "url")
+	if status == "" {
+		return "", pendingError{msg: fmt.Sprintf("knative service %s is waiting for a URL", id)}
+	}
+	serviceURL, err := url.Parse(status)
+	if err != nil {
+		return "", fmt.Errorf("knative service %s has an invalid URL %q: %w", id, status, err)
+	}
+	if path != "" {
+		serviceURL.Path = strings.TrimSuffix(serviceURL.Path, "/") + "/" + strings.TrimPrefix(path, "/")
+	}
+	if serviceURL.Path == "" {
+		serviceURL.Path = "/"
+	}
+	return serviceURL.String(), nil
+}
@@ -0,0 +1,64 @@
+package routes
+
+import (
+	"context"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/bigkevmcd/webhook-secret-operator/test"
+)
+
+var _ KnativeServiceGetter = (*KubeKnativeServiceGetter)(nil)
+
+var testKnativeServiceID = types.NamespacedName{Name: "test-ksvc", Namespace: "test-ns"}
+
+func TestKnativeServiceURL(t *testing.T) {
+	urlTests := []struct {
+		name    string
+		url     string
+		path    string
+		want    string
+		wantErr string
+	}{
+		{"status URL", "https://test-ksvc.test-ns.example.com", "", "https://test-ksvc.test-ns.example.com/", ""},
+		{"with path", "https://test-ksvc.test-ns.example.com", "/test/api", "https://test-ksvc.test-ns.example.com/test/api", ""},
+		{"with path and trailing slash", "http://test-ksvc.test-ns.example.com/", "test/api", "http://test-ksvc.test-ns.example.com/test/api", ""},
+		{"pending", "", "", "", "knative service test-ns/test-ksvc is waiting for a URL"},
+	}
+
+	for _, tt := range urlTests {
+		t.Run(tt.name, func(rt *testing.T) {
+			g := NewKnativeServiceGetter(fake.NewFakeClient(test.MakeKnativeService(testKnativeServiceID, tt.url)))
+
+			hookURL, err := g.KnativeServiceURL(context.TODO(), testKnativeServiceID, tt.path)
+			if !test.MatchError(rt, tt.wantErr, err) {
+				rt.Fatalf("error failed to match, got %#v, want %s", err, tt.wantErr)
+			}
+			if hookURL != tt.want {
+				rt.Fatalf("got %s, want %s", hookURL, tt.want)
+			}
+		})
+	}
+}
+
+func TestKnativeServiceURLWhilePending(t *testing.T) {
+	g := NewKnativeServiceGetter(fake.NewFakeClient(test.MakeKnativeService(testKnativeServiceID, "")))
+
+	_, err := g.KnativeServiceURL(context.TODO(), testKnativeServiceID, "")
+
+	if !IsPending(err) {
+		t.Fatalf("expected a pending error, got %v", err)
+	}
+}
+
+func TestKnativeServiceURLWithMissingService(t *testing.T) {
+	g := NewKnativeServiceGetter(fake.NewFakeClient())
+
+	_, err := g.KnativeServiceURL(context.TODO(), testKnativeServiceID, "")
+
+	if !IsNotFound(err) {
+		t.Fatalf("expected a not found error, got %v", err)
+	}
+}
@@ -30,18 +30,22 @@ func (k KubeRouteGetter) RouteURL(ctx context.Context, id types.NamespacedName,
 	if err != nil {
 		return "", fmt.Errorf("error getting route %s/%s: %w", id.Namespace, id.Name, err)
 	}
+	return routeURL(loaded, path), nil
+}
 
+// routeURL returns the URL for the host of the Route, with the path.
+func routeURL(r *routev1.Route, path string) string {
 	scheme := "http"
-	if loaded.Spec.TLS != nil {
+	if r.Spec.TLS != nil {
 		scheme = "https"
 	}
 	if path == "" {
 		path = "/"
 	}
-	routeURL := url.URL{
+	u := url.URL{
 		Scheme: scheme,
-		Host:   loaded.Spec.Host,
+		Host:   r.Spec.Host,
 		Path:   path,
 	}
-	return routeURL.String(), err
+	return u.String()
 }
@@ -0,0 +1,106 @@
+package routes
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// KubeServiceGetter is an implementation of ServiceGetter.
+type KubeServiceGetter struct {
+	kubeClient client.Client
+}
+
+// NewServiceGetter creates and returns a KubeServiceGetter that looks up
+// Services in k8s.
+func NewServiceGetter(c client.Client) *KubeServiceGetter {
+	return &KubeServiceGetter{
+		kubeClient: c,
+	}
+}
+
+// ServiceURL looks for a namespaced LoadBalancer Service, and returns the URL
+// for the load balancer's hostname or IP, on the port, or the only port of the
+// Service if no port is provided.
+//
+// The URL is https if the port is 443, or the port's name or appProtocol is
+// https, and a pending error is returned until the load balancer has been
+// provisioned.
+func (k KubeServiceGetter) ServiceURL(ctx context.Context, id types.NamespacedName, port int32, path string) (string, error) {
+	loaded := &corev1.Service{}
+	err := k.kubeClient.Get(ctx, id, loaded)
+	if err != nil {
+		return "", fmt.Errorf("error getting service %s: %w", id, err)
+	}
+	if loaded.Spec.Type != corev1.ServiceTypeLoadBalancer {
+		return "", fmt.Errorf("service %s is not a LoadBalancer service", id)
+	}
+	servicePort, err := findServicePort(loaded.Spec.Ports, port)
+	if err != nil {
+		return "", fmt.Errorf("service %s %s", id, err)
+	}
+	host := loadBalancerHost(loaded.Status.LoadBalancer)
+	if host == "" {
+		return "", pendingError{msg: fmt.Sprintf("service %s is waiting for a load balancer", id)}
+	}
+
+	scheme := "http"
+	if servicePort.Port == 443 || strings.EqualFold(servicePort.Name, "https") ||
+		(servicePort.AppProtocol != nil && strings.EqualFold(*servicePort.AppProtocol, "https")) {
+		scheme = "https"
+	}
+	if isDefaultPort(scheme, servicePort.Port) {
+		if strings.Contains(host, ":") {
+			host = "[" + host + "]"
+		}
+	} else {
+		host = net.JoinHostPort(host, strconv.Itoa(int(servicePort.Port)))
+	}
+	if path == "" {
+		path = "/"
+	}
+	serviceURL := url.URL{
+		Scheme: scheme,
+		Host:   host,
+		Path:   path,
+	}
+	return serviceURL.String(), nil
+}
+
+// findServicePort returns the port with the number, or the only port if the
+// number is 0.
+func findServicePort(ports []corev1.ServicePort, port int32) (corev1.ServicePort, error) {
+	if port == 0 {
+		if len(ports) == 1 {
+			return ports[0], nil
+		}
+		return corev1.ServicePort{}, fmt.Errorf("has %d ports, a port must be selected", len(ports))
+	}
+	for _, p := range ports {
+		if p.Port == port {
+			return p, nil
+		}
+	}
+	return corev1.ServicePort{}, fmt.Errorf("has no port %d", port)
+}
+
+// loadBalancerHost returns the hostname, or IP, of the first load balancer
+// ingress point.
+func loadBalancerHost(s corev1.LoadBalancerStatus) string {
+	for _, i := range s.Ingress {
+		if i.Hostname != "" {
+			return i.Hostname
+		}
+		if i.IP != "" {
+			return i.IP
+		}
+	}
+	return ""
+}
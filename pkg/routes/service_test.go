@@ -0,0 +1,83 @@
+package routes
+
+import (
+	"context"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/bigkevmcd/webhook-secret-operator/test"
+)
+
+var _ ServiceGetter = (*KubeServiceGetter)(nil)
+
+var testServiceID = types.NamespacedName{Name: "test-service", Namespace: "test-ns"}
+
+func TestServiceURL(t *testing.T) {
+	https := "https"
+	urlTests := []struct {
+		name    string
+		service *corev1.Service
+		port    int32
+		path    string
+		want    string
+		wantErr string
+	}{
+		{"hostname", test.MakeService(testServiceID, test.LoadBalancerHostname("lb.example.com"), test.ServicePort("", 8080, nil)), 0, "", "http://lb.example.com:8080/", ""},
+		{"IP", test.MakeService(testServiceID, test.LoadBalancerIP("192.0.2.10"), test.ServicePort("", 80, nil)), 0, "", "http://192.0.2.10/", ""},
+		{"IPv6", test.MakeService(testServiceID, test.LoadBalancerIP("2001:db8::1"), test.ServicePort("", 80, nil)), 0, "", "http://[2001:db8::1]/", ""},
+		{"IPv6 with port", test.MakeService(testServiceID, test.LoadBalancerIP("2001:db8::1"), test.ServicePort("", 8080, nil)), 0, "", "http://[2001:db8::1]:8080/", ""},
+		{"with path", test.MakeService(testServiceID, test.LoadBalancerHostname("lb.example.com"), test.ServicePort("", 80, nil)), 0, "/test/api", "http://lb.example.com/test/api", ""},
+		{"port 443", test.MakeService(testServiceID, test.LoadBalancerHostname("lb.example.com"), test.ServicePort("", 443, nil)), 0, "", "https://lb.example.com/", ""},
+		{"https port name", test.MakeService(testServiceID, test.LoadBalancerHostname("lb.example.com"), test.ServicePort("https", 8443, nil)), 0, "", "https://lb.example.com:8443/", ""},
+		{"https appProtocol", test.MakeService(testServiceID, test.LoadBalancerHostname("lb.example.com"), test.ServicePort("listener", 8443, &https)), 0, "", "https://lb.example.com:8443/", ""},
+		{"selected port",
+			test.MakeService(testServiceID, test.LoadBalancerHostname("lb.example.com"), test.ServicePort("http", 80, nil), test.ServicePort("https", 443, nil)),
+			443, "", "https://lb.example.com/", ""},
+		{"multiple ports",
+			test.MakeService(testServiceID, test.LoadBalancerHostname("lb.example.com"), test.ServicePort("http", 80, nil), test.ServicePort("https", 443, nil)),
+			0, "", "", "service test-ns/test-service has 2 ports, a port must be selected"},
+		{"unknown port", test.MakeService(testServiceID, test.LoadBalancerHostname("lb.example.com"), test.ServicePort("", 80, nil)), 8080, "", "",
+			"service test-ns/test-service has no port 8080"},
+		{"pending", test.MakeService(testServiceID, test.ServicePort("", 80, nil)), 0, "", "", "service test-ns/test-service is waiting for a load balancer"},
+		{"not a load balancer",
+			test.MakeService(testServiceID, test.ServicePort("", 80, nil), func(s *corev1.Service) { s.Spec.Type = corev1.ServiceTypeClusterIP }),
+			0, "", "", "service test-ns/test-service is not a LoadBalancer service"},
+	}
+
+	for _, tt := range urlTests {
+		t.Run(tt.name, func(rt *testing.T) {
+			g := NewServiceGetter(fake.NewFakeClient(tt.service))
+
+			hookURL, err := g.ServiceURL(context.TODO(), testServiceID, tt.port, tt.path)
+			if !test.MatchError(rt, tt.wantErr, err) {
+				rt.Fatalf("error failed to match, got %#v, want %s", err, tt.wantErr)
+			}
+			if hookURL != tt.want {
+				rt.Fatalf("got %s, want %s", hookURL, tt.want)
+			}
+		})
+	}
+}
+
+func TestServiceURLWhilePending(t *testing.T) {
+	g := NewServiceGetter(fake.NewFakeClient(test.MakeService(testServiceID, test.ServicePort("", 80, nil))))
+
+	_, err := g.ServiceURL(context.TODO(), testServiceID, 0, "")
+
+	if !IsPending(err) {
+		t.Fatalf("expected a pending error, got %v", err)
+	}
+}
+
+func TestServiceURLWithMissingService(t *testing.T) {
+	g := NewServiceGetter(fake.NewFakeClient())
+
+	_, err := g.ServiceURL(context.TODO(), testServiceID, 0, "")
+
+	if !IsNotFound(err) {
+		t.Fatalf("expected a not found error, got %v", err)
+	}
+}
@@ -4,10 +4,15 @@ import (
 	"context"
 
 	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/bigkevmcd/webhook-secret-operator/pkg/git"
 )
 
 // SecretGetter takes a namespaced name and finds a secret with that name, or
 // returns an error.
 type SecretGetter interface {
-	SecretToken(ctx context.Context, key types.NamespacedName) (string, error)
+	// Credentials finds a secret with that name, and returns the credentials
+	// that it contains for authenticating with a git host, the key is the
+	// key that a token is stored in, if it's not the default.
+	Credentials(ctx context.Context, id types.NamespacedName, key string) (git.Credentials, error)
 }
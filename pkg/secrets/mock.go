@@ -5,6 +5,8 @@ import (
 	"fmt"
 
 	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/bigkevmcd/webhook-secret-operator/pkg/git"
 )
 
 var _ SecretGetter = (*MockSecret)(nil)
@@ -19,13 +21,13 @@ type MockSecret struct {
 	secrets map[string]string
 }
 
-// SecretToken implements the SecretGetter interface.
-func (k MockSecret) SecretToken(ctx context.Context, secretID types.NamespacedName) (string, error) {
+// Credentials implements the SecretGetter interface.
+func (k MockSecret) Credentials(ctx context.Context, secretID types.NamespacedName, tokenKey string) (git.Credentials, error) {
 	token, ok := k.secrets[key(secretID)]
 	if !ok {
-		return "", fmt.Errorf("mock not found")
+		return git.Credentials{}, fmt.Errorf("mock not found")
 	}
-	return token, nil
+	return git.Credentials{Token: token}, nil
 }
 
 // AddStubResponse is a mock method that sets up a token to be returned.
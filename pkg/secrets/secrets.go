@@ -3,11 +3,16 @@ package secrets
 import (
 	"context"
 	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
 
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/types"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/bigkevmcd/webhook-secret-operator/pkg/git"
 )
 
 // KubeSecretGetter is an implementation of SecretGetter.
@@ -22,20 +27,170 @@ func New(c client.Client) *KubeSecretGetter {
 	}
 }
 
-// SecretToken looks for a namespaced secret, and returns the 'token' key from
-// it, or an error if not found.
-func (k KubeSecretGetter) SecretToken(ctx context.Context, id types.NamespacedName) (string, error) {
+// Keys in the auth secret for each type of credentials.
+const (
+	defaultTokenKey = "token"
+
+	appIDKey          = "appID"
+	installationIDKey = "installationID"
+	privateKeyKey     = "privateKey"
+
+	clientIDKey     = "clientID"
+	clientSecretKey = "clientSecret"
+	tokenURLKey     = "tokenURL"
+	scopesKey       = "scopes"
+)
+
+// Credentials looks for a namespaced secret, and returns the credentials from
+// it.
+//
+// If the secret is a kubernetes.io/basic-auth Secret, the username and
+// password are used.
+//
+// If the key is not empty, the token is read from that key, otherwise the
+// credentials are read from the first of these that the secret has:
+//   - a 'token' key
+//   - 'appID', 'installationID' and 'privateKey' keys for a GitHub App
+//   - 'clientID', 'clientSecret' and 'tokenURL' keys, and an optional
+//     space-separated 'scopes' key, for an OAuth2 client
+//   - 'username' and 'password' keys
+//
+// If the secret doesn't contain valid credentials, an error that satisfies
+// IsInvalidSecret is returned.
+func (k KubeSecretGetter) Credentials(ctx context.Context, id types.NamespacedName, key string) (git.Credentials, error) {
 	loaded := &corev1.Secret{}
-	err := k.kubeClient.Get(context.TODO(), id, loaded)
+	err := k.kubeClient.Get(ctx, id, loaded)
 	if errors.IsNotFound(err) {
-		return "", err
+		return git.Credentials{}, err
+	}
+	if err != nil {
+		return git.Credentials{}, fmt.Errorf("error getting secret %s/%s: %w", id.Namespace, id.Name, err)
+	}
+	creds, err := credentialsFromSecret(loaded, key)
+	if err != nil {
+		return git.Credentials{}, invalidSecretError{id: id, msg: err.Error()}
+	}
+	return creds, nil
+}
+
+func credentialsFromSecret(s *corev1.Secret, key string) (git.Credentials, error) {
+	if s.Type == corev1.SecretTypeBasicAuth {
+		return basicAuth(s)
+	}
+	if key != "" {
+		token, ok := s.Data[key]
+		if !ok {
+			return git.Credentials{}, fmt.Errorf("no '%s' key", key)
+		}
+		return git.Credentials{Token: string(token)}, nil
 	}
+	if token, ok := s.Data[defaultTokenKey]; ok {
+		return git.Credentials{Token: string(token)}, nil
+	}
+	if _, ok := s.Data[appIDKey]; ok {
+		app, err := githubApp(s)
+		if err != nil {
+			return git.Credentials{}, err
+		}
+		return git.Credentials{GitHubApp: app}, nil
+	}
+	if _, ok := s.Data[clientIDKey]; ok {
+		client, err := oauth2Client(s)
+		if err != nil {
+			return git.Credentials{}, err
+		}
+		return git.Credentials{OAuth2: client}, nil
+	}
+	if _, ok := s.Data[corev1.BasicAuthUsernameKey]; ok {
+		return basicAuth(s)
+	}
+	return git.Credentials{}, fmt.Errorf("no '%s', '%s', '%s' or '%s' key", defaultTokenKey, appIDKey, clientIDKey, corev1.BasicAuthUsernameKey)
+}
+
+func basicAuth(s *corev1.Secret) (git.Credentials, error) {
+	username, err := stringValue(s, corev1.BasicAuthUsernameKey)
 	if err != nil {
-		return "", fmt.Errorf("error getting secret %s/%s: %w", id.Namespace, id.Name, err)
+		return git.Credentials{}, err
 	}
-	token, ok := loaded.Data["token"]
+	password, err := stringValue(s, corev1.BasicAuthPasswordKey)
+	if err != nil {
+		return git.Credentials{}, err
+	}
+	return git.Credentials{Username: username, Password: password}, nil
+}
+
+func githubApp(s *corev1.Secret) (*git.GitHubApp, error) {
+	appID, err := int64Value(s, appIDKey)
+	if err != nil {
+		return nil, err
+	}
+	installationID, err := int64Value(s, installationIDKey)
+	if err != nil {
+		return nil, err
+	}
+	key, ok := s.Data[privateKeyKey]
 	if !ok {
-		return "", fmt.Errorf("secret invalid, no 'token' key in %s/%s", id.Namespace, id.Name)
+		return nil, fmt.Errorf("no '%s' key", privateKeyKey)
+	}
+	return &git.GitHubApp{AppID: appID, InstallationID: installationID, PrivateKey: key}, nil
+}
+
+func oauth2Client(s *corev1.Secret) (*git.OAuth2Client, error) {
+	clientID, err := stringValue(s, clientIDKey)
+	if err != nil {
+		return nil, err
+	}
+	clientSecret, err := stringValue(s, clientSecretKey)
+	if err != nil {
+		return nil, err
+	}
+	tokenURL, err := stringValue(s, tokenURLKey)
+	if err != nil {
+		return nil, err
+	}
+	if u, err := url.Parse(tokenURL); err != nil || !u.IsAbs() {
+		return nil, fmt.Errorf("'%s' is not an absolute URL", tokenURLKey)
+	}
+	return &git.OAuth2Client{
+		ClientID:     clientID,
+		ClientSecret: clientSecret,
+		TokenURL:     tokenURL,
+		Scopes:       strings.Fields(string(s.Data[scopesKey])),
+	}, nil
+}
+
+func stringValue(s *corev1.Secret, key string) (string, error) {
+	v := strings.TrimSpace(string(s.Data[key]))
+	if v == "" {
+		return "", fmt.Errorf("no '%s' key", key)
 	}
-	return string(token), nil
+	return v, nil
+}
+
+func int64Value(s *corev1.Secret, key string) (int64, error) {
+	v, ok := s.Data[key]
+	if !ok {
+		return 0, fmt.Errorf("no '%s' key", key)
+	}
+	i, err := strconv.ParseInt(strings.TrimSpace(string(v)), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("'%s' is not a number", key)
+	}
+	return i, nil
+}
+
+type invalidSecretError struct {
+	id  types.NamespacedName
+	msg string
+}
+
+func (e invalidSecretError) Error() string {
+	return fmt.Sprintf("secret invalid, %s in %s/%s", e.msg, e.id.Namespace, e.id.Name)
+}
+
+// IsInvalidSecret returns true if the provided error means that a secret
+// doesn't contain valid credentials.
+func IsInvalidSecret(err error) bool {
+	_, ok := err.(invalidSecretError)
+	return ok
 }
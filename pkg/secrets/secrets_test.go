@@ -4,35 +4,31 @@ import (
 	"context"
 	"testing"
 
+	"github.com/google/go-cmp/cmp"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/bigkevmcd/webhook-secret-operator/pkg/git"
+	"github.com/bigkevmcd/webhook-secret-operator/test"
 )
 
 var _ SecretGetter = (*KubeSecretGetter)(nil)
 
 var testID = types.NamespacedName{Name: "test-secret", Namespace: "test-ns"}
 
-func TestSecretToken(t *testing.T) {
+func TestCredentialsWithToken(t *testing.T) {
 	g := New(fake.NewFakeClient(createSecret(testID, "secret-token")))
 
-	secret, err := g.SecretToken(context.TODO(), testID)
+	creds, err := g.Credentials(context.TODO(), testID, "")
 	if err != nil {
 		t.Fatal(err)
 	}
 
-	if secret != "secret-token" {
-		t.Fatalf("got %s, want secret-token", secret)
-	}
-}
-
-func TestSecretTokenWithMissingSecret(t *testing.T) {
-	g := New(fake.NewFakeClient())
-
-	_, err := g.SecretToken(context.TODO(), testID)
-	if err.Error() != `secrets "test-secret" not found` {
-		t.Fatal(err)
+	if creds.Token != "secret-token" {
+		t.Fatalf("got %s, want secret-token", creds.Token)
 	}
 }
 
@@ -52,3 +48,142 @@ func createSecret(id types.NamespacedName, token string) *corev1.Secret {
 		},
 	}
 }
+
+func TestCredentials(t *testing.T) {
+	credsTests := []struct {
+		name       string
+		secretType corev1.SecretType
+		key        string
+		data       map[string][]byte
+		want       git.Credentials
+		wantErr    string
+	}{
+		{
+			name: "token",
+			data: map[string][]byte{"token": []byte("secret-token")},
+			want: git.Credentials{Token: "secret-token"},
+		},
+		{
+			name: "token in a custom key",
+			key:  "github-token",
+			data: map[string][]byte{"token": []byte("secret-token"), "github-token": []byte("other-token")},
+			want: git.Credentials{Token: "other-token"},
+		},
+		{
+			name:    "missing custom key",
+			key:     "github-token",
+			data:    map[string][]byte{"token": []byte("secret-token")},
+			wantErr: "secret invalid, no 'github-token' key in test-ns/test-secret",
+		},
+		{
+			name: "GitHub App",
+			data: map[string][]byte{
+				"appID":          []byte("1234"),
+				"installationID": []byte("5678\n"),
+				"privateKey":     []byte("test-key"),
+			},
+			want: git.Credentials{
+				GitHubApp: &git.GitHubApp{AppID: 1234, InstallationID: 5678, PrivateKey: []byte("test-key")},
+			},
+		},
+		{
+			name: "GitHub App with missing installation",
+			data: map[string][]byte{
+				"appID":      []byte("1234"),
+				"privateKey": []byte("test-key"),
+			},
+			wantErr: "secret invalid, no 'installationID' key in test-ns/test-secret",
+		},
+		{
+			name: "GitHub App with invalid app ID",
+			data: map[string][]byte{
+				"appID":          []byte("my-app"),
+				"installationID": []byte("5678"),
+				"privateKey":     []byte("test-key"),
+			},
+			wantErr: "secret invalid, 'appID' is not a number in test-ns/test-secret",
+		},
+		{
+			name: "OAuth2 client",
+			data: map[string][]byte{
+				"clientID":     []byte("my-client"),
+				"clientSecret": []byte("t0ps3cr3t"),
+				"tokenURL":     []byte("https://bitbucket.org/site/oauth2/access_token"),
+				"scopes":       []byte("webhook repository:admin"),
+			},
+			want: git.Credentials{
+				OAuth2: &git.OAuth2Client{
+					ClientID:     "my-client",
+					ClientSecret: "t0ps3cr3t",
+					TokenURL:     "https://bitbucket.org/site/oauth2/access_token",
+					Scopes:       []string{"webhook", "repository:admin"},
+				},
+			},
+		},
+		{
+			name: "OAuth2 client with invalid token URL",
+			data: map[string][]byte{
+				"clientID":     []byte("my-client"),
+				"clientSecret": []byte("t0ps3cr3t"),
+				"tokenURL":     []byte("/oauth2/token"),
+			},
+			wantErr: "secret invalid, 'tokenURL' is not an absolute URL in test-ns/test-secret",
+		},
+		{
+			name: "username and password",
+			data: map[string][]byte{"username": []byte("jdoe"), "password": []byte("t0ps3cr3t")},
+			want: git.Credentials{Username: "jdoe", Password: "t0ps3cr3t"},
+		},
+		{
+			name:    "username without password",
+			data:    map[string][]byte{"username": []byte("jdoe")},
+			wantErr: "secret invalid, no 'password' key in test-ns/test-secret",
+		},
+		{
+			name:       "basic-auth secret",
+			secretType: corev1.SecretTypeBasicAuth,
+			key:        "github-token",
+			data:       map[string][]byte{"username": []byte("jdoe"), "password": []byte("t0ps3cr3t")},
+			want:       git.Credentials{Username: "jdoe", Password: "t0ps3cr3t"},
+		},
+		{
+			name:    "no credentials",
+			data:    map[string][]byte{"secret": []byte("secret")},
+			wantErr: "secret invalid, no 'token', 'appID', 'clientID' or 'username' key in test-ns/test-secret",
+		},
+	}
+
+	for _, tt := range credsTests {
+		t.Run(tt.name, func(rt *testing.T) {
+			s := createSecret(testID, "")
+			s.Data = tt.data
+			if tt.secretType != "" {
+				s.Type = tt.secretType
+			}
+			g := New(fake.NewFakeClient(s))
+
+			creds, err := g.Credentials(context.TODO(), testID, tt.key)
+			if !test.MatchError(rt, tt.wantErr, err) {
+				rt.Fatalf("error failed to match, got %#v, want %s", err, tt.wantErr)
+			}
+			if tt.wantErr != "" && !IsInvalidSecret(err) {
+				rt.Fatalf("got %#v, want an invalid secret error", err)
+			}
+			if diff := cmp.Diff(tt.want, creds); diff != "" {
+				rt.Fatalf("incorrect credentials:\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestCredentialsWithMissingSecret(t *testing.T) {
+	g := New(fake.NewFakeClient())
+
+	_, err := g.Credentials(context.TODO(), testID, "")
+	if !errors.IsNotFound(err) {
+		t.Fatal(err)
+	}
+	if err.Error() != `secrets "test-secret" not found` {
+		t.Fatal(err)
+	}
+}
@@ -5,3 +5,5 @@ for file in deploy/*.yaml; do
 done
 echo "---" >> release.yaml
 cat deploy/crds/apps.bigkevmcd.com_webhooksecrets_crd.yaml >> release.yaml
+echo "---" >> release.yaml
+cat deploy/crds/apps.bigkevmcd.com_authsecretgrants_crd.yaml >> release.yaml
@@ -0,0 +1,86 @@
+package test
+
+import (
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+type eventListenerFunc func(*unstructured.Unstructured)
+
+// EventListenerAddress is an option function for MakeEventListener that sets
+// the status of the EventListener as if Tekton had created the service.
+func EventListenerAddress(serviceName string) eventListenerFunc {
+	return func(u *unstructured.Unstructured) {
+		_ = unstructured.SetNestedField(u.Object, serviceName, "status", "configuration", "generatedName")
+		_ = unstructured.SetNestedField(u.Object,
+			fmt.Sprintf("http://%s.%s.svc.cluster.local:8080", serviceName, u.GetNamespace()),
+			"status", "address", "url")
+	}
+}
+
+// InterceptorTrigger is an option function for MakeEventListener that adds a
+// trigger with a reference to the named interceptor, with the params.
+func InterceptorTrigger(trigger, interceptor string, params ...interface{}) eventListenerFunc {
+	return func(u *unstructured.Unstructured) {
+		addTrigger(u, map[string]interface{}{
+			"name": trigger,
+			"interceptors": []interface{}{
+				map[string]interface{}{
+					"ref":    map[string]interface{}{"name": interceptor},
+					"params": params,
+				},
+			},
+		})
+	}
+}
+
+// LegacyInterceptorTrigger is an option function for MakeEventListener that
+// adds a trigger with the deprecated embedded configuration for the named
+// interceptor.
+func LegacyInterceptorTrigger(trigger, interceptor string) eventListenerFunc {
+	return func(u *unstructured.Unstructured) {
+		addTrigger(u, map[string]interface{}{
+			"name": trigger,
+			"interceptors": []interface{}{
+				map[string]interface{}{
+					interceptor: map[string]interface{}{
+						"eventTypes": []interface{}{"push"},
+					},
+				},
+			},
+		})
+	}
+}
+
+// InterceptorParam is a helper for creating interceptor params for
+// InterceptorTrigger.
+func InterceptorParam(name string, value interface{}) interface{} {
+	return map[string]interface{}{"name": name, "value": value}
+}
+
+func addTrigger(u *unstructured.Unstructured, trigger map[string]interface{}) {
+	triggers, _, _ := unstructured.NestedSlice(u.Object, "spec", "triggers")
+	_ = unstructured.SetNestedSlice(u.Object, append(triggers, trigger), "spec", "triggers")
+}
+
+// MakeEventListener is a test helper that creates Tekton EventListeners,
+// without options, the EventListener has no status.
+func MakeEventListener(id types.NamespacedName, opts ...eventListenerFunc) *unstructured.Unstructured {
+	u := &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": "triggers.tekton.dev/v1beta1",
+			"kind":       "EventListener",
+			"metadata": map[string]interface{}{
+				"name":      id.Name,
+				"namespace": id.Namespace,
+			},
+			"spec": map[string]interface{}{},
+		},
+	}
+	for _, o := range opts {
+		o(u)
+	}
+	return u
+}
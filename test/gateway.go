@@ -0,0 +1,110 @@
+package test
+
+import (
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+type gatewayFunc func(*unstructured.Unstructured)
+
+// Hostnames is an option function for MakeHTTPRoute that sets the hostnames
+// of the HTTPRoute.
+func Hostnames(hostnames ...string) gatewayFunc {
+	return func(u *unstructured.Unstructured) {
+		_ = unstructured.SetNestedStringSlice(u.Object, hostnames, "spec", "hostnames")
+	}
+}
+
+// ParentRef is an option function for MakeHTTPRoute that adds a parent
+// reference, the fields are added to the reference, e.g. namespace or
+// sectionName.
+func ParentRef(name string, fields map[string]interface{}) gatewayFunc {
+	return func(u *unstructured.Unstructured) {
+		ref := map[string]interface{}{"name": name}
+		for k, v := range fields {
+			ref[k] = v
+		}
+		refs, _, _ := unstructured.NestedSlice(u.Object, "spec", "parentRefs")
+		_ = unstructured.SetNestedSlice(u.Object, append(refs, ref), "spec", "parentRefs")
+	}
+}
+
+// PathMatch is an option function for MakeHTTPRoute that adds a rule that
+// matches the path prefix.
+func PathMatch(path string) gatewayFunc {
+	return func(u *unstructured.Unstructured) {
+		rule := map[string]interface{}{
+			"matches": []interface{}{
+				map[string]interface{}{
+					"path": map[string]interface{}{"type": "PathPrefix", "value": path},
+				},
+			},
+		}
+		rules, _, _ := unstructured.NestedSlice(u.Object, "spec", "rules")
+		_ = unstructured.SetNestedSlice(u.Object, append(rules, rule), "spec", "rules")
+	}
+}
+
+// Listener is an option function for MakeGateway that adds a listener, with
+// TLS if the protocol is HTTPS, the hostname is optional.
+func Listener(name, protocol string, port int64, hostname string) gatewayFunc {
+	return func(u *unstructured.Unstructured) {
+		l := map[string]interface{}{"name": name, "protocol": protocol, "port": port}
+		if hostname != "" {
+			l["hostname"] = hostname
+		}
+		if protocol == "HTTPS" {
+			l["tls"] = map[string]interface{}{"mode": "Terminate"}
+		}
+		listeners, _, _ := unstructured.NestedSlice(u.Object, "spec", "listeners")
+		_ = unstructured.SetNestedSlice(u.Object, append(listeners, l), "spec", "listeners")
+	}
+}
+
+// GatewayAddress is an option function for MakeGateway that adds an address
+// to the status of the Gateway.
+func GatewayAddress(value string) gatewayFunc {
+	return func(u *unstructured.Unstructured) {
+		addresses, _, _ := unstructured.NestedSlice(u.Object, "status", "addresses")
+		_ = unstructured.SetNestedSlice(u.Object, append(addresses, map[string]interface{}{"value": value}), "status", "addresses")
+	}
+}
+
+// MakeHTTPRoute is a test helper that creates Gateway API HTTPRoutes.
+//
+// Without options, the HTTPRoute has the hostname example.com, and the parent
+// Gateway "test-gateway".
+func MakeHTTPRoute(id types.NamespacedName, opts ...gatewayFunc) *unstructured.Unstructured {
+	if len(opts) == 0 {
+		opts = []gatewayFunc{Hostnames("example.com"), ParentRef("test-gateway", nil)}
+	}
+	return makeGatewayObject("HTTPRoute", id, opts)
+}
+
+// MakeGateway is a test helper that creates Gateway API Gateways.
+//
+// Without options, the Gateway has an HTTPS listener on port 443.
+func MakeGateway(id types.NamespacedName, opts ...gatewayFunc) *unstructured.Unstructured {
+	if len(opts) == 0 {
+		opts = []gatewayFunc{Listener("https", "HTTPS", 443, "")}
+	}
+	return makeGatewayObject("Gateway", id, opts)
+}
+
+func makeGatewayObject(kind string, id types.NamespacedName, opts []gatewayFunc) *unstructured.Unstructured {
+	u := &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": "gateway.networking.k8s.io/v1",
+			"kind":       kind,
+			"metadata": map[string]interface{}{
+				"name":      id.Name,
+				"namespace": id.Namespace,
+			},
+			"spec": map[string]interface{}{},
+		},
+	}
+	for _, o := range opts {
+		o(u)
+	}
+	return u
+}
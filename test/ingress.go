@@ -0,0 +1,88 @@
+package test
+
+import (
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+type ingressFunc func(*unstructured.Unstructured)
+
+// IngressRule is an option function for MakeIngress that adds a rule for
+// the host, with the paths.
+func IngressRule(host string, paths ...string) ingressFunc {
+	return func(u *unstructured.Unstructured) {
+		rule := map[string]interface{}{"host": host}
+		if len(paths) > 0 {
+			httpPaths := []interface{}{}
+			for _, p := range paths {
+				httpPaths = append(httpPaths, map[string]interface{}{"path": p, "pathType": "Prefix"})
+			}
+			rule["http"] = map[string]interface{}{"paths": httpPaths}
+		}
+		rules, _, _ := unstructured.NestedSlice(u.Object, "spec", "rules")
+		_ = unstructured.SetNestedSlice(u.Object, append(rules, rule), "spec", "rules")
+	}
+}
+
+// IngressServiceRule is an option function for MakeIngress that adds a rule
+// for the host, with a path that has the service as its backend.
+func IngressServiceRule(host, path, serviceName string) ingressFunc {
+	return func(u *unstructured.Unstructured) {
+		rule := map[string]interface{}{
+			"host": host,
+			"http": map[string]interface{}{
+				"paths": []interface{}{
+					map[string]interface{}{
+						"path":     path,
+						"pathType": "Prefix",
+						"backend": map[string]interface{}{
+							"service": map[string]interface{}{
+								"name": serviceName,
+								"port": map[string]interface{}{"number": int64(8080)},
+							},
+						},
+					},
+				},
+			},
+		}
+		rules, _, _ := unstructured.NestedSlice(u.Object, "spec", "rules")
+		_ = unstructured.SetNestedSlice(u.Object, append(rules, rule), "spec", "rules")
+	}
+}
+
+// IngressTLS is an option function for MakeIngress that adds a TLS entry for
+// the hosts.
+func IngressTLS(hosts ...string) ingressFunc {
+	return func(u *unstructured.Unstructured) {
+		tlsHosts := []interface{}{}
+		for _, h := range hosts {
+			tlsHosts = append(tlsHosts, h)
+		}
+		tls, _, _ := unstructured.NestedSlice(u.Object, "spec", "tls")
+		_ = unstructured.SetNestedSlice(u.Object, append(tls, map[string]interface{}{"hosts": tlsHosts}), "spec", "tls")
+	}
+}
+
+// MakeIngress is a test helper that creates networking.k8s.io/v1 Ingresses.
+//
+// Without options, the Ingress has a single rule for example.com, with TLS.
+func MakeIngress(id types.NamespacedName, opts ...ingressFunc) *unstructured.Unstructured {
+	u := &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": "networking.k8s.io/v1",
+			"kind":       "Ingress",
+			"metadata": map[string]interface{}{
+				"name":      id.Name,
+				"namespace": id.Namespace,
+			},
+			"spec": map[string]interface{}{},
+		},
+	}
+	if len(opts) == 0 {
+		opts = []ingressFunc{IngressRule("example.com"), IngressTLS("example.com")}
+	}
+	for _, o := range opts {
+		o(u)
+	}
+	return u
+}
@@ -0,0 +1,25 @@
+package test
+
+import (
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// MakeKnativeService is a test helper that creates Knative Services, with the
+// URL in the status, if the URL is empty, the Service has no status.
+func MakeKnativeService(id types.NamespacedName, url string) *unstructured.Unstructured {
+	u := &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": "serving.knative.dev/v1",
+			"kind":       "Service",
+			"metadata": map[string]interface{}{
+				"name":      id.Name,
+				"namespace": id.Namespace,
+			},
+		},
+	}
+	if url != "" {
+		_ = unstructured.SetNestedField(u.Object, url, "status", "url")
+	}
+	return u
+}
@@ -0,0 +1,50 @@
+package test
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+type serviceFunc func(*corev1.Service)
+
+// LoadBalancerHostname is an option function for MakeService that adds a load
+// balancer hostname to the status of the service.
+func LoadBalancerHostname(h string) serviceFunc {
+	return func(s *corev1.Service) {
+		s.Status.LoadBalancer.Ingress = append(s.Status.LoadBalancer.Ingress, corev1.LoadBalancerIngress{Hostname: h})
+	}
+}
+
+// LoadBalancerIP is an option function for MakeService that adds a load
+// balancer IP to the status of the service.
+func LoadBalancerIP(ip string) serviceFunc {
+	return func(s *corev1.Service) {
+		s.Status.LoadBalancer.Ingress = append(s.Status.LoadBalancer.Ingress, corev1.LoadBalancerIngress{IP: ip})
+	}
+}
+
+// ServicePort is an option function for MakeService that adds a port to the
+// service, the appProtocol is optional.
+func ServicePort(name string, port int32, appProtocol *string) serviceFunc {
+	return func(s *corev1.Service) {
+		s.Spec.Ports = append(s.Spec.Ports, corev1.ServicePort{Name: name, Port: port, AppProtocol: appProtocol})
+	}
+}
+
+// MakeService is a test helper that creates LoadBalancer services.
+func MakeService(id types.NamespacedName, opts ...serviceFunc) *corev1.Service {
+	s := &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      id.Name,
+			Namespace: id.Namespace,
+		},
+		Spec: corev1.ServiceSpec{
+			Type: corev1.ServiceTypeLoadBalancer,
+		},
+	}
+	for _, o := range opts {
+		o(s)
+	}
+	return s
+}